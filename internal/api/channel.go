@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/internal/ingress"
@@ -21,6 +22,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 type channel struct {
@@ -59,6 +61,9 @@ func (ch *channel) route(r *wkhttp.WKHttp) {
 	r.POST("/channel/whitelist_set", ch.whitelistSet) // 设置白明单（覆盖
 	r.POST("/channel/whitelist_remove", ch.whitelistRemove)
 	r.GET("/channel/whitelist", ch.whitelistGet) // 获取白名单
+
+	// 子频道
+	r.GET("/channel/children", ch.channelChildren) // 获取子频道列表
 	//################### 频道消息 ###################
 	// 同步频道消息
 	r.POST("/channel/messagesync", ch.syncMessages)
@@ -100,6 +105,10 @@ func (ch *channel) channelCreateOrUpdate(c *wkhttp.Context) {
 	// channelInfo := wkstore.NewChannelInfo(req.ChannelID, req.ChannelType)
 	channelInfo := req.ToChannelInfo()
 	err = ch.addOrUpdateChannel(channelInfo)
+	if isChannelParentErr(err) {
+		c.ResponseError(err)
+		return
+	}
 	if err != nil && err != wkdb.ErrNotFound {
 		ch.Error("创建或更新频道失败", zap.Error(err), zap.String("channelID", req.ChannelID), zap.Uint8("channelType", req.ChannelType))
 		c.ResponseError(errors.New("创建或更新频道失败"))
@@ -131,6 +140,14 @@ func (ch *channel) updateOrAddChannelInfo(c *wkhttp.Context) {
 		c.ResponseError(errors.New("数据格式有误！"))
 		return
 	}
+	if err := req.checkParent(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	if err := req.checkHistoryVisibility(); err != nil {
+		c.ResponseError(err)
+		return
+	}
 
 	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.ChannelID, req.ChannelType) // 获取频道的领导节点
 	if err != nil {
@@ -147,6 +164,10 @@ func (ch *channel) updateOrAddChannelInfo(c *wkhttp.Context) {
 
 	channelInfo := req.ToChannelInfo()
 	err = ch.addOrUpdateChannel(channelInfo)
+	if isChannelParentErr(err) {
+		c.ResponseError(err)
+		return
+	}
 	if err != nil {
 		ch.Error("添加或更新频道信息失败！", zap.Error(err))
 		c.ResponseError(errors.New("添加或更新频道信息失败！"))
@@ -220,9 +241,17 @@ func (ch *channel) addSubscriber(c *wkhttp.Context) {
 }
 
 func (ch *channel) addSubscriberWithReq(req subscriberAddReq) error {
-	var err error
+	members, err := service.Store.GetSubscribers(req.ChannelId, req.ChannelType)
+	if err != nil {
+		ch.Error("获取所有订阅者失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		return err
+	}
 	existSubscribers := make([]string, 0)
+	joinMsgSeqs := make(map[string]uint64) // 重置前订阅者加入时的消息序号，重新加入的订阅者保持不变
 	if req.Reset == 1 {
+		for _, member := range members {
+			joinMsgSeqs[member.Uid] = member.JoinMsgSeq
+		}
 		err = service.Store.RemoveAllSubscriber(req.ChannelId, req.ChannelType)
 		if err != nil {
 			ch.Error("移除所有订阅者失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
@@ -233,11 +262,6 @@ func (ch *channel) addSubscriberWithReq(req subscriberAddReq) error {
 			service.TagManager.RemoveTag(tagKey)
 		}
 	} else {
-		members, err := service.Store.GetSubscribers(req.ChannelId, req.ChannelType)
-		if err != nil {
-			ch.Error("获取所有订阅者失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
-			return err
-		}
 		for _, member := range members {
 			existSubscribers = append(existSubscribers, member.Uid)
 		}
@@ -252,7 +276,7 @@ func (ch *channel) addSubscriberWithReq(req subscriberAddReq) error {
 		}
 	}
 	if len(newSubscribers) > 0 {
-		lastMsgSeq, err := service.Store.GetLastMsgSeq(req.ChannelId, req.ChannelType)
+		lastMsgSeq, err := channelLastMsgSeq(ch.s.client, req.ChannelId, req.ChannelType)
 		if err != nil {
 			ch.Error("获取最大消息序号失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
 			return err
@@ -263,10 +287,15 @@ func (ch *channel) addSubscriberWithReq(req subscriberAddReq) error {
 		createdAt := time.Now()
 		updatedAt := time.Now()
 		for _, subscriber := range newSubscribers {
+			joinMsgSeq, ok := joinMsgSeqs[subscriber]
+			if !ok {
+				joinMsgSeq = lastMsgSeq
+			}
 			members = append(members, wkdb.Member{
-				Uid:       subscriber,
-				CreatedAt: &createdAt,
-				UpdatedAt: &updatedAt,
+				Uid:        subscriber,
+				CreatedAt:  &createdAt,
+				UpdatedAt:  &updatedAt,
+				JoinMsgSeq: joinMsgSeq,
 			})
 		}
 		err = service.Store.AddSubscribers(req.ChannelId, req.ChannelType, members)
@@ -342,8 +371,47 @@ func (ch *channel) updateTagBySubscribers(channelId string, channelType uint8, s
 	updateTag(channelId, channelType)
 
 	// 更新cmd频道的tag
-	cmdChannelId := options.G.OrginalConvertCmdChannel(channelId)
-	cfg, err := service.Cluster.LoadOnlyChannelClusterConfig(cmdChannelId, channelType)
+	err := ch.updateLeaderTag(options.G.OrginalConvertCmdChannel(channelId), channelType, subscribers, remove, updateTag)
+	if err != nil {
+		return err
+	}
+
+	// 更新逐级继承了订阅者的子频道的tag
+	return ch.updateInheritChildrenTag(channelId, channelType, subscribers, remove, updateTag, 1)
+}
+
+// 更新继承了订阅者的子频道（包括子频道的子频道）的tag
+func (ch *channel) updateInheritChildrenTag(channelId string, channelType uint8, subscribers []string, remove bool, updateTag func(chId string, chType uint8), depth int) error {
+	if depth > service.MaxChannelInheritDepth {
+		ch.Warn("updateTagByAddSubscribers: channel inherit too deep", zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return nil
+	}
+	children, err := service.Store.GetChannelChildren(channelId, channelType)
+	if err != nil {
+		ch.Error("updateTagByAddSubscribers: getChannelChildren failed", zap.Error(err))
+		return err
+	}
+	for _, child := range children {
+		if !child.Inherit {
+			continue
+		}
+		for _, childChannelId := range []string{child.ChannelId, options.G.OrginalConvertCmdChannel(child.ChannelId)} {
+			err = ch.updateLeaderTag(childChannelId, child.ChannelType, subscribers, remove, updateTag)
+			if err != nil {
+				return err
+			}
+		}
+		err = ch.updateInheritChildrenTag(child.ChannelId, child.ChannelType, subscribers, remove, updateTag, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 在频道的领导节点上更新频道的tag
+func (ch *channel) updateLeaderTag(channelId string, channelType uint8, subscribers []string, remove bool, updateTag func(chId string, chType uint8)) error {
+	cfg, err := service.Cluster.LoadOnlyChannelClusterConfig(channelId, channelType)
 	if err != nil && err != cluster.ErrChannelClusterConfigNotFound {
 		ch.Info("updateTagByAddSubscribers: loadOnlyChannelClusterConfig failed")
 		return nil
@@ -352,10 +420,10 @@ func (ch *channel) updateTagBySubscribers(channelId string, channelType uint8, s
 		return nil
 	}
 	if options.G.IsLocalNode(cfg.LeaderId) {
-		updateTag(cmdChannelId, channelType)
+		updateTag(channelId, channelType)
 	} else {
 		err = ch.s.client.UpdateTag(cfg.LeaderId, &ingress.TagUpdateReq{
-			ChannelId:   cmdChannelId,
+			ChannelId:   channelId,
 			ChannelType: channelType,
 			Uids:        subscribers,
 			Remove:      remove,
@@ -366,7 +434,6 @@ func (ch *channel) updateTagBySubscribers(channelId string, channelType uint8, s
 			return err
 		}
 	}
-
 	return nil
 }
 
@@ -497,6 +564,94 @@ func (ch *channel) setTmpSubscriber(c *wkhttp.Context) {
 	c.ResponseOK()
 }
 
+// 用户在频道内可见的起始消息序号（包含），0表示全部可见，service.NotVisibleMsgSeq表示没有可见的消息
+// 频道信息和订阅者保存在频道的槽领导节点上，不是槽领导时请求槽领导节点
+func visibleStartMsgSeq(client *ingress.Client, channelId string, channelType uint8, uid string) (uint64, error) {
+	if channelType == wkproto.ChannelTypePerson || strings.TrimSpace(uid) == "" || options.G.IsSystemUid(uid) {
+		return 0, nil
+	}
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(channelId, channelType)
+	if err != nil {
+		return 0, err
+	}
+	if options.G.IsLocalNode(leaderInfo.Id) {
+		return service.VisibleStartMsgSeq(channelId, channelType, uid)
+	}
+	return client.RequestVisibleStartMsgSeq(leaderInfo.Id, channelId, channelType, uid)
+}
+
+// 批量获取用户在多个频道内可见的起始消息序号（key为wkutil.ChannelToKey，没有返回的频道全部可见）
+// 按频道的槽领导节点分组，每个节点只请求一次
+func visibleStartMsgSeqs(client *ingress.Client, uid string, channels []ingress.ChannelReq) (map[string]uint64, error) {
+	visibleStartSeqs := make(map[string]uint64, len(channels))
+	if strings.TrimSpace(uid) == "" || options.G.IsSystemUid(uid) {
+		return visibleStartSeqs, nil
+	}
+	nodeChannels := make(map[uint64][]ingress.ChannelReq)
+	for _, channel := range channels {
+		if channel.ChannelType == wkproto.ChannelTypePerson {
+			continue
+		}
+		leaderInfo, err := service.Cluster.SlotLeaderOfChannel(channel.ChannelId, channel.ChannelType)
+		if err != nil {
+			return nil, err
+		}
+		nodeChannels[leaderInfo.Id] = append(nodeChannels[leaderInfo.Id], channel)
+	}
+
+	var (
+		mu           sync.Mutex
+		requestGroup errgroup.Group
+	)
+	for nodeId, chs := range nodeChannels {
+		nodeId, chs := nodeId, chs
+		requestGroup.Go(func() error {
+			var msgSeqs []uint64
+			if options.G.IsLocalNode(nodeId) {
+				msgSeqs = make([]uint64, 0, len(chs))
+				for _, ch := range chs {
+					msgSeq, err := service.VisibleStartMsgSeq(ch.ChannelId, ch.ChannelType, uid)
+					if err != nil {
+						return err
+					}
+					msgSeqs = append(msgSeqs, msgSeq)
+				}
+			} else {
+				var err error
+				msgSeqs, err = client.RequestVisibleStartMsgSeqs(nodeId, uid, chs)
+				if err != nil {
+					return err
+				}
+			}
+			mu.Lock()
+			for i, ch := range chs {
+				visibleStartSeqs[wkutil.ChannelToKey(ch.ChannelId, ch.ChannelType)] = msgSeqs[i]
+			}
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := requestGroup.Wait(); err != nil {
+		return nil, err
+	}
+	return visibleStartSeqs, nil
+}
+
+// 频道最新的消息序号（消息保存在频道的领导节点上，不是频道领导时请求频道领导节点）
+func channelLastMsgSeq(client *ingress.Client, channelId string, channelType uint8) (uint64, error) {
+	leaderInfo, err := service.Cluster.LeaderOfChannelForRead(channelId, channelType)
+	if err != nil {
+		if errors.Is(err, cluster.ErrChannelClusterConfigNotFound) { // 频道还没有消息
+			return 0, nil
+		}
+		return 0, err
+	}
+	if options.G.IsLocalNode(leaderInfo.Id) {
+		return service.Store.GetLastMsgSeq(channelId, channelType)
+	}
+	return client.RequestLastMsgSeq(leaderInfo.Id, channelId, channelType)
+}
+
 func setTmpSubscriberWithReq(req tmpSubscriberSetReq) error {
 	tag, err := service.TagManager.MakeTag(req.Uids)
 	if err != nil {
@@ -700,6 +855,16 @@ func (ch *channel) channelDelete(c *wkhttp.Context) {
 		return
 	}
 
+	// 从父频道的子频道列表中移除
+	if channelInfo.ParentChannelId != "" {
+		err = service.Store.RemoveChannelChild(channelInfo.ParentChannelId, channelInfo.ParentChannelType, wkdb.ChannelChild{ChannelId: req.ChannelId, ChannelType: req.ChannelType})
+		if err != nil {
+			ch.Error("移除子频道失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+			c.ResponseError(errors.New("移除子频道失败！"))
+			return
+		}
+	}
+
 	c.ResponseOK()
 }
 
@@ -873,6 +1038,45 @@ func (ch *channel) whitelistGet(c *wkhttp.Context) {
 	c.JSON(http.StatusOK, whitelist)
 }
 
+// 获取子频道列表
+func (ch *channel) channelChildren(c *wkhttp.Context) {
+	channelId := c.Query("channel_id")
+	channelType := wkutil.ParseUint8(c.Query("channel_type"))
+	if strings.TrimSpace(channelId) == "" {
+		c.ResponseError(errors.New("频道ID不能为空！"))
+		return
+	}
+
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(channelId, channelType) // 子频道列表保存在父频道所在的槽
+	if err != nil {
+		ch.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", channelId), zap.Uint8("channelType", channelType))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	leaderIsSelf := leaderInfo.Id == options.G.Cluster.NodeId
+	if !leaderIsSelf {
+		ch.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), nil)
+		return
+	}
+
+	children, err := service.Store.GetChannelChildren(channelId, channelType)
+	if err != nil {
+		ch.Error("获取子频道失败！", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		c.ResponseError(errors.New("获取子频道失败！"))
+		return
+	}
+	resps := make([]*channelChildResp, 0, len(children))
+	for _, child := range children {
+		resps = append(resps, &channelChildResp{
+			ChannelId:   child.ChannelId,
+			ChannelType: child.ChannelType,
+			Inherit:     wkutil.BoolToInt(child.Inherit),
+		})
+	}
+	c.JSON(http.StatusOK, resps)
+}
+
 type PullMode int // 拉取模式
 
 const (
@@ -952,6 +1156,22 @@ func (ch *channel) syncMessages(c *wkhttp.Context) {
 		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
 		return
 	}
+
+	// 新成员的历史消息可见范围
+	visibleStartSeq, err := visibleStartMsgSeq(ch.s.client, fakeChannelID, req.ChannelType, req.LoginUID)
+	if err != nil {
+		ch.Error("获取消息可见范围失败！", zap.Error(err), zap.Any("req", req))
+		c.ResponseError(err)
+		return
+	}
+	if visibleStartSeq == service.NotVisibleMsgSeq {
+		c.JSON(http.StatusOK, emptySyncMessageResp)
+		return
+	}
+	if req.PullMode == PullModeUp && req.StartMessageSeq != 0 && req.StartMessageSeq < visibleStartSeq {
+		req.StartMessageSeq = visibleStartSeq
+	}
+
 	if req.StartMessageSeq == 0 && req.EndMessageSeq == 0 {
 		messages, err = service.Store.LoadLastMsgs(fakeChannelID, req.ChannelType, limit)
 	} else if req.PullMode == PullModeUp { // 向上拉取
@@ -965,15 +1185,20 @@ func (ch *channel) syncMessages(c *wkhttp.Context) {
 		return
 	}
 	messageResps := make([]*types.MessageResp, 0, len(messages))
+	var invisible bool // 是否有不可见的消息
 	if len(messages) > 0 {
 		for _, message := range messages {
+			if uint64(message.MessageSeq) < visibleStartSeq {
+				invisible = true
+				continue
+			}
 			messageResp := &types.MessageResp{}
 			messageResp.From(message, options.G.SystemUID)
 			messageResps = append(messageResps, messageResp)
 		}
 	}
 	var more bool = true // 是否有更多数据
-	if len(messageResps) < limit {
+	if len(messageResps) < limit || (invisible && req.PullMode == PullModeDown) {
 		more = false
 	}
 	if len(messageResps) > 0 {
@@ -1044,6 +1269,11 @@ func (ch *channel) addOrUpdateChannel(channelInfo wkdb.ChannelInfo) error {
 	if err != nil && err != wkdb.ErrNotFound {
 		return err
 	}
+	if channelInfo.ParentChannelId != "" && (existChannel.ParentChannelId != channelInfo.ParentChannelId || existChannel.ParentChannelType != channelInfo.ParentChannelType) {
+		if err = checkChannelAncestors(channelInfo, service.DatasourceManager.GetChannelInfo); err != nil {
+			return err
+		}
+	}
 
 	if wkdb.IsEmptyChannelInfo(existChannel) {
 		err = service.Store.AddChannelInfo(channelInfo)
@@ -1056,5 +1286,104 @@ func (ch *channel) addOrUpdateChannel(channelInfo wkdb.ChannelInfo) error {
 			return err
 		}
 	}
+
+	// 父频道或继承关系变化，更新父频道的子频道列表
+	if existChannel.ParentChannelId == channelInfo.ParentChannelId && existChannel.ParentChannelType == channelInfo.ParentChannelType && existChannel.Inherit == channelInfo.Inherit {
+		return nil
+	}
+	if existChannel.ParentChannelId != "" && (existChannel.ParentChannelId != channelInfo.ParentChannelId || existChannel.ParentChannelType != channelInfo.ParentChannelType) {
+		err = service.Store.RemoveChannelChild(existChannel.ParentChannelId, existChannel.ParentChannelType, wkdb.ChannelChild{ChannelId: channelInfo.ChannelId, ChannelType: channelInfo.ChannelType})
+		if err != nil {
+			return err
+		}
+	}
+	if channelInfo.ParentChannelId != "" {
+		err = service.Store.AddChannelChild(channelInfo.ParentChannelId, channelInfo.ParentChannelType, wkdb.ChannelChild{
+			ChannelId:   channelInfo.ChannelId,
+			ChannelType: channelInfo.ChannelType,
+			Inherit:     channelInfo.Inherit,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// 订阅者来源变化，使频道（包括逐级继承了订阅者的子频道）的缓存和tag失效
+	return ch.invalidateChannel(channelInfo.ChannelId, channelInfo.ChannelType, 0)
+}
+
+var (
+	errChannelParentCycle   = errors.New("父频道不能是自己的子频道！")
+	errChannelParentTooDeep = fmt.Errorf("父频道层级不能超过%d！", service.MaxChannelInheritDepth)
+)
+
+// 父频道参数错误，直接返回给调用方
+func isChannelParentErr(err error) bool {
+	return err == errChannelParentCycle || err == errChannelParentTooDeep
+}
+
+// 沿着父频道逐级向上检查，祖先频道不能是频道自己（成环），层级不能超过service.MaxChannelInheritDepth
+func checkChannelAncestors(channelInfo wkdb.ChannelInfo, getChannelInfo func(channelId string, channelType uint8) (wkdb.ChannelInfo, error)) error {
+	parentId, parentType := channelInfo.ParentChannelId, channelInfo.ParentChannelType
+	for depth := 1; parentId != ""; depth++ {
+		if parentId == channelInfo.ChannelId && parentType == channelInfo.ChannelType {
+			return errChannelParentCycle
+		}
+		if depth > service.MaxChannelInheritDepth {
+			return errChannelParentTooDeep
+		}
+		parent, err := getChannelInfo(parentId, parentType)
+		if err != nil && err != wkdb.ErrNotFound {
+			return err
+		}
+		parentId, parentType = parent.ParentChannelId, parent.ParentChannelType
+	}
+	return nil
+}
+
+// 使频道在本节点和频道领导节点（tag在频道领导节点上制作）的缓存和tag失效，继承了订阅者的子频道同样失效
+func (ch *channel) invalidateChannel(channelId string, channelType uint8, depth int) error {
+	if depth > service.MaxChannelInheritDepth {
+		ch.Warn("invalidateChannel: channel inherit too deep", zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return nil
+	}
+	service.DatasourceManager.Invalidate(channelId, channelType)
+
+	for _, fakeChannelId := range []string{channelId, options.G.OrginalConvertCmdChannel(channelId)} {
+		if err := ch.invalidateLeaderChannel(fakeChannelId, channelType, channelId); err != nil {
+			return err
+		}
+	}
+
+	children, err := service.Store.GetChannelChildren(channelId, channelType)
+	if err != nil {
+		ch.Error("invalidateChannel: getChannelChildren failed", zap.Error(err))
+		return err
+	}
+	for _, child := range children {
+		if !child.Inherit {
+			continue
+		}
+		if err = ch.invalidateChannel(child.ChannelId, child.ChannelType, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 在频道（fakeChannelId，普通频道或cmd频道）的领导节点上使channelId的缓存和tag失效
+func (ch *channel) invalidateLeaderChannel(fakeChannelId string, channelType uint8, channelId string) error {
+	cfg, err := service.Cluster.LoadOnlyChannelClusterConfig(fakeChannelId, channelType)
+	if err != nil && err != cluster.ErrChannelClusterConfigNotFound {
+		ch.Info("invalidateLeaderChannel: loadOnlyChannelClusterConfig failed")
+		return nil
+	}
+	if cfg.LeaderId == 0 || options.G.IsLocalNode(cfg.LeaderId) { // 频道还没选举过不存在tag，本节点在上面已经失效了
+		return nil
+	}
+	err = ch.s.client.InvalidateChannel(cfg.LeaderId, channelId, channelType)
+	if err != nil {
+		ch.Error("invalidateLeaderChannel: invalidate channel failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType), zap.Uint64("leaderId", cfg.LeaderId))
+		return err
+	}
 	return nil
 }
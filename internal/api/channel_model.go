@@ -7,6 +7,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/types"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/pkg/errors"
 )
 
@@ -17,6 +18,13 @@ type channelInfoReq struct {
 	Large       int    `json:"large"`        // 是否是超大群
 	Ban         int    `json:"ban"`          // 是否封禁频道（封禁后此频道所有人都将不能发消息，除了系统账号）
 	Disband     int    `json:"disband"`      // 是否解散频道
+	// 父频道（子区、话题等子频道才有）
+	ParentChannelID   string `json:"parent_channel_id"`   // 父频道ID
+	ParentChannelType uint8  `json:"parent_channel_type"` // 父频道类型
+	Inherit           int    `json:"inherit"`             // 是否继承父频道的订阅者和权限（黑名单、白名单、封禁、解散）
+	// 新成员的历史消息可见策略
+	HistoryVisibility uint8  `json:"history_visibility"` // 0.可见全部历史消息 1.只可见加入之后的消息 2.可见加入前最近history_count条消息
+	HistoryCount      uint32 `json:"history_count"`      // history_visibility为2时，可见的加入前消息数量
 }
 
 func (c channelInfoReq) ToChannelInfo() wkdb.ChannelInfo {
@@ -30,9 +38,42 @@ func (c channelInfoReq) ToChannelInfo() wkdb.ChannelInfo {
 		Disband:     c.Disband == 1,
 		CreatedAt:   &createdAt,
 		UpdatedAt:   &updatedAt,
+
+		ParentChannelId:   c.ParentChannelID,
+		ParentChannelType: c.ParentChannelType,
+		Inherit:           c.ParentChannelID != "" && c.Inherit == 1,
+
+		HistoryVisibility: c.HistoryVisibility,
+		HistoryCount:      c.HistoryCount,
 	}
 }
 
+// checkParent 检查父频道参数（祖先频道是否成环和层级在addOrUpdateChannel里检查）
+func (c channelInfoReq) checkParent() error {
+	if c.ParentChannelID == "" {
+		return nil
+	}
+	if c.ParentChannelType == 0 || c.ParentChannelType == wkproto.ChannelTypePerson {
+		return errors.New("父频道类型错误！")
+	}
+	if c.ParentChannelID == c.ChannelID && c.ParentChannelType == c.ChannelType {
+		return errors.New("父频道不能是自己！")
+	}
+	return nil
+}
+
+// checkHistoryVisibility 检查历史消息可见策略参数
+func (c channelInfoReq) checkHistoryVisibility() error {
+	if c.HistoryVisibility > wkdb.HistoryVisibilityLastN {
+		return errors.New("history_visibility错误！")
+	}
+	// 继承父频道订阅者的子频道没有自己的订阅者，不支持限制历史消息
+	if c.HistoryVisibility != wkdb.HistoryVisibilityAll && c.ParentChannelID != "" && c.Inherit == 1 {
+		return errors.New("继承父频道订阅者的子频道不支持设置history_visibility！")
+	}
+	return nil
+}
+
 // ChannelCreateReq 频道创建请求
 type channelCreateReq struct {
 	channelInfoReq
@@ -51,6 +92,12 @@ func (r channelCreateReq) Check() error {
 	if options.IsSpecialChar(r.ChannelID) {
 		return errors.New("频道ID不能包含特殊字符！")
 	}
+	if err := r.checkParent(); err != nil {
+		return err
+	}
+	if err := r.checkHistoryVisibility(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -185,3 +232,9 @@ type syncMessageResp struct {
 	More            int                  `json:"more"`              // 是否还有更多 1.是 0.否
 	Messages        []*types.MessageResp `json:"messages"`          // 消息数据
 }
+
+type channelChildResp struct {
+	ChannelId   string `json:"channel_id"`   // 子频道ID
+	ChannelType uint8  `json:"channel_type"` // 子频道类型
+	Inherit     int    `json:"inherit"`      // 是否继承父频道的订阅者和权限
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckChannelAncestors(t *testing.T) {
+	channels := map[string]wkdb.ChannelInfo{
+		"community": {ChannelId: "community", ChannelType: 2},
+		"topic":     {ChannelId: "topic", ChannelType: 2, ParentChannelId: "community", ParentChannelType: 2},
+		"thread":    {ChannelId: "thread", ChannelType: 2, ParentChannelId: "topic", ParentChannelType: 2},
+	}
+	getChannelInfo := func(channelId string, channelType uint8) (wkdb.ChannelInfo, error) {
+		return channels[channelId], nil
+	}
+
+	// 正常的父频道
+	assert.NoError(t, checkChannelAncestors(wkdb.ChannelInfo{ChannelId: "reply", ChannelType: 2, ParentChannelId: "thread", ParentChannelType: 2}, getChannelInfo))
+
+	// 父频道是自己的子频道（成环）
+	err := checkChannelAncestors(wkdb.ChannelInfo{ChannelId: "community", ChannelType: 2, ParentChannelId: "thread", ParentChannelType: 2}, getChannelInfo)
+	assert.Equal(t, errChannelParentCycle, err)
+
+	// 层级超过限制
+	for i := 0; i < service.MaxChannelInheritDepth; i++ {
+		channels[fmt.Sprintf("c%d", i)] = wkdb.ChannelInfo{ChannelId: fmt.Sprintf("c%d", i), ChannelType: 2, ParentChannelId: fmt.Sprintf("c%d", i+1), ParentChannelType: 2}
+	}
+	err = checkChannelAncestors(wkdb.ChannelInfo{ChannelId: "leaf", ChannelType: 2, ParentChannelId: "c0", ParentChannelType: 2}, getChannelInfo)
+	assert.Equal(t, errChannelParentTooDeep, err)
+	err = checkChannelAncestors(wkdb.ChannelInfo{ChannelId: "leaf", ChannelType: 2, ParentChannelId: "c1", ParentChannelType: 2}, getChannelInfo)
+	assert.NoError(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/internal/options"
@@ -22,12 +23,16 @@ import (
 type conversation struct {
 	s *Server
 	wklog.Log
+
+	syncRecordMap  map[string][]*syncRecord // 设备最后一次同步会话返回的记录，客户端回执后才记录为设备的同步位置
+	syncRecordLock sync.RWMutex
 }
 
 func newConversation(s *Server) *conversation {
 	return &conversation{
-		s:   s,
-		Log: wklog.NewWKLog("conversation"),
+		s:             s,
+		Log:           wklog.NewWKLog("conversation"),
+		syncRecordMap: map[string][]*syncRecord{},
 	}
 }
 
@@ -37,7 +42,9 @@ func (s *conversation) route(r *wkhttp.WKHttp) {
 	r.POST("/conversations/clearUnread", s.clearConversationUnread) // 清空会话未读数量
 	r.POST("/conversations/setUnread", s.setConversationUnread)     // 设置会话未读数量
 	r.POST("/conversations/delete", s.deleteConversation)           // 删除会话
+	r.POST("/conversation/setting", s.setConversationSetting)       // 设置会话（置顶、免打扰、归档、分组、草稿）
 	r.POST("/conversation/sync", s.syncUserConversation)            // 同步会话
+	r.POST("/conversation/syncack", s.syncack)                      // 同步会话回执(按设备记录同步位置)
 	r.POST("/conversation/syncMessages", s.syncRecentMessages)      // 同步会话最近消息
 }
 
@@ -255,6 +262,89 @@ func (s *conversation) setConversationUnread(c *wkhttp.Context) {
 	c.ResponseOK()
 }
 
+// 设置会话的置顶、免打扰、归档、分组和草稿，只修改请求里传了的字段
+func (s *conversation) setConversationSetting(c *wkhttp.Context) {
+	var req conversationSettingReq
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+
+	if options.G.ClusterOn() {
+		leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.UID, wkproto.ChannelTypePerson) // 获取频道的领导节点
+		if err != nil {
+			s.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", req.UID), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+			c.ResponseError(errors.New("获取频道所在节点失败！"))
+			return
+		}
+		leaderIsSelf := leaderInfo.Id == options.G.Cluster.NodeId
+		if !leaderIsSelf {
+			s.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+			c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+			return
+		}
+	}
+
+	fakeChannelId := req.ChannelID
+	if req.ChannelType == wkproto.ChannelTypePerson {
+		fakeChannelId = options.GetFakeChannelIDWith(req.UID, req.ChannelID)
+	}
+
+	conversation, err := service.Store.GetConversation(req.UID, fakeChannelId, req.ChannelType)
+	if err != nil && err != wkdb.ErrNotFound {
+		s.Error("Failed to query conversation", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	now := time.Now()
+	if wkdb.IsEmptyConversation(conversation) {
+		createdAt := now
+		conversation = wkdb.Conversation{
+			Uid:         req.UID,
+			Type:        wkdb.ConversationTypeChat,
+			ChannelId:   fakeChannelId,
+			ChannelType: req.ChannelType,
+			CreatedAt:   &createdAt,
+		}
+	}
+
+	if req.Pinned != nil {
+		conversation.Pinned = *req.Pinned
+	}
+	if req.Mute != nil {
+		conversation.Mute = *req.Mute
+	}
+	if req.Archived != nil {
+		conversation.Archived = *req.Archived
+	}
+	if req.Folder != nil {
+		conversation.Folder = *req.Folder
+	}
+	if req.Draft != nil {
+		conversation.Draft = *req.Draft
+	}
+	conversation.SettingUpdatedAt = &now
+	conversation.UpdatedAt = &now
+
+	err = service.Store.AddOrUpdateUserConversations(req.UID, []wkdb.Conversation{conversation})
+	if err != nil {
+		s.Error("Failed to update conversation setting", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	service.ConversationManager.DeleteFromCache(req.UID, fakeChannelId, req.ChannelType)
+
+	c.ResponseOK()
+}
+
 func (s *conversation) deleteConversation(c *wkhttp.Context) {
 	var req deleteChannelReq
 	bodyBytes, err := BindJSON(&req, c)
@@ -304,9 +394,11 @@ func (s *conversation) deleteConversation(c *wkhttp.Context) {
 func (s *conversation) syncUserConversation(c *wkhttp.Context) {
 	var req struct {
 		UID         string `json:"uid"`
+		DeviceFlag  *uint8 `json:"device_flag"`   // 设备标识，传了则按设备记录每个会话已同步到的消息序号（客户端回执后生效）
 		Version     int64  `json:"version"`       // 当前客户端的会话最大版本号(客户端最新会话的时间戳)
 		LastMsgSeqs string `json:"last_msg_seqs"` // 客户端所有会话的最后一条消息序列号 格式： channelID:channelType:last_msg_seq|channelID:channelType:last_msg_seq
 		MsgCount    int64  `json:"msg_count"`     // 每个会话消息数量
+		RollUp      int    `json:"roll_up"`       // 1.子频道的会话归到最上级父频道的会话下返回
 	}
 	bodyBytes, err := BindJSON(&req, c)
 	if err != nil {
@@ -386,21 +478,36 @@ func (s *conversation) syncUserConversation(c *wkhttp.Context) {
 	// 去掉重复的会话
 	conversations = removeDuplicates(conversations)
 
+	// 按设备同步且客户端有本地数据时，客户端没传的会话使用设备上次同步到的位置
+	var deviceCursorMap map[string]uint64
+	if req.DeviceFlag != nil && req.Version > 0 {
+		deviceCursorMap, err = getDeviceSyncCursorMap(req.UID, *req.DeviceFlag)
+		if err != nil {
+			s.Error("获取设备同步位置失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", *req.DeviceFlag))
+			c.ResponseError(errors.New("获取设备同步位置失败！"))
+			return
+		}
+	}
+
 	// 设置最近会话已读至的消息序列号
 	for _, conversation := range conversations {
 
 		realChannelId := getRealChannelId(conversation.ChannelId, conversation.ChannelType)
 
-		msgSeq := channelLastMsgMap[fmt.Sprintf("%s-%d", realChannelId, conversation.ChannelType)]
+		msgSeq, ok := channelLastMsgMap[fmt.Sprintf("%s-%d", realChannelId, conversation.ChannelType)]
+		if !ok {
+			msgSeq = deviceCursorMap[wkutil.ChannelToKey(conversation.ChannelId, conversation.ChannelType)]
+		}
 
 		if msgSeq != 0 {
 			msgSeq = msgSeq + 1 // 如果客户端传递了messageSeq，则需要获取这个messageSeq之后的消息
 		}
 
 		channelRecentMessageReqs = append(channelRecentMessageReqs, &channelRecentMessageReq{
-			ChannelId:   conversation.ChannelId,
-			ChannelType: conversation.ChannelType,
-			LastMsgSeq:  msgSeq,
+			ChannelId:      conversation.ChannelId,
+			ChannelType:    conversation.ChannelType,
+			LastMsgSeq:     msgSeq,
+			ReadedToMsgSeq: conversation.ReadToMsgSeq,
 		})
 		// syncUserConversationR := newSyncUserConversationResp(conversation)
 		// resps = append(resps, syncUserConversationR)
@@ -433,33 +540,135 @@ func (s *conversation) syncUserConversation(c *wkhttp.Context) {
 						resp.LastMsgSeq = uint32(lastMsg.MessageSeq)
 						resp.LastClientMsgNo = lastMsg.ClientMsgNo
 						resp.Timestamp = int64(lastMsg.Timestamp)
-						if lastMsg.MessageSeq > uint64(resp.ReadedToMsgSeq) {
-							resp.Unread = int(lastMsg.MessageSeq - uint64(resp.ReadedToMsgSeq))
+						// 加入前不可见的历史消息不计入未读
+						unreadStart := unreadStartMsgSeq(uint64(resp.ReadedToMsgSeq), channelRecentMessage.VisibleStartSeq)
+						if lastMsg.MessageSeq >= unreadStart {
+							resp.Unread = int(lastMsg.MessageSeq - unreadStart + 1)
 						}
 
 						resp.Version = time.Unix(int64(lastMsg.Timestamp), 0).UnixNano()
 					}
 
 					resp.Recents = channelRecentMessage.Messages
+					resp.MentionCount = channelRecentMessage.MentionCount
+					resp.LastMentionSeq = channelRecentMessage.LastMentionSeq
+					resp.ParentChannelId = channelRecentMessage.ParentChannelId
+					resp.ParentChannelType = channelRecentMessage.ParentChannelType
 					break
 				}
 			}
 
+			// 会话设置在客户端版本之后有修改，即使没有新消息也需要同步给客户端
+			settingChanged := conversation.SettingUpdatedAt != nil && conversation.SettingUpdatedAt.UnixNano() > req.Version
+			if settingChanged && conversation.SettingUpdatedAt.UnixNano() > resp.Version {
+				resp.Version = conversation.SettingUpdatedAt.UnixNano()
+			}
+
 			msgSeq := channelLastMsgMap[fmt.Sprintf("%s-%d", conversation.ChannelId, conversation.ChannelType)]
 
-			if msgSeq != 0 && msgSeq >= uint64(resp.LastMsgSeq) {
+			if msgSeq != 0 && msgSeq >= uint64(resp.LastMsgSeq) && !settingChanged {
 				continue
 			}
 
-			if len(resp.Recents) > 0 {
+			if len(resp.Recents) > 0 || settingChanged {
 				resps = append(resps, resp)
 			}
 		}
 	}
 
+	// 记录本次返回给设备的每个会话的消息序号，等客户端回执（/conversation/syncack）后再记录为设备的同步位置
+	if req.DeviceFlag != nil {
+		records := make([]*syncRecord, 0, len(resps))
+		for _, resp := range resps {
+			channelId := resp.ChannelId
+			if resp.ChannelType == wkproto.ChannelTypePerson {
+				channelId = options.GetFakeChannelIDWith(req.UID, resp.ChannelId)
+			}
+			records = append(records, &syncRecord{
+				channelId:   channelId,
+				channelType: resp.ChannelType,
+				lastMsgSeq:  uint64(resp.LastMsgSeq),
+			})
+		}
+		recordKey := syncRecordKey(req.UID, req.DeviceFlag)
+		s.syncRecordLock.Lock()
+		s.syncRecordMap[recordKey] = records
+		s.syncRecordLock.Unlock()
+	}
+
+	if req.RollUp == 1 {
+		resps = rollUpConversations(resps)
+	}
+
 	c.JSON(http.StatusOK, resps)
 }
 
+// 同步会话回执，将设备最后一次同步到的会话消息序号记录为设备的同步位置
+func (s *conversation) syncack(c *wkhttp.Context) {
+	var req conversationSyncackReq
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.UID, wkproto.ChannelTypePerson) // 获取频道的领导节点
+	if err != nil {
+		s.Error("获取频道所在节点失败！!", zap.Error(err), zap.String("channelID", req.UID), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	if leaderInfo.Id != options.G.Cluster.NodeId {
+		s.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	recordKey := syncRecordKey(req.UID, &req.DeviceFlag)
+	s.syncRecordLock.Lock()
+	records := s.syncRecordMap[recordKey]
+	delete(s.syncRecordMap, recordKey)
+	s.syncRecordLock.Unlock()
+	if len(records) == 0 {
+		c.ResponseOK()
+		return
+	}
+
+	cursorMap, err := getDeviceSyncCursorMap(req.UID, req.DeviceFlag)
+	if err != nil {
+		s.Error("获取设备同步位置失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", req.DeviceFlag))
+		c.ResponseError(errors.New("获取设备同步位置失败！"))
+		return
+	}
+	cursors := make([]wkdb.DeviceSyncCursor, 0, len(records))
+	for _, record := range records {
+		if record.lastMsgSeq <= cursorMap[wkutil.ChannelToKey(record.channelId, record.channelType)] {
+			continue
+		}
+		cursors = append(cursors, wkdb.DeviceSyncCursor{
+			Uid:         req.UID,
+			DeviceFlag:  req.DeviceFlag,
+			ChannelId:   record.channelId,
+			ChannelType: record.channelType,
+			MessageSeq:  record.lastMsgSeq,
+		})
+	}
+	if len(cursors) > 0 {
+		err = service.Store.SetDeviceSyncCursors(req.UID, cursors)
+		if err != nil {
+			s.Error("保存设备同步位置失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", req.DeviceFlag))
+			c.ResponseError(errors.New("保存设备同步位置失败！"))
+			return
+		}
+	}
+	c.ResponseOK()
+}
+
 func removeDuplicates(conversations []wkdb.Conversation) []wkdb.Conversation {
 	seen := make(map[string]bool)
 	result := []wkdb.Conversation{}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/types"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/pkg/errors"
 )
@@ -25,6 +27,18 @@ func (req clearConversationUnreadReq) Check() error {
 	return nil
 }
 
+type conversationSyncackReq struct {
+	UID        string `json:"uid"`         // 用户uid
+	DeviceFlag uint8  `json:"device_flag"` // 设备标识，需要与同步会话时传的一致
+}
+
+func (req conversationSyncackReq) Check() error {
+	if req.UID == "" {
+		return errors.New("uid cannot be empty")
+	}
+	return nil
+}
+
 type deleteChannelReq struct {
 	UID         string `json:"uid"`
 	ChannelID   string `json:"channel_id"`
@@ -42,16 +56,27 @@ func (req deleteChannelReq) Check() error {
 }
 
 type syncUserConversationResp struct {
-	ChannelId       string               `json:"channel_id"`         // 频道ID
-	ChannelType     uint8                `json:"channel_type"`       // 频道类型
-	Unread          int                  `json:"unread"`             // 未读消息
-	Timestamp       int64                `json:"timestamp"`          // 最后一次会话时间
-	LastMsgSeq      uint32               `json:"last_msg_seq"`       // 最后一条消息seq
-	LastClientMsgNo string               `json:"last_client_msg_no"` // 最后一次消息客户端编号
-	OffsetMsgSeq    int64                `json:"offset_msg_seq"`     // 偏移位的消息seq
-	ReadedToMsgSeq  uint32               `json:"readed_to_msg_seq"`  // 已读至的消息seq
-	Version         int64                `json:"version"`            // 数据版本
-	Recents         []*types.MessageResp `json:"recents"`            // 最近N条消息
+	ChannelId         string               `json:"channel_id"`                    // 频道ID
+	ChannelType       uint8                `json:"channel_type"`                  // 频道类型
+	Unread            int                  `json:"unread"`                        // 未读消息
+	Timestamp         int64                `json:"timestamp"`                     // 最后一次会话时间
+	LastMsgSeq        uint32               `json:"last_msg_seq"`                  // 最后一条消息seq
+	LastClientMsgNo   string               `json:"last_client_msg_no"`            // 最后一次消息客户端编号
+	OffsetMsgSeq      int64                `json:"offset_msg_seq"`                // 偏移位的消息seq
+	ReadedToMsgSeq    uint32               `json:"readed_to_msg_seq"`             // 已读至的消息seq
+	Version           int64                `json:"version"`                       // 数据版本
+	Pinned            int                  `json:"pinned"`                        // 是否置顶
+	Mute              int                  `json:"mute"`                          // 是否免打扰
+	Archived          int                  `json:"archived"`                      // 是否归档
+	Folder            string               `json:"folder"`                        // 自定义分组
+	Draft             string               `json:"draft"`                         // 草稿
+	MentionCount      int                  `json:"mention_count"`                 // 未读消息里被提醒（@）的次数
+	LastMentionSeq    uint64               `json:"last_mention_seq"`              // 未读消息里最后一次被提醒的消息seq
+	ParentChannelId   string               `json:"parent_channel_id,omitempty"`   // 父频道ID（子频道才有）
+	ParentChannelType uint8                `json:"parent_channel_type,omitempty"` // 父频道类型
+	Recents           []*types.MessageResp `json:"recents"`                       // 最近N条消息
+	// 归到此频道下的子频道会话（请求开启了roll_up才有）
+	Children []*syncUserConversationResp `json:"children,omitempty"`
 }
 
 func newSyncUserConversationResp(conversation wkdb.Conversation) *syncUserConversationResp {
@@ -69,17 +94,105 @@ func newSyncUserConversationResp(conversation wkdb.Conversation) *syncUserConver
 		ChannelType:    conversation.ChannelType,
 		Unread:         int(conversation.UnreadCount),
 		ReadedToMsgSeq: uint32(conversation.ReadToMsgSeq),
+		Pinned:         wkutil.BoolToInt(conversation.Pinned),
+		Mute:           wkutil.BoolToInt(conversation.Mute),
+		Archived:       wkutil.BoolToInt(conversation.Archived),
+		Folder:         conversation.Folder,
+		Draft:          conversation.Draft,
 	}
 }
 
+// 子频道的会话归到最上级父频道的会话下，未读数和提醒数累加到父频道
+// 父频道没有会话时生成一个只有汇总数据的父频道会话；层级过深或父频道成环的会话不归并
+func rollUpConversations(resps []*syncUserConversationResp) []*syncUserConversationResp {
+	respMap := make(map[string]*syncUserConversationResp, len(resps))
+	for _, resp := range resps {
+		respMap[wkutil.ChannelToKey(resp.ChannelId, resp.ChannelType)] = resp
+	}
+	var parentResps []*syncUserConversationResp // 生成的父频道会话
+
+	rootOf := func(resp *syncUserConversationResp) *syncUserConversationResp {
+		root := resp
+		for depth := 0; root.ParentChannelId != ""; depth++ {
+			if depth >= service.MaxChannelInheritDepth {
+				return resp
+			}
+			parentKey := wkutil.ChannelToKey(root.ParentChannelId, root.ParentChannelType)
+			parent, ok := respMap[parentKey]
+			if !ok {
+				parent = &syncUserConversationResp{
+					ChannelId:   root.ParentChannelId,
+					ChannelType: root.ParentChannelType,
+				}
+				respMap[parentKey] = parent
+				parentResps = append(parentResps, parent)
+			}
+			if parent == resp {
+				return resp
+			}
+			root = parent
+		}
+		return root
+	}
+
+	rolled := make([]*syncUserConversationResp, 0, len(resps))
+	for _, resp := range resps {
+		root := rootOf(resp)
+		if root == resp {
+			rolled = append(rolled, resp)
+			continue
+		}
+		root.Children = append(root.Children, resp)
+		root.Unread += resp.Unread
+		root.MentionCount += resp.MentionCount
+		if resp.Version > root.Version {
+			root.Version = resp.Version
+			root.Timestamp = resp.Timestamp
+		}
+	}
+	return append(rolled, parentResps...)
+}
+
+type conversationSettingReq struct {
+	UID         string  `json:"uid"`
+	ChannelID   string  `json:"channel_id"`
+	ChannelType uint8   `json:"channel_type"`
+	Pinned      *bool   `json:"pinned"`   // 是否置顶
+	Mute        *bool   `json:"mute"`     // 是否免打扰
+	Archived    *bool   `json:"archived"` // 是否归档
+	Folder      *string `json:"folder"`   // 自定义分组
+	Draft       *string `json:"draft"`    // 草稿
+}
+
+func (req conversationSettingReq) Check() error {
+	if req.UID == "" {
+		return errors.New("uid cannot be empty")
+	}
+	if req.ChannelID == "" || req.ChannelType == 0 {
+		return errors.New("channel_id or channel_type cannot be empty")
+	}
+	if req.Pinned == nil && req.Mute == nil && req.Archived == nil && req.Folder == nil && req.Draft == nil {
+		return errors.New("no setting to update")
+	}
+	return nil
+}
+
 type channelRecentMessageReq struct {
-	ChannelId   string `json:"channel_id"`
-	ChannelType uint8  `json:"channel_type"`
-	LastMsgSeq  uint64 `json:"last_msg_seq"`
+	ChannelId      string `json:"channel_id"`
+	ChannelType    uint8  `json:"channel_type"`
+	LastMsgSeq     uint64 `json:"last_msg_seq"`
+	ReadedToMsgSeq uint64 `json:"readed_to_msg_seq,omitempty"` // 用户已读至的消息seq，用于统计未读消息里的提醒（@）
 }
 
 type channelRecentMessage struct {
-	ChannelId   string               `json:"channel_id"`
-	ChannelType uint8                `json:"channel_type"`
-	Messages    []*types.MessageResp `json:"messages"`
+	ChannelId      string               `json:"channel_id"`
+	ChannelType    uint8                `json:"channel_type"`
+	Messages       []*types.MessageResp `json:"messages"`
+	MentionCount   int                  `json:"mention_count,omitempty"`    // 已读之后被提醒（@）的次数
+	LastMentionSeq uint64               `json:"last_mention_seq,omitempty"` // 已读之后最后一次被提醒的消息seq
+	// 用户可见的起始消息seq（包含），未读数量从已读之后和这个seq中较大的开始计算
+	VisibleStartSeq uint64 `json:"visible_start_seq,omitempty"`
+	// 父频道（子频道的会话客户端可以归到父频道下显示）
+	ParentChannelId   string `json:"parent_channel_id,omitempty"`
+	ParentChannelType uint8  `json:"parent_channel_type,omitempty"`
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollUpConversations(t *testing.T) {
+	resps := []*syncUserConversationResp{
+		{ChannelId: "community", ChannelType: 2, Unread: 1, Version: 1},
+		{ChannelId: "topic1", ChannelType: 2, Unread: 2, MentionCount: 1, Version: 3, Timestamp: 3, ParentChannelId: "community", ParentChannelType: 2},
+		{ChannelId: "thread1", ChannelType: 2, Unread: 3, Version: 2, ParentChannelId: "topic1", ParentChannelType: 2},
+		{ChannelId: "topic2", ChannelType: 2, Unread: 4, ParentChannelId: "community2", ParentChannelType: 2},
+		{ChannelId: "a", ChannelType: 2, Unread: 1, ParentChannelId: "b", ParentChannelType: 2},
+		{ChannelId: "b", ChannelType: 2, Unread: 1, ParentChannelId: "a", ParentChannelType: 2},
+	}
+
+	rolled := rollUpConversations(resps)
+	assert.Equal(t, 4, len(rolled))
+
+	// 子频道和子频道的子频道都归到最上级父频道
+	community := rolled[0]
+	assert.Equal(t, "community", community.ChannelId)
+	assert.Equal(t, 2, len(community.Children))
+	assert.Equal(t, 6, community.Unread)
+	assert.Equal(t, 1, community.MentionCount)
+	assert.Equal(t, int64(3), community.Version)
+	assert.Equal(t, int64(3), community.Timestamp)
+
+	// 父频道成环的会话不归并
+	assert.Equal(t, "a", rolled[1].ChannelId)
+	assert.Equal(t, "b", rolled[2].ChannelId)
+
+	// 父频道没有会话时生成汇总的父频道会话
+	community2 := rolled[3]
+	assert.Equal(t, "community2", community2.ChannelId)
+	assert.Equal(t, 4, community2.Unread)
+	assert.Equal(t, 1, len(community2.Children))
+}
+
+func TestUnreadStartMsgSeq(t *testing.T) {
+	// 没有可见范围限制时从已读之后开始
+	assert.Equal(t, uint64(11), unreadStartMsgSeq(10, 0))
+	// 加入前的历史消息不可见，从可见的起始消息开始
+	assert.Equal(t, uint64(50), unreadStartMsgSeq(10, 50))
+	// 已读超过可见起始消息时从已读之后开始
+	assert.Equal(t, uint64(61), unreadStartMsgSeq(60, 50))
+}
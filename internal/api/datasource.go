@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/network"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// 数据源（第三方的频道信息、订阅者、黑白名单变化后，通知各个节点使缓存失效）
+type datasource struct {
+	s *Server
+	wklog.Log
+}
+
+func newDatasource(s *Server) *datasource {
+	return &datasource{
+		s:   s,
+		Log: wklog.NewWKLog("datasource"),
+	}
+}
+
+func (d *datasource) route(r *wkhttp.WKHttp) {
+	r.POST("/datasource/invalidate", d.invalidate)            // 使所有节点的频道缓存失效
+	r.POST("/datasource/invalidate_local", d.invalidateLocal) // 仅仅使本节点的频道缓存失效(节点内部调用)
+}
+
+type datasourceInvalidateReq struct {
+	ChannelId   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+}
+
+func (r datasourceInvalidateReq) Check() error {
+	if strings.TrimSpace(r.ChannelId) == "" {
+		return errors.New("channel_id不能为空！")
+	}
+	if r.ChannelType == 0 {
+		return errors.New("channel_type不能为0！")
+	}
+	return nil
+}
+
+func (d *datasource) invalidate(c *wkhttp.Context) {
+	var req datasourceInvalidateReq
+	if err := c.BindJSON(&req); err != nil {
+		d.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	service.DatasourceManager.Invalidate(req.ChannelId, req.ChannelType)
+
+	// 通知其他节点使缓存失效
+	nodes := service.Cluster.Nodes()
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), options.G.Cluster.ReqTimeout)
+	defer cancel()
+	requestGroup, _ := errgroup.WithContext(timeoutCtx)
+	for _, node := range nodes {
+		if node.Id == options.G.Cluster.NodeId {
+			continue
+		}
+		if !node.Online {
+			continue
+		}
+		requestGroup.Go(func(n *pb.Node) func() error {
+			return func() error {
+				return d.requestInvalidateLocal(n, req)
+			}
+		}(node))
+	}
+	if err := requestGroup.Wait(); err != nil {
+		d.Error("通知节点使缓存失效失败！", zap.Error(err))
+		c.ResponseError(errors.New("通知节点使缓存失效失败！"))
+		return
+	}
+	c.ResponseOK()
+}
+
+func (d *datasource) requestInvalidateLocal(nodeInfo *pb.Node, req datasourceInvalidateReq) error {
+	reqURL := fmt.Sprintf("%s/datasource/invalidate_local", nodeInfo.ApiServerAddr)
+	resp, err := network.Post(reqURL, []byte(wkutil.ToJSON(req)), nil)
+	if err != nil {
+		d.Error("通知节点使缓存失效失败！", zap.Error(err), zap.String("reqURL", reqURL))
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("使缓存失效请求状态错误！[%d]", resp.StatusCode)
+	}
+	return nil
+}
+
+func (d *datasource) invalidateLocal(c *wkhttp.Context) {
+	var req datasourceInvalidateReq
+	if err := c.BindJSON(&req); err != nil {
+		d.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	service.DatasourceManager.Invalidate(req.ChannelId, req.ChannelType)
+	c.ResponseOK()
+}
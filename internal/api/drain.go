@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"go.uber.org/zap"
+)
+
+// 节点排空（滚动发布时使用，操作针对单个节点，通过node_id指定节点）
+type drain struct {
+	s *Server
+	wklog.Log
+}
+
+func newDrain(s *Server) *drain {
+	return &drain{
+		s:   s,
+		Log: wklog.NewWKLog("drain"),
+	}
+}
+
+func (d *drain) route(r *wkhttp.WKHttp) {
+	r.GET("/node/drain", d.status)         // 排空进度
+	r.POST("/node/drain", d.start)         // 开始排空
+	r.POST("/node/drain/cancel", d.cancel) // 取消排空
+}
+
+func (d *drain) status(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, d.Log) {
+		return
+	}
+	c.JSON(http.StatusOK, service.DrainManager.DrainStatus())
+}
+
+func (d *drain) start(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, d.Log) {
+		return
+	}
+	if err := service.DrainManager.Drain(); err != nil {
+		d.Error("start drain failed", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+func (d *drain) cancel(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, d.Log) {
+		return
+	}
+	if err := service.DrainManager.Cancel(); err != nil {
+		d.Error("cancel drain failed", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"go.uber.org/zap"
+)
+
+// 消息静态加密管理（数据密钥是节点本地的，所以操作都是针对单个节点）
+type encryption struct {
+	s *Server
+	wklog.Log
+}
+
+func newEncryption(s *Server) *encryption {
+	return &encryption{
+		s:   s,
+		Log: wklog.NewWKLog("encryption"),
+	}
+}
+
+func (e *encryption) route(r *wkhttp.WKHttp) {
+	r.GET("/db/encryption", e.status)               // 加密状态
+	r.POST("/db/encryption/rotate", e.rotate)       // 轮换数据密钥并重加密
+	r.POST("/db/encryption/reencrypt", e.reencrypt) // 重加密
+}
+
+func (e *encryption) status(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, e.Log) {
+		return
+	}
+	c.JSON(http.StatusOK, newEncryptionStatusResp(service.Store.DB().GetEncryptionStatus()))
+}
+
+func (e *encryption) rotate(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, e.Log) {
+		return
+	}
+	db := service.Store.DB()
+	if err := db.RotateDataKey(); err != nil {
+		e.Error("rotate data key failed", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := db.StartReencrypt(); err != nil {
+		e.Error("start reencrypt failed", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+func (e *encryption) reencrypt(c *wkhttp.Context) {
+	if forwardToNodeIfNeed(c, e.Log) {
+		return
+	}
+	if err := service.Store.DB().StartReencrypt(); err != nil {
+		e.Error("start reencrypt failed", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+type encryptionStatusResp struct {
+	On               bool                     `json:"on"`                // 是否开启加密
+	Reencrypting     bool                     `json:"reencrypting"`      // 是否正在重加密
+	ReencryptedCount int64                    `json:"reencrypted_count"` // 已重加密的payload数量
+	ReencryptErr     string                   `json:"reencrypt_err"`     // 重加密错误
+	LastReencryptAt  int64                    `json:"last_reencrypt_at"` // 最近一次重加密完成时间（纳秒）
+	Shards           []shardDataKeyStatusResp `json:"shards"`            // 分区的数据密钥状态
+}
+
+type shardDataKeyStatusResp struct {
+	ShardId        uint32 `json:"shard_id"`
+	CurrentVersion uint32 `json:"current_version"`
+	KeyCount       int    `json:"key_count"`
+}
+
+func newEncryptionStatusResp(status wkdb.EncryptionStatus) *encryptionStatusResp {
+	shards := make([]shardDataKeyStatusResp, 0, len(status.Shards))
+	for _, shard := range status.Shards {
+		shards = append(shards, shardDataKeyStatusResp{
+			ShardId:        shard.ShardId,
+			CurrentVersion: shard.CurrentVersion,
+			KeyCount:       shard.KeyCount,
+		})
+	}
+	return &encryptionStatusResp{
+		On:               status.On,
+		Reencrypting:     status.Reencrypting,
+		ReencryptedCount: status.ReencryptedCount,
+		ReencryptErr:     status.ReencryptErr,
+		LastReencryptAt:  status.LastReencryptAt,
+		Shards:           shards,
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.uber.org/zap"
+)
+
+// 跨集群联邦（接收其他集群同步过来的消息）
+type federation struct {
+	s *Server
+	wklog.Log
+}
+
+func newFederation(s *Server) *federation {
+	return &federation{
+		s:   s,
+		Log: wklog.NewWKLog("federation"),
+	}
+}
+
+// 接收对端集群同步消息的路径（不校验管理者令牌，使用联邦令牌校验）
+const federationMessagesPath = "/federation/messages"
+
+func (f *federation) route(r *wkhttp.WKHttp) {
+	r.POST(federationMessagesPath, f.receiveMessages) // 接收对端集群同步过来的消息
+}
+
+// 按顺序将对端集群同步过来的消息发送到本集群的频道，消息ID由本集群重新生成并返回映射，某条消息失败时后面的消息不再处理
+func (f *federation) receiveMessages(c *wkhttp.Context) {
+	if !options.G.Federation.On {
+		c.ResponseError(errors.New("没有开启联邦同步！"))
+		return
+	}
+	if options.G.Federation.Token == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(options.FederationTokenHeader)), []byte(options.G.Federation.Token)) != 1 {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	var req types.FederationMessagesReq
+	if err := c.BindJSON(&req); err != nil {
+		f.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	sourceClusterId := strings.TrimSpace(req.SourceClusterId)
+	if sourceClusterId == "" {
+		c.ResponseError(errors.New("source_cluster_id不能为空！"))
+		return
+	}
+	if sourceClusterId == options.G.Federation.ClusterId {
+		c.ResponseError(errors.New("不能接收本集群的消息！"))
+		return
+	}
+
+	mappings := make([]*types.FederationMessageMapping, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		if options.IsFederationClientMsgNo(msg.ClientMsgNo) { // 已经是同步过来的消息，不再转发，避免回环
+			continue
+		}
+		if !options.G.IsFederationChannel(msg.ChannelId, msg.ChannelType) { // 只接收本集群也配置了联邦同步的频道
+			f.Warn("频道没有开启联邦同步，拒绝接收！", zap.String("sourceClusterId", sourceClusterId), zap.Int64("sourceMessageId", msg.MessageId), zap.String("channelId", msg.ChannelId), zap.Uint8("channelType", msg.ChannelType))
+			c.JSON(http.StatusOK, &types.FederationMessagesResp{
+				Messages:        mappings,
+				FailedMessageId: msg.MessageId,
+				Error:           "channel is not a federation channel",
+			})
+			return
+		}
+		channelId := msg.ChannelId
+		if msg.ChannelType == wkproto.ChannelTypePerson {
+			// 个人频道为fake频道ID，转换为接收者
+			channelId = options.G.CmdChannelConvertOrginalChannel(channelId)
+			uid1, uid2 := options.GetFromUIDAndToUIDWith(channelId)
+			if uid1 == msg.FromUid {
+				channelId = uid2
+			} else {
+				channelId = uid1
+			}
+			if strings.TrimSpace(channelId) == "" {
+				f.Warn("个人频道ID不合法，忽略！", zap.String("sourceClusterId", sourceClusterId), zap.Int64("sourceMessageId", msg.MessageId), zap.String("channelId", msg.ChannelId))
+				continue
+			}
+		}
+
+		// client_msg_no映射为源集群的消息ID，重试推送时本集群会去重
+		clientMsgNo := options.FederationClientMsgNo(sourceClusterId, msg.MessageId)
+		messageId, err := sendMessageToChannel(messageSendReq{
+			Header:      msg.Header,
+			ClientMsgNo: clientMsgNo,
+			StreamNo:    msg.StreamNo,
+			FromUID:     msg.FromUid,
+			Expire:      msg.Expire,
+			Payload:     msg.Payload,
+		}, channelId, msg.ChannelType, clientMsgNo, wkproto.StreamFlagIng)
+		if err != nil {
+			f.Error("发送联邦同步消息失败！", zap.Error(err), zap.String("sourceClusterId", sourceClusterId), zap.Int64("sourceMessageId", msg.MessageId), zap.String("channelId", channelId), zap.Uint8("channelType", msg.ChannelType))
+			// 返回已处理的消息和失败的消息，源集群只重试失败的消息
+			c.JSON(http.StatusOK, &types.FederationMessagesResp{
+				Messages:        mappings,
+				FailedMessageId: msg.MessageId,
+				Error:           err.Error(),
+			})
+			return
+		}
+		mappings = append(mappings, &types.FederationMessageMapping{
+			SourceMessageId: msg.MessageId,
+			MessageId:       messageId,
+		})
+	}
+	c.JSON(http.StatusOK, &types.FederationMessagesResp{
+		Messages: mappings,
+	})
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/track"
 	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/network"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
 	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
@@ -44,15 +46,18 @@ func newMessage(s *Server) *message {
 
 // Route route
 func (m *message) route(r *wkhttp.WKHttp) {
-	r.POST("/message/send", m.send)           // 发送消息
-	r.POST("/message/sendbatch", m.sendBatch) // 批量发送消息
-	r.POST("/message/sync", m.sync)           // 消息同步(写模式)
-	r.POST("/message/syncack", m.syncack)     // 消息同步回执(写模式)
+	r.POST("/message/send", m.send)            // 发送消息
+	r.POST("/message/sendbatch", m.sendBatch)  // 批量发送消息
+	r.POST("/message/sync", m.sync)            // 消息同步(写模式)
+	r.POST("/message/syncack", m.syncack)      // 消息同步回执(写模式)
+	r.POST("/message/sync/reset", m.syncReset) // 重置设备的同步位置
 
 	r.POST("/messages", m.searchMessages) // 批量查询消息
 
 	r.POST("/message", m.searchMessage) // 搜索单条消息
 
+	r.POST("/message/dedup", m.dedup) // 查询重复的消息(节点内部调用)
+
 }
 
 func (m *message) send(c *wkhttp.Context) {
@@ -70,6 +75,7 @@ func (m *message) send(c *wkhttp.Context) {
 	if strings.TrimSpace(req.FromUID) == "" {
 		req.FromUID = options.G.SystemUID
 	}
+	req.traceparent = trace.TraceparentFromHeader(c.Request.Header) // 延续上游服务的链路
 
 	channelId := req.ChannelID
 	channelType := req.ChannelType
@@ -209,6 +215,63 @@ func (m *message) requestSetSubscribersForTmpChannel(tmpChannelId string, uids [
 	return nil
 }
 
+// 查询重复的消息(在频道领导节点上查询)
+func (m *message) dedup(c *wkhttp.Context) {
+	var req messageDedupReq
+	if err := c.BindJSON(&req); err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	msg, exist, err := service.GetDedupMessage(req.ChannelId, req.ChannelType, req.FromUid, req.ClientMsgNo)
+	if err != nil {
+		m.Error("查询重复消息失败！", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.ResponseError(err)
+		return
+	}
+	resp := messageDedupResp{}
+	if exist {
+		resp.MessageId = msg.MessageID
+		resp.MessageSeq = uint64(msg.MessageSeq)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// 请求频道领导节点查询重复消息的id，不存在返回0
+func requestDedupMessageId(fakeChannelId string, channelType uint8, fromUid string, clientMsgNo string) (int64, error) {
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	nodeInfo, err := service.Cluster.LeaderOfChannel(timeoutCtx, fakeChannelId, channelType)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	if options.G.IsLocalNode(nodeInfo.Id) {
+		msg, exist, err := service.GetDedupMessage(fakeChannelId, channelType, fromUid, clientMsgNo)
+		if err != nil || !exist {
+			return 0, err
+		}
+		return msg.MessageID, nil
+	}
+	reqURL := fmt.Sprintf("%s/%s", nodeInfo.ApiServerAddr, "message/dedup")
+	resp, err := network.Post(reqURL, []byte(wkutil.ToJSON(messageDedupReq{
+		ChannelId:   fakeChannelId,
+		ChannelType: channelType,
+		FromUid:     fromUid,
+		ClientMsgNo: clientMsgNo,
+	})), nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := handlerIMError(resp); err != nil {
+		return 0, err
+	}
+	var dedupResp messageDedupResp
+	if err := wkutil.ReadJSONByByte([]byte(resp.Body), &dedupResp); err != nil {
+		return 0, err
+	}
+	return dedupResp.MessageId, nil
+}
+
 func sendMessageToChannel(req messageSendReq, channelId string, channelType uint8, clientMsgNo string, streamFlag wkproto.StreamFlag) (int64, error) {
 
 	// m.s.monitor.SendPacketInc(req.Header.NoPersist != 1)
@@ -229,6 +292,24 @@ func sendMessageToChannel(req messageSendReq, channelId string, channelType uint
 		fakeChannelId = options.G.OrginalConvertCmdChannel(fakeChannelId)
 	}
 
+	// 消息去重（客户端指定的client_msg_no重试发送时，返回原消息的id，频道内也会再次去重）
+	if strings.TrimSpace(req.ClientMsgNo) != "" && req.ClientMsgNo == clientMsgNo && req.Header.NoPersist == 0 {
+		dedupMessageId, err := requestDedupMessageId(fakeChannelId, channelType, req.FromUID, clientMsgNo)
+		if err != nil {
+			wklog.Warn("查询重复消息失败！", zap.Error(err), zap.String("fakeChannelId", fakeChannelId), zap.Uint8("channelType", channelType), zap.String("clientMsgNo", clientMsgNo))
+		} else if dedupMessageId != 0 {
+			return dedupMessageId, nil
+		}
+	}
+
+	payload := req.Payload
+	if req.Mention != nil {
+		var err error
+		if payload, err = req.Mention.setToPayload(payload); err != nil {
+			return 0, err
+		}
+	}
+
 	var setting wkproto.Setting
 	if len(strings.TrimSpace(req.StreamNo)) > 0 {
 		setting = setting.Set(wkproto.SettingStream)
@@ -246,7 +327,7 @@ func sendMessageToChannel(req messageSendReq, channelId string, channelType uint
 		ClientMsgNo: clientMsgNo,
 		ChannelID:   channelId,
 		ChannelType: channelType,
-		Payload:     req.Payload,
+		Payload:     payload,
 	}
 	messageId := options.G.GenMessageId()
 
@@ -262,9 +343,10 @@ func sendMessageToChannel(req messageSendReq, channelId string, channelType uint
 		Track: track.Message{
 			PreStart: time.Now(),
 		},
+		TraceContext: req.traceparent,
 	}
+	event.Record(track.PositionStart)
 	eventbus.Channel.SendMessage(fakeChannelId, channelType, event)
-	event.Track.Record(track.PositionStart)
 	eventbus.Channel.Advance(fakeChannelId, channelType)
 
 	return messageId, nil
@@ -272,10 +354,11 @@ func sendMessageToChannel(req messageSendReq, channelId string, channelType uint
 
 func (m *message) sendBatch(c *wkhttp.Context) {
 	var req struct {
-		Header      types.MessageHeader `json:"header"`      // 消息头
-		FromUID     string              `json:"from_uid"`    // 发送者UID
-		Subscribers []string            `json:"subscribers"` // 订阅者 如果此字段有值，表示消息只发给指定的订阅者
-		Payload     []byte              `json:"payload"`     // 消息内容
+		Header      types.MessageHeader `json:"header"`        // 消息头
+		FromUID     string              `json:"from_uid"`      // 发送者UID
+		ClientMsgNo string              `json:"client_msg_no"` // 客户端消息编号（重试时相同编号的消息不会重复发送给同一个订阅者）
+		Subscribers []string            `json:"subscribers"`   // 订阅者 如果此字段有值，表示消息只发给指定的订阅者
+		Payload     []byte              `json:"payload"`       // 消息内容
 	}
 	if err := c.BindJSON(&req); err != nil {
 		m.Error("数据格式有误！", zap.Error(err))
@@ -297,13 +380,18 @@ func (m *message) sendBatch(c *wkhttp.Context) {
 	failUids := make([]string, 0)
 	reasons := make([]string, 0)
 	for _, subscriber := range req.Subscribers {
-		clientMsgNo := fmt.Sprintf("%s0", wkutil.GenUUID())
+		clientMsgNo := req.ClientMsgNo
+		if strings.TrimSpace(clientMsgNo) == "" {
+			clientMsgNo = fmt.Sprintf("%s0", wkutil.GenUUID())
+		}
 		_, err := sendMessageToChannel(messageSendReq{
 			Header:      req.Header,
+			ClientMsgNo: req.ClientMsgNo,
 			FromUID:     req.FromUID,
 			ChannelID:   subscriber,
 			ChannelType: wkproto.ChannelTypePerson,
 			Payload:     req.Payload,
+			traceparent: trace.TraceparentFromHeader(c.Request.Header),
 		}, subscriber, wkproto.ChannelTypePerson, clientMsgNo, wkproto.StreamFlagIng)
 		if err != nil {
 			failUids = append(failUids, subscriber)
@@ -375,6 +463,17 @@ func (m *message) sync(c *wkhttp.Context) {
 		}
 	}
 
+	// 按设备同步时使用设备自己的同步位置，设备还没有同步位置的频道使用会话的同步位置
+	var deviceCursorMap map[string]uint64
+	if req.DeviceFlag != nil {
+		deviceCursorMap, err = getDeviceSyncCursorMap(req.UID, *req.DeviceFlag)
+		if err != nil {
+			m.Error("获取设备同步位置失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", *req.DeviceFlag))
+			c.ResponseError(errors.New("获取设备同步位置失败！"))
+			return
+		}
+	}
+
 	// 获取真实的频道ID
 	// getRealChannelId := func(fakeChannelId string, channelType uint8) string {
 	// 	realChannelId := fakeChannelId
@@ -391,17 +490,21 @@ func (m *message) sync(c *wkhttp.Context) {
 
 	var channelRecentMessageReqs []*channelRecentMessageReq
 	for _, conversation := range conversations {
-
+		readToMsgSeq := conversation.ReadToMsgSeq
+		if seq, ok := deviceCursorMap[wkutil.ChannelToKey(conversation.ChannelId, conversation.ChannelType)]; ok {
+			readToMsgSeq = seq
+		}
 		channelRecentMessageReqs = append(channelRecentMessageReqs, &channelRecentMessageReq{
 			ChannelId:   conversation.ChannelId,
 			ChannelType: conversation.ChannelType,
-			LastMsgSeq:  conversation.ReadToMsgSeq + 1, // 这里加1的目的是为了不查询到ReadedToMsgSeq本身这条消息
+			LastMsgSeq:  readToMsgSeq + 1, // 这里加1的目的是为了不查询到ReadedToMsgSeq本身这条消息
 		})
 	}
 
 	// 先清空旧记录
+	recordKey := syncRecordKey(req.UID, req.DeviceFlag)
 	m.syncRecordLock.Lock()
-	m.syncRecordMap[req.UID] = nil
+	m.syncRecordMap[recordKey] = nil
 	m.syncRecordLock.Unlock()
 
 	// 获取每个session的消息
@@ -435,7 +538,7 @@ func (m *message) sync(c *wkhttp.Context) {
 				lastMsg = channelRecentMessage.Messages[len(channelRecentMessage.Messages)-1]
 			}
 			m.syncRecordLock.Lock()
-			m.syncRecordMap[req.UID] = append(m.syncRecordMap[req.UID], &syncRecord{
+			m.syncRecordMap[recordKey] = append(m.syncRecordMap[recordKey], &syncRecord{
 				channelId:   channelRecentMessage.ChannelId,
 				channelType: channelRecentMessage.ChannelType,
 				lastMsgSeq:  lastMsg.MessageSeq,
@@ -483,13 +586,25 @@ func (m *message) syncack(c *wkhttp.Context) {
 
 	m.syncRecordLock.RLock()
 	defer m.syncRecordLock.RUnlock()
-	if len(m.syncRecordMap[req.UID]) == 0 {
+	records := m.syncRecordMap[syncRecordKey(req.UID, req.DeviceFlag)]
+	if len(records) == 0 {
+		c.ResponseOK()
+		return
+	}
+
+	if req.DeviceFlag != nil {
+		err = m.syncackOfDevice(req.UID, *req.DeviceFlag, records)
+		if err != nil {
+			m.Error("消息同步回执失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", *req.DeviceFlag))
+			c.ResponseError(errors.New("消息同步回执失败！"))
+			return
+		}
 		c.ResponseOK()
 		return
 	}
 
 	conversations := make([]wkdb.Conversation, 0)
-	for _, record := range m.syncRecordMap[req.UID] {
+	for _, record := range records {
 		needAdd := false
 
 		fakeChannelId := record.channelId
@@ -556,6 +671,93 @@ func (m *message) syncack(c *wkhttp.Context) {
 	c.ResponseOK()
 }
 
+// 按设备记录同步位置（不更新会话的同步位置，会话的同步位置只给没有传设备标识的客户端使用）
+func (m *message) syncackOfDevice(uid string, deviceFlag uint8, records []*syncRecord) error {
+	cursorMap, err := getDeviceSyncCursorMap(uid, deviceFlag)
+	if err != nil {
+		return err
+	}
+	cursors := make([]wkdb.DeviceSyncCursor, 0, len(records))
+	for _, record := range records {
+		if !options.G.IsCmdChannel(record.channelId) {
+			m.Warn("不是cmd频道！", zap.String("uid", uid), zap.String("channelId", record.channelId), zap.Uint8("channelType", record.channelType))
+			continue
+		}
+		if record.lastMsgSeq <= cursorMap[wkutil.ChannelToKey(record.channelId, record.channelType)] {
+			continue
+		}
+		cursors = append(cursors, wkdb.DeviceSyncCursor{
+			Uid:         uid,
+			DeviceFlag:  deviceFlag,
+			ChannelId:   record.channelId,
+			ChannelType: record.channelType,
+			MessageSeq:  record.lastMsgSeq,
+		})
+	}
+	return service.Store.SetDeviceSyncCursors(uid, cursors)
+}
+
+// 重置设备的同步位置，重置后设备从会话的同步位置开始同步
+func (m *message) syncReset(c *wkhttp.Context) {
+	var req syncResetReq
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		m.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.UID, wkproto.ChannelTypePerson) // 获取频道的领导节点
+	if err != nil {
+		m.Error("获取频道所在节点失败！!", zap.Error(err), zap.String("channelID", req.UID), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	if leaderInfo.Id != options.G.Cluster.NodeId {
+		m.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	err = service.Store.ResetDeviceSyncCursors(req.UID, req.DeviceFlag)
+	if err != nil {
+		m.Error("重置设备同步位置失败！", zap.Error(err), zap.String("uid", req.UID), zap.Uint8("deviceFlag", req.DeviceFlag))
+		c.ResponseError(errors.New("重置设备同步位置失败！"))
+		return
+	}
+
+	m.syncRecordLock.Lock()
+	delete(m.syncRecordMap, syncRecordKey(req.UID, &req.DeviceFlag))
+	m.syncRecordLock.Unlock()
+
+	c.ResponseOK()
+}
+
+// 同步记录的key，传了设备标识的按设备分开记录
+func syncRecordKey(uid string, deviceFlag *uint8) string {
+	if deviceFlag == nil {
+		return uid
+	}
+	return fmt.Sprintf("%s@%d", uid, *deviceFlag)
+}
+
+// 获取用户设备的同步位置，key为频道key
+func getDeviceSyncCursorMap(uid string, deviceFlag uint8) (map[string]uint64, error) {
+	cursors, err := service.Store.GetDeviceSyncCursors(uid, deviceFlag)
+	if err != nil {
+		return nil, err
+	}
+	cursorMap := make(map[string]uint64, len(cursors))
+	for _, cursor := range cursors {
+		cursorMap[wkutil.ChannelToKey(cursor.ChannelId, cursor.ChannelType)] = cursor.MessageSeq
+	}
+	return cursorMap, nil
+}
+
 func (m *message) searchMessages(c *wkhttp.Context) {
 	var req struct {
 		LoginUid     string   `json:"login_uid"`
@@ -642,9 +844,20 @@ func (m *message) searchMessages(c *wkhttp.Context) {
 		}
 	}
 
+	// 新成员的历史消息可见范围
+	visibleStartSeq, err := visibleStartMsgSeq(m.s.client, fakeChannelId, req.ChannelType, req.LoginUid)
+	if err != nil {
+		m.Error("查询消息可见范围失败！", zap.Error(err), zap.String("loginUid", req.LoginUid), zap.String("channelId", fakeChannelId))
+		c.ResponseError(err)
+		return
+	}
+
 	resps := make([]*types.MessageResp, 0, len(messages))
 	if len(messages) > 0 {
 		for _, message := range messages {
+			if uint64(message.MessageSeq) < visibleStartSeq {
+				continue
+			}
 			resp := &types.MessageResp{}
 			resp.From(message, options.G.SystemUID)
 			resps = append(resps, resp)
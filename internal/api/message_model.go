@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"strings"
 
 	"github.com/WuKongIM/WuKongIM/internal/types"
@@ -19,6 +20,8 @@ type messageSendReq struct {
 	Subscribers []string            `json:"subscribers"`   // 订阅者 如果此字段有值，表示消息只发给指定的订阅者
 	Payload     []byte              `json:"payload"`       // 消息内容
 	TagKey      string              `json:"tag_key"`       // tagKey
+	Mention     *messageMentionReq  `json:"mention"`       // 提醒（@）的用户，会写入payload的mention字段
+	traceparent string              // 上游服务的链路上下文（W3C traceparent）
 }
 
 // Check 检查输入
@@ -29,8 +32,43 @@ func (m messageSendReq) Check() error {
 	return nil
 }
 
+type messageMentionReq struct {
+	Uids []string `json:"uids"` // 被提醒的用户，包含all表示提醒所有人
+	All  int      `json:"all"`  // 是否提醒所有人 1.是 0.否
+}
+
+// 将提醒写入payload的mention字段（payload必须是json对象）
+func (m *messageMentionReq) setToPayload(payload []byte) ([]byte, error) {
+	var content map[string]interface{}
+	if err := json.Unmarshal(payload, &content); err != nil || content == nil {
+		return nil, errors.New("payload不是json对象，不能设置mention！")
+	}
+	mention := map[string]interface{}{}
+	if len(m.Uids) > 0 {
+		mention["uids"] = m.Uids
+	}
+	if m.All == 1 {
+		mention["all"] = 1
+	}
+	content["mention"] = mention
+	return json.Marshal(content)
+}
+
+type messageDedupReq struct {
+	ChannelId   string `json:"channel_id"`
+	ChannelType uint8  `json:"channel_type"`
+	FromUid     string `json:"from_uid"`
+	ClientMsgNo string `json:"client_msg_no"`
+}
+
+type messageDedupResp struct {
+	MessageId  int64  `json:"message_id"`  // 原消息的id，不存在为0
+	MessageSeq uint64 `json:"message_seq"` // 原消息的序号
+}
+
 type syncReq struct {
 	UID        string `json:"uid"`         // 用户uid
+	DeviceFlag *uint8 `json:"device_flag"` // 设备标识，传了则按设备记录同步位置
 	MessageSeq uint64 `json:"message_seq"` // 客户端最大消息序列号
 	Limit      int    `json:"limit"`       // 消息数量限制
 }
@@ -45,9 +83,23 @@ func (r syncReq) Check() error {
 	return nil
 }
 
+type syncResetReq struct {
+	UID        string `json:"uid"`         // 用户uid
+	DeviceFlag uint8  `json:"device_flag"` // 设备标识
+}
+
+func (r syncResetReq) Check() error {
+	if strings.TrimSpace(r.UID) == "" {
+		return errors.New("用户uid不能为空！")
+	}
+	return nil
+}
+
 type syncackReq struct {
 	// 用户uid
 	UID string `json:"uid"`
+	// 设备标识，需要与同步时传的一致
+	DeviceFlag *uint8 `json:"device_flag"`
 	// 最后一次同步的message_seq
 	LastMessageSeq uint64 `json:"last_message_seq"`
 }
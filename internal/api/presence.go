@@ -0,0 +1,228 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/network"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.uber.org/zap"
+)
+
+// presence 用户在线状态（自定义状态、最后在线时间、订阅）
+// 用户的在线状态在用户所在槽的领导节点上，批量操作会按领导节点分组后分别处理
+type presence struct {
+	s *Server
+	wklog.Log
+}
+
+func newPresence(s *Server) *presence {
+	return &presence{
+		s:   s,
+		Log: wklog.NewWKLog("presence"),
+	}
+}
+
+func (p *presence) route(r *wkhttp.WKHttp) {
+	r.POST("/presence/status", p.setStatus)        // 设置自定义状态
+	r.POST("/presence/query", p.query)             // 获取用户的在线状态
+	r.POST("/presence/subscribe", p.subscribe)     // 订阅用户的在线状态变化
+	r.POST("/presence/unsubscribe", p.unsubscribe) // 取消订阅
+}
+
+type presenceStatusReq struct {
+	UID        string              `json:"uid"`
+	State      types.PresenceState `json:"state"`       // away:离开 busy:忙碌 为空表示正常
+	StatusText string              `json:"status_text"` // 自定义状态文字
+	Emoji      string              `json:"emoji"`       // 自定义状态表情
+}
+
+func (r presenceStatusReq) Check() error {
+	if strings.TrimSpace(r.UID) == "" {
+		return errors.New("uid不能为空！")
+	}
+	if !r.State.Valid() {
+		return errors.New("state不合法！")
+	}
+	return nil
+}
+
+type presenceUidsReq struct {
+	UID  string   `json:"uid"`  // 订阅者（查询时可以为空）
+	UIDs []string `json:"uids"` // 目标用户
+}
+
+func (r presenceUidsReq) Check(needUid bool) error {
+	if needUid && strings.TrimSpace(r.UID) == "" {
+		return errors.New("uid不能为空！")
+	}
+	if len(r.UIDs) > options.G.Presence.MaxSubscribe {
+		return fmt.Errorf("uids数量不能超过%d！", options.G.Presence.MaxSubscribe)
+	}
+	return nil
+}
+
+func (p *presence) setStatus(c *wkhttp.Context) {
+	var req presenceStatusReq
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		p.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(); err != nil {
+		c.ResponseError(err)
+		return
+	}
+
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.UID, wkproto.ChannelTypePerson) // 获取频道的领导节点
+	if err != nil {
+		p.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", req.UID), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	if leaderInfo.Id != options.G.Cluster.NodeId {
+		p.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	err = service.PresenceManager.SetStatus(req.UID, req.State, req.StatusText, req.Emoji)
+	if err != nil {
+		p.Error("设置在线状态失败！", zap.Error(err), zap.String("uid", req.UID))
+		c.ResponseError(err)
+		return
+	}
+	c.ResponseOK()
+}
+
+func (p *presence) query(c *wkhttp.Context) {
+	p.handleUids(c, false, func(req presenceUidsReq) ([]*types.Presence, error) {
+		return service.PresenceManager.Presences(req.UIDs)
+	})
+}
+
+func (p *presence) subscribe(c *wkhttp.Context) {
+	p.handleUids(c, true, func(req presenceUidsReq) ([]*types.Presence, error) {
+		return service.PresenceManager.Subscribe(req.UID, req.UIDs)
+	})
+}
+
+func (p *presence) unsubscribe(c *wkhttp.Context) {
+	p.handleUids(c, true, func(req presenceUidsReq) ([]*types.Presence, error) {
+		service.PresenceManager.Unsubscribe(req.UID, req.UIDs)
+		return nil, nil
+	})
+}
+
+func (p *presence) handleUids(c *wkhttp.Context, needUid bool, local func(req presenceUidsReq) ([]*types.Presence, error)) {
+	var req presenceUidsReq
+	if err := c.BindJSON(&req); err != nil {
+		p.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if err := req.Check(needUid); err != nil {
+		c.ResponseError(err)
+		return
+	}
+	if !options.G.Presence.On {
+		c.ResponseError(errors.New("presence is off"))
+		return
+	}
+	presences, err := p.forCluster(c.Request.URL.Path, req, local)
+	if err != nil {
+		p.Error("处理在线状态请求失败！", zap.Error(err), zap.String("path", c.Request.URL.Path))
+		c.ResponseError(err)
+		return
+	}
+	if presences == nil {
+		presences = make([]*types.Presence, 0)
+	}
+	c.JSON(http.StatusOK, presences)
+}
+
+// 按用户所在槽的领导节点分组，本节点的用户本地处理，其他节点的用户转发到对应的节点处理，最后合并结果
+func (p *presence) forCluster(path string, req presenceUidsReq, local func(req presenceUidsReq) ([]*types.Presence, error)) ([]*types.Presence, error) {
+	uidInPeerMap := make(map[uint64][]string)
+	localUids := make([]string, 0)
+	for _, uid := range req.UIDs {
+		leaderInfo, err := service.Cluster.SlotLeaderOfChannel(uid, wkproto.ChannelTypePerson) // 获取频道的领导节点
+		if err != nil {
+			p.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", uid), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+			return nil, errors.New("获取频道所在节点失败！")
+		}
+		if leaderInfo.Id == options.G.Cluster.NodeId {
+			localUids = append(localUids, uid)
+			continue
+		}
+		uidInPeerMap[leaderInfo.Id] = append(uidInPeerMap[leaderInfo.Id], uid)
+	}
+
+	var (
+		presences []*types.Presence
+		reqErr    error
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+	)
+	for nodeId, uids := range uidInPeerMap {
+		wg.Add(1)
+		go func(nodeId uint64, uids []string) {
+			defer wg.Done()
+			results, err := p.requestNode(nodeId, path, presenceUidsReq{UID: req.UID, UIDs: uids})
+			mu.Lock()
+			if err != nil {
+				reqErr = err
+			} else {
+				presences = append(presences, results...)
+			}
+			mu.Unlock()
+		}(nodeId, uids)
+	}
+	if len(localUids) > 0 {
+		results, err := local(presenceUidsReq{UID: req.UID, UIDs: localUids})
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		presences = append(presences, results...)
+		mu.Unlock()
+	}
+	wg.Wait()
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	return presences, nil
+}
+
+func (p *presence) requestNode(nodeId uint64, path string, req presenceUidsReq) ([]*types.Presence, error) {
+	nodeInfo, err := service.Cluster.NodeInfoById(nodeId)
+	if err != nil {
+		p.Error("获取节点信息失败！", zap.Error(err), zap.Uint64("nodeId", nodeId))
+		return nil, errors.New("获取节点信息失败！")
+	}
+	reqURL := fmt.Sprintf("%s%s", nodeInfo.ApiServerAddr, path)
+	resp, err := network.Post(reqURL, []byte(wkutil.ToJSON(req)), nil)
+	if err != nil {
+		p.Error("请求节点失败！", zap.Error(err), zap.String("reqURL", reqURL))
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求在线状态状态错误！[%d]", resp.StatusCode)
+	}
+	var presences []*types.Presence
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &presences)
+	if err != nil {
+		return nil, err
+	}
+	return presences, nil
+}
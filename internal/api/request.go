@@ -8,24 +8,28 @@ import (
 	"sync"
 	"time"
 
+	"github.com/WuKongIM/WuKongIM/internal/ingress"
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/types"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/sendgrid/rest"
 	"go.uber.org/zap"
 )
 
 type request struct {
+	client *ingress.Client
 	wklog.Log
 }
 
 func newRequset() *request {
 
 	return &request{
-		Log: wklog.NewWKLog("request"),
+		client: ingress.NewClient(),
+		Log:    wklog.NewWKLog("request"),
 	}
 }
 
@@ -141,10 +145,22 @@ func (s *request) getRecentMessages(uid string, msgCount int, channels []*channe
 			recentMessages []wkdb.Message
 			err            error
 		)
+		// 新成员的历史消息可见范围（一次同步的所有频道批量查询）
+		visibleChannels := make([]ingress.ChannelReq, 0, len(channels))
+		for _, channel := range channels {
+			visibleChannels = append(visibleChannels, ingress.ChannelReq{ChannelId: channel.ChannelId, ChannelType: channel.ChannelType})
+		}
+		visibleStartSeqs, err := visibleStartMsgSeqs(s.client, uid, visibleChannels)
+		if err != nil {
+			s.Error("查询消息可见范围失败！", zap.Error(err), zap.String("uid", uid))
+			return nil, err
+		}
 		for _, channel := range channels {
 			fakeChannelID := channel.ChannelId
 			msgSeq := channel.LastMsgSeq
 			messageResps := types.MessageRespSlice{}
+			visibleStartSeq := visibleStartSeqs[wkutil.ChannelToKey(fakeChannelID, channel.ChannelType)]
+
 			if orderByLast {
 
 				if msgSeq > 0 {
@@ -158,6 +174,9 @@ func (s *request) getRecentMessages(uid string, msgCount int, channels []*channe
 				}
 				if len(recentMessages) > 0 {
 					for _, recentMessage := range recentMessages {
+						if uint64(recentMessage.MessageSeq) < visibleStartSeq {
+							continue
+						}
 						messageResp := &types.MessageResp{}
 						messageResp.From(recentMessage, options.G.SystemUID)
 						messageResps = append(messageResps, messageResp)
@@ -165,6 +184,9 @@ func (s *request) getRecentMessages(uid string, msgCount int, channels []*channe
 				}
 				sort.Sort(sort.Reverse(messageResps))
 			} else {
+				if msgSeq < visibleStartSeq {
+					msgSeq = visibleStartSeq
+				}
 				recentMessages, err = service.Store.LoadNextRangeMsgs(fakeChannelID, channel.ChannelType, msgSeq, 0, msgCount)
 				if err != nil {
 					s.Error("查询最近消息失败！", zap.Error(err), zap.String("uid", uid), zap.String("fakeChannelID", fakeChannelID), zap.Uint8("channelType", channel.ChannelType), zap.Uint64("LastMsgSeq", channel.LastMsgSeq))
@@ -179,16 +201,51 @@ func (s *request) getRecentMessages(uid string, msgCount int, channels []*channe
 				}
 			}
 
-			channelRecentMessages = append(channelRecentMessages, &channelRecentMessage{
-				ChannelId:   channel.ChannelId,
-				ChannelType: channel.ChannelType,
-				Messages:    messageResps,
-			})
+			// 已读之后被提醒（@）的次数（不统计不可见的消息）
+			var (
+				mentionCount   int
+				lastMentionSeq uint64
+			)
+			if visibleStartSeq != service.NotVisibleMsgSeq {
+				mentionCount, lastMentionSeq, err = service.Store.GetMentionStat(fakeChannelID, channel.ChannelType, uid, unreadStartMsgSeq(channel.ReadedToMsgSeq, visibleStartSeq))
+				if err != nil {
+					s.Error("查询提醒失败！", zap.Error(err), zap.String("uid", uid), zap.String("fakeChannelID", fakeChannelID), zap.Uint8("channelType", channel.ChannelType))
+					return nil, err
+				}
+			}
+
+			recentMessage := &channelRecentMessage{
+				ChannelId:       channel.ChannelId,
+				ChannelType:     channel.ChannelType,
+				Messages:        messageResps,
+				MentionCount:    mentionCount,
+				LastMentionSeq:  lastMentionSeq,
+				VisibleStartSeq: visibleStartSeq,
+			}
+			// 子频道带上父频道，方便客户端把会话归到父频道下
+			if channel.ChannelType != wkproto.ChannelTypePerson {
+				channelInfo, err := service.DatasourceManager.GetChannelInfo(fakeChannelID, channel.ChannelType)
+				if err != nil {
+					s.Error("查询频道信息失败！", zap.Error(err), zap.String("fakeChannelID", fakeChannelID), zap.Uint8("channelType", channel.ChannelType))
+					return nil, err
+				}
+				recentMessage.ParentChannelId = channelInfo.ParentChannelId
+				recentMessage.ParentChannelType = channelInfo.ParentChannelType
+			}
+			channelRecentMessages = append(channelRecentMessages, recentMessage)
 		}
 	}
 	return channelRecentMessages, nil
 }
 
+// 未读消息的起始序号（包含）：已读之后的消息，并且不早于用户可见的起始消息
+func unreadStartMsgSeq(readedToMsgSeq uint64, visibleStartSeq uint64) uint64 {
+	if visibleStartSeq > readedToMsgSeq+1 {
+		return visibleStartSeq
+	}
+	return readedToMsgSeq + 1
+}
+
 func handlerIMError(resp *rest.Response) error {
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
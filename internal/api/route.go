@@ -2,8 +2,10 @@ package api
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
@@ -35,26 +37,48 @@ func (a *route) route(r *wkhttp.WKHttp) {
 func (a *route) routeUserIMAddr(c *wkhttp.Context) {
 
 	intranet := wkutil.IntToBool(wkutil.ParseInt(c.Query("intranet"))) // 是否返回内网地址
+	self := wkutil.IntToBool(wkutil.ParseInt(c.Query("self")))         // 是否只返回本节点的地址（节点排空时，节点之间获取地址使用）
+
+	// 节点排空中或维护中，返回建议用户连接的其他节点地址
+	if !self && a.isDraining() {
+		addr, err := service.DrainManager.SuggestAddr(c.Query("uid"), intranet)
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"tcp_addr": addr.TCPAddr,
+				"ws_addr":  addr.WSAddr,
+				"wss_addr": addr.WSSAddr,
+			})
+			return
+		}
+		a.Warn("get suggest addr failed", zap.Error(err))
+	}
 
-	var (
-		tcpAddr string
-		wsAddr  string
-		wssAddr string
-	)
-
-	if intranet {
-		tcpAddr = options.G.Intranet.TCPAddr
-	} else {
-		tcpAddr = options.G.External.TCPAddr
-		wsAddr = options.G.External.WSAddr
-		wssAddr = options.G.External.WSSAddr
+	// 客户端指定了所在的可用区，优先返回同可用区的节点（优先用户所在的领导节点，减少连接代理）
+	if zone := strings.TrimSpace(c.Query("zone")); !self && zone != "" {
+		addr, err := service.DrainManager.ZoneAddr(c.Query("uid"), zone, intranet)
+		if err != nil {
+			a.Warn("get zone addr failed", zap.Error(err), zap.String("zone", zone))
+		} else if !options.G.IsLocalNode(addr.NodeId) {
+			c.JSON(http.StatusOK, gin.H{
+				"tcp_addr": addr.TCPAddr,
+				"ws_addr":  addr.WSAddr,
+				"wss_addr": addr.WSSAddr,
+			})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	tcpAddr, wsAddr, wssAddr := a.localIMAddr(intranet)
+
+	resp := gin.H{
 		"tcp_addr": tcpAddr,
 		"ws_addr":  wsAddr,
 		"wss_addr": wssAddr,
-	})
+	}
+	if self {
+		resp["draining"] = a.isDraining()
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // 批量获取用户所在节点地址
@@ -69,18 +93,44 @@ func (a *route) routeUserIMAddrOfBatch(c *wkhttp.Context) {
 		return
 	}
 
-	var (
-		tcpAddr string
-		wsAddr  string
-		wssAddr string
-	)
-
-	if intranet {
-		tcpAddr = options.G.Intranet.TCPAddr
-	} else {
-		tcpAddr = options.G.External.TCPAddr
-		wsAddr = options.G.External.WSAddr
-		wssAddr = options.G.External.WSSAddr
+	tcpAddr, wsAddr, wssAddr := a.localIMAddr(intranet)
+
+	// 节点排空中或维护中，按建议的节点分组返回
+	if a.isDraining() {
+		var (
+			resps     []userAddrResp
+			nodeIndex = map[uint64]int{}
+			localUids []string
+		)
+		for _, uid := range uids {
+			addr, err := service.DrainManager.SuggestAddr(uid, intranet)
+			if err != nil {
+				a.Warn("get suggest addr failed", zap.Error(err), zap.String("uid", uid))
+				localUids = append(localUids, uid)
+				continue
+			}
+			index, ok := nodeIndex[addr.NodeId]
+			if !ok {
+				index = len(resps)
+				nodeIndex[addr.NodeId] = index
+				resps = append(resps, userAddrResp{
+					TCPAddr: addr.TCPAddr,
+					WSAddr:  addr.WSAddr,
+					WSSAddr: addr.WSSAddr,
+				})
+			}
+			resps[index].UIDs = append(resps[index].UIDs, uid)
+		}
+		if len(localUids) > 0 {
+			resps = append(resps, userAddrResp{
+				UIDs:    localUids,
+				TCPAddr: tcpAddr,
+				WSAddr:  wsAddr,
+				WSSAddr: wssAddr,
+			})
+		}
+		c.JSON(http.StatusOK, resps)
+		return
 	}
 
 	c.JSON(http.StatusOK, []userAddrResp{
@@ -94,6 +144,38 @@ func (a *route) routeUserIMAddrOfBatch(c *wkhttp.Context) {
 
 }
 
+// 本节点的IM连接地址
+func (a *route) localIMAddr(intranet bool) (tcpAddr string, wsAddr string, wssAddr string) {
+	if intranet {
+		tcpAddr = options.G.Intranet.TCPAddr
+	} else {
+		tcpAddr = options.G.External.TCPAddr
+		wsAddr = options.G.External.WSAddr
+		wssAddr = options.G.External.WSSAddr
+	}
+	return
+}
+
+// 节点是否不再接收新的连接（排空中或维护中）
+func (a *route) isDraining() bool {
+	if service.DrainManager == nil {
+		return false
+	}
+	return service.DrainManager.IsDraining() || a.isMaintenance()
+}
+
+// 节点是否处于维护模式
+func (a *route) isMaintenance() bool {
+	if !options.G.ClusterOn() {
+		return false
+	}
+	node, err := service.Cluster.NodeInfoById(options.G.Cluster.NodeId)
+	if err != nil || node == nil {
+		return false
+	}
+	return node.Maintenance
+}
+
 type userAddrResp struct {
 	TCPAddr string   `json:"tcp_addr"`
 	WSAddr  string   `json:"ws_addr"`
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/RussellLuo/timingwheel"
 	"github.com/WuKongIM/WuKongIM/internal/ingress"
 	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
 )
 
 type Server struct {
@@ -68,3 +73,23 @@ type everyScheduler struct {
 func (s *everyScheduler) Next(prev time.Time) time.Time {
 	return prev.Add(s.Interval)
 }
+
+// 如果请求指定的节点（node_id）不是本节点，则转发到指定节点，返回true表示已转发
+func forwardToNodeIfNeed(c *wkhttp.Context, log wklog.Log) bool {
+	nodeId := wkutil.ParseUint64(c.Query("node_id"))
+	if nodeId == 0 || nodeId == options.G.Cluster.NodeId {
+		return false
+	}
+	node, err := service.Cluster.NodeInfoById(nodeId)
+	if err != nil {
+		c.ResponseError(err)
+		return true
+	}
+	if node == nil {
+		log.Error("node not found", zap.Uint64("nodeId", nodeId))
+		c.ResponseError(fmt.Errorf("node not found"))
+		return true
+	}
+	c.Forward(fmt.Sprintf("%s%s", node.ApiServerAddr, c.Request.URL.Path))
+	return true
+}
@@ -47,6 +47,10 @@ func (s *apiServer) start() {
 			c.Next()
 			return
 		}
+		if c.Request.URL.Path == federationMessagesPath { // 联邦同步接口使用联邦令牌校验
+			c.Next()
+			return
+		}
 		managerToken := c.GetHeader("token")
 		if managerToken != options.G.ManagerToken {
 			c.AbortWithStatus(http.StatusUnauthorized)
@@ -125,6 +129,26 @@ func (s *apiServer) setRoutes() {
 	tag := newTag(s.s)
 	tag.route(s.r)
 
+	// 静态加密
+	encryption := newEncryption(s.s)
+	encryption.route(s.r)
+
+	// 节点排空
+	drain := newDrain(s.s)
+	drain.route(s.r)
+
+	// 用户在线状态
+	presence := newPresence(s.s)
+	presence.route(s.r)
+
+	// 数据源
+	datasource := newDatasource(s.s)
+	datasource.route(s.r)
+
+	// 跨集群联邦
+	federation := newFederation(s.s)
+	federation.route(s.r)
+
 	// 分布式api
 	clusterServer, ok := service.Cluster.(*cluster.Server)
 	if ok {
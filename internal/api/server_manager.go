@@ -103,6 +103,14 @@ func (m *managerServer) setRoutes() {
 	tag := newTag(m.s)
 	tag.route(m.r)
 
+	// 静态加密api
+	encryption := newEncryption(m.s)
+	encryption.route(m.r)
+
+	// 节点排空api
+	drain := newDrain(m.s)
+	drain.route(m.r)
+
 	// // 系统api
 	// system := NewSystemAPI(s.s)
 	// system.Route(s.r)
@@ -498,14 +498,19 @@ func (m *MigrateTask) importChannel(channel *mgChannelResp) error {
 
 	// add channel subscribers
 	if len(relationData.Subscribers) > 0 {
+		lastMsgSeq, err := channelLastMsgSeq(m.s.client, channel.ChannelID, channel.ChannelType)
+		if err != nil {
+			return err
+		}
 		members := make([]wkdb.Member, 0, len(relationData.Subscribers))
 		createdAt := time.Now()
 		updatedAt := time.Now()
 		for _, uid := range relationData.Subscribers {
 			members = append(members, wkdb.Member{
-				Uid:       uid,
-				CreatedAt: &createdAt,
-				UpdatedAt: &updatedAt,
+				Uid:        uid,
+				CreatedAt:  &createdAt,
+				UpdatedAt:  &updatedAt,
+				JoinMsgSeq: lastMsgSeq,
 			})
 		}
 		err = service.Store.AddSubscribers(channel.ChannelID, channel.ChannelType, members)
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,12 +41,14 @@ func newUser(s *Server) *user {
 // Route 用户相关路由配置
 func (u *user) route(r *wkhttp.WKHttp) {
 
-	r.POST("/user/token", u.updateToken)                  // 更新用户token
-	r.POST("/user/device_quit", u.deviceQuit)             // 强制设备退出
-	r.POST("/user/onlinestatus", u.getOnlineStatus)       // 获取用户在线状态
-	r.POST("/user/systemuids_add", u.systemUidsAdd)       // 添加系统uid
-	r.POST("/user/systemuids_remove", u.systemUidsRemove) // 移除系统uid
-	r.GET("/user/systemuids", u.getSystemUids)            // 获取系统uid
+	r.POST("/user/token", u.updateToken)                   // 更新用户token
+	r.POST("/user/device_quit", u.deviceQuit)              // 强制设备退出
+	r.POST("/user/device_quit_others", u.deviceQuitOthers) // 强制除指定设备外的其他设备退出
+	r.GET("/user/devices", u.devices)                      // 获取用户的设备列表（登录信息、在线状态、连接统计）
+	r.POST("/user/onlinestatus", u.getOnlineStatus)        // 获取用户在线状态
+	r.POST("/user/systemuids_add", u.systemUidsAdd)        // 添加系统uid
+	r.POST("/user/systemuids_remove", u.systemUidsRemove)  // 移除系统uid
+	r.GET("/user/systemuids", u.getSystemUids)             // 获取系统uid
 
 	r.POST("/user/systemuids_add_to_cache", u.systemUidsAddToCache)           // 仅仅添加系统账号至缓存
 	r.POST("/user/systemuids_remove_from_cache", u.systemUidsRemoveFromCache) // 仅仅从缓存中移除系统账号
@@ -105,11 +110,11 @@ func (u *user) quitUserDevice(uid string, deviceFlag wkproto.DeviceFlag) error {
 		Id:          device.Id,
 		Uid:         uid,
 		DeviceFlag:  uint64(deviceFlag),
-		DeviceLevel: uint8(wkproto.DeviceLevelMaster),
+		DeviceLevel: device.DeviceLevel, // 保留原设备等级，方便设备列表查看
 		Token:       "",
 		CreatedAt:   device.CreatedAt,
 		UpdatedAt:   &updatedAt,
-	}) // 这里的deviceLevel可以随便给 不影响逻辑 这里随便给的master
+	})
 	if err != nil {
 		u.Error("清空用户token失败！", zap.Error(err), zap.String("uid", uid), zap.Uint8("deviceFlag", deviceFlag.ToUint8()))
 		return err
@@ -129,6 +134,217 @@ func (u *user) quitUserDevice(uid string, deviceFlag wkproto.DeviceFlag) error {
 	return nil
 }
 
+// 强制除指定设备外的其他设备退出（退出其他设备）
+func (u *user) deviceQuitOthers(c *wkhttp.Context) {
+	var req struct {
+		UID        string             `json:"uid"`         // 用户uid
+		DeviceFlag wkproto.DeviceFlag `json:"device_flag"` // 保留的设备flag
+	}
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		u.Error("数据格式有误！", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if strings.TrimSpace(req.UID) == "" {
+		c.ResponseError(errors.New("uid不能为空！"))
+		return
+	}
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(req.UID, wkproto.ChannelTypePerson) // 获取频道的领导节点
+	if err != nil {
+		u.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", req.UID), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	leaderIsSelf := leaderInfo.Id == options.G.Cluster.NodeId
+	if !leaderIsSelf {
+		u.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	devices, err := service.Store.GetDevices(req.UID)
+	if err != nil {
+		u.Error("获取用户设备失败！", zap.Error(err), zap.String("uid", req.UID))
+		c.ResponseError(err)
+		return
+	}
+	for _, device := range devices {
+		if device.DeviceFlag == uint64(req.DeviceFlag) || device.Token == "" {
+			continue
+		}
+		err = u.quitUserDevice(req.UID, wkproto.DeviceFlag(device.DeviceFlag))
+		if err != nil {
+			c.ResponseError(err)
+			return
+		}
+	}
+	c.ResponseOK()
+}
+
+// 获取用户的设备列表
+func (u *user) devices(c *wkhttp.Context) {
+	uid := c.Query("uid")
+	if strings.TrimSpace(uid) == "" {
+		c.ResponseError(errors.New("uid不能为空！"))
+		return
+	}
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(uid, wkproto.ChannelTypePerson) // 获取频道的领导节点
+	if err != nil {
+		u.Error("获取频道所在节点失败！", zap.Error(err), zap.String("channelID", uid), zap.Uint8("channelType", wkproto.ChannelTypePerson))
+		c.ResponseError(errors.New("获取频道所在节点失败！"))
+		return
+	}
+	leaderIsSelf := leaderInfo.Id == options.G.Cluster.NodeId
+	if !leaderIsSelf {
+		u.Debug("转发请求：", zap.String("url", fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path)))
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderInfo.ApiServerAddr, c.Request.URL.Path), nil)
+		return
+	}
+
+	devices, err := service.Store.GetDevices(uid)
+	if err != nil {
+		u.Error("获取用户设备失败！", zap.Error(err), zap.String("uid", uid))
+		c.ResponseError(err)
+		return
+	}
+
+	// 用户的所有连接都在用户的领导节点上
+	conns := eventbus.User.ConnsByUid(uid)
+	connInfoMap := u.getConnInfosForCluster(uid, conns)
+
+	resps := make([]*deviceResp, 0, len(devices))
+	for _, device := range devices {
+		resp := newDeviceResp(device)
+		for _, conn := range conns {
+			if uint64(conn.DeviceFlag) != device.DeviceFlag {
+				continue
+			}
+			resp.Online = 1
+			connInfo := connInfoMap[deviceConnKey(conn.NodeId, conn.ConnId)]
+			if connInfo == nil { // 连接所在节点的统计获取失败，只返回基础信息
+				connInfo = &ConnInfo{
+					ID:       conn.ConnId,
+					UID:      conn.Uid,
+					DeviceID: conn.DeviceId,
+					Version:  conn.ProtoVersion,
+				}
+				if conn.RemoteAddr != "" {
+					connInfo.IP = conn.RemoteIp()
+				}
+			}
+			resp.Conns = append(resp.Conns, &deviceConnResp{
+				NodeId:   conn.NodeId,
+				ConnInfo: connInfo,
+			})
+		}
+		resps = append(resps, resp)
+	}
+	c.JSON(http.StatusOK, resps)
+}
+
+// 获取用户连接在各自节点上的连接信息（含统计）
+func (u *user) getConnInfosForCluster(uid string, conns []*eventbus.Conn) map[string]*ConnInfo {
+	connInfoMap := make(map[string]*ConnInfo)
+	nodeConnCount := make(map[uint64]int)
+	for _, conn := range conns {
+		nodeConnCount[conn.NodeId]++
+	}
+	for nodeId, count := range nodeConnCount {
+		var (
+			connInfos []*ConnInfo
+			err       error
+		)
+		if options.G.IsLocalNode(nodeId) {
+			for _, resultConn := range u.s.GetConnInfos(uid, ByID, 0, service.ConnManager.ConnCount()) {
+				connInfos = append(connInfos, newConnInfo(resultConn))
+			}
+		} else {
+			connInfos, err = u.requestConnInfos(nodeId, uid, count)
+			if err != nil {
+				u.Warn("获取节点上的连接信息失败！", zap.Error(err), zap.Uint64("nodeId", nodeId), zap.String("uid", uid))
+				continue
+			}
+		}
+		for _, connInfo := range connInfos {
+			if connInfo.UID != uid { // connz是模糊匹配
+				continue
+			}
+			connInfoMap[deviceConnKey(nodeId, connInfo.ID)] = connInfo
+		}
+	}
+	return connInfoMap
+}
+
+func (u *user) requestConnInfos(nodeId uint64, uid string, limit int) ([]*ConnInfo, error) {
+	nodeInfo, err := service.Cluster.NodeInfoById(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if nodeInfo == nil {
+		return nil, fmt.Errorf("节点[%d]不存在！", nodeId)
+	}
+	resp, err := network.Get(fmt.Sprintf("%s/connz", nodeInfo.ApiServerAddr), map[string]string{
+		"uid":     uid,
+		"node_id": strconv.FormatUint(nodeId, 10),
+		"limit":   strconv.Itoa(limit * 10), // connz是模糊匹配，多取一些
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取连接信息请求状态错误！[%d]", resp.StatusCode)
+	}
+	var connz Connz
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), &connz)
+	if err != nil {
+		return nil, err
+	}
+	return connz.Connections, nil
+}
+
+func deviceConnKey(nodeId uint64, connId int64) string {
+	return fmt.Sprintf("%d-%d", nodeId, connId)
+}
+
+// 限制用户同等级有效设备的数量，超过限制则强制最早更新token的设备退出
+func (u *user) limitDeviceCount(uid string, deviceFlag wkproto.DeviceFlag, deviceLevel wkproto.DeviceLevel) {
+	maxCount := options.G.MaxSlaveDeviceCount
+	if deviceLevel == wkproto.DeviceLevelMaster {
+		maxCount = options.G.MaxMasterDeviceCount
+	}
+	if maxCount <= 0 {
+		return
+	}
+	devices, err := service.Store.GetDevices(uid)
+	if err != nil {
+		u.Error("获取用户设备失败！", zap.Error(err), zap.String("uid", uid))
+		return
+	}
+	// 同等级的其他有效设备
+	others := make([]wkdb.Device, 0, len(devices))
+	for _, device := range devices {
+		if device.DeviceFlag == uint64(deviceFlag) || device.Token == "" || device.DeviceLevel != uint8(deviceLevel) {
+			continue
+		}
+		others = append(others, device)
+	}
+	overflow := len(others) + 1 - maxCount
+	if overflow <= 0 {
+		return
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if others[i].UpdatedAt == nil || others[j].UpdatedAt == nil {
+			return others[i].UpdatedAt == nil
+		}
+		return others[i].UpdatedAt.Before(*others[j].UpdatedAt)
+	})
+	for _, device := range others[:overflow] {
+		u.Info("设备数量超过限制，强制设备退出", zap.String("uid", uid), zap.Uint64("deviceFlag", device.DeviceFlag), zap.Uint8("deviceLevel", device.DeviceLevel))
+		_ = u.quitUserDevice(uid, wkproto.DeviceFlag(device.DeviceFlag))
+	}
+}
+
 func (u *user) getOnlineStatus(c *wkhttp.Context) {
 	var uids []string
 	err := c.BindJSON(&uids)
@@ -357,6 +573,9 @@ func (u *user) updateToken(c *wkhttp.Context) {
 		}
 	}
 
+	// 限制同等级设备的数量
+	u.limitDeviceCount(req.UID, req.DeviceFlag, req.DeviceLevel)
+
 	if req.DeviceLevel == wkproto.DeviceLevelMaster {
 		// 如果存在旧连接，则发起踢出请求
 		oldConns := eventbus.User.ConnsByDeviceFlag(req.UID, req.DeviceFlag)
@@ -635,6 +854,59 @@ func (u UpdateTokenReq) Check() error {
 	return nil
 }
 
+type deviceResp struct {
+	DeviceFlag   uint8             `json:"device_flag"`    // 设备标记 0. APP 1.web 2.pc
+	DeviceLevel  uint8             `json:"device_level"`   // 设备等级 0.为从设备 1.为主设备
+	TokenValid   int               `json:"token_valid"`    // token是否有效 1.有效 0.无效（已退出或被吊销）
+	Online       int               `json:"online"`         // 是否在线
+	DeviceId     string            `json:"device_id"`      // 最后一次登录的客户端设备ID
+	Version      uint8             `json:"version"`        // 最后一次登录的客户端协议版本
+	LastLoginAt  int64             `json:"last_login_at"`  // 最后一次登录时间（10位时间戳）
+	LastLoginIp  string            `json:"last_login_ip"`  // 最后一次登录IP
+	ConnCount    uint32            `json:"conn_count"`     // 连接数量
+	SendMsgCount uint64            `json:"send_msg_count"` // 发送消息数量
+	RecvMsgCount uint64            `json:"recv_msg_count"` // 接收消息数量
+	SendMsgBytes uint64            `json:"send_msg_bytes"` // 发送消息字节数
+	RecvMsgBytes uint64            `json:"recv_msg_bytes"` // 接收消息字节数
+	CreatedAt    int64             `json:"created_at"`     // 创建时间（10位时间戳）
+	UpdatedAt    int64             `json:"updated_at"`     // 更新时间（10位时间戳）
+	Conns        []*deviceConnResp `json:"conns"`          // 当前在线的连接
+}
+
+func newDeviceResp(d wkdb.Device) *deviceResp {
+	resp := &deviceResp{
+		DeviceFlag:   uint8(d.DeviceFlag),
+		DeviceLevel:  d.DeviceLevel,
+		DeviceId:     d.DeviceId,
+		Version:      d.Version,
+		LastLoginIp:  d.LastLoginIp,
+		ConnCount:    d.ConnCount,
+		SendMsgCount: d.SendMsgCount,
+		RecvMsgCount: d.RecvMsgCount,
+		SendMsgBytes: d.SendMsgBytes,
+		RecvMsgBytes: d.RecvMsgBytes,
+		Conns:        make([]*deviceConnResp, 0),
+	}
+	if d.Token != "" {
+		resp.TokenValid = 1
+	}
+	if d.LastLoginAt != nil {
+		resp.LastLoginAt = d.LastLoginAt.Unix()
+	}
+	if d.CreatedAt != nil {
+		resp.CreatedAt = d.CreatedAt.Unix()
+	}
+	if d.UpdatedAt != nil {
+		resp.UpdatedAt = d.UpdatedAt.Unix()
+	}
+	return resp
+}
+
+type deviceConnResp struct {
+	NodeId uint64 `json:"node_id"` // 连接所在节点
+	*ConnInfo
+}
+
 type OnlinestatusResp struct {
 	UID        string `json:"uid"`         // 在线用户uid
 	DeviceFlag uint8  `json:"device_flag"` // 设备标记 0. APP 1.web
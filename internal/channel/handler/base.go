@@ -11,12 +11,15 @@ import (
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkserver/proto"
 	"go.uber.org/zap"
+	"k8s.io/utils/lru"
 )
 
 type Handler struct {
 	wklog.Log
 	client        *ingress.Client
 	commonService *common.Service
+	// 缓存频道是否是超大群，避免每批分发都查询一次频道信息
+	largeCache *lru.Cache
 }
 
 func NewHandler() *Handler {
@@ -24,6 +27,7 @@ func NewHandler() *Handler {
 		Log:           wklog.NewWKLog("handler"),
 		client:        ingress.NewClient(),
 		commonService: common.NewService(),
+		largeCache:    lru.New(10000),
 	}
 	h.routes()
 	return h
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
 	"github.com/WuKongIM/WuKongIM/internal/ingress"
@@ -9,6 +11,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/track"
 	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"go.uber.org/zap"
 )
@@ -19,7 +22,7 @@ func (h *Handler) distribute(ctx *eventbus.ChannelContext) {
 	// 记录消息轨迹
 	events := ctx.Events
 	for _, event := range events {
-		event.Track.Record(track.PositionChannelDistribute)
+		event.Record(track.PositionChannelDistribute)
 	}
 
 	// 消息分发
@@ -46,9 +49,18 @@ func (h *Handler) distributeCommon(ctx *eventbus.ChannelContext) {
 		return
 	}
 
+	// 超大群由领导节点标记，转发到其他节点的事件会带上这个标记
+	large := false
+	if options.G.IsLocalNode(ctx.LeaderId) {
+		large = h.isLargeChannel(ctx.ChannelId, ctx.ChannelType)
+	}
+
 	// 打标签
 	for _, event := range ctx.Events {
 		event.TagKey = tag.Key
+		if large {
+			event.Large = true
+		}
 	}
 	// 分发
 	h.distributeByTag(ctx.LeaderId, tag, ctx.ChannelId, ctx.ChannelType, ctx.Events)
@@ -109,6 +121,10 @@ func (h *Handler) distributeByTag(leaderId uint64, tag *types.Tag, channelId str
 		}
 	}
 
+	// 超大群采用读扩散：只推送在线成员，不推离线（开启了只推送提醒时只推被提醒的用户）
+	large := len(events) > 0 && events[0].Large
+	offlinePush := !large || options.G.Channel.LargeOfflineMentionOnly
+
 	// 本地分发
 	var offlineUids []string // 需要推离线的用户
 	var pubshEvents []*eventbus.Event
@@ -127,7 +143,7 @@ func (h *Handler) distributeByTag(leaderId uint64, tag *types.Tag, channelId str
 			if !h.isOnline(uid) {
 				continue
 			}
-			if !h.masterDeviceIsOnline(uid) {
+			if offlinePush && !h.masterDeviceIsOnline(uid) {
 				if offlineUids == nil {
 					offlineUids = make([]string, 0, len(node.Uids))
 				}
@@ -159,13 +175,19 @@ func (h *Handler) distributeByTag(leaderId uint64, tag *types.Tag, channelId str
 	if len(offlineUids) > 0 {
 		offlineEvents := make([]*eventbus.Event, 0, len(events))
 		for _, event := range events {
+			users := largeOfflineUsers(event, offlineUids)
+			if len(users) == 0 {
+				continue
+			}
 			cloneEvent := event.Clone()
-			cloneEvent.OfflineUsers = offlineUids
+			cloneEvent.OfflineUsers = users
 			cloneEvent.Type = eventbus.EventPushOffline
 			offlineEvents = append(offlineEvents, cloneEvent)
 		}
-		_ = eventbus.Pusher.AddEvents(offlineEvents)
-		// eventbus.Pusher.Advance(id) // 不需要推进，因为是离线消息
+		if len(offlineEvents) > 0 {
+			_ = eventbus.Pusher.AddEvents(offlineEvents)
+			// eventbus.Pusher.Advance(id) // 不需要推进，因为是离线消息
+		}
 	}
 
 }
@@ -272,13 +294,24 @@ func (h *Handler) makeChannelTag(fakeChannelId string, channelType uint8) (*type
 				return nil, err
 			}
 		} else {
-			members, err := service.Store.GetSubscribers(fakeChannelId, channelType)
+			parentId, parentType, ok, err := h.inheritParent(fakeChannelId, channelType)
 			if err != nil {
-				h.Error("processMakeTag: getSubscribers failed", zap.Error(err), zap.String("fakeChannelId", fakeChannelId), zap.Uint8("channelType", channelType))
+				h.Error("processMakeTag: get inherit parent failed", zap.Error(err), zap.String("fakeChannelId", fakeChannelId), zap.Uint8("channelType", channelType))
 				return nil, err
 			}
-			for _, member := range members {
-				subscribers = append(subscribers, member.Uid)
+			if ok {
+				// 子频道继承祖先频道的订阅者
+				subscribers, err = h.getSubscribersFromLeader(parentId, parentType)
+				if err != nil {
+					h.Error("processMakeTag: get parent subscribers failed", zap.Error(err), zap.String("fakeChannelId", fakeChannelId), zap.Uint8("channelType", channelType))
+					return nil, err
+				}
+			} else {
+				subscribers, err = service.DatasourceManager.GetSubscribers(fakeChannelId, channelType)
+				if err != nil {
+					h.Error("processMakeTag: getSubscribers failed", zap.Error(err), zap.String("fakeChannelId", fakeChannelId), zap.Uint8("channelType", channelType))
+					return nil, err
+				}
 			}
 		}
 
@@ -296,8 +329,21 @@ func (h *Handler) makeChannelTag(fakeChannelId string, channelType uint8) (*type
 func (h *Handler) getCmdSubscribers(channelId string, channelType uint8) ([]string, error) {
 	// 原频道id
 	orgFakeChannelId := options.G.CmdChannelConvertOrginalChannel(channelId)
-	// 获取原频道的领导节点id
-	leaderNode, err := service.Cluster.LeaderOfChannelForRead(orgFakeChannelId, channelType)
+	// 子频道继承父频道的订阅者
+	parentId, parentType, ok, err := h.inheritParent(orgFakeChannelId, channelType)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return h.getSubscribersFromLeader(parentId, parentType)
+	}
+	return h.getSubscribersFromLeader(orgFakeChannelId, channelType)
+}
+
+// 从频道的领导节点获取订阅者
+func (h *Handler) getSubscribersFromLeader(channelId string, channelType uint8) ([]string, error) {
+	// 获取频道的领导节点id
+	leaderNode, err := service.Cluster.LeaderOfChannelForRead(channelId, channelType)
 	if err != nil {
 		h.Error("processMakeTag: get leaderNode failed", zap.Error(err), zap.String("fakeChannelId", channelId), zap.Uint8("channelType", channelType))
 		return nil, err
@@ -310,25 +356,94 @@ func (h *Handler) getCmdSubscribers(channelId string, channelType uint8) ([]stri
 	// 如果是本地节点，则直接获取订阅者
 	var subscribers []string
 	if options.G.IsLocalNode(leaderId) {
-		members, err := service.Store.GetSubscribers(orgFakeChannelId, channelType)
+		subscribers, err = service.DatasourceManager.GetSubscribers(channelId, channelType)
 		if err != nil {
-			h.Error("processMakeTag: getSubscribers failed", zap.Error(err), zap.String("orgFakeChannelId", orgFakeChannelId), zap.Uint8("channelType", channelType))
+			h.Error("processMakeTag: getSubscribers failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
 			return nil, err
 		}
-		for _, member := range members {
-			subscribers = append(subscribers, member.Uid)
-		}
 	} else {
 		// 如果不是本地节点，则去请求领导节点获取订阅者
-		subscribers, err = h.client.RequestSubscribers(leaderId, orgFakeChannelId, channelType)
+		subscribers, err = h.client.RequestSubscribers(leaderId, channelId, channelType)
 		if err != nil {
-			h.Error("processMakeTag: requestSubscribers failed", zap.Error(err), zap.String("orgFakeChannelId", orgFakeChannelId), zap.Uint8("channelType", channelType))
+			h.Error("processMakeTag: requestSubscribers failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
 			return nil, err
 		}
 	}
 	return subscribers, nil
 }
 
+// 频道是否继承父频道的订阅者，继承则返回最终提供订阅者的祖先频道
+// 查询失败时返回错误，不能当作没有继承（否则子频道的消息会发给子频道自己的订阅者，也就是没有人）
+func (h *Handler) inheritParent(channelId string, channelType uint8) (string, uint8, bool, error) {
+	rootChannelId, rootChannelType, ok, err := service.InheritRootChannel(channelId, channelType)
+	if err != nil {
+		h.Warn("inheritParent: get inherit root channel failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return "", 0, false, err
+	}
+	return rootChannelId, rootChannelType, ok, nil
+}
+
+// 超大群标记的缓存时间，频道设置为超大群后最多延迟这么久生效
+const largeCacheExpire = time.Second * 10
+
+type largeCacheItem struct {
+	large    bool
+	expireAt time.Time
+}
+
+// 是否是超大群
+func (h *Handler) isLargeChannel(channelId string, channelType uint8) bool {
+	if channelType == wkproto.ChannelTypePerson || options.G.IsCmdChannel(channelId) {
+		return false
+	}
+	cacheKey := fmt.Sprintf("%s@%d", channelId, channelType)
+	if value, ok := h.largeCache.Get(cacheKey); ok {
+		item := value.(largeCacheItem)
+		if time.Now().Before(item.expireAt) {
+			return item.large
+		}
+	}
+	channelInfo, err := service.DatasourceManager.GetChannelInfo(channelId, channelType)
+	if err != nil {
+		// 查询失败不缓存，下次重新查询
+		h.Warn("isLargeChannel: get channel failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return false
+	}
+	h.largeCache.Add(cacheKey, largeCacheItem{
+		large:    channelInfo.Large,
+		expireAt: time.Now().Add(largeCacheExpire),
+	})
+	return channelInfo.Large
+}
+
+// 超大群不推离线，开启了只推送提醒后离线推送只保留被提醒（@）的用户
+func largeOfflineUsers(event *eventbus.Event, offlineUids []string) []string {
+	if !event.Large {
+		return offlineUids
+	}
+	if !options.G.Channel.LargeOfflineMentionOnly {
+		return nil
+	}
+	sendPacket, ok := event.Frame.(*wkproto.SendPacket)
+	if !ok {
+		return nil
+	}
+	mention := wkdb.ParseMention(sendPacket.Payload)
+	if mention.IsEmpty() {
+		return nil
+	}
+	if mention.All {
+		return offlineUids
+	}
+	users := make([]string, 0, len(mention.Uids))
+	for _, uid := range offlineUids {
+		if mention.Contains(uid) {
+			users = append(users, uid)
+		}
+	}
+	return users
+}
+
 func (h *Handler) isOnline(uid string) bool {
 	toConns := eventbus.User.AuthedConnsByUid(uid)
 	return len(toConns) > 0
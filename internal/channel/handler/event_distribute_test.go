@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLargeOfflineUsers(t *testing.T) {
+	options.G = options.New()
+	options.G.Channel.LargeOfflineMentionOnly = true
+
+	offlineUids := []string{"u1", "u2", "u3"}
+	newEvent := func(large bool, payload string) *eventbus.Event {
+		return &eventbus.Event{
+			Large: large,
+			Frame: &wkproto.SendPacket{ChannelID: "g1", ChannelType: wkproto.ChannelTypeGroup, Payload: []byte(payload)},
+		}
+	}
+
+	// 普通频道不过滤
+	assert.Equal(t, offlineUids, largeOfflineUsers(newEvent(false, `{"content":"hi"}`), offlineUids))
+
+	// 超大群只推送被提醒的用户
+	assert.Equal(t, []string{"u2"}, largeOfflineUsers(newEvent(true, `{"content":"hi","mention":{"uids":["u2","u4"]}}`), offlineUids))
+	assert.Equal(t, offlineUids, largeOfflineUsers(newEvent(true, `{"mention":{"all":1}}`), offlineUids))
+	assert.Empty(t, largeOfflineUsers(newEvent(true, `{"content":"hi"}`), offlineUids))
+
+	// 关闭只推送提醒后超大群不推离线
+	options.G.Channel.LargeOfflineMentionOnly = false
+	assert.Empty(t, largeOfflineUsers(newEvent(true, `{"content":"hi","mention":{"all":1}}`), offlineUids))
+}
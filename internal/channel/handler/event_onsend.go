@@ -8,7 +8,7 @@ import (
 func (h *Handler) onSend(ctx *eventbus.ChannelContext) {
 	// 记录消息轨迹
 	for _, event := range ctx.Events {
-		event.Track.Record(track.PositionChannelOnSend)
+		event.Record(track.PositionChannelOnSend)
 	}
 	// 权限判断
 	h.permission(ctx)
@@ -20,7 +20,7 @@ func (h *Handler) permission(ctx *eventbus.ChannelContext) {
 	channelType := ctx.ChannelType
 	// 记录消息轨迹
 	for _, event := range events {
-		event.Track.Record(track.PositionChannelPermission)
+		event.Record(track.PositionChannelPermission)
 	}
 
 	// --------------- 判断频道权限 ----------------
@@ -63,7 +63,7 @@ func (h *Handler) hasPermissionForChannel(channelId string, channelType uint8) (
 	}
 
 	// 查询频道基本信息
-	channelInfo, err := service.Store.GetChannel(channelId, channelType)
+	channelInfo, err := service.DatasourceManager.GetChannelInfo(channelId, channelType)
 	if err != nil {
 		h.Error("hasPermission: GetChannel error", zap.Error(err))
 		return wkproto.ReasonSystemError, err
@@ -125,7 +125,7 @@ func (h *Handler) hasPermissionForCommChannel(channelId string, channelType uint
 		realFakeChannelId = options.G.CmdChannelConvertOrginalChannel(channelId)
 	}
 	// 判断是否是黑名单内
-	isDenylist, err := service.Store.ExistDenylist(realFakeChannelId, channelType, fromUid)
+	isDenylist, err := service.DatasourceManager.ExistDenylist(realFakeChannelId, channelType, fromUid)
 	if err != nil {
 		h.Error("ExistDenylist error", zap.Error(err))
 		return wkproto.ReasonSystemError, err
@@ -133,8 +133,19 @@ func (h *Handler) hasPermissionForCommChannel(channelId string, channelType uint
 	if isDenylist {
 		return wkproto.ReasonInBlacklist, nil
 	}
+
+	// 子频道继承父频道的订阅者和权限，由最终提供权限的祖先频道判断
+	rootChannelId, rootChannelType, inherit, err := service.InheritRootChannel(realFakeChannelId, channelType)
+	if err != nil {
+		h.Error("InheritRootChannel error", zap.Error(err), zap.String("channelId", realFakeChannelId), zap.Uint8("channelType", channelType))
+		return wkproto.ReasonSystemError, err
+	}
+	if inherit {
+		return h.requestAllowSendForChannel(rootChannelId, rootChannelType, fromUid)
+	}
+
 	// 判断是否是订阅者
-	isSubscriber, err := service.Store.ExistSubscriber(realFakeChannelId, channelType, fromUid)
+	isSubscriber, err := service.DatasourceManager.ExistSubscriber(realFakeChannelId, channelType, fromUid)
 	if err != nil {
 		h.Error("ExistSubscriber error", zap.Error(err))
 		return wkproto.ReasonSystemError, err
@@ -145,14 +156,14 @@ func (h *Handler) hasPermissionForCommChannel(channelId string, channelType uint
 
 	// 判断是否在白名单内
 	if !options.G.WhitelistOffOfPerson {
-		hasAllowlist, err := service.Store.HasAllowlist(realFakeChannelId, channelType)
+		hasAllowlist, err := service.DatasourceManager.HasAllowlist(realFakeChannelId, channelType)
 		if err != nil {
 			h.Error("HasAllowlist error", zap.Error(err))
 			return wkproto.ReasonSystemError, err
 		}
 
 		if hasAllowlist { // 如果频道有白名单，则判断是否在白名单内
-			isAllowlist, err := service.Store.ExistAllowlist(realFakeChannelId, channelType, fromUid)
+			isAllowlist, err := service.DatasourceManager.ExistAllowlist(realFakeChannelId, channelType, fromUid)
 			if err != nil {
 				h.Error("ExistAllowlist error", zap.Error(err))
 				return wkproto.ReasonSystemError, err
@@ -218,9 +229,33 @@ func (h *Handler) requestAllowSend(from, to string) (wkproto.ReasonCode, error)
 	return wkproto.ReasonCode(resp.Status), nil
 }
 
+// 请求祖先频道的槽领导节点判断是否允许发送
+func (h *Handler) requestAllowSendForChannel(channelId string, channelType uint8, from string) (wkproto.ReasonCode, error) {
+
+	leaderNode, err := service.Cluster.SlotLeaderOfChannel(channelId, channelType)
+	if err != nil {
+		return wkproto.ReasonSystemError, err
+	}
+	if options.G.IsLocalNode(leaderNode.Id) {
+		return service.AllowSendForChannel(channelId, channelType, from)
+	}
+
+	resp, err := h.client.RequestAllowSendForChannel(leaderNode.Id, channelId, channelType, from)
+	if err != nil {
+		return wkproto.ReasonSystemError, err
+	}
+	if resp.Status == proto.StatusOK {
+		return wkproto.ReasonSuccess, nil
+	}
+	if resp.Status == proto.StatusError {
+		return wkproto.ReasonSystemError, errors.New(string(resp.Body))
+	}
+	return wkproto.ReasonCode(resp.Status), nil
+}
+
 func (h *Handler) allowSend(from, to string) (wkproto.ReasonCode, error) {
 	// 判断是否是黑名单内
-	isDenylist, err := service.Store.ExistDenylist(to, wkproto.ChannelTypePerson, from)
+	isDenylist, err := service.DatasourceManager.ExistDenylist(to, wkproto.ChannelTypePerson, from)
 	if err != nil {
 		h.Error("ExistDenylist error", zap.String("from", from), zap.String("to", to), zap.Error(err))
 		return wkproto.ReasonSystemError, err
@@ -231,7 +266,7 @@ func (h *Handler) allowSend(from, to string) (wkproto.ReasonCode, error) {
 
 	if !options.G.WhitelistOffOfPerson {
 		// 判断是否在白名单内
-		isAllowlist, err := service.Store.ExistAllowlist(to, wkproto.ChannelTypePerson, from)
+		isAllowlist, err := service.DatasourceManager.ExistAllowlist(to, wkproto.ChannelTypePerson, from)
 		if err != nil {
 			h.Error("ExistAllowlist error", zap.Error(err))
 			return wkproto.ReasonSystemError, err
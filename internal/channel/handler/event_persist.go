@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
@@ -18,11 +19,14 @@ func (h *Handler) persist(ctx *eventbus.ChannelContext) {
 	// 记录消息轨迹
 	events := ctx.Events
 	for _, e := range events {
-		e.Track.Record(track.PositionChannelPersist)
+		e.Record(track.PositionChannelPersist)
 	}
 
+	// 消息去重（客户端超时重试的消息，回执原消息的id和序号，不再存储和投递）
+	duplicates := h.findDuplicates(ctx.ChannelId, ctx.ChannelType, events)
+
 	// 存储消息
-	persists := h.toPersistMessages(ctx.ChannelId, ctx.ChannelType, events)
+	persists := h.toPersistMessages(ctx.ChannelId, ctx.ChannelType, events, duplicates)
 	if len(persists) > 0 {
 		timeoutCtx, cancel := h.WithTimeout()
 		defer cancel()
@@ -59,6 +63,9 @@ func (h *Handler) persist(ctx *eventbus.ChannelContext) {
 	// webhook
 	if options.G.WebhookOn(types.EventMsgNotify) {
 		for _, e := range events {
+			if _, ok := duplicates[e]; ok {
+				continue
+			}
 			sendPacket := e.Frame.(*wkproto.SendPacket)
 			if e.ReasonCode == wkproto.ReasonSuccess && !sendPacket.NoPersist {
 				cloneEvent := e.Clone()
@@ -73,6 +80,9 @@ func (h *Handler) persist(ctx *eventbus.ChannelContext) {
 		if e.ReasonCode != wkproto.ReasonSuccess {
 			continue
 		}
+		if _, ok := duplicates[e]; ok {
+			continue
+		}
 		cloneEvent := e.Clone()
 		cloneEvent.Type = eventbus.EventChannelDistribute
 		eventbus.Channel.AddEvent(ctx.ChannelId, ctx.ChannelType, cloneEvent)
@@ -82,14 +92,57 @@ func (h *Handler) persist(ctx *eventbus.ChannelContext) {
 
 }
 
+// 查找重复的消息（相同发送者在同一频道发送相同client_msg_no的消息）
+// 重复的消息会被设置为原消息的id和序号（同一批次内重复的，序号在原消息存储后一起填充）
+func (h *Handler) findDuplicates(channelId string, channelType uint8, events []*eventbus.Event) map[*eventbus.Event]struct{} {
+	if options.G.Channel.DedupWindow <= 0 {
+		return nil
+	}
+	var (
+		duplicates  map[*eventbus.Event]struct{}
+		batchEvents map[string]*eventbus.Event // 本批次内的消息
+	)
+	for _, e := range events {
+		sendPacket := e.Frame.(*wkproto.SendPacket)
+		if sendPacket.NoPersist || sendPacket.ClientMsgNo == "" || e.ReasonCode != wkproto.ReasonSuccess {
+			continue
+		}
+		if duplicates == nil {
+			duplicates = make(map[*eventbus.Event]struct{})
+			batchEvents = make(map[string]*eventbus.Event)
+		}
+		dedupKey := fmt.Sprintf("%s@%s", e.Conn.Uid, sendPacket.ClientMsgNo)
+		if origin, ok := batchEvents[dedupKey]; ok {
+			e.MessageId = origin.MessageId
+			duplicates[e] = struct{}{}
+			continue
+		}
+		msg, exist, err := service.GetDedupMessage(channelId, channelType, e.Conn.Uid, sendPacket.ClientMsgNo)
+		if err != nil {
+			h.Warn("get dedup message failed", zap.Error(err), zap.String("fakeChannelId", channelId), zap.Uint8("channelType", channelType), zap.String("clientMsgNo", sendPacket.ClientMsgNo))
+		}
+		if exist {
+			e.MessageId = msg.MessageID
+			e.MessageSeq = uint64(msg.MessageSeq)
+			duplicates[e] = struct{}{}
+			continue
+		}
+		batchEvents[dedupKey] = e
+	}
+	return duplicates
+}
+
 // 转换成存储消息
-func (h *Handler) toPersistMessages(channelId string, channelType uint8, events []*eventbus.Event) []wkdb.Message {
+func (h *Handler) toPersistMessages(channelId string, channelType uint8, events []*eventbus.Event, duplicates map[*eventbus.Event]struct{}) []wkdb.Message {
 	persists := make([]wkdb.Message, 0, len(events))
 	for _, e := range events {
 		sendPacket := e.Frame.(*wkproto.SendPacket)
 		if sendPacket.NoPersist || e.ReasonCode != wkproto.ReasonSuccess {
 			continue
 		}
+		if _, ok := duplicates[e]; ok {
+			continue
+		}
 		msg := wkdb.Message{
 			RecvPacket: wkproto.RecvPacket{
 				Framer: wkproto.Framer{
@@ -12,7 +12,7 @@ func (h *Handler) sendack(ctx *eventbus.ChannelContext) {
 	events := ctx.Events
 	for _, e := range events {
 		sendPacket := e.Frame.(*wkproto.SendPacket)
-		e.Track.Record(track.PositionChannelSendack)
+		e.Record(track.PositionChannelSendack)
 		if options.G.Logger.TraceOn {
 			h.Trace(e.Track.String(),
 				"sendack",
@@ -28,6 +28,18 @@ func (h *Handler) sendack(ctx *eventbus.ChannelContext) {
 				zap.Uint64("conn.fromNode", e.Conn.NodeId),
 				zap.Int64("conn.connId", e.Conn.ConnId),
 			)
+			record := track.NewRecord(track.ActionSendack, options.G.Cluster.NodeId, e.Track)
+			record.MessageId = e.MessageId
+			record.MessageSeq = e.MessageSeq
+			record.ClientMsgNo = sendPacket.ClientMsgNo
+			record.ChannelId = ctx.ChannelId
+			record.ChannelType = ctx.ChannelType
+			record.Uid = e.Conn.Uid
+			record.DeviceId = e.Conn.DeviceId
+			record.DeviceFlag = e.Conn.DeviceFlag.ToUint8()
+			record.DeviceLevel = uint8(e.Conn.DeviceLevel)
+			record.ConnId = e.Conn.ConnId
+			track.Records.Add(record)
 		}
 	}
 
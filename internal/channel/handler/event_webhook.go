@@ -11,7 +11,7 @@ import (
 func (h *Handler) webhook(ctx *eventbus.ChannelContext) {
 	var err error
 	if options.G.WebhookOn(types.EventMsgNotify) {
-		err = service.Store.AppendMessageOfNotifyQueue(h.toPersistMessages(ctx.ChannelId, ctx.ChannelType, ctx.Events))
+		err = service.Store.AppendMessageOfNotifyQueue(h.toPersistMessages(ctx.ChannelId, ctx.ChannelType, ctx.Events, nil))
 		if err != nil {
 			h.Error("store notify queue message failed", zap.Error(err), zap.Int("msgs", len(ctx.Events)), zap.String("channelId", ctx.ChannelId), zap.Uint8("channelType", ctx.ChannelType))
 		}
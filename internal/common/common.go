@@ -1,15 +1,20 @@
 package common
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/RussellLuo/timingwheel"
 	"github.com/WuKongIM/WuKongIM/internal/errors"
+	"github.com/WuKongIM/WuKongIM/internal/eventbus"
 	"github.com/WuKongIM/WuKongIM/internal/ingress"
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/track"
 	"github.com/WuKongIM/WuKongIM/internal/types"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"go.uber.org/zap"
 )
@@ -50,6 +55,64 @@ func (s *Service) AfterFunc(d time.Duration, f func()) {
 	s.timingWheel.AfterFunc(d, f)
 }
 
+// SendOnlineCmd 给指定用户发送在线cmd消息（不存储，只推送给在线的用户）
+func (s *Service) SendOnlineCmd(uids []string, payload []byte) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	channelId := options.G.Channel.OnlineCmdChannelId
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	nodeInfo, err := service.Cluster.LeaderOfChannel(timeoutCtx, channelId, wkproto.ChannelTypeTemp)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	// 在在线cmd频道的领导节点上生成接收者的tag
+	tagKey := fmt.Sprintf("%scmd", wkutil.GenUUID())
+	if options.G.IsLocalNode(nodeInfo.Id) {
+		if _, err = service.TagManager.MakeTagWithTagKey(tagKey, uids); err != nil {
+			return err
+		}
+	} else {
+		err = s.client.UpdateTag(nodeInfo.Id, &ingress.TagUpdateReq{
+			TagKey: tagKey,
+			Uids:   uids,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sendPacket := &wkproto.SendPacket{
+		Framer: wkproto.Framer{
+			SyncOnce:  true,
+			NoPersist: true,
+		},
+		ClientMsgNo: fmt.Sprintf("%s0", wkutil.GenUUID()),
+		ChannelID:   channelId,
+		ChannelType: wkproto.ChannelTypeTemp,
+		Payload:     payload,
+	}
+	event := &eventbus.Event{
+		Conn: &eventbus.Conn{
+			Uid:      options.G.SystemUID,
+			DeviceId: options.G.SystemDeviceId,
+		},
+		Type:      eventbus.EventChannelOnSend,
+		Frame:     sendPacket,
+		MessageId: options.G.GenMessageId(),
+		TagKey:    tagKey,
+		Track: track.Message{
+			PreStart: time.Now(),
+		},
+	}
+	event.Record(track.PositionStart)
+	eventbus.Channel.SendMessage(channelId, wkproto.ChannelTypeTemp, event)
+	eventbus.Channel.Advance(channelId, wkproto.ChannelTypeTemp)
+	return nil
+}
+
 type everyScheduler struct {
 	Interval time.Duration
 }
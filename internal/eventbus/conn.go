@@ -2,6 +2,7 @@ package eventbus
 
 import (
 	"fmt"
+	"net"
 	"sync/atomic"
 
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
@@ -45,6 +46,8 @@ type Conn struct {
 	AesKey []byte
 	// 连接的通讯协议版本
 	ProtoVersion uint8
+	// 连接的客户端地址
+	RemoteAddr string
 
 	// 启动时间
 	Uptime uint64
@@ -62,6 +65,7 @@ func (c *Conn) Encode() ([]byte, error) {
 	enc.WriteBinary(c.AesIV)
 	enc.WriteBinary(c.AesKey)
 	enc.WriteUint8(c.ProtoVersion)
+	enc.WriteString(c.RemoteAddr)
 	return enc.Bytes(), nil
 }
 
@@ -109,11 +113,26 @@ func (c *Conn) Decode(data []byte) error {
 		return err
 	}
 
+	// 兼容旧版本
+	if dec.Len() > 0 {
+		if c.RemoteAddr, err = dec.String(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (c *Conn) Size() uint64 {
-	return uint64(8 + len(c.Uid) + len(c.DeviceId) + 1 + 1 + 8 + 1 + len(c.AesIV) + len(c.AesKey) + 1)
+	return uint64(8 + len(c.Uid) + len(c.DeviceId) + 1 + 1 + 8 + 1 + len(c.AesIV) + len(c.AesKey) + 1 + len(c.RemoteAddr))
+}
+
+// RemoteIp 连接的客户端ip（开启代理协议后为客户端的真实ip）
+func (c *Conn) RemoteIp() string {
+	if host, _, err := net.SplitHostPort(c.RemoteAddr); err == nil {
+		return host
+	}
+	return c.RemoteAddr
 }
 
 func (c *Conn) Equal(cn *Conn) bool {
@@ -4,7 +4,10 @@ import (
 	"fmt"
 
 	"github.com/WuKongIM/WuKongIM/internal/track"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type EventType uint8
@@ -83,8 +86,11 @@ type Event struct {
 	TagKey       string // tag的key
 	ToUid        string // 发送事件的目标用户
 	SourceNodeId uint64 // 事件发起源节点
+	Large        bool   // 是否是超大群的消息（读扩散，不更新成员的最近会话）
 	// 事件记录
 	Track track.Message
+	// 链路追踪上下文（W3C traceparent），开启了链路追踪才有值
+	TraceContext string
 	// 不需要编码
 	Index        uint64
 	OfflineUsers []string // 离线用户集合
@@ -101,7 +107,9 @@ func (e *Event) Clone() *Event {
 		TagKey:       e.TagKey,
 		ToUid:        e.ToUid,
 		SourceNodeId: e.SourceNodeId,
+		Large:        e.Large,
 		Track:        e.Track.Clone(),
+		TraceContext: e.TraceContext,
 		Index:        e.Index,
 		OfflineUsers: e.OfflineUsers,
 	}
@@ -127,11 +135,30 @@ func (e *Event) Size() uint64 {
 	if e.hasTrack() == 1 {
 		size += e.Track.Size()
 	}
+	if e.hasTraceContext() == 1 {
+		size += uint64(2 + len(e.TraceContext))
+	}
 	return size
 }
 
+// Record 记录消息轨迹，开启了链路追踪时同时记录此阶段的span（从上一个位置到当前位置）
+func (e *Event) Record(p track.Position) {
+	start := e.Track.PreStart
+	e.Track.Record(p)
+	if e.spanOn() {
+		attrs := []attribute.KeyValue{attribute.Int64("message.id", e.MessageId)}
+		if e.ToUid != "" {
+			attrs = append(attrs, attribute.String("to.uid", e.ToUid))
+		}
+		if e.Conn != nil {
+			attrs = append(attrs, attribute.String("uid", e.Conn.Uid), attribute.String("device.id", e.Conn.DeviceId))
+		}
+		e.TraceContext = trace.RecordSpan(e.TraceContext, p.String(), start, attrs...)
+	}
+}
+
 func (e Event) encodeWithEcoder(enc *wkproto.Encoder) error {
-	var flag uint8 = e.hasConn()<<7 | e.hasFrame()<<6 | e.hasTrack()<<5
+	var flag uint8 = e.hasConn()<<7 | e.hasFrame()<<6 | e.hasTrack()<<5 | wkutil.BoolToUint8(e.Large)<<4 | e.hasTraceContext()<<3
 	enc.WriteUint8(flag)
 
 	enc.WriteUint8(e.Type.Uint8())
@@ -161,6 +188,9 @@ func (e Event) encodeWithEcoder(enc *wkproto.Encoder) error {
 	if e.hasTrack() == 1 {
 		enc.WriteBinary(e.Track.Encode())
 	}
+	if e.hasTraceContext() == 1 {
+		enc.WriteString(e.TraceContext)
+	}
 	return nil
 }
 
@@ -172,6 +202,8 @@ func (e *Event) decodeWithDecoder(dec *wkproto.Decoder) error {
 	hasConn := (flag >> 7) & 0x01
 	hasFrame := (flag >> 6) & 0x01
 	hasTrack := (flag >> 5) & 0x01
+	e.Large = (flag>>4)&0x01 == 1
+	hasTraceContext := (flag >> 3) & 0x01
 
 	typeUint8, err := dec.Uint8()
 	if err != nil {
@@ -245,6 +277,12 @@ func (e *Event) decodeWithDecoder(dec *wkproto.Decoder) error {
 		}
 	}
 
+	if hasTraceContext == 1 {
+		if e.TraceContext, err = dec.String(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -268,6 +306,28 @@ func (e *Event) hasTrack() uint8 {
 	return 0
 }
 
+// 是否需要记录span，没有链路上下文时，只有收到发送消息和消息回执才创建根span
+func (e *Event) spanOn() bool {
+	if !trace.SpanOn() {
+		return false
+	}
+	if e.TraceContext != "" {
+		return true
+	}
+	if e.Frame == nil {
+		return false
+	}
+	frameType := e.Frame.GetFrameType()
+	return frameType == wkproto.SEND || frameType == wkproto.RECVACK
+}
+
+func (e *Event) hasTraceContext() uint8 {
+	if e.TraceContext != "" {
+		return 1
+	}
+	return 0
+}
+
 type EventBatch []*Event
 
 func (e EventBatch) Encode() ([]byte, error) {
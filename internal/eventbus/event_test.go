@@ -43,9 +43,11 @@ func TestUserEventHandler(t *testing.T) {
 
 func TestEventEncodeDecode(t *testing.T) {
 	event := &Event{
-		Type:      EventConnect,
-		MessageId: 12345,
-		Conn:      &Conn{Uid: "test"},
+		Type:         EventConnect,
+		MessageId:    12345,
+		Large:        true,
+		Conn:         &Conn{Uid: "test"},
+		TraceContext: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
 		Frame: &wkproto.ConnectPacket{
 			UID: "test",
 		},
@@ -64,6 +66,8 @@ func TestEventEncodeDecode(t *testing.T) {
 
 	assert.Equal(t, event.Type, decodedEvent.Type, "Expected event types to match")
 	assert.Equal(t, event.MessageId, decodedEvent.MessageId, "Expected message IDs to match")
+	assert.Equal(t, event.Large, decodedEvent.Large, "Expected large flags to match")
+	assert.Equal(t, event.TraceContext, decodedEvent.TraceContext, "Expected trace contexts to match")
 }
 
 func TestEventBatchEncodeDecode(t *testing.T) {
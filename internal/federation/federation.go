@@ -0,0 +1,343 @@
+package federation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/ingress"
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Federation 跨集群联邦同步，频道领导节点将频道联邦同步队列里的消息按顺序推送给对端集群
+// 队列与消息在同一个batch里写入并随频道日志复制到所有副本，领导变化后新的领导节点从队列继续推送
+type Federation struct {
+	wklog.Log
+	httpClient *http.Client
+	client     *ingress.Client
+	stoped     chan struct{}
+
+	mu sync.Mutex
+	// 对端集群 -> 频道 -> 已推送到的消息序号，只保存在内存，重启或领导变化后从队列头重新推送（对端按client_msg_no去重）
+	progress map[string]map[string]uint64
+	// 对端集群 -> 频道 -> 队头消息连续推送失败的次数
+	errCounts map[string]map[string]int
+}
+
+func New() *Federation {
+	return &Federation{
+		Log:       wklog.NewWKLog("Federation"),
+		client:    ingress.NewClient(),
+		stoped:    make(chan struct{}),
+		progress:  make(map[string]map[string]uint64),
+		errCounts: make(map[string]map[string]int),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   5 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				MaxIdleConnsPerHost:   10,
+				IdleConnTimeout:       300 * time.Second,
+				TLSHandshakeTimeout:   time.Second * 10,
+				ResponseHeaderTimeout: 20 * time.Second,
+				ExpectContinueTimeout: 1 * time.Second,
+			},
+		},
+	}
+}
+
+func (f *Federation) Start() error {
+	if !options.G.FederationOn() {
+		return nil
+	}
+	for _, peer := range options.G.Federation.Peers {
+		f.progress[peer.Id] = make(map[string]uint64)
+		f.errCounts[peer.Id] = make(map[string]int)
+	}
+	for _, peer := range options.G.Federation.Peers {
+		go f.peerQueueLoop(peer)
+	}
+	f.Info("federation started", zap.String("clusterId", options.G.Federation.ClusterId), zap.Int("peers", len(options.G.Federation.Peers)))
+	return nil
+}
+
+func (f *Federation) Stop() {
+	close(f.stoped)
+}
+
+// 按频道推送对端集群的联邦同步队列，频道之间互不影响
+func (f *Federation) peerQueueLoop(peer *options.FederationPeer) {
+	errorSleepTime := time.Second * 1 // 发生错误后sleep时间
+	ticker := time.NewTicker(options.G.Federation.PushInterval)
+	defer ticker.Stop()
+
+	for {
+		channels, err := service.Store.GetFederationQueueChannels()
+		if err != nil {
+			f.Error("获取联邦同步队列的频道失败！", zap.Error(err), zap.String("peerId", peer.Id))
+			if !f.sleep(errorSleepTime) {
+				return
+			}
+			continue
+		}
+		f.pruneProgress(peer.Id, channels)
+
+		busy := false // 有频道还有积压
+		for _, ch := range channels {
+			select {
+			case <-f.stoped:
+				return
+			default:
+			}
+			if !f.isLeader(ch.ChannelId, ch.ChannelType) { // 只有频道的领导节点推送
+				continue
+			}
+			full, err := f.pushChannel(peer, ch.ChannelId, ch.ChannelType)
+			if err != nil {
+				f.Warn("推送频道联邦同步消息失败！", zap.Error(err), zap.String("peerId", peer.Id), zap.String("channelId", ch.ChannelId), zap.Uint8("channelType", ch.ChannelType))
+				continue
+			}
+			if full {
+				busy = true
+			}
+		}
+		if busy {
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-f.stoped:
+			return
+		}
+	}
+}
+
+// 推送频道队列里还没推送给对端集群的消息，返回是否还有积压
+func (f *Federation) pushChannel(peer *options.FederationPeer, channelId string, channelType uint8) (bool, error) {
+	channelKey := wkutil.ChannelToKey(channelId, channelType)
+	startSeq, errCount := f.getProgress(peer.Id, channelKey)
+
+	messages, err := service.Store.GetMessagesOfFederationQueue(channelId, channelType, startSeq, options.G.Federation.CountPerPush)
+	if err != nil {
+		return false, err
+	}
+	if len(messages) == 0 {
+		return false, nil
+	}
+
+	resp, pushErr := f.push(peer, messages)
+	pushedSeq, errCount, dropped := nextProgress(startSeq, messages, resp, pushErr, errCount, options.G.Federation.RetryMaxCount)
+	if dropped != nil {
+		f.Error("联邦同步消息推送失败超过最大次数，丢弃！", zap.String("peerId", peer.Id), zap.Int64("messageId", dropped.MessageID), zap.Uint32("messageSeq", dropped.MessageSeq), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+	}
+	f.setProgress(peer.Id, channelKey, pushedSeq, errCount)
+
+	if pushedSeq > startSeq {
+		f.removePushed(channelId, channelType)
+	}
+	if pushErr != nil {
+		return false, pushErr
+	}
+	if resp != nil && resp.FailedMessageId != 0 {
+		return false, errors.Errorf("federation peer handle message[%d] failed: %s", resp.FailedMessageId, resp.Error)
+	}
+	return len(messages) >= options.G.Federation.CountPerPush, nil
+}
+
+// 根据推送结果计算频道新的推送位置，失败的消息只重试它自己，超过最大重试次数后只丢弃这一条
+// 返回新的推送位置、队头消息连续失败的次数和被丢弃的消息
+func nextProgress(startSeq uint64, messages []wkdb.Message, resp *types.FederationMessagesResp, pushErr error, errCount int, retryMaxCount int) (uint64, int, *wkdb.Message) {
+	failedIndex := -1
+	if pushErr != nil {
+		failedIndex = 0
+	} else if resp != nil && resp.FailedMessageId != 0 {
+		for i, msg := range messages {
+			if msg.MessageID == resp.FailedMessageId {
+				failedIndex = i
+				break
+			}
+		}
+		if failedIndex == -1 { // 对端返回的消息不在本批内，按整批失败处理
+			failedIndex = 0
+		}
+	}
+	if failedIndex == -1 {
+		return uint64(messages[len(messages)-1].MessageSeq), 0, nil
+	}
+
+	pushedSeq := startSeq
+	if failedIndex > 0 { // 失败消息之前的已推送成功，重新开始计算失败次数
+		pushedSeq = uint64(messages[failedIndex-1].MessageSeq)
+		errCount = 0
+	}
+	errCount++
+	if errCount >= retryMaxCount {
+		dropped := messages[failedIndex]
+		return uint64(dropped.MessageSeq), 0, &dropped
+	}
+	return pushedSeq, errCount, nil
+}
+
+// 移除频道队列里所有对端集群都已推送的消息，并通知频道的其他副本移除
+func (f *Federation) removePushed(channelId string, channelType uint8) {
+	channelKey := wkutil.ChannelToKey(channelId, channelType)
+	f.mu.Lock()
+	var endSeq uint64
+	for i, peer := range options.G.Federation.Peers {
+		seq := f.progress[peer.Id][channelKey]
+		if i == 0 || seq < endSeq {
+			endSeq = seq
+		}
+	}
+	f.mu.Unlock()
+	if endSeq == 0 {
+		return
+	}
+
+	if err := service.Store.RemoveMessagesOfFederationQueue(channelId, channelType, endSeq); err != nil {
+		f.Warn("从联邦同步队列里移除消息失败！", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return
+	}
+	cfg, err := service.Cluster.LoadOnlyChannelClusterConfig(channelId, channelType)
+	if err != nil {
+		f.Warn("获取频道分布式配置失败！", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return
+	}
+	req := &ingress.FederationQueueRemoveReq{
+		ChannelId:     channelId,
+		ChannelType:   channelType,
+		EndMessageSeq: endSeq,
+	}
+	for _, nodeId := range append(cfg.Replicas, cfg.Learners...) {
+		if options.G.IsLocalNode(nodeId) {
+			continue
+		}
+		// 副本移除失败不影响推送，副本成为领导后会重新推送未移除的消息，对端会去重
+		if err = f.client.RemoveFederationQueue(nodeId, req); err != nil {
+			f.Warn("通知副本移除联邦同步队列失败！", zap.Error(err), zap.Uint64("nodeId", nodeId), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		}
+	}
+}
+
+func (f *Federation) isLeader(channelId string, channelType uint8) bool {
+	leader, err := service.Cluster.LeaderOfChannelForRead(channelId, channelType)
+	if err != nil || leader == nil {
+		return false
+	}
+	return options.G.IsLocalNode(leader.Id)
+}
+
+func (f *Federation) getProgress(peerId string, channelKey string) (uint64, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.progress[peerId][channelKey], f.errCounts[peerId][channelKey]
+}
+
+func (f *Federation) setProgress(peerId string, channelKey string, pushedSeq uint64, errCount int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.progress[peerId][channelKey] = pushedSeq
+	if errCount > 0 {
+		f.errCounts[peerId][channelKey] = errCount
+	} else {
+		delete(f.errCounts[peerId], channelKey)
+	}
+}
+
+// 队列里已经没有的频道不再保留推送位置
+func (f *Federation) pruneProgress(peerId string, channels []wkdb.Channel) {
+	exists := make(map[string]struct{}, len(channels))
+	for _, ch := range channels {
+		exists[wkutil.ChannelToKey(ch.ChannelId, ch.ChannelType)] = struct{}{}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for channelKey := range f.progress[peerId] {
+		if _, ok := exists[channelKey]; !ok {
+			delete(f.progress[peerId], channelKey)
+			delete(f.errCounts[peerId], channelKey)
+		}
+	}
+}
+
+// 推送消息给对端集群，返回源消息ID与对端消息ID的映射
+func (f *Federation) push(peer *options.FederationPeer, messages []wkdb.Message) (*types.FederationMessagesResp, error) {
+	req := &types.FederationMessagesReq{
+		SourceClusterId: options.G.Federation.ClusterId,
+		Messages:        make([]*types.FederationMessage, 0, len(messages)),
+	}
+	for _, msg := range messages {
+		req.Messages = append(req.Messages, &types.FederationMessage{
+			Header: types.MessageHeader{
+				RedDot:   wkutil.BoolToInt(msg.RedDot),
+				SyncOnce: wkutil.BoolToInt(msg.SyncOnce),
+			},
+			MessageId:   msg.MessageID,
+			MessageSeq:  msg.MessageSeq,
+			ClientMsgNo: msg.ClientMsgNo,
+			StreamNo:    msg.StreamNo,
+			FromUid:     msg.FromUID,
+			ChannelId:   msg.ChannelID,
+			ChannelType: msg.ChannelType,
+			Expire:      msg.Expire,
+			Timestamp:   msg.Timestamp,
+			Payload:     msg.Payload,
+		})
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/federation/messages", peer.APIUrl), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(options.FederationTokenHeader, peer.Token)
+	resp, err := f.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("federation peer response status[%d] error: %s", resp.StatusCode, string(body))
+	}
+	var federationResp types.FederationMessagesResp
+	if err = json.Unmarshal(body, &federationResp); err != nil {
+		return nil, err
+	}
+	return &federationResp, nil
+}
+
+// 休眠，返回false表示已停止
+func (f *Federation) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-f.stoped:
+		return false
+	}
+}
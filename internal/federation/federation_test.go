@@ -0,0 +1,43 @@
+package federation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextProgress(t *testing.T) {
+	messages := []wkdb.Message{
+		{RecvPacket: wkproto.RecvPacket{MessageID: 11, MessageSeq: 1}},
+		{RecvPacket: wkproto.RecvPacket{MessageID: 12, MessageSeq: 2}},
+		{RecvPacket: wkproto.RecvPacket{MessageID: 13, MessageSeq: 3}},
+	}
+
+	// 全部成功
+	seq, errCount, dropped := nextProgress(0, messages, &types.FederationMessagesResp{}, nil, 1, 3)
+	assert.Equal(t, uint64(3), seq)
+	assert.Equal(t, 0, errCount)
+	assert.Nil(t, dropped)
+
+	// 请求失败，停留在原位置重试
+	seq, errCount, dropped = nextProgress(0, messages, nil, errors.New("timeout"), 1, 3)
+	assert.Equal(t, uint64(0), seq)
+	assert.Equal(t, 2, errCount)
+	assert.Nil(t, dropped)
+
+	// 中间的消息失败，之前的消息推进
+	seq, errCount, dropped = nextProgress(0, messages, &types.FederationMessagesResp{FailedMessageId: 12}, nil, 2, 3)
+	assert.Equal(t, uint64(1), seq)
+	assert.Equal(t, 1, errCount)
+	assert.Nil(t, dropped)
+
+	// 超过最大重试次数只丢弃失败的那条消息
+	seq, errCount, dropped = nextProgress(1, messages[1:], &types.FederationMessagesResp{FailedMessageId: 12}, nil, 2, 3)
+	assert.Equal(t, uint64(2), seq)
+	assert.Equal(t, 0, errCount)
+	assert.Equal(t, int64(12), dropped.MessageID)
+}
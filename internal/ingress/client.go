@@ -76,6 +76,20 @@ func (c *Client) RequestAllowSendForPerson(toNodeId uint64, from, to string) (*p
 	return c.request(toNodeId, "/wk/ingress/allowSend", data)
 }
 
+// 判断频道是否允许发送者发送消息（子频道继承父频道权限）
+func (c *Client) RequestAllowSendForChannel(toNodeId uint64, channelId string, channelType uint8, from string) (*proto.Response, error) {
+	req := &AllowSendForChannelReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		From:        from,
+	}
+	data, err := req.encode()
+	if err != nil {
+		return nil, err
+	}
+	return c.request(toNodeId, "/wk/ingress/allowSendForChannel", data)
+}
+
 func (c *Client) RequestSubscribers(toNodeId uint64, channelId string, channelType uint8) ([]string, error) {
 
 	req := &ChannelReq{
@@ -103,6 +117,122 @@ func (c *Client) RequestSubscribers(toNodeId uint64, channelId string, channelTy
 	return subResp.Subscribers, nil
 }
 
+// RequestVisibleStartMsgSeq 请求频道的槽领导节点获取用户在频道内可见的起始消息序号
+func (c *Client) RequestVisibleStartMsgSeq(toNodeId uint64, channelId string, channelType uint8, uid string) (uint64, error) {
+	req := &VisibleStartMsgSeqReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		Uid:         uid,
+	}
+	data, err := req.encode()
+	if err != nil {
+		return 0, err
+	}
+	return c.requestMsgSeq(toNodeId, "/wk/ingress/visibleStartMsgSeq", data)
+}
+
+// RequestVisibleStartMsgSeqs 请求槽领导节点批量获取用户在多个频道内可见的起始消息序号（与channels顺序一致）
+func (c *Client) RequestVisibleStartMsgSeqs(toNodeId uint64, uid string, channels []ChannelReq) ([]uint64, error) {
+	req := &VisibleStartMsgSeqsReq{
+		Uid:      uid,
+		Channels: channels,
+	}
+	data, err := req.encode()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.request(toNodeId, "/wk/ingress/visibleStartMsgSeqs", data)
+	if err != nil {
+		return nil, err
+	}
+	err = c.handleRespError(resp)
+	if err != nil {
+		return nil, err
+	}
+	seqsResp := &MsgSeqsResp{}
+	err = seqsResp.decode(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(seqsResp.MsgSeqs) != len(channels) {
+		return nil, fmt.Errorf("visible start msg seqs count[%d] not match channels[%d]", len(seqsResp.MsgSeqs), len(channels))
+	}
+	return seqsResp.MsgSeqs, nil
+}
+
+// RequestLastMsgSeq 请求频道的领导节点获取频道最新的消息序号
+func (c *Client) RequestLastMsgSeq(toNodeId uint64, channelId string, channelType uint8) (uint64, error) {
+	req := &ChannelReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+	}
+	data, err := req.Encode()
+	if err != nil {
+		return 0, err
+	}
+	return c.requestMsgSeq(toNodeId, "/wk/ingress/lastMsgSeq", data)
+}
+
+func (c *Client) requestMsgSeq(toNodeId uint64, path string, body []byte) (uint64, error) {
+	resp, err := c.request(toNodeId, path, body)
+	if err != nil {
+		return 0, err
+	}
+	err = c.handleRespError(resp)
+	if err != nil {
+		return 0, err
+	}
+	seqResp := &MsgSeqResp{}
+	err = seqResp.decode(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	return seqResp.MsgSeq, nil
+}
+
+// PresenceConnClosed 通知用户所在槽的领导节点连接已关闭
+func (c *Client) PresenceConnClosed(toNodeId uint64, req *PresenceConnClosedReq) error {
+	data, err := req.encode()
+	if err != nil {
+		return err
+	}
+	resp, err := c.request(toNodeId, "/wk/ingress/presenceConnClosed", data)
+	if err != nil {
+		return err
+	}
+	return c.handleRespError(resp)
+}
+
+// RemoveFederationQueue 通知频道副本移除联邦同步队列里已同步的消息
+func (c *Client) RemoveFederationQueue(toNodeId uint64, req *FederationQueueRemoveReq) error {
+	data, err := req.encode()
+	if err != nil {
+		return err
+	}
+	resp, err := c.request(toNodeId, "/wk/ingress/federationQueueRemove", data)
+	if err != nil {
+		return err
+	}
+	return c.handleRespError(resp)
+}
+
+// InvalidateChannel 通知频道的领导节点使频道的缓存和tag失效
+func (c *Client) InvalidateChannel(toNodeId uint64, channelId string, channelType uint8) error {
+	req := &ChannelReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+	}
+	data, err := req.Encode()
+	if err != nil {
+		return err
+	}
+	resp, err := c.request(toNodeId, "/wk/ingress/invalidateChannel", data)
+	if err != nil {
+		return err
+	}
+	return c.handleRespError(resp)
+}
+
 func (c *Client) request(toNodeId uint64, path string, body []byte) (*proto.Response, error) {
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
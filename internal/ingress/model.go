@@ -103,6 +103,36 @@ func (a *AllowSendReq) encode() ([]byte, error) {
 	return enc.Bytes(), nil
 }
 
+type AllowSendForChannelReq struct {
+	ChannelId   string // 频道ID
+	ChannelType uint8  // 频道类型
+	From        string // 发送者
+}
+
+func (a *AllowSendForChannelReq) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if a.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if a.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if a.From, err = dec.String(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *AllowSendForChannelReq) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(a.ChannelId)
+	enc.WriteUint8(a.ChannelType)
+	enc.WriteString(a.From)
+	return enc.Bytes(), nil
+}
+
 type TagUpdateReq struct {
 	TagKey      string
 	ChannelId   string
@@ -216,3 +246,188 @@ func (s *SubscribersResp) Decode(data []byte) error {
 	}
 	return nil
 }
+
+// PresenceConnClosedReq 通知用户所在槽的领导节点连接已关闭
+type PresenceConnClosedReq struct {
+	Uid    string
+	NodeId uint64 // 连接所在节点
+	ConnId int64
+}
+
+func (p *PresenceConnClosedReq) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(p.Uid)
+	enc.WriteUint64(p.NodeId)
+	enc.WriteInt64(p.ConnId)
+	return enc.Bytes(), nil
+}
+
+func (p *PresenceConnClosedReq) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if p.Uid, err = dec.String(); err != nil {
+		return err
+	}
+	if p.NodeId, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if p.ConnId, err = dec.Int64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type VisibleStartMsgSeqReq struct {
+	ChannelId   string
+	ChannelType uint8
+	Uid         string
+}
+
+func (v *VisibleStartMsgSeqReq) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(v.ChannelId)
+	enc.WriteUint8(v.ChannelType)
+	enc.WriteString(v.Uid)
+	return enc.Bytes(), nil
+}
+
+func (v *VisibleStartMsgSeqReq) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if v.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if v.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if v.Uid, err = dec.String(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// VisibleStartMsgSeqsReq 批量获取用户在多个频道内可见的起始消息序号（频道都在同一个槽领导节点上）
+type VisibleStartMsgSeqsReq struct {
+	Uid      string
+	Channels []ChannelReq
+}
+
+func (v *VisibleStartMsgSeqsReq) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(v.Uid)
+	enc.WriteUint32(uint32(len(v.Channels)))
+	for _, ch := range v.Channels {
+		enc.WriteString(ch.ChannelId)
+		enc.WriteUint8(ch.ChannelType)
+	}
+	return enc.Bytes(), nil
+}
+
+func (v *VisibleStartMsgSeqsReq) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if v.Uid, err = dec.String(); err != nil {
+		return err
+	}
+	count, err := dec.Uint32()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < int(count); i++ {
+		var ch ChannelReq
+		if ch.ChannelId, err = dec.String(); err != nil {
+			return err
+		}
+		if ch.ChannelType, err = dec.Uint8(); err != nil {
+			return err
+		}
+		v.Channels = append(v.Channels, ch)
+	}
+	return nil
+}
+
+// MsgSeqsResp 多个消息序号（与请求的频道顺序一致）
+type MsgSeqsResp struct {
+	MsgSeqs []uint64
+}
+
+func (m *MsgSeqsResp) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint32(uint32(len(m.MsgSeqs)))
+	for _, msgSeq := range m.MsgSeqs {
+		enc.WriteUint64(msgSeq)
+	}
+	return enc.Bytes(), nil
+}
+
+func (m *MsgSeqsResp) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	count, err := dec.Uint32()
+	if err != nil {
+		return err
+	}
+	m.MsgSeqs = make([]uint64, 0, count)
+	for i := 0; i < int(count); i++ {
+		msgSeq, err := dec.Uint64()
+		if err != nil {
+			return err
+		}
+		m.MsgSeqs = append(m.MsgSeqs, msgSeq)
+	}
+	return nil
+}
+
+type MsgSeqResp struct {
+	MsgSeq uint64
+}
+
+func (m *MsgSeqResp) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(m.MsgSeq)
+	return enc.Bytes(), nil
+}
+
+func (m *MsgSeqResp) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if m.MsgSeq, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FederationQueueRemoveReq 移除频道副本的联邦同步队列里已同步的消息
+type FederationQueueRemoveReq struct {
+	ChannelId     string
+	ChannelType   uint8
+	EndMessageSeq uint64 // 移除小于等于此序号的消息
+}
+
+func (f *FederationQueueRemoveReq) encode() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(f.ChannelId)
+	enc.WriteUint8(f.ChannelType)
+	enc.WriteUint64(f.EndMessageSeq)
+	return enc.Bytes(), nil
+}
+
+func (f *FederationQueueRemoveReq) decode(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if f.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if f.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if f.EndMessageSeq, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
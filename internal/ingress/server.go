@@ -28,10 +28,24 @@ func (i *Ingress) SetRoutes() {
 	service.Cluster.Route("/wk/ingress/getTag", i.handleGetTag)
 	// 判断接受者是否允许发送消息
 	service.Cluster.Route("/wk/ingress/allowSend", i.handleAllowSend)
+	// 判断频道是否允许发送者发送消息
+	service.Cluster.Route("/wk/ingress/allowSendForChannel", i.handleAllowSendForChannel)
 	// 更新tag
 	service.Cluster.Route("/wk/ingress/updateTag", i.handleUpdateTag)
 	// 获取订阅者
 	service.Cluster.Route("/wk/ingress/getSubscribers", i.handleGetSubscribers)
+	// 用户连接关闭（在线状态）
+	service.Cluster.Route("/wk/ingress/presenceConnClosed", i.handlePresenceConnClosed)
+	// 用户在频道内可见的起始消息序号（槽领导节点）
+	service.Cluster.Route("/wk/ingress/visibleStartMsgSeq", i.handleVisibleStartMsgSeq)
+	// 批量获取用户在多个频道内可见的起始消息序号（槽领导节点）
+	service.Cluster.Route("/wk/ingress/visibleStartMsgSeqs", i.handleVisibleStartMsgSeqs)
+	// 频道最新的消息序号（频道领导节点）
+	service.Cluster.Route("/wk/ingress/lastMsgSeq", i.handleLastMsgSeq)
+	// 移除联邦同步队列里已同步的消息（频道副本节点）
+	service.Cluster.Route("/wk/ingress/federationQueueRemove", i.handleFederationQueueRemove)
+	// 使频道的缓存和tag失效（频道领导节点）
+	service.Cluster.Route("/wk/ingress/invalidateChannel", i.handleInvalidateChannel)
 
 }
 
@@ -106,6 +120,29 @@ func (i *Ingress) handleAllowSend(ctx *wkserver.Context) {
 	ctx.WriteErrorAndStatus(errors.New("not allow send"), proto.Status(reasonCode))
 }
 
+func (i *Ingress) handleAllowSendForChannel(ctx *wkserver.Context) {
+	req := &AllowSendForChannelReq{}
+	err := req.decode(ctx.Body())
+	if err != nil {
+		i.Error("handleAllowSendForChannel Unmarshal err", zap.Error(err))
+		ctx.WriteErr(err)
+		return
+	}
+
+	reasonCode, err := service.AllowSendForChannel(req.ChannelId, req.ChannelType, req.From)
+	if err != nil {
+		i.Error("handleAllowSendForChannel: allowSend failed", zap.Error(err))
+		ctx.WriteErr(err)
+		return
+	}
+
+	if reasonCode == wkproto.ReasonSuccess {
+		ctx.WriteOk()
+		return
+	}
+	ctx.WriteErrorAndStatus(errors.New("not allow send"), proto.Status(reasonCode))
+}
+
 func (i *Ingress) handleUpdateTag(c *wkserver.Context) {
 	var req = &TagUpdateReq{}
 	err := req.Decode(c.Body())
@@ -187,18 +224,13 @@ func (i *Ingress) handleGetSubscribers(c *wkserver.Context) {
 		return
 	}
 
-	members, err := service.Store.GetSubscribers(req.ChannelId, req.ChannelType)
+	subscribers, err := service.DatasourceManager.GetSubscribers(req.ChannelId, req.ChannelType)
 	if err != nil {
 		i.Error("handleGetSubscribers: get subscribers failed", zap.Error(err))
 		c.WriteErr(err)
 		return
 	}
 
-	subscribers := make([]string, 0, len(members))
-	for _, member := range members {
-		subscribers = append(subscribers, member.Uid)
-	}
-
 	resp := &SubscribersResp{
 		Subscribers: subscribers,
 	}
@@ -210,3 +242,120 @@ func (i *Ingress) handleGetSubscribers(c *wkserver.Context) {
 	}
 	c.Write(data)
 }
+
+func (i *Ingress) handlePresenceConnClosed(c *wkserver.Context) {
+	req := &PresenceConnClosedReq{}
+	err := req.decode(c.Body())
+	if err != nil {
+		i.Error("handlePresenceConnClosed: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	if service.PresenceManager == nil {
+		c.WriteOk()
+		return
+	}
+	service.PresenceManager.ConnClosed(req.Uid, req.NodeId, req.ConnId)
+	c.WriteOk()
+}
+
+func (i *Ingress) handleVisibleStartMsgSeq(c *wkserver.Context) {
+	req := &VisibleStartMsgSeqReq{}
+	err := req.decode(c.Body())
+	if err != nil {
+		i.Error("handleVisibleStartMsgSeq: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	msgSeq, err := service.VisibleStartMsgSeq(req.ChannelId, req.ChannelType, req.Uid)
+	if err != nil {
+		i.Error("handleVisibleStartMsgSeq: get visible start msg seq failed", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(err)
+		return
+	}
+	i.writeMsgSeq(c, msgSeq)
+}
+
+func (i *Ingress) handleVisibleStartMsgSeqs(c *wkserver.Context) {
+	req := &VisibleStartMsgSeqsReq{}
+	err := req.decode(c.Body())
+	if err != nil {
+		i.Error("handleVisibleStartMsgSeqs: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	resp := &MsgSeqsResp{MsgSeqs: make([]uint64, 0, len(req.Channels))}
+	for _, ch := range req.Channels {
+		msgSeq, err := service.VisibleStartMsgSeq(ch.ChannelId, ch.ChannelType, req.Uid)
+		if err != nil {
+			i.Error("handleVisibleStartMsgSeqs: get visible start msg seq failed", zap.Error(err), zap.String("channelId", ch.ChannelId), zap.Uint8("channelType", ch.ChannelType))
+			c.WriteErr(err)
+			return
+		}
+		resp.MsgSeqs = append(resp.MsgSeqs, msgSeq)
+	}
+	data, err := resp.encode()
+	if err != nil {
+		i.Error("handleVisibleStartMsgSeqs: encode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	c.Write(data)
+}
+
+func (i *Ingress) handleLastMsgSeq(c *wkserver.Context) {
+	req := &ChannelReq{}
+	err := req.Decode(c.Body())
+	if err != nil {
+		i.Error("handleLastMsgSeq: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	msgSeq, err := service.Store.GetLastMsgSeq(req.ChannelId, req.ChannelType)
+	if err != nil {
+		i.Error("handleLastMsgSeq: get last msg seq failed", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(err)
+		return
+	}
+	i.writeMsgSeq(c, msgSeq)
+}
+
+func (i *Ingress) handleFederationQueueRemove(c *wkserver.Context) {
+	req := &FederationQueueRemoveReq{}
+	err := req.decode(c.Body())
+	if err != nil {
+		i.Error("handleFederationQueueRemove: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	err = service.Store.RemoveMessagesOfFederationQueue(req.ChannelId, req.ChannelType, req.EndMessageSeq)
+	if err != nil {
+		i.Error("handleFederationQueueRemove: remove federation queue failed", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(err)
+		return
+	}
+	c.WriteOk()
+}
+
+func (i *Ingress) handleInvalidateChannel(c *wkserver.Context) {
+	req := &ChannelReq{}
+	err := req.Decode(c.Body())
+	if err != nil {
+		i.Error("handleInvalidateChannel: decode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	service.DatasourceManager.Invalidate(req.ChannelId, req.ChannelType)
+	c.WriteOk()
+}
+
+func (i *Ingress) writeMsgSeq(c *wkserver.Context, msgSeq uint64) {
+	resp := &MsgSeqResp{MsgSeq: msgSeq}
+	data, err := resp.encode()
+	if err != nil {
+		i.Error("writeMsgSeq: encode failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	c.Write(data)
+}
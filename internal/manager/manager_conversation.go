@@ -178,12 +178,16 @@ func (c *ConversationManager) GetFromCache(uid string, conversationType wkdb.Con
 	conversations := make([]wkdb.Conversation, 0, len(updates))
 
 	for _, update := range updates {
+		readToMsgSeq := update.getUserMessageSeq(uid)
+		if readToMsgSeq == 0 && update.isLarge() {
+			readToMsgSeq = c.largeReadToMsgSeq(uid, update)
+		}
 		conversations = append(conversations, wkdb.Conversation{
 			Uid:          uid,
 			Type:         conversationType,
 			ChannelId:    update.channelId,
 			ChannelType:  update.channelType,
-			ReadToMsgSeq: update.getUserMessageSeq(uid),
+			ReadToMsgSeq: readToMsgSeq,
 		})
 	}
 
@@ -191,6 +195,21 @@ func (c *ConversationManager) GetFromCache(uid string, conversationType wkdb.Con
 
 }
 
+// 超大群成员的已读位置，以成员自己的最近会话为准，没有最近会话（已删除或还未补建）的从本次缓存的第一条消息之前开始算未读
+func (c *ConversationManager) largeReadToMsgSeq(uid string, update *conversationUpdate) uint64 {
+	conversation, err := service.Store.GetConversation(uid, update.channelId, update.channelType)
+	if err != nil && err != wkdb.ErrNotFound {
+		c.Warn("largeReadToMsgSeq: get conversation failed", zap.Error(err), zap.String("uid", uid), zap.String("channelId", update.channelId), zap.Uint8("channelType", update.channelType))
+	}
+	if err == nil && !wkdb.IsEmptyConversation(conversation) {
+		return conversation.ReadToMsgSeq
+	}
+	if update.suggestMessageSeq > 0 {
+		return update.suggestMessageSeq - 1
+	}
+	return 0
+}
+
 func (c *ConversationManager) DeleteFromCache(uid string, channelId string, channelType uint8) {
 	worker := c.worker(channelId, channelType)
 
@@ -318,6 +337,12 @@ func (c *conversationWorker) handleReq(fakeChannelId string, channelType uint8,
 		update.addOrUpdateUser(fromUid, uint64(msg.MessageSeq))
 	}
 
+	// 超大群是读扩散，每条消息不写成员的最近会话，频道活跃期间成员的最近会话在同步时根据tag从缓存派生，
+	// 未读数根据频道最新消息序号和成员自己的已读位置计算；成员的最近会话只在订阅者变化（tag变化）时为缺失的成员补建一次
+	if firstMsg.Large {
+		update.markLarge()
+	}
+
 	if channelType == wkproto.ChannelTypePerson {
 		// 如果是个人频道并且不是第一条消息，则不需要更新最近会话
 		if firstMsg.MessageSeq > 1 {
@@ -597,6 +622,7 @@ type conversationUpdate struct {
 	deleted          map[string]struct{}   // 已删除最近会话的用户
 	lastTagKey       string                // 最后一次更新所有最近会话的tagKey
 	updateAll        bool                  // 是否需要更新整个频道的订阅者的最近会话
+	large            bool                  // 是否是超大群（读扩散，tag内的成员都视为有此最近会话）
 	sync.RWMutex
 	suggestMessageSeq uint64 // 更新所有的时候建议使用的messageSeq
 
@@ -686,7 +712,7 @@ func (c *conversationUpdate) exist(uid string) bool {
 		}
 	}
 
-	if c.updateAll {
+	if c.updateAll || c.large {
 		if c.channelType != wkproto.ChannelTypePerson && c.lastTagKey != "" {
 			tag := service.TagManager.Get(c.lastTagKey)
 			if tag != nil && tag.ExistUserInNode(uid, options.G.Cluster.NodeId) {
@@ -708,6 +734,25 @@ func (c *conversationUpdate) getUserMessageSeq(uid string) uint64 {
 	}
 	return 0
 }
+
+// 标记为超大群，有新消息时之前删除的最近会话重新出现
+func (c *conversationUpdate) markLarge() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.large = true
+	if len(c.deleted) > 0 {
+		c.deleted = make(map[string]struct{})
+	}
+}
+
+func (c *conversationUpdate) isLarge() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.large
+}
+
 func (c *conversationUpdate) shouldUpdateAll() {
 	c.Lock()
 	defer c.Unlock()
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationUpdateLarge(t *testing.T) {
+	options.G = options.New()
+	options.G.Cluster.NodeId = 1
+
+	tg := NewTagManager(1, func() uint64 { return 0 })
+	service.TagManager = tg
+
+	tag := &types.Tag{
+		Key:       "large-tag",
+		CreatedAt: time.Now(),
+		Nodes: []*types.Node{
+			{LeaderId: 1, Uids: []string{"u1", "u2"}},
+			{LeaderId: 2, Uids: []string{"u3"}},
+		},
+	}
+	tg.getBlucketByTagKey(tag.Key).setTag(tag)
+
+	update := newConversationUpdate("g1", wkproto.ChannelTypeGroup, tag.Key, 10)
+	update.addOrUpdateUser("u1", 10)
+
+	// 普通频道只有指定的用户才有缓存的最近会话
+	assert.True(t, update.exist("u1"))
+	assert.False(t, update.exist("u2"))
+
+	// 超大群tag内本节点的成员都有派生的最近会话
+	update.markLarge()
+	assert.True(t, update.exist("u2"))
+	assert.False(t, update.exist("u3"))
+
+	// 删除的最近会话在有新消息时重新出现
+	update.deleteUser("u2")
+	assert.False(t, update.exist("u2"))
+	update.markLarge()
+	assert.True(t, update.exist("u2"))
+}
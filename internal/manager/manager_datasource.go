@@ -0,0 +1,223 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/utils/lru"
+)
+
+// 数据源缓存的数据类型
+const (
+	datasourceKindChannelInfo = "channelInfo"
+	datasourceKindSubscribers = "subscribers"
+	datasourceKindDenylist    = "denylist"
+	datasourceKindAllowlist   = "allowlist"
+)
+
+// Datasource 第三方数据源（由 server.Datasource 实现）
+type Datasource interface {
+	// 获取订阅者
+	GetSubscribers(channelID string, channelType uint8) ([]string, error)
+	// 获取黑名单
+	GetBlacklist(channelID string, channelType uint8) ([]string, error)
+	// 获取白名单
+	GetWhitelist(channelID string, channelType uint8) ([]string, error)
+	// 获取频道信息
+	GetChannelInfo(channelID string, channelType uint8) (wkdb.ChannelInfo, error)
+}
+
+// DatasourceManager 频道数据获取管理
+// 开启了数据源后，频道信息、订阅者、黑名单、白名单从第三方数据源获取，并在本节点缓存（过期时间为Datasource.CacheExpire）
+// 第三方数据变化后可以通过 /datasource/invalidate 接口使各个节点的缓存失效
+type DatasourceManager struct {
+	datasource Datasource
+	cache      *lru.Cache
+	// 同一个频道同时只请求一次数据源，其他请求等待结果
+	group singleflight.Group
+	wklog.Log
+}
+
+type datasourceCacheItem struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+// 缓存的成员集合（订阅者、黑名单、白名单）
+type datasourceUidSet struct {
+	uids   []string
+	uidMap map[string]struct{}
+}
+
+func newDatasourceUidSet(uids []string) *datasourceUidSet {
+	uidMap := make(map[string]struct{}, len(uids))
+	for _, uid := range uids {
+		uidMap[uid] = struct{}{}
+	}
+	return &datasourceUidSet{
+		uids:   uids,
+		uidMap: uidMap,
+	}
+}
+
+func (d *datasourceUidSet) exist(uid string) bool {
+	_, ok := d.uidMap[uid]
+	return ok
+}
+
+func NewDatasourceManager(datasource Datasource) *DatasourceManager {
+	return &DatasourceManager{
+		datasource: datasource,
+		cache:      lru.New(10000),
+		Log:        wklog.NewWKLog("datasourceManager"),
+	}
+}
+
+// GetChannelInfo 获取频道信息
+func (d *DatasourceManager) GetChannelInfo(channelId string, channelType uint8) (wkdb.ChannelInfo, error) {
+	if !options.G.DatasourceChannelInfoOn() {
+		return service.Store.GetChannel(channelId, channelType)
+	}
+	value, err := d.getOrRequest(datasourceKindChannelInfo, channelId, channelType, func() (interface{}, error) {
+		return d.datasource.GetChannelInfo(channelId, channelType)
+	})
+	if err != nil {
+		return wkdb.EmptyChannelInfo, err
+	}
+	return value.(wkdb.ChannelInfo), nil
+}
+
+// GetSubscribers 获取频道的订阅者
+func (d *DatasourceManager) GetSubscribers(channelId string, channelType uint8) ([]string, error) {
+	if !options.G.DatasourceSubscriberOn() {
+		members, err := service.Store.GetSubscribers(channelId, channelType)
+		if err != nil {
+			return nil, err
+		}
+		uids := make([]string, 0, len(members))
+		for _, member := range members {
+			uids = append(uids, member.Uid)
+		}
+		return uids, nil
+	}
+	set, err := d.getUidSet(datasourceKindSubscribers, channelId, channelType, d.datasource.GetSubscribers)
+	if err != nil {
+		return nil, err
+	}
+	return set.uids, nil
+}
+
+// ExistSubscriber 是否是频道的订阅者
+func (d *DatasourceManager) ExistSubscriber(channelId string, channelType uint8, uid string) (bool, error) {
+	if !options.G.DatasourceSubscriberOn() {
+		return service.Store.ExistSubscriber(channelId, channelType, uid)
+	}
+	set, err := d.getUidSet(datasourceKindSubscribers, channelId, channelType, d.datasource.GetSubscribers)
+	if err != nil {
+		return false, err
+	}
+	return set.exist(uid), nil
+}
+
+// ExistDenylist 是否在频道的黑名单内
+func (d *DatasourceManager) ExistDenylist(channelId string, channelType uint8, uid string) (bool, error) {
+	if !options.G.DatasourceSubscriberOn() {
+		return service.Store.ExistDenylist(channelId, channelType, uid)
+	}
+	set, err := d.getUidSet(datasourceKindDenylist, channelId, channelType, d.datasource.GetBlacklist)
+	if err != nil {
+		return false, err
+	}
+	return set.exist(uid), nil
+}
+
+// HasAllowlist 频道是否有白名单
+func (d *DatasourceManager) HasAllowlist(channelId string, channelType uint8) (bool, error) {
+	if !options.G.DatasourceSubscriberOn() {
+		return service.Store.HasAllowlist(channelId, channelType)
+	}
+	set, err := d.getUidSet(datasourceKindAllowlist, channelId, channelType, d.datasource.GetWhitelist)
+	if err != nil {
+		return false, err
+	}
+	return len(set.uids) > 0, nil
+}
+
+// ExistAllowlist 是否在频道的白名单内
+func (d *DatasourceManager) ExistAllowlist(channelId string, channelType uint8, uid string) (bool, error) {
+	if !options.G.DatasourceSubscriberOn() {
+		return service.Store.ExistAllowlist(channelId, channelType, uid)
+	}
+	set, err := d.getUidSet(datasourceKindAllowlist, channelId, channelType, d.datasource.GetWhitelist)
+	if err != nil {
+		return false, err
+	}
+	return set.exist(uid), nil
+}
+
+// Invalidate 使频道在本节点的缓存失效，同时移除频道的tag，下次发消息时会重新获取订阅者
+func (d *DatasourceManager) Invalidate(channelId string, channelType uint8) {
+	for _, kind := range []string{datasourceKindChannelInfo, datasourceKindSubscribers, datasourceKindDenylist, datasourceKindAllowlist} {
+		d.cache.Remove(d.cacheKey(kind, channelId, channelType))
+	}
+
+	for _, fakeChannelId := range []string{channelId, options.G.OrginalConvertCmdChannel(channelId)} {
+		tagKey := service.TagManager.GetChannelTag(fakeChannelId, channelType)
+		if tagKey != "" {
+			service.TagManager.RemoveTag(tagKey)
+			service.TagManager.RemoveChannelTag(fakeChannelId, channelType)
+		}
+	}
+}
+
+func (d *DatasourceManager) getUidSet(kind string, channelId string, channelType uint8, request func(channelId string, channelType uint8) ([]string, error)) (*datasourceUidSet, error) {
+	value, err := d.getOrRequest(kind, channelId, channelType, func() (interface{}, error) {
+		uids, err := request(channelId, channelType)
+		if err != nil {
+			return nil, err
+		}
+		return newDatasourceUidSet(uids), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*datasourceUidSet), nil
+}
+
+func (d *DatasourceManager) getOrRequest(kind string, channelId string, channelType uint8, request func() (interface{}, error)) (interface{}, error) {
+	key := d.cacheKey(kind, channelId, channelType)
+	if v, ok := d.cache.Get(key); ok {
+		item := v.(datasourceCacheItem)
+		if time.Now().Before(item.expireAt) {
+			return item.value, nil
+		}
+		d.cache.Remove(key)
+	}
+	value, err, _ := d.group.Do(key, func() (interface{}, error) {
+		value, err := request()
+		if err != nil {
+			return nil, err
+		}
+		d.cache.Add(key, datasourceCacheItem{
+			value:    value,
+			expireAt: time.Now().Add(options.G.Datasource.CacheExpire),
+		})
+		return value, nil
+	})
+	if err != nil {
+		d.Error("request datasource failed", zap.Error(err), zap.String("kind", kind), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return nil, err
+	}
+	return value, nil
+}
+
+func (d *DatasourceManager) cacheKey(kind string, channelId string, channelType uint8) string {
+	return fmt.Sprintf("%s:%s", kind, wkutil.ChannelToKey(channelId, channelType))
+}
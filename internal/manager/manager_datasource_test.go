@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDatasource struct {
+	subscriberRequests atomic.Int64
+}
+
+func (t *testDatasource) GetSubscribers(channelID string, channelType uint8) ([]string, error) {
+	t.subscriberRequests.Add(1)
+	time.Sleep(time.Millisecond * 50)
+	return []string{"u1", "u2"}, nil
+}
+
+func (t *testDatasource) GetBlacklist(channelID string, channelType uint8) ([]string, error) {
+	return []string{"u3"}, nil
+}
+
+func (t *testDatasource) GetWhitelist(channelID string, channelType uint8) ([]string, error) {
+	return nil, nil
+}
+
+func (t *testDatasource) GetChannelInfo(channelID string, channelType uint8) (wkdb.ChannelInfo, error) {
+	return wkdb.ChannelInfo{ChannelId: channelID, ChannelType: channelType}, nil
+}
+
+func TestDatasourceManagerCache(t *testing.T) {
+	options.G = options.New()
+	options.G.Datasource.Addr = "http://127.0.0.1"
+	options.G.Datasource.SubscriberOn = true
+	options.G.Datasource.CacheExpire = time.Minute
+
+	ds := &testDatasource{}
+	d := NewDatasourceManager(ds)
+
+	// 并发判断成员只请求一次数据源
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exist, err := d.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "u1")
+			assert.Nil(t, err)
+			assert.True(t, exist)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), ds.subscriberRequests.Load())
+
+	exist, err := d.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "u3")
+	assert.Nil(t, err)
+	assert.False(t, exist)
+	assert.Equal(t, int64(1), ds.subscriberRequests.Load())
+
+	exist, err = d.ExistDenylist("g1", wkproto.ChannelTypeGroup, "u3")
+	assert.Nil(t, err)
+	assert.True(t, exist)
+
+	has, err := d.HasAllowlist("g1", wkproto.ChannelTypeGroup)
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
@@ -0,0 +1,392 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/network"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wknet"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDraining       = errors.New("node is draining")
+	ErrNotDraining    = errors.New("node is not draining")
+	ErrNoDrainTarget  = errors.New("no available node to migrate")
+	ErrNoZoneNode     = errors.New("no available node in zone")
+	drainAddrCacheTTL = time.Second * 30 // 其他节点地址的缓存时间
+)
+
+// DrainManager 节点排空管理
+// 排空流程：不再接受新连接 -> 等待正在重试的消息完成 -> 分批给连接发送disconnect包（携带建议连接的节点地址）并关闭连接
+type DrainManager struct {
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	status types.DrainStatus
+	cancel context.CancelFunc
+	doneC  chan struct{}
+
+	addrMu    sync.Mutex
+	addrCache map[string]*drainAddrCache // 其他节点的地址缓存，key为 nodeId-intranet
+
+	wklog.Log
+}
+
+type drainAddrCache struct {
+	addr     *types.NodeIMAddr
+	cachedAt time.Time
+}
+
+func NewDrainManager() *DrainManager {
+	return &DrainManager{
+		addrCache: make(map[string]*drainAddrCache),
+		Log:       wklog.NewWKLog("drainManager"),
+	}
+}
+
+// Drain 开始排空节点
+func (d *DrainManager) Drain() error {
+	if !d.draining.CompareAndSwap(false, true) {
+		return ErrDraining
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	doneC := make(chan struct{})
+
+	d.mu.Lock()
+	d.cancel = cancel
+	d.doneC = doneC
+	d.status = types.DrainStatus{
+		Draining:  true,
+		Phase:     types.DrainPhaseWaitRetry,
+		StartedAt: time.Now().Unix(),
+	}
+	d.mu.Unlock()
+
+	d.Info("start drain node", zap.Uint64("nodeId", options.G.Cluster.NodeId))
+
+	go func() {
+		defer close(doneC)
+		d.run(ctx)
+	}()
+	return nil
+}
+
+// Cancel 取消排空，恢复接受新连接（已经断开的连接不会恢复）
+func (d *DrainManager) Cancel() error {
+	if !d.draining.Load() {
+		return ErrNotDraining
+	}
+	d.mu.Lock()
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.status.Draining = false
+	d.status.Phase = types.DrainPhaseCanceled
+	d.status.FinishedAt = time.Now().Unix()
+	d.mu.Unlock()
+
+	d.draining.Store(false)
+	d.Info("drain canceled", zap.Uint64("nodeId", options.G.Cluster.NodeId))
+	return nil
+}
+
+// WaitDone 等待排空完成
+func (d *DrainManager) WaitDone(timeout time.Duration) bool {
+	d.mu.RLock()
+	doneC := d.doneC
+	d.mu.RUnlock()
+	if doneC == nil {
+		return true
+	}
+	select {
+	case <-doneC:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (d *DrainManager) IsDraining() bool {
+	return d.draining.Load()
+}
+
+func (d *DrainManager) DrainStatus() types.DrainStatus {
+	d.mu.RLock()
+	status := d.status
+	d.mu.RUnlock()
+
+	if service.ConnManager != nil {
+		status.RemainingConns = service.ConnManager.ConnCount()
+	}
+	if service.RetryManager != nil {
+		status.PendingRetries = service.RetryManager.RetryMessageCount()
+	}
+	return status
+}
+
+func (d *DrainManager) run(ctx context.Context) {
+	start := time.Now()
+
+	// 等待正在重试的消息完成（连接断开后重试的消息会被丢弃，客户端只能通过同步获取）
+	if !d.waitRetry(ctx) {
+		return
+	}
+
+	// 分批断开连接
+	d.setPhase(types.DrainPhaseDisconnecting)
+	conns := service.ConnManager.GetAllConn()
+	d.mu.Lock()
+	d.status.TotalConns = len(conns)
+	d.mu.Unlock()
+
+	batchSize := options.G.Drain.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(conns)
+	}
+	for i, conn := range conns {
+		if ctx.Err() != nil {
+			return
+		}
+		d.disconnect(conn)
+
+		d.mu.Lock()
+		d.status.DisconnectedConns++
+		d.mu.Unlock()
+
+		if (i+1)%batchSize == 0 && i+1 < len(conns) {
+			select {
+			case <-time.After(options.G.Drain.BatchInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	d.mu.Lock()
+	d.status.Phase = types.DrainPhaseDone
+	d.status.FinishedAt = time.Now().Unix()
+	d.mu.Unlock()
+
+	d.Info("drain done", zap.Int("conns", len(conns)), zap.Duration("cost", time.Since(start)))
+}
+
+// 等待正在重试的消息完成，返回false表示排空被取消
+func (d *DrainManager) waitRetry(ctx context.Context) bool {
+	timeoutC := time.After(options.G.Drain.RetryWaitTimeout)
+	tick := time.NewTicker(time.Millisecond * 200)
+	defer tick.Stop()
+	for {
+		pending := service.RetryManager.RetryMessageCount()
+		if pending == 0 {
+			return true
+		}
+		select {
+		case <-tick.C:
+		case <-timeoutC:
+			d.Warn("wait retry messages timeout", zap.Int("pending", pending), zap.Duration("timeout", options.G.Drain.RetryWaitTimeout))
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (d *DrainManager) setPhase(phase types.DrainPhase) {
+	d.mu.Lock()
+	d.status.Phase = phase
+	d.mu.Unlock()
+}
+
+// 断开连接，已认证的连接会收到携带建议地址的disconnect包
+func (d *DrainManager) disconnect(conn wknet.Conn) {
+	connCtxObj := conn.Context()
+	if connCtxObj == nil {
+		_ = conn.Close()
+		return
+	}
+	connCtx := connCtxObj.(*eventbus.Conn)
+	if !connCtx.Auth {
+		_ = conn.Close()
+		return
+	}
+
+	reason := &types.DrainDisconnectReason{
+		Reason: "node draining",
+	}
+	addr, err := d.SuggestAddr(connCtx.Uid, false)
+	if err != nil {
+		d.Warn("get suggest addr failed", zap.Error(err), zap.String("uid", connCtx.Uid))
+	} else {
+		reason.NodeId = addr.NodeId
+		reason.TCPAddr = addr.TCPAddr
+		reason.WSAddr = addr.WSAddr
+		reason.WSSAddr = addr.WSSAddr
+	}
+
+	eventbus.User.ConnWrite(connCtx, &wkproto.DisconnectPacket{
+		ReasonCode: wkproto.ReasonNodeNotMatch,
+		Reason:     wkutil.ToJSON(reason),
+	})
+	service.CommonService.AfterFunc(time.Second*2, func() {
+		eventbus.User.CloseConn(connCtx)
+	})
+}
+
+// SuggestAddr 按uid散列到其他在线节点，返回其连接地址（跳过正在排空的节点，优先同可用区的节点）
+func (d *DrainManager) SuggestAddr(uid string, intranet bool) (*types.NodeIMAddr, error) {
+	nodes := d.targetNodes()
+	if len(nodes) == 0 {
+		return nil, ErrNoDrainTarget
+	}
+	if zone := options.G.Cluster.Zone; zone != "" {
+		if addr := d.hashAddr(uid, d.zoneNodes(nodes, zone), intranet); addr != nil {
+			return addr, nil
+		}
+	}
+	if addr := d.hashAddr(uid, nodes, intranet); addr != nil {
+		return addr, nil
+	}
+	return nil, ErrNoDrainTarget
+}
+
+// ZoneAddr 获取指定可用区内建议用户连接的节点地址，优先用户所在的领导节点，其次是本节点，最后按uid散列到该可用区的其他节点
+// 如果返回的是本节点，则只有NodeId有值
+func (d *DrainManager) ZoneAddr(uid string, zone string, intranet bool) (*types.NodeIMAddr, error) {
+	leader, err := service.Cluster.SlotLeaderOfChannel(uid, wkproto.ChannelTypePerson)
+	if err != nil {
+		d.Warn("get slot leader failed", zap.Error(err), zap.String("uid", uid))
+	} else if leader != nil && leader.Online && !leader.Maintenance && leader.Zone == zone {
+		if options.G.IsLocalNode(leader.Id) {
+			if !d.IsDraining() {
+				return &types.NodeIMAddr{NodeId: leader.Id}, nil
+			}
+		} else {
+			addr, err := d.nodeIMAddr(leader, intranet)
+			if err != nil {
+				d.Warn("get node im addr failed", zap.Error(err), zap.Uint64("nodeId", leader.Id))
+			} else if !addr.Draining {
+				return addr, nil
+			}
+		}
+	}
+	if options.G.Cluster.Zone == zone && !d.IsDraining() && !d.localMaintenance() {
+		return &types.NodeIMAddr{NodeId: options.G.Cluster.NodeId}, nil
+	}
+	if addr := d.hashAddr(uid, d.zoneNodes(d.targetNodes(), zone), intranet); addr != nil {
+		return addr, nil
+	}
+	return nil, ErrNoZoneNode
+}
+
+// 本节点是否处于维护模式
+func (d *DrainManager) localMaintenance() bool {
+	node, err := service.Cluster.NodeInfoById(options.G.Cluster.NodeId)
+	if err != nil || node == nil {
+		return false
+	}
+	return node.Maintenance
+}
+
+// 按uid散列到节点，返回第一个可用节点的连接地址
+func (d *DrainManager) hashAddr(uid string, nodes []*pb.Node, intranet bool) *types.NodeIMAddr {
+	if len(nodes) == 0 {
+		return nil
+	}
+	start := int(wkutil.HashCrc32(uid) % uint32(len(nodes)))
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[(start+i)%len(nodes)]
+		addr, err := d.nodeIMAddr(node, intranet)
+		if err != nil {
+			d.Warn("get node im addr failed", zap.Error(err), zap.Uint64("nodeId", node.Id))
+			continue
+		}
+		if addr.Draining {
+			continue
+		}
+		return addr
+	}
+	return nil
+}
+
+// 指定可用区的节点
+func (d *DrainManager) zoneNodes(nodes []*pb.Node, zone string) []*pb.Node {
+	var zoneNodes []*pb.Node
+	for _, node := range nodes {
+		if node.Zone == zone {
+			zoneNodes = append(zoneNodes, node)
+		}
+	}
+	return zoneNodes
+}
+
+// 可以迁移的目标节点
+func (d *DrainManager) targetNodes() []*pb.Node {
+	var nodes []*pb.Node
+	for _, node := range service.Cluster.Nodes() {
+		if node.Id == options.G.Cluster.NodeId || !node.Online || node.Maintenance { // 维护中的节点不接收新连接
+			continue
+		}
+		if node.Status != pb.NodeStatus_NodeStatusJoined {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Id < nodes[j].Id
+	})
+	return nodes
+}
+
+// 请求节点的 /route 获取其连接地址
+func (d *DrainManager) nodeIMAddr(node *pb.Node, intranet bool) (*types.NodeIMAddr, error) {
+	key := fmt.Sprintf("%d-%v", node.Id, intranet)
+	d.addrMu.Lock()
+	cache := d.addrCache[key]
+	d.addrMu.Unlock()
+	if cache != nil && time.Since(cache.cachedAt) < drainAddrCacheTTL {
+		return cache.addr, nil
+	}
+
+	queryParams := map[string]string{
+		"self": "1", // 只返回节点自己的地址
+	}
+	if intranet {
+		queryParams["intranet"] = "1"
+	}
+	resp, err := network.Get(fmt.Sprintf("%s%s", node.ApiServerAddr, "/route"), queryParams, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request route error: %s", resp.Body)
+	}
+	addr := &types.NodeIMAddr{}
+	err = wkutil.ReadJSONByByte([]byte(resp.Body), addr)
+	if err != nil {
+		return nil, err
+	}
+	addr.NodeId = node.Id
+
+	d.addrMu.Lock()
+	d.addrCache[key] = &drainAddrCache{
+		addr:     addr,
+		cachedAt: time.Now(),
+	}
+	d.addrMu.Unlock()
+	return addr, nil
+}
@@ -0,0 +1,345 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/ingress"
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPresenceOff          = errors.New("presence is off")
+	ErrPresenceInvalidState = errors.New("invalid presence state")
+)
+
+// PresenceManager 用户在线状态管理
+// 用户的在线状态变化都在用户所在槽的领导节点上处理（领导节点有用户所有的连接），
+// 订阅关系也保存在被订阅用户所在槽的领导节点的内存里，过期后需要客户端重新订阅
+type PresenceManager struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[string]time.Time // 被订阅的uid -> 订阅者uid -> 订阅过期时间
+	onlineUids  map[string]struct{}             // 已经通知过上线的用户
+
+	changeMu sync.Mutex
+	changes  map[string]struct{} // 在线状态可能发生变化的用户，同一用户多次变化合并处理
+	changeC  chan struct{}       // 有新的变化
+	stopC    chan struct{}
+	client   *ingress.Client
+	wklog.Log
+}
+
+func NewPresenceManager() *PresenceManager {
+	return &PresenceManager{
+		subscribers: make(map[string]map[string]time.Time),
+		onlineUids:  make(map[string]struct{}),
+		changes:     make(map[string]struct{}),
+		changeC:     make(chan struct{}, 1),
+		stopC:       make(chan struct{}),
+		client:      ingress.NewClient(),
+		Log:         wklog.NewWKLog("presenceManager"),
+	}
+}
+
+func (p *PresenceManager) Start() error {
+	if !options.G.Presence.On {
+		return nil
+	}
+	go p.loop()
+	return nil
+}
+
+func (p *PresenceManager) Stop() {
+	if !options.G.Presence.On {
+		return
+	}
+	close(p.stopC)
+}
+
+// Online 用户上线
+func (p *PresenceManager) Online(uid string) {
+	if !options.G.Presence.On {
+		return
+	}
+	p.changed(uid)
+}
+
+// ConnClosed 用户的连接关闭，如果本节点不是用户所在槽的领导节点，则通知领导节点
+func (p *PresenceManager) ConnClosed(uid string, nodeId uint64, connId int64) {
+	if !options.G.Presence.On {
+		return
+	}
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(uid, wkproto.ChannelTypePerson)
+	if err != nil {
+		p.Warn("ConnClosed: get leader failed", zap.Error(err), zap.String("uid", uid))
+		return
+	}
+	if !options.G.IsLocalNode(leaderInfo.Id) {
+		go func() {
+			err := p.client.PresenceConnClosed(leaderInfo.Id, &ingress.PresenceConnClosedReq{
+				Uid:    uid,
+				NodeId: nodeId,
+				ConnId: connId,
+			})
+			if err != nil {
+				p.Warn("ConnClosed: notify leader failed", zap.Error(err), zap.String("uid", uid), zap.Uint64("leaderId", leaderInfo.Id))
+			}
+		}()
+		return
+	}
+
+	// 其他节点上的连接，交给用户事件移除领导节点上的逻辑连接，移除后再处理在线状态
+	if !options.G.IsLocalNode(nodeId) {
+		conn := eventbus.User.ConnById(uid, nodeId, connId)
+		if conn != nil {
+			eventbus.User.AddEvent(uid, &eventbus.Event{
+				Type:         eventbus.EventConnRemove,
+				Conn:         conn,
+				SourceNodeId: options.G.Cluster.NodeId,
+			})
+			eventbus.User.Advance(uid)
+			return
+		}
+	}
+	p.changed(uid)
+}
+
+// ConnRemoved 用户的逻辑连接已移除，只有用户所在槽的领导节点处理在线状态
+func (p *PresenceManager) ConnRemoved(uid string) {
+	if !options.G.Presence.On {
+		return
+	}
+	leaderInfo, err := service.Cluster.SlotLeaderOfChannel(uid, wkproto.ChannelTypePerson)
+	if err != nil {
+		p.Warn("ConnRemoved: get leader failed", zap.Error(err), zap.String("uid", uid))
+		return
+	}
+	if !options.G.IsLocalNode(leaderInfo.Id) {
+		return
+	}
+	p.changed(uid)
+}
+
+// SetStatus 设置用户的自定义状态
+func (p *PresenceManager) SetStatus(uid string, state types.PresenceState, statusText, emoji string) error {
+	if !options.G.Presence.On {
+		return ErrPresenceOff
+	}
+	if !state.Valid() {
+		return ErrPresenceInvalidState
+	}
+	presence, err := service.Store.GetPresence(uid)
+	if err != nil {
+		return err
+	}
+	presence.Uid = uid
+	presence.State = string(state)
+	presence.StatusText = statusText
+	presence.Emoji = emoji
+	presence.UpdatedAt = time.Now().Unix()
+	if err = service.Store.SetPresence(presence); err != nil {
+		return err
+	}
+	p.notify(p.toPresence(presence))
+	return nil
+}
+
+// Subscribe 订阅指定用户的在线状态变化
+func (p *PresenceManager) Subscribe(subscriber string, uids []string) ([]*types.Presence, error) {
+	if !options.G.Presence.On {
+		return nil, ErrPresenceOff
+	}
+	expireAt := time.Now().Add(options.G.Presence.SubscribeExpire)
+	p.mu.Lock()
+	for _, uid := range uids {
+		subs := p.subscribers[uid]
+		if subs == nil {
+			subs = make(map[string]time.Time)
+			p.subscribers[uid] = subs
+		}
+		subs[subscriber] = expireAt
+	}
+	p.mu.Unlock()
+	return p.Presences(uids)
+}
+
+// Unsubscribe 取消订阅
+func (p *PresenceManager) Unsubscribe(subscriber string, uids []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, uid := range uids {
+		subs := p.subscribers[uid]
+		if subs == nil {
+			continue
+		}
+		delete(subs, subscriber)
+		if len(subs) == 0 {
+			delete(p.subscribers, uid)
+		}
+	}
+}
+
+// Presences 获取指定用户的在线状态
+func (p *PresenceManager) Presences(uids []string) ([]*types.Presence, error) {
+	if !options.G.Presence.On {
+		return nil, ErrPresenceOff
+	}
+	presences := make([]*types.Presence, 0, len(uids))
+	for _, uid := range uids {
+		presence, err := service.Store.GetPresence(uid)
+		if err != nil {
+			return nil, err
+		}
+		presences = append(presences, p.toPresence(presence))
+	}
+	return presences, nil
+}
+
+// 记录在线状态可能发生变化的用户，不阻塞调用方（连接和用户事件处理）
+func (p *PresenceManager) changed(uid string) {
+	p.changeMu.Lock()
+	p.changes[uid] = struct{}{}
+	p.changeMu.Unlock()
+	select {
+	case p.changeC <- struct{}{}:
+	default:
+	}
+}
+
+// 取出所有待处理的变化
+func (p *PresenceManager) takeChanges() []string {
+	p.changeMu.Lock()
+	defer p.changeMu.Unlock()
+	if len(p.changes) == 0 {
+		return nil
+	}
+	uids := make([]string, 0, len(p.changes))
+	for uid := range p.changes {
+		uids = append(uids, uid)
+	}
+	p.changes = make(map[string]struct{})
+	return uids
+}
+
+func (p *PresenceManager) loop() {
+	tk := time.NewTicker(time.Minute)
+	defer tk.Stop()
+	for {
+		select {
+		case <-p.changeC:
+			for _, uid := range p.takeChanges() {
+				p.handleChanged(uid)
+			}
+		case <-tk.C:
+			p.removeExpiredSubscribers()
+		case <-p.stopC:
+			return
+		}
+	}
+}
+
+// 根据用户当前的连接判断是否上线或下线，上下线时记录最后在线时间并通知订阅者
+func (p *PresenceManager) handleChanged(uid string) {
+	online := len(eventbus.User.AuthedConnsByUid(uid)) > 0
+
+	p.mu.Lock()
+	_, notified := p.onlineUids[uid]
+	if online == notified { // 状态没变化
+		p.mu.Unlock()
+		return
+	}
+	if online {
+		p.onlineUids[uid] = struct{}{}
+	} else {
+		delete(p.onlineUids, uid)
+	}
+	p.mu.Unlock()
+
+	presence, err := service.Store.GetPresence(uid)
+	if err != nil {
+		p.Error("handleChanged: get presence failed", zap.Error(err), zap.String("uid", uid))
+		return
+	}
+	presence.Uid = uid
+	presence.LastSeen = time.Now().Unix()
+	if err = service.Store.SetPresence(presence); err != nil {
+		p.Error("handleChanged: set presence failed", zap.Error(err), zap.String("uid", uid))
+		return
+	}
+	p.notify(p.toPresence(presence))
+}
+
+// 给订阅者推送在线状态变化的cmd消息
+func (p *PresenceManager) notify(presence *types.Presence) {
+	now := time.Now()
+	p.mu.RLock()
+	subs := p.subscribers[presence.Uid]
+	uids := make([]string, 0, len(subs))
+	for subscriber, expireAt := range subs {
+		if expireAt.After(now) {
+			uids = append(uids, subscriber)
+		}
+	}
+	p.mu.RUnlock()
+	if len(uids) == 0 {
+		return
+	}
+	payload := []byte(wkutil.ToJSON(map[string]interface{}{
+		"cmd":   types.PresenceCmd,
+		"param": presence,
+	}))
+	if err := service.CommonService.SendOnlineCmd(uids, payload); err != nil {
+		p.Warn("notify: send presence cmd failed", zap.Error(err), zap.String("uid", presence.Uid))
+	}
+}
+
+func (p *PresenceManager) removeExpiredSubscribers() {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for uid, subs := range p.subscribers {
+		for subscriber, expireAt := range subs {
+			if !expireAt.After(now) {
+				delete(subs, subscriber)
+			}
+		}
+		if len(subs) == 0 {
+			delete(p.subscribers, uid)
+		}
+	}
+}
+
+func (p *PresenceManager) toPresence(presence wkdb.Presence) *types.Presence {
+	conns := eventbus.User.AuthedConnsByUid(presence.Uid)
+	deviceFlags := make([]uint8, 0, len(conns))
+	for _, conn := range conns {
+		exist := false
+		for _, deviceFlag := range deviceFlags {
+			if deviceFlag == conn.DeviceFlag.ToUint8() {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			deviceFlags = append(deviceFlags, conn.DeviceFlag.ToUint8())
+		}
+	}
+	return &types.Presence{
+		Uid:         presence.Uid,
+		Online:      wkutil.BoolToInt(len(conns) > 0),
+		DeviceFlags: deviceFlags,
+		State:       types.PresenceState(presence.State),
+		StatusText:  presence.StatusText,
+		Emoji:       presence.Emoji,
+		LastSeen:    presence.LastSeen,
+	}
+}
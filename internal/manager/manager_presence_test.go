@@ -0,0 +1,25 @@
+package manager
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenceChangedCoalesce(t *testing.T) {
+	p := NewPresenceManager()
+
+	// 没有消费也不阻塞，同一用户的多次变化合并
+	for i := 0; i < 2000; i++ {
+		p.changed("u1")
+	}
+	p.changed("u2")
+
+	uids := p.takeChanges()
+	sort.Strings(uids)
+	assert.Equal(t, []string{"u1", "u2"}, uids)
+	assert.Len(t, p.changeC, 1)
+
+	assert.Nil(t, p.takeChanges())
+}
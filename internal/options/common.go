@@ -41,6 +41,22 @@ func GetCommunityTopicParentChannelID(channelID string) string {
 	return ""
 }
 
+// FederationTokenHeader 对端集群推送消息时携带联邦令牌的请求头（与管理者令牌的请求头token区分开）
+const FederationTokenHeader = "X-Federation-Token"
+
+// federationClientMsgNoPrefix 联邦同步过来的消息的client_msg_no前缀
+const federationClientMsgNoPrefix = "fed:"
+
+// FederationClientMsgNo 联邦同步过来的消息在本集群使用的client_msg_no（用于重试去重和防止消息被再次同步出去）
+func FederationClientMsgNo(sourceClusterId string, sourceMessageId int64) string {
+	return fmt.Sprintf("%s%s:%d", federationClientMsgNoPrefix, sourceClusterId, sourceMessageId)
+}
+
+// IsFederationClientMsgNo 是否是联邦同步过来的消息
+func IsFederationClientMsgNo(clientMsgNo string) bool {
+	return strings.HasPrefix(clientMsgNo, federationClientMsgNoPrefix)
+}
+
 // 判断字符串是否存在特殊字符
 func IsSpecialChar(s string) bool {
 	return strings.Contains(s, "@") || strings.Contains(s, "#") || strings.Contains(s, "&")
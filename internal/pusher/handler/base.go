@@ -4,15 +4,19 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkserver/proto"
+	"k8s.io/utils/lru"
 )
 
 type Handler struct {
+	// 缓存接收者会话的免打扰状态，避免每条消息的每个接收者都查询一次最近会话
+	muteCache *lru.Cache
 	wklog.Log
 }
 
 func NewHandler() *Handler {
 	h := &Handler{
-		Log: wklog.NewWKLog("handler"),
+		muteCache: lru.New(100000),
+		Log:       wklog.NewWKLog("handler"),
 	}
 	h.routes()
 	return h
@@ -1,10 +1,81 @@
 package handler
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.uber.org/zap"
 )
 
 func (h *Handler) pushOffline(ctx *eventbus.PushContext) {
-	service.Webhook.NotifyOfflineMsg(ctx.Events)
+	events := make([]*eventbus.Event, 0, len(ctx.Events))
+	for _, e := range ctx.Events {
+		var mention wkdb.Mention
+		if sendPacket, ok := e.Frame.(*wkproto.SendPacket); ok {
+			mention = wkdb.ParseMention(sendPacket.Payload)
+		}
+		// 超大群只推送提醒了的用户在分发时已经过滤
+		offlineUsers := make([]string, 0, len(e.OfflineUsers))
+		for _, uid := range e.OfflineUsers {
+			mentioned := mention.Contains(uid)
+			// 免打扰的会话不推送离线消息（被提醒的除外）
+			if !mentioned && h.conversationMuted(uid, e) {
+				continue
+			}
+			offlineUsers = append(offlineUsers, uid)
+		}
+		if len(offlineUsers) == 0 {
+			continue
+		}
+		e.OfflineUsers = offlineUsers
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return
+	}
+	service.Webhook.NotifyOfflineMsg(events)
+}
+
+// 免打扰状态的缓存时间，会话设置免打扰后最多延迟这么久生效
+const muteCacheExpire = time.Second * 10
+
+type muteCacheItem struct {
+	mute     bool
+	expireAt time.Time
+}
+
+// 接收者是否对消息所在的会话设置了免打扰
+func (h *Handler) conversationMuted(uid string, e *eventbus.Event) bool {
+	sendPacket, ok := e.Frame.(*wkproto.SendPacket)
+	if !ok {
+		return false
+	}
+	fakeChannelId := sendPacket.ChannelID
+	if sendPacket.ChannelType == wkproto.ChannelTypePerson {
+		fakeChannelId = options.GetFakeChannelIDWith(e.Conn.Uid, uid)
+	}
+	cacheKey := fmt.Sprintf("%s@%s@%d", uid, fakeChannelId, sendPacket.ChannelType)
+	if value, ok := h.muteCache.Get(cacheKey); ok {
+		item := value.(muteCacheItem)
+		if time.Now().Before(item.expireAt) {
+			return item.mute
+		}
+	}
+
+	conversation, err := service.Store.GetConversation(uid, fakeChannelId, sendPacket.ChannelType)
+	if err != nil && err != wkdb.ErrNotFound {
+		// 查询失败不缓存，下次重新查询
+		h.Warn("get conversation failed", zap.Error(err), zap.String("uid", uid), zap.String("channelId", fakeChannelId), zap.Uint8("channelType", sendPacket.ChannelType))
+		return false
+	}
+	h.muteCache.Add(cacheKey, muteCacheItem{
+		mute:     conversation.Mute,
+		expireAt: time.Now().Add(muteCacheExpire),
+	})
+	return conversation.Mute
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/track"
 	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
@@ -30,7 +31,7 @@ func (h *Handler) processChannelPush(events []*eventbus.Event) {
 			continue
 		}
 		// 记录消息轨迹
-		e.Track.Record(track.PositionPushOnline)
+		e.Record(track.PositionPushOnline)
 
 		sendPacket := e.Frame.(*wkproto.SendPacket)
 
@@ -46,6 +47,9 @@ func (h *Handler) processChannelPush(events []*eventbus.Event) {
 			fromUid = ""
 		}
 
+		// 免打扰的会话不显示红点（被提醒的除外）
+		muted := sendPacket.RedDot && !wkdb.ParseMention(sendPacket.Payload).Contains(e.ToUid) && h.conversationMuted(e.ToUid, e)
+
 		for _, toConn := range toConns {
 			if toConn.Uid == e.Conn.Uid && toConn.NodeId == e.Conn.NodeId && toConn.ConnId == e.Conn.ConnId { // 自己发的不处理
 				continue
@@ -83,6 +87,9 @@ func (h *Handler) processChannelPush(events []*eventbus.Event) {
 			if toConn.Uid == recvPacket.FromUID { // 如果是自己则不显示红点
 				recvPacket.RedDot = false
 			}
+			if muted {
+				recvPacket.RedDot = false
+			}
 			if len(toConn.AesIV) == 0 || len(toConn.AesKey) == 0 {
 				h.Error("aesIV or aesKey is empty",
 					zap.String("uid", toConn.Uid),
@@ -133,7 +140,7 @@ func (h *Handler) processChannelPush(events []*eventbus.Event) {
 			for _, e := range events {
 				sendPacket := e.Frame.(*wkproto.SendPacket)
 				// 记录消息轨迹
-				e.Track.Record(track.PositionPushOnlineEnd)
+				e.Record(track.PositionPushOnlineEnd)
 				connCount := eventbus.User.ConnCountByUid(e.ToUid)
 				h.Trace(e.Track.String(),
 					"pushOnline",
@@ -148,6 +155,15 @@ func (h *Handler) processChannelPush(events []*eventbus.Event) {
 					zap.Uint64("conn.nodeId", e.Conn.NodeId),
 					zap.Int64("conn.connId", e.Conn.ConnId),
 				)
+				record := track.NewRecord(track.ActionPushOnline, options.G.Cluster.NodeId, e.Track)
+				record.MessageId = e.MessageId
+				record.MessageSeq = e.MessageSeq
+				record.ClientMsgNo = sendPacket.ClientMsgNo
+				record.ChannelId = sendPacket.ChannelID
+				record.ChannelType = sendPacket.ChannelType
+				record.Uid = e.ToUid
+				record.ConnCount = connCount
+				track.Records.Add(record)
 			}
 		}
 	}
@@ -52,7 +52,7 @@ func (d *Datasource) GetChannelInfo(channelID string, channelType uint8) (wkdb.C
 	channelInfo := channelInfoResp.toChannelInfo()
 	channelInfo.ChannelId = channelID
 	channelInfo.ChannelType = channelType
-	return wkdb.EmptyChannelInfo, nil
+	return *channelInfo, nil
 
 }
 
@@ -144,14 +144,27 @@ func (d *Datasource) requestCMD(cmd string, param map[string]interface{}) (strin
 }
 
 type channelInfoResp struct {
-	Large   int `json:"large"`   // 是否是超大群
-	Ban     int `json:"ban"`     // 是否封禁频道（封禁后此频道所有人都将不能发消息，除了系统账号）
-	Disband int `json:"disband"` // 是否解散频道
+	Large             int    `json:"large"`               // 是否是超大群
+	Ban               int    `json:"ban"`                 // 是否封禁频道（封禁后此频道所有人都将不能发消息，除了系统账号）
+	Disband           int    `json:"disband"`             // 是否解散频道
+	Webhook           string `json:"webhook"`             // 频道的webhook地址
+	ParentChannelId   string `json:"parent_channel_id"`   // 父频道ID
+	ParentChannelType uint8  `json:"parent_channel_type"` // 父频道类型
+	Inherit           int    `json:"inherit"`             // 是否继承父频道的订阅者和权限
+	HistoryVisibility uint8  `json:"history_visibility"`  // 新成员的历史消息可见策略
+	HistoryCount      uint32 `json:"history_count"`       // 新成员可见的加入前消息数量
 }
 
 func (c channelInfoResp) toChannelInfo() *wkdb.ChannelInfo {
 	return &wkdb.ChannelInfo{
-		Large: c.Large == 1,
-		Ban:   c.Ban == 1,
+		Large:             c.Large == 1,
+		Ban:               c.Ban == 1,
+		Disband:           c.Disband == 1,
+		Webhook:           c.Webhook,
+		ParentChannelId:   c.ParentChannelId,
+		ParentChannelType: c.ParentChannelType,
+		Inherit:           c.Inherit == 1,
+		HistoryVisibility: c.HistoryVisibility,
+		HistoryCount:      c.HistoryCount,
 	}
 }
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/client"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasourceSubscribers(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		subscribers = []string{"test1", "test2"}
+	)
+	ds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Cmd string `json:"cmd"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		switch req.Cmd {
+		case "getSubscribers":
+			_ = json.NewEncoder(w).Encode(subscribers)
+		case "getSystemUIDs", "getBlacklist", "getWhitelist":
+			_ = json.NewEncoder(w).Encode([]string{})
+		}
+	}))
+	defer ds.Close()
+
+	s := NewTestServer(t)
+	s.opts.Mode = options.TestMode
+	s.opts.Datasource.Addr = ds.URL
+	s.opts.Datasource.SubscriberOn = true
+	err := s.Start()
+	assert.Nil(t, err)
+	defer s.StopNoErr()
+
+	s.MustWaitAllSlotsReady(time.Second * 10) // 等待服务准备好
+
+	exist, err := service.DatasourceManager.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "test2")
+	assert.Nil(t, err)
+	assert.True(t, exist)
+	exist, err = service.DatasourceManager.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "test3")
+	assert.Nil(t, err)
+	assert.False(t, exist)
+
+	// 订阅者来自数据源，不需要添加到本地
+	cli1 := client.New(s.opts.External.TCPAddr, client.WithUID("test1"))
+	err = cli1.Connect()
+	assert.Nil(t, err)
+	cli2 := client.New(s.opts.External.TCPAddr, client.WithUID("test2"))
+	err = cli2.Connect()
+	assert.Nil(t, err)
+
+	recvC := make(chan string, 1)
+	cli2.SetOnRecv(func(recv *wkproto.RecvPacket) error {
+		recvC <- string(recv.Payload)
+		return nil
+	})
+	err = cli1.SendMessage(client.NewChannel("g1", wkproto.ChannelTypeGroup), []byte("hello"))
+	assert.Nil(t, err)
+	select {
+	case payload := <-recvC:
+		assert.Equal(t, "hello", payload)
+	case <-time.After(time.Second * 5):
+		t.Fatal("wait message timeout")
+	}
+
+	// 数据源变化后，缓存失效前仍然是旧数据
+	mu.Lock()
+	subscribers = []string{"test1", "test2", "test3"}
+	mu.Unlock()
+	exist, err = service.DatasourceManager.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "test3")
+	assert.Nil(t, err)
+	assert.False(t, exist)
+
+	service.DatasourceManager.Invalidate("g1", wkproto.ChannelTypeGroup)
+	exist, err = service.DatasourceManager.ExistSubscriber("g1", wkproto.ChannelTypeGroup, "test3")
+	assert.Nil(t, err)
+	assert.True(t, exist)
+}
+
+func TestDatasourceChannelInfo(t *testing.T) {
+	ds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"large":1,"ban":1,"webhook":"http://hook","parent_channel_id":"p1","parent_channel_type":2,"inherit":1,"history_visibility":2,"history_count":10}`))
+	}))
+	defer ds.Close()
+
+	s := &Server{opts: options.New()}
+	s.opts.Datasource.Addr = ds.URL
+
+	channelInfo, err := NewDatasource(s).GetChannelInfo("g1", wkproto.ChannelTypeGroup)
+	assert.Nil(t, err)
+	assert.Equal(t, "g1", channelInfo.ChannelId)
+	assert.True(t, channelInfo.Large)
+	assert.True(t, channelInfo.Ban)
+	assert.False(t, channelInfo.Disband)
+	assert.Equal(t, "http://hook", channelInfo.Webhook)
+	assert.Equal(t, "p1", channelInfo.ParentChannelId)
+	assert.Equal(t, wkproto.ChannelTypeGroup, channelInfo.ParentChannelType)
+	assert.True(t, channelInfo.Inherit)
+	assert.Equal(t, uint8(2), channelInfo.HistoryVisibility)
+	assert.Equal(t, uint32(10), channelInfo.HistoryCount)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/pkg/client"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendMessageDedup(t *testing.T) {
+	s := NewTestServer(t)
+	s.opts.Mode = options.TestMode
+	err := s.Start()
+	assert.Nil(t, err)
+	defer s.StopNoErr()
+
+	s.MustWaitAllSlotsReady(time.Second * 10) // 等待服务准备好
+
+	cli1 := client.New(s.opts.External.TCPAddr, client.WithUID("test1"))
+	err = cli1.Connect()
+	assert.Nil(t, err)
+	cli2 := client.New(s.opts.External.TCPAddr, client.WithUID("test2"))
+	err = cli2.Connect()
+	assert.Nil(t, err)
+
+	sendackC := make(chan *wkproto.SendackPacket, 2)
+	cli1.SetOnSendack(func(sendack *wkproto.SendackPacket) {
+		sendackC <- sendack
+	})
+	recvC := make(chan *wkproto.RecvPacket, 2)
+	cli2.SetOnRecv(func(recv *wkproto.RecvPacket) error {
+		recvC <- recv
+		return nil
+	})
+
+	waitSendack := func() *wkproto.SendackPacket {
+		select {
+		case sendack := <-sendackC:
+			return sendack
+		case <-time.After(time.Second * 5):
+			t.Fatal("wait sendack timeout")
+		}
+		return nil
+	}
+
+	// 客户端重试发送相同client_msg_no的消息
+	channel := client.NewChannel("test2", wkproto.ChannelTypePerson)
+	err = cli1.SendMessage(channel, []byte("hello"), client.SendOptionWithClientMsgNo("no1"))
+	assert.Nil(t, err)
+	sendack1 := waitSendack()
+
+	err = cli1.SendMessage(channel, []byte("hello"), client.SendOptionWithClientMsgNo("no1"))
+	assert.Nil(t, err)
+	sendack2 := waitSendack()
+
+	assert.Equal(t, wkproto.ReasonSuccess, sendack2.ReasonCode)
+	assert.Equal(t, sendack1.MessageID, sendack2.MessageID)
+	assert.Equal(t, sendack1.MessageSeq, sendack2.MessageSeq)
+
+	// 接收者只收到一条
+	select {
+	case recv := <-recvC:
+		assert.Equal(t, sendack1.MessageID, recv.MessageID)
+	case <-time.After(time.Second * 5):
+		t.Fatal("wait message timeout")
+	}
+	select {
+	case <-recvC:
+		t.Fatal("received duplicate message")
+	case <-time.After(time.Millisecond * 500):
+	}
+}
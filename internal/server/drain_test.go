@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	s := NewTestServer(t)
+	s.opts.Mode = options.TestMode
+	err := s.Start()
+	assert.Nil(t, err)
+	defer s.StopNoErr()
+
+	s.MustWaitAllSlotsReady(time.Second * 10) // 等待服务准备好
+
+	cli1 := client.New(s.opts.External.TCPAddr, client.WithUID("test1"))
+	err = cli1.Connect()
+	assert.Nil(t, err)
+
+	err = s.drainManager.Drain()
+	assert.Nil(t, err)
+	assert.True(t, s.drainManager.WaitDone(time.Second*10))
+
+	status := s.drainManager.DrainStatus()
+	assert.True(t, status.Draining)
+	assert.Equal(t, types.DrainPhaseDone, status.Phase)
+	assert.Equal(t, 1, status.TotalConns)
+	assert.Equal(t, 1, status.DisconnectedConns)
+
+	// 排空中不接受新连接
+	cli2 := client.New(s.opts.External.TCPAddr, client.WithUID("test2"))
+	err = cli2.Connect()
+	assert.NotNil(t, err)
+
+	// 取消排空后恢复接受新连接
+	err = s.drainManager.Cancel()
+	assert.Nil(t, err)
+	cli3 := client.New(s.opts.External.TCPAddr, client.WithUID("test3"))
+	err = cli3.Connect()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/internal/types"
+	"github.com/WuKongIM/WuKongIM/pkg/client"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	s := NewTestServer(t)
+	s.opts.Mode = options.TestMode
+	s.opts.Presence.On = true
+	err := s.Start()
+	assert.Nil(t, err)
+	defer s.StopNoErr()
+
+	s.MustWaitAllSlotsReady(time.Second * 10) // 等待服务准备好
+
+	presenceC := make(chan *types.Presence, 10)
+	cli1 := client.New(s.opts.External.TCPAddr, client.WithUID("test1"))
+	cli1.SetOnRecv(func(recv *wkproto.RecvPacket) error {
+		var cmd struct {
+			Cmd   string          `json:"cmd"`
+			Param *types.Presence `json:"param"`
+		}
+		if err := json.Unmarshal(recv.Payload, &cmd); err == nil && cmd.Cmd == types.PresenceCmd {
+			presenceC <- cmd.Param
+		}
+		return nil
+	})
+	err = cli1.Connect()
+	assert.Nil(t, err)
+
+	presences, err := s.presenceManager.Subscribe("test1", []string{"test2"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(presences))
+	assert.Equal(t, 0, presences[0].Online)
+
+	waitPresence := func() *types.Presence {
+		select {
+		case p := <-presenceC:
+			return p
+		case <-time.After(time.Second * 5):
+			t.Fatal("wait presence timeout")
+		}
+		return nil
+	}
+
+	// 上线
+	cli2 := client.New(s.opts.External.TCPAddr, client.WithUID("test2"))
+	err = cli2.Connect()
+	assert.Nil(t, err)
+	p := waitPresence()
+	assert.Equal(t, "test2", p.Uid)
+	assert.Equal(t, 1, p.Online)
+	assert.NotZero(t, p.LastSeen)
+
+	// 自定义状态
+	err = s.presenceManager.SetStatus("test2", types.PresenceStateBusy, "meeting", "📅")
+	assert.Nil(t, err)
+	p = waitPresence()
+	assert.Equal(t, types.PresenceStateBusy, p.State)
+	assert.Equal(t, "meeting", p.StatusText)
+	assert.Equal(t, "📅", p.Emoji)
+
+	// 下线
+	cli2.Close()
+	p = waitPresence()
+	assert.Equal(t, 0, p.Online)
+	assert.Equal(t, types.PresenceStateBusy, p.State)
+}
@@ -32,23 +32,6 @@ func (s *Server) onData(conn wknet.Conn) error {
 		isAuth = false
 	}
 
-	if !isAuth {
-		if isProxyProto(buff) { // 是否是代理协议
-			remoteAddr, size, err := parseProxyProto(buff)
-			if err != nil && err != ErrNoProxyProtocol {
-				s.Warn("Failed to parse proxy proto", zap.Error(err))
-			}
-			if remoteAddr != nil {
-				conn.SetRemoteAddr(remoteAddr)
-				s.Debug("parse proxy proto success", zap.String("remoteAddr", remoteAddr.String()))
-			}
-			if size > 0 {
-				_, _ = conn.Discard(size)
-				buff = buff[size:]
-			}
-		}
-	}
-
 	// 解码协议包
 	data, _ := gnetUnpacket(buff)
 	if len(data) == 0 {
@@ -96,6 +79,9 @@ func (s *Server) onData(conn wknet.Conn) error {
 			ProtoVersion: connectPacket.Version,
 			Uptime:       fasttime.UnixTimestamp(),
 		}
+		if remoteAddr := conn.RemoteAddr(); remoteAddr != nil {
+			connCtx.RemoteAddr = remoteAddr.String()
+		}
 		conn.SetContext(connCtx)
 
 		conn.SetMaxIdle(time.Second * 4) // 给4秒的时间去认证
@@ -130,7 +116,7 @@ func (s *Server) onData(conn wknet.Conn) error {
 					PreStart: time.Now(),
 				},
 			}
-			event.Track.Record(track.PositionStart)
+			event.Record(track.PositionStart)
 			if frame.GetFrameType() == wkproto.SEND {
 				event.MessageId = options.G.GenMessageId()
 				connCtx.InMsgCount.Add(1)
@@ -16,12 +16,14 @@ import (
 	channelhandler "github.com/WuKongIM/WuKongIM/internal/channel/handler"
 	"github.com/WuKongIM/WuKongIM/internal/common"
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/federation"
 	"github.com/WuKongIM/WuKongIM/internal/ingress"
 	"github.com/WuKongIM/WuKongIM/internal/manager"
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	pusherevent "github.com/WuKongIM/WuKongIM/internal/pusher/event"
 	pusherhandler "github.com/WuKongIM/WuKongIM/internal/pusher/handler"
 	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/internal/track"
 	userevent "github.com/WuKongIM/WuKongIM/internal/user/event"
 	userhandler "github.com/WuKongIM/WuKongIM/internal/user/handler"
 	"github.com/WuKongIM/WuKongIM/internal/webhook"
@@ -67,7 +69,10 @@ type Server struct {
 	retryManager        *manager.RetryManager        // 消息重试管理
 	conversationManager *manager.ConversationManager // 会话管理
 	tagManager          *manager.TagManager          // tag管理
+	drainManager        *manager.DrainManager        // 节点排空管理
+	presenceManager     *manager.PresenceManager     // 用户在线状态管理
 	webhook             *webhook.Webhook
+	federation          *federation.Federation // 跨集群联邦同步
 
 	// 用户事件池
 	userHandler   *userhandler.Handler
@@ -124,8 +129,13 @@ func New(opts *options.Options) *Server {
 			trace.WithServiceName(s.opts.Trace.ServiceName),
 			trace.WithServiceHostName(s.opts.Trace.ServiceHostName),
 			trace.WithPrometheusApiUrl(s.opts.Trace.PrometheusApiUrl),
+			trace.WithEndpoint(s.opts.Trace.Endpoint),
+			trace.WithInsecure(s.opts.Trace.Insecure),
+			trace.WithSampleRatio(s.opts.Trace.SampleRatio),
+			trace.WithNodeId(s.opts.Cluster.NodeId),
 		))
 	trace.SetGlobalTrace(s.trace)
+	track.Records = track.NewRecorder(s.opts.Logger.TraceRecordCount) // 本地消息轨迹记录
 
 	gin.SetMode(opts.GinMode)
 
@@ -134,9 +144,21 @@ func New(opts *options.Options) *Server {
 	storeOpts.DataDir = path.Join(s.opts.DataDir, "db")
 	storeOpts.SlotCount = uint32(s.opts.Cluster.SlotCount)
 	storeOpts.GetSlotId = s.getSlotId
+	storeOpts.IsLocalSlot = func(slotId uint32) bool {
+		return s.clusterServer.IsLocalSlot(slotId)
+	}
 	storeOpts.IsCmdChannel = opts.IsCmdChannel
+	storeOpts.IsFederationMessage = func(channelId string, channelType uint8, clientMsgNo string) bool {
+		// 从其他集群同步过来的消息不再同步出去，避免回环
+		return opts.FederationOn() && opts.IsFederationChannel(channelId, channelType) && !options.IsFederationClientMsgNo(clientMsgNo)
+	}
+	storeOpts.DedupWindow = s.opts.Channel.DedupWindow
 	storeOpts.Db.ShardNum = s.opts.Db.ShardNum
 	storeOpts.Db.MemTableSize = s.opts.Db.MemTableSize
+	storeOpts.Db.EncryptionMasterKey, storeOpts.Db.EncryptionOldMasterKey, err = s.opts.DbEncryptionMasterKeys()
+	if err != nil {
+		s.Panic("load db encryption master key error", zap.Error(err))
+	}
 	s.store = clusterstore.NewStore(storeOpts)
 
 	service.Store = s.store
@@ -145,45 +167,69 @@ func New(opts *options.Options) *Server {
 	s.datasource = NewDatasource(s)
 
 	// 初始化长连接引擎
-	s.engine = wknet.NewEngine(
+	engineOpts := []wknet.Option{
 		wknet.WithAddr(s.opts.Addr),
 		wknet.WithWSAddr(s.opts.WSAddr),
 		wknet.WithWSSAddr(s.opts.WSSAddr),
 		wknet.WithWSTLSConfig(s.opts.WSTLSConfig),
+		wknet.WithWSCompression(s.opts.WSConfig.CompressionOn, s.opts.WSConfig.CompressionThreshold),
 		wknet.WithOnReadBytes(func(n int) {
 			trace.GlobalTrace.Metrics.System().ExtranetIncomingAdd(int64(n))
 		}),
 		wknet.WithOnWirteBytes(func(n int) {
 			trace.GlobalTrace.Metrics.System().ExtranetOutgoingAdd(int64(n))
 		}),
-	)
+	}
+	if s.opts.ProxyProtocol.On { // 代理协议，获取四层负载均衡后面的客户端真实地址
+		trustedNets, err := s.opts.ProxyProtocolTrustedNets()
+		if err != nil {
+			s.Panic("proxy protocol trusted cidrs error", zap.Error(err))
+		}
+		engineOpts = append(engineOpts, wknet.WithProxyProtocol(true, trustedNets))
+	}
+	if s.opts.WSConfig.JSONOn { // websocket json文本帧协议
+		engineOpts = append(engineOpts, wknet.WithWSTextCodec(func() wknet.WSTextCodec {
+			return newWSJSONCodec(s.opts.Proto)
+		}))
+	}
+	s.engine = wknet.NewEngine(engineOpts...)
 
 	s.demoServer = NewDemoServer(s) // demo server
 
 	s.webhook = webhook.New()
 	service.Webhook = s.webhook
+	s.federation = federation.New()
 	// manager
 	s.retryManager = manager.NewRetryManager()               // 消息重试管理
 	s.conversationManager = manager.NewConversationManager() // 会话管理
 	s.tagManager = manager.NewTagManager(16, func() uint64 {
 		return service.Cluster.NodeVersion()
 	})
+	s.drainManager = manager.NewDrainManager()       // 节点排空管理
+	s.presenceManager = manager.NewPresenceManager() // 用户在线状态管理
 	// register service
 	service.ConnManager = manager.NewConnManager(18) // 连接管理
 	service.ConversationManager = s.conversationManager
 	service.RetryManager = s.retryManager
 	service.TagManager = s.tagManager
-	service.SystemAccountManager = manager.NewSystemAccountManager() // 系统账号管理
+	service.DrainManager = s.drainManager
+	service.PresenceManager = s.presenceManager
+	service.SystemAccountManager = manager.NewSystemAccountManager()       // 系统账号管理
+	service.DatasourceManager = manager.NewDatasourceManager(s.datasource) // 频道数据获取（数据源）
 
 	s.commonService = common.NewService()
 	service.CommonService = s.commonService
 
 	// 初始化分布式服务
 	initNodes := make(map[uint64]string)
+	initNodeZones := make(map[uint64]string)
 	if len(s.opts.Cluster.InitNodes) > 0 {
 		for _, node := range s.opts.Cluster.InitNodes {
 			serverAddr := strings.ReplaceAll(node.ServerAddr, "tcp://", "")
 			initNodes[node.Id] = serverAddr
+			if node.Zone != "" {
+				initNodeZones[node.Id] = node.Zone
+			}
 		}
 	}
 	role := pb.NodeRole_NodeRoleReplica
@@ -197,6 +243,8 @@ func New(opts *options.Options) *Server {
 			cluster.WithDataDir(path.Join(opts.DataDir, "cluster")),
 			cluster.WithSlotCount(uint32(s.opts.Cluster.SlotCount)),
 			cluster.WithInitNodes(initNodes),
+			cluster.WithInitNodeZones(initNodeZones),
+			cluster.WithZone(s.opts.Cluster.Zone),
 			cluster.WithSeed(s.opts.Cluster.Seed),
 			cluster.WithRole(role),
 			cluster.WithServerAddr(s.opts.Cluster.ServerAddr),
@@ -213,12 +261,17 @@ func New(opts *options.Options) *Server {
 				return s.store.OnMetaApply(slotId, logs)
 			}),
 			cluster.WithChannelClusterStorage(clusterstore.NewChannelClusterConfigStore(s.store)),
+			cluster.WithEncodeSlotImport(clusterstore.EncodeCMDSlotImport),
+			cluster.WithSlotKeysOfLog(clusterstore.CMDSlotKeys),
 			cluster.WithElectionIntervalTick(s.opts.Cluster.ElectionIntervalTick),
 			cluster.WithHeartbeatIntervalTick(s.opts.Cluster.HeartbeatIntervalTick),
 			cluster.WithTickInterval(s.opts.Cluster.TickInterval),
 			cluster.WithChannelReactorSubCount(s.opts.Cluster.ChannelReactorSubCount),
 			cluster.WithSlotReactorSubCount(s.opts.Cluster.SlotReactorSubCount),
 			cluster.WithPongMaxTick(s.opts.Cluster.PongMaxTick),
+			cluster.WithLogChecksumInterval(s.opts.Cluster.LogChecksumInterval),
+			cluster.WithLogChecksumRangeSize(s.opts.Cluster.LogChecksumRangeSize),
+			cluster.WithLogChecksumAutoRepair(s.opts.Cluster.LogChecksumAutoRepair),
 			cluster.WithAuth(s.opts.Auth),
 			cluster.WithServiceName(s.opts.Trace.ServiceName),
 			cluster.WithLokiUrl(s.opts.Logger.Loki.Url),
@@ -301,6 +354,11 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// 用户在线状态管理
+	if err = s.presenceManager.Start(); err != nil {
+		return err
+	}
+
 	err = s.trace.Start()
 	if err != nil {
 		return err
@@ -342,10 +400,20 @@ func (s *Server) Start() error {
 		s.Panic("webhook start error", zap.Error(err))
 	}
 
+	err = s.federation.Start()
+	if err != nil {
+		s.Panic("federation start error", zap.Error(err))
+	}
+
 	if err = s.apiServer.Start(); err != nil {
 		return err
 	}
 
+	err = s.userHandler.Start()
+	if err != nil {
+		return err
+	}
+
 	err = s.userEventPool.Start()
 	if err != nil {
 		return err
@@ -373,10 +441,17 @@ func (s *Server) StopNoErr() {
 
 func (s *Server) Stop() error {
 
+	// 停止前先排空节点，引导客户端连接到其他节点
+	if s.opts.Drain.OnStop {
+		s.drain()
+	}
+
 	s.cancel()
 
 	s.userEventPool.Stop()
 
+	s.userHandler.Stop()
+
 	s.channelEventPool.Stop()
 
 	s.pushEventPool.Stop()
@@ -385,6 +460,8 @@ func (s *Server) Stop() error {
 
 	s.retryManager.Stop()
 
+	s.presenceManager.Stop()
+
 	s.commonService.Stop()
 
 	if s.opts.Conversation.On {
@@ -409,11 +486,26 @@ func (s *Server) Stop() error {
 
 	s.webhook.Stop()
 
+	s.federation.Stop()
+
 	s.Info("Server is stopped")
 
 	return nil
 }
 
+// 排空节点并等待完成
+func (s *Server) drain() {
+	if !s.drainManager.IsDraining() {
+		if err := s.drainManager.Drain(); err != nil {
+			s.Warn("drain failed", zap.Error(err))
+			return
+		}
+	}
+	if !s.drainManager.WaitDone(s.opts.Drain.StopTimeout) {
+		s.Warn("wait drain done timeout", zap.Duration("timeout", s.opts.Drain.StopTimeout))
+	}
+}
+
 // 等待分布式就绪
 func (s *Server) MustWaitClusterReady(timeout time.Duration) {
 	service.Cluster.MustWaitClusterReady(timeout)
@@ -442,24 +534,18 @@ func (s *Server) onConnect(conn wknet.Conn) error {
 	conn.SetMaxIdle(time.Second * 2) // 在认证之前，连接最多空闲2秒
 	s.trace.Metrics.App().ConnCountAdd(1)
 
+	// 节点排空中，不再接受新连接（在加入连接管理之前拒绝，避免被路由到即将关闭的连接）
+	if s.drainManager.IsDraining() {
+		s.Debug("node is draining, conn will be closed", zap.String("remoteAddr", conn.RemoteAddr().String()))
+		_ = conn.Close()
+		return nil
+	}
+
 	service.ConnManager.AddConn(conn)
 
 	return nil
 }
 
-// 解析代理协议，获取真实IP
-// func (s *Server) handleProxyProto(buff []byte) error {
-// 	remoteAddr, size, err := parseProxyProto(buff)
-// 	if err != nil && err != ErrNoProxyProtocol {
-// 		s.Warn("Failed to parse proxy proto", zap.Error(err))
-// 	}
-// 	if remoteAddr != nil {
-// 		conn.SetRemoteAddr(remoteAddr)
-// 		s.Debug("parse proxy proto success", zap.String("remoteAddr", remoteAddr.String()))
-// 	}
-// 	return nil
-// }
-
 func (s *Server) onClose(conn wknet.Conn) {
 
 	s.trace.Metrics.App().ConnCountAdd(-1)
@@ -470,7 +556,9 @@ func (s *Server) onClose(conn wknet.Conn) {
 		if connCtx.Auth {
 			deviceOnlineCount := eventbus.User.ConnCountByDeviceFlag(connCtx.Uid, connCtx.DeviceFlag)
 			totalOnlineCount := eventbus.User.ConnCountByUid(connCtx.Uid)
-			service.Webhook.Offline(connCtx.Uid, wkproto.DeviceFlag(connCtx.DeviceFlag), connCtx.ConnId, deviceOnlineCount, totalOnlineCount) // 触发离线webhook
+			service.Webhook.Offline(connCtx.Uid, wkproto.DeviceFlag(connCtx.DeviceFlag), connCtx.ConnId, deviceOnlineCount, totalOnlineCount, connCtx.RemoteIp()) // 触发离线webhook
+			// 在线状态
+			service.PresenceManager.ConnClosed(connCtx.Uid, options.G.Cluster.NodeId, connCtx.ConnId)
 		}
 	}
 	service.ConnManager.RemoveConn(conn)
@@ -0,0 +1,398 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wknet"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// json文本帧协议的包类型
+const (
+	wsJSONTypeConnect    = "connect"
+	wsJSONTypeConnack    = "connack"
+	wsJSONTypeSend       = "send"
+	wsJSONTypeSendack    = "sendack"
+	wsJSONTypeRecv       = "recv"
+	wsJSONTypeRecvack    = "recvack"
+	wsJSONTypePing       = "ping"
+	wsJSONTypePong       = "pong"
+	wsJSONTypeDisconnect = "disconnect"
+)
+
+var errWSJSONNotConnected = errors.New("ws json: not connected")
+
+// wsJSONCodec websocket json文本帧协议的编解码器，负责json与悟空IM二进制协议的互相转换
+// json客户端不需要实现DH密钥交换和payload加解密，由编解码器代为完成，所以json协议建议在wss下使用
+type wsJSONCodec struct {
+	proto   wkproto.Protocol
+	version uint8
+
+	mu            sync.RWMutex
+	clientPrivKey [32]byte // 代替客户端生成的DH私钥
+	aesKey        []byte
+	aesIV         []byte
+
+	encodeMu sync.Mutex
+	pending  []byte // 上次Encode剩下的不完整的协议包数据
+}
+
+func newWSJSONCodec(proto wkproto.Protocol) wknet.WSTextCodec {
+	return &wsJSONCodec{
+		proto:   proto,
+		version: wkproto.LatestVersion,
+	}
+}
+
+// Decode 将客户端的json文本帧转换为二进制协议数据
+func (w *wsJSONCodec) Decode(text []byte) ([]byte, error) {
+	packet := &wsJSONPacket{}
+	if err := json.Unmarshal(text, packet); err != nil {
+		return nil, err
+	}
+	var (
+		frame wkproto.Frame
+		err   error
+	)
+	switch packet.Type {
+	case wsJSONTypeConnect:
+		frame, err = w.decodeConnect(packet.Data)
+	case wsJSONTypeSend:
+		frame, err = w.decodeSend(packet.Data)
+	case wsJSONTypeRecvack:
+		recvack := &wsJSONRecvack{}
+		if err = json.Unmarshal(packet.Data, recvack); err != nil {
+			return nil, err
+		}
+		frame = &wkproto.RecvackPacket{
+			MessageID:  recvack.MessageID,
+			MessageSeq: recvack.MessageSeq,
+		}
+	case wsJSONTypePing:
+		frame = &wkproto.PingPacket{}
+	default:
+		return nil, fmt.Errorf("ws json: unsupported packet type[%s]", packet.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.proto.EncodeFrame(frame, w.version)
+}
+
+// Encode 将服务端的二进制协议数据转换为json文本帧
+// 不完整的协议包留到下次调用时和新数据一起转换，json协议不支持的协议包以二进制帧原样发送
+func (w *wsJSONCodec) Encode(data []byte) ([]wsutil.Message, error) {
+	w.encodeMu.Lock()
+	defer w.encodeMu.Unlock()
+
+	if len(w.pending) > 0 {
+		data = append(w.pending, data...)
+		w.pending = nil
+	}
+	var msgs []wsutil.Message
+	for len(data) > 0 {
+		frame, size, err := w.proto.DecodeFrame(data, w.version)
+		if err != nil {
+			return nil, err
+		}
+		if frame == nil || size <= 0 {
+			break
+		}
+		frameData := data[:size]
+		data = data[size:]
+
+		packet, err := w.encodeFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+		if packet == nil {
+			msgs = append(msgs, wsutil.Message{OpCode: ws.OpBinary, Payload: append([]byte(nil), frameData...)})
+			continue
+		}
+		text, err := json.Marshal(packet)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, wsutil.Message{OpCode: ws.OpText, Payload: text})
+	}
+	if len(data) > 0 {
+		w.pending = append([]byte(nil), data...)
+	}
+	return msgs, nil
+}
+
+func (w *wsJSONCodec) decodeConnect(data []byte) (wkproto.Frame, error) {
+	connect := &wsJSONConnect{}
+	if err := json.Unmarshal(data, connect); err != nil {
+		return nil, err
+	}
+	clientPrivKey, clientPubKey := wkutil.GetCurve25519KeypPair()
+	w.mu.Lock()
+	w.clientPrivKey = clientPrivKey
+	w.mu.Unlock()
+
+	clientTimestamp := connect.ClientTimestamp
+	if clientTimestamp == 0 {
+		clientTimestamp = time.Now().UnixMilli()
+	}
+	deviceId := connect.DeviceID
+	if deviceId == "" {
+		deviceId = wkutil.GenUUID()
+	}
+	return &wkproto.ConnectPacket{
+		Version:         w.version,
+		ClientKey:       base64.StdEncoding.EncodeToString(clientPubKey[:]),
+		DeviceID:        deviceId,
+		DeviceFlag:      wkproto.DeviceFlag(connect.DeviceFlag),
+		ClientTimestamp: clientTimestamp,
+		UID:             connect.UID,
+		Token:           connect.Token,
+	}, nil
+}
+
+func (w *wsJSONCodec) decodeSend(data []byte) (wkproto.Frame, error) {
+	send := &wsJSONSend{}
+	if err := json.Unmarshal(data, send); err != nil {
+		return nil, err
+	}
+	aesKey, aesIV := w.getAesKeyAndIV()
+	if len(aesKey) == 0 {
+		return nil, errWSJSONNotConnected
+	}
+	payload, err := wkutil.AesEncryptPkcs7Base64(send.Payload, aesKey, aesIV)
+	if err != nil {
+		return nil, err
+	}
+	clientMsgNo := send.ClientMsgNo
+	if clientMsgNo == "" {
+		clientMsgNo = wkutil.GenUUID()
+	}
+	packet := &wkproto.SendPacket{
+		Framer: wkproto.Framer{
+			NoPersist: send.NoPersist,
+			RedDot:    send.RedDot,
+			SyncOnce:  send.SyncOnce,
+		},
+		Expire:      send.Expire,
+		ClientSeq:   send.ClientSeq,
+		ClientMsgNo: clientMsgNo,
+		StreamNo:    send.StreamNo,
+		ChannelID:   send.ChannelID,
+		ChannelType: send.ChannelType,
+		Topic:       send.Topic,
+		Payload:     payload,
+	}
+	if send.Topic != "" {
+		packet.Setting.Set(wkproto.SettingTopic)
+	}
+	if send.StreamNo != "" {
+		packet.Setting.Set(wkproto.SettingStream)
+	}
+	msgKey, err := wkutil.AesEncryptPkcs7Base64([]byte(packet.VerityString()), aesKey, aesIV)
+	if err != nil {
+		return nil, err
+	}
+	packet.MsgKey = wkutil.MD5(string(msgKey))
+	return packet, nil
+}
+
+func (w *wsJSONCodec) encodeFrame(frame wkproto.Frame) (*wsJSONPacket, error) {
+	var (
+		typ  string
+		data interface{}
+	)
+	switch frame.GetFrameType() {
+	case wkproto.CONNACK:
+		connack := frame.(*wkproto.ConnackPacket)
+		if connack.ReasonCode == wkproto.ReasonSuccess {
+			if err := w.setServerKey(connack.ServerKey, connack.Salt); err != nil {
+				return nil, err
+			}
+		}
+		typ = wsJSONTypeConnack
+		data = &wsJSONConnack{
+			ServerVersion: connack.ServerVersion,
+			TimeDiff:      connack.TimeDiff,
+			ReasonCode:    uint8(connack.ReasonCode),
+			NodeId:        connack.NodeId,
+		}
+	case wkproto.SENDACK:
+		sendack := frame.(*wkproto.SendackPacket)
+		typ = wsJSONTypeSendack
+		data = &wsJSONSendack{
+			MessageID:   sendack.MessageID,
+			MessageSeq:  sendack.MessageSeq,
+			ClientSeq:   sendack.ClientSeq,
+			ClientMsgNo: sendack.ClientMsgNo,
+			ReasonCode:  uint8(sendack.ReasonCode),
+		}
+	case wkproto.RECV:
+		recv := frame.(*wkproto.RecvPacket)
+		payload := recv.Payload
+		if !recv.Setting.IsSet(wkproto.SettingNoEncrypt) {
+			aesKey, aesIV := w.getAesKeyAndIV()
+			if len(aesKey) == 0 {
+				return nil, errWSJSONNotConnected
+			}
+			var err error
+			payload, err = wkutil.AesDecryptPkcs7Base64(recv.Payload, aesKey, aesIV)
+			if err != nil {
+				return nil, err
+			}
+		}
+		typ = wsJSONTypeRecv
+		data = &wsJSONRecv{
+			MessageID:   recv.MessageID,
+			MessageSeq:  recv.MessageSeq,
+			ClientMsgNo: recv.ClientMsgNo,
+			StreamNo:    recv.StreamNo,
+			StreamSeq:   recv.StreamSeq,
+			StreamFlag:  uint8(recv.StreamFlag),
+			Timestamp:   recv.Timestamp,
+			ChannelID:   recv.ChannelID,
+			ChannelType: recv.ChannelType,
+			Topic:       recv.Topic,
+			FromUID:     recv.FromUID,
+			Expire:      recv.Expire,
+			RedDot:      recv.RedDot,
+			NoPersist:   recv.NoPersist,
+			SyncOnce:    recv.SyncOnce,
+			Payload:     wsJSONPayload(payload),
+		}
+	case wkproto.PONG:
+		typ = wsJSONTypePong
+	case wkproto.DISCONNECT:
+		disconnect := frame.(*wkproto.DisconnectPacket)
+		typ = wsJSONTypeDisconnect
+		data = &wsJSONDisconnect{
+			ReasonCode: uint8(disconnect.ReasonCode),
+			Reason:     disconnect.Reason,
+		}
+	default:
+		// json协议不支持的协议包，由调用方原样发送
+		return nil, nil
+	}
+	packet := &wsJSONPacket{Type: typ}
+	if data != nil {
+		dataBytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		packet.Data = dataBytes
+	}
+	return packet, nil
+}
+
+// 通过服务端的DH公钥计算出payload的加密密钥（与sdk的计算方式一致）
+func (w *wsJSONCodec) setServerKey(serverKey string, salt string) error {
+	serverKeyBytes, err := base64.StdEncoding.DecodeString(serverKey)
+	if err != nil {
+		return err
+	}
+	if len(serverKeyBytes) < 32 {
+		return errors.New("ws json: server key is illegal")
+	}
+	var serverPubKey [32]byte
+	copy(serverPubKey[:], serverKeyBytes[:32])
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	shareKey := wkutil.GetCurve25519Key(w.clientPrivKey, serverPubKey)
+	w.aesKey = []byte(wkutil.MD5(base64.StdEncoding.EncodeToString(shareKey[:]))[:16])
+	w.aesIV = []byte(salt)
+	return nil
+}
+
+func (w *wsJSONCodec) getAesKeyAndIV() ([]byte, []byte) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.aesKey, w.aesIV
+}
+
+// wsJSONPayload 消息内容，如果payload是合法的json则原样输出，否则输出为json字符串
+func wsJSONPayload(payload []byte) json.RawMessage {
+	if json.Valid(payload) {
+		return payload
+	}
+	data, _ := json.Marshal(string(payload))
+	return data
+}
+
+type wsJSONPacket struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type wsJSONConnect struct {
+	UID             string `json:"uid"`
+	Token           string `json:"token"`
+	DeviceID        string `json:"device_id"`
+	DeviceFlag      uint8  `json:"device_flag"`
+	ClientTimestamp int64  `json:"client_timestamp"`
+}
+
+type wsJSONConnack struct {
+	ServerVersion uint8  `json:"server_version"`
+	TimeDiff      int64  `json:"time_diff"`
+	ReasonCode    uint8  `json:"reason_code"`
+	NodeId        uint64 `json:"node_id"`
+}
+
+type wsJSONSend struct {
+	ClientSeq   uint64          `json:"client_seq"`
+	ClientMsgNo string          `json:"client_msg_no"`
+	StreamNo    string          `json:"stream_no"`
+	ChannelID   string          `json:"channel_id"`
+	ChannelType uint8           `json:"channel_type"`
+	Topic       string          `json:"topic"`
+	Expire      uint32          `json:"expire"`
+	RedDot      bool            `json:"red_dot"`
+	NoPersist   bool            `json:"no_persist"`
+	SyncOnce    bool            `json:"sync_once"`
+	Payload     json.RawMessage `json:"payload"` // 消息内容（json对象），原样作为消息的payload
+}
+
+type wsJSONSendack struct {
+	MessageID   int64  `json:"message_id,string"`
+	MessageSeq  uint32 `json:"message_seq"`
+	ClientSeq   uint64 `json:"client_seq"`
+	ClientMsgNo string `json:"client_msg_no"`
+	ReasonCode  uint8  `json:"reason_code"`
+}
+
+type wsJSONRecv struct {
+	MessageID   int64           `json:"message_id,string"`
+	MessageSeq  uint32          `json:"message_seq"`
+	ClientMsgNo string          `json:"client_msg_no"`
+	StreamNo    string          `json:"stream_no,omitempty"`
+	StreamSeq   uint32          `json:"stream_seq,omitempty"`
+	StreamFlag  uint8           `json:"stream_flag,omitempty"`
+	Timestamp   int32           `json:"timestamp"`
+	ChannelID   string          `json:"channel_id"`
+	ChannelType uint8           `json:"channel_type"`
+	Topic       string          `json:"topic,omitempty"`
+	FromUID     string          `json:"from_uid"`
+	Expire      uint32          `json:"expire,omitempty"`
+	RedDot      bool            `json:"red_dot"`
+	NoPersist   bool            `json:"no_persist"`
+	SyncOnce    bool            `json:"sync_once"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+type wsJSONRecvack struct {
+	MessageID  int64  `json:"message_id,string"`
+	MessageSeq uint32 `json:"message_seq"`
+}
+
+type wsJSONDisconnect struct {
+	ReasonCode uint8  `json:"reason_code"`
+	Reason     string `json:"reason"`
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/gobwas/ws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWSJSONCodec(t *testing.T) {
+	proto := wkproto.New()
+	codec := newWSJSONCodec(proto)
+
+	// connect
+	data, err := codec.Decode([]byte(`{"type":"connect","data":{"uid":"u1","token":"t1","device_flag":1}}`))
+	assert.NoError(t, err)
+	frame, _, err := proto.DecodeFrame(data, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	connect := frame.(*wkproto.ConnectPacket)
+	assert.Equal(t, "u1", connect.UID)
+	assert.Equal(t, "t1", connect.Token)
+	assert.Equal(t, wkproto.DeviceFlag(1), connect.DeviceFlag)
+	assert.NotEmpty(t, connect.ClientKey)
+
+	// 模拟服务端计算密钥
+	serverPrivKey, serverPubKey := wkutil.GetCurve25519KeypPair()
+	clientKey, _ := base64.StdEncoding.DecodeString(connect.ClientKey)
+	var clientPubKey [32]byte
+	copy(clientPubKey[:], clientKey)
+	shareKey := wkutil.GetCurve25519Key(serverPrivKey, clientPubKey)
+	aesKey := []byte(wkutil.MD5(base64.StdEncoding.EncodeToString(shareKey[:]))[:16])
+	aesIV := []byte(wkutil.GetRandomString(16))
+
+	// connack
+	data, err = proto.EncodeFrame(&wkproto.ConnackPacket{
+		ServerKey:  base64.StdEncoding.EncodeToString(serverPubKey[:]),
+		Salt:       string(aesIV),
+		ReasonCode: wkproto.ReasonSuccess,
+		NodeId:     1,
+	}, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	msgs, err := codec.Encode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, ws.OpText, msgs[0].OpCode)
+	assert.JSONEq(t, `{"type":"connack","data":{"server_version":0,"time_diff":0,"reason_code":1,"node_id":1}}`, string(msgs[0].Payload))
+
+	// send
+	data, err = codec.Decode([]byte(`{"type":"send","data":{"client_seq":1,"channel_id":"u2","channel_type":1,"payload":{"type":1,"content":"hello"}}}`))
+	assert.NoError(t, err)
+	frame, _, err = proto.DecodeFrame(data, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	send := frame.(*wkproto.SendPacket)
+	payload, err := wkutil.AesDecryptPkcs7Base64(send.Payload, aesKey, aesIV)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":1,"content":"hello"}`, string(payload))
+	msgKey, err := wkutil.AesEncryptPkcs7Base64([]byte(send.VerityString()), aesKey, aesIV)
+	assert.NoError(t, err)
+	assert.Equal(t, wkutil.MD5(string(msgKey)), send.MsgKey)
+
+	// recv
+	encPayload, err := wkutil.AesEncryptPkcs7Base64([]byte(`{"type":1,"content":"world"}`), aesKey, aesIV)
+	assert.NoError(t, err)
+	data, err = proto.EncodeFrame(&wkproto.RecvPacket{
+		MessageID:   1000,
+		MessageSeq:  2,
+		ChannelID:   "u2",
+		ChannelType: 1,
+		FromUID:     "u2",
+		Payload:     encPayload,
+	}, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	// 不完整的协议包等下次数据到达后再转换
+	msgs, err = codec.Encode(data[:len(data)/2])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(msgs))
+	msgs, err = codec.Encode(data[len(data)/2:])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(msgs))
+	packet := &wsJSONPacket{}
+	err = json.Unmarshal(msgs[0].Payload, packet)
+	assert.NoError(t, err)
+	assert.Equal(t, wsJSONTypeRecv, packet.Type)
+	recv := &wsJSONRecv{}
+	err = json.Unmarshal(packet.Data, recv)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), recv.MessageID)
+	assert.Equal(t, uint32(2), recv.MessageSeq)
+	assert.JSONEq(t, `{"type":1,"content":"world"}`, string(recv.Payload))
+
+	// recvack
+	data, err = codec.Decode([]byte(`{"type":"recvack","data":{"message_id":"1000","message_seq":2}}`))
+	assert.NoError(t, err)
+	frame, _, err = proto.DecodeFrame(data, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	recvack := frame.(*wkproto.RecvackPacket)
+	assert.Equal(t, int64(1000), recvack.MessageID)
+	assert.Equal(t, uint32(2), recvack.MessageSeq)
+
+	// json协议不支持的协议包原样以二进制帧发送
+	data, err = proto.EncodeFrame(&wkproto.SubackPacket{
+		SubNo:       "1",
+		ChannelID:   "g1",
+		ChannelType: 2,
+	}, wkproto.LatestVersion)
+	assert.NoError(t, err)
+	msgs, err = codec.Encode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, ws.OpBinary, msgs[0].OpCode)
+	assert.Equal(t, data, msgs[0].Payload)
+}
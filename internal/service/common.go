@@ -1,10 +1,13 @@
 package service
 
 import (
+	"errors"
+	"math"
 	"time"
 
 	"github.com/RussellLuo/timingwheel"
 	"github.com/WuKongIM/WuKongIM/internal/options"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"go.uber.org/zap"
@@ -15,12 +18,55 @@ var CommonService ICommonService
 type ICommonService interface {
 	Schedule(interval time.Duration, f func()) *timingwheel.Timer
 	AfterFunc(d time.Duration, f func())
+	// SendOnlineCmd 给指定用户发送在线cmd消息（不存储，只推送给在线的用户）
+	SendOnlineCmd(uids []string, payload []byte) error
+}
+
+// GetDedupMessage 获取去重时间窗口内发送者在频道内相同client_msg_no的消息（需要在频道领导节点上调用）
+func GetDedupMessage(channelId string, channelType uint8, fromUid string, clientMsgNo string) (wkdb.Message, bool, error) {
+	if options.G.Channel.DedupWindow <= 0 || clientMsgNo == "" {
+		return wkdb.EmptyMessage, false, nil
+	}
+	msg, err := Store.GetDedupMessage(channelId, channelType, fromUid, clientMsgNo)
+	if err != nil {
+		if err == wkdb.ErrNotFound {
+			return wkdb.EmptyMessage, false, nil
+		}
+		return wkdb.EmptyMessage, false, err
+	}
+	if time.Since(time.Unix(int64(msg.Timestamp), 0)) > options.G.Channel.DedupWindow {
+		return wkdb.EmptyMessage, false, nil
+	}
+	return msg, true, nil
+}
+
+// NotVisibleMsgSeq 用户在频道内没有可见的消息（限制了历史消息可见范围的频道，用户不是订阅者）
+const NotVisibleMsgSeq uint64 = math.MaxUint64
+
+// VisibleStartMsgSeq 用户在频道内可见的起始消息序号（包含），0表示全部可见（需要在频道的槽领导节点上调用）
+func VisibleStartMsgSeq(channelId string, channelType uint8, uid string) (uint64, error) {
+	channelInfo, err := Store.GetChannel(channelId, channelType)
+	if err != nil {
+		return 0, err
+	}
+	// 继承父频道订阅者的子频道没有自己的订阅者，不支持限制历史消息（创建时已校验）
+	if channelInfo.HistoryVisibility == wkdb.HistoryVisibilityAll || channelInfo.Inherit {
+		return 0, nil
+	}
+	member, err := Store.GetSubscriber(channelId, channelType, uid)
+	if err != nil {
+		if err == wkdb.ErrNotFound {
+			return NotVisibleMsgSeq, nil
+		}
+		return 0, err
+	}
+	return channelInfo.VisibleStartMsgSeq(member), nil
 }
 
 // 判断单聊是否允许发送消息
 func AllowSendForPerson(from, to string) (wkproto.ReasonCode, error) {
 	// 判断是否是黑名单内
-	isDenylist, err := Store.ExistDenylist(to, wkproto.ChannelTypePerson, from)
+	isDenylist, err := DatasourceManager.ExistDenylist(to, wkproto.ChannelTypePerson, from)
 	if err != nil {
 		wklog.Error("ExistDenylist error", zap.String("from", from), zap.String("to", to), zap.Error(err))
 		return wkproto.ReasonSystemError, err
@@ -31,7 +77,7 @@ func AllowSendForPerson(from, to string) (wkproto.ReasonCode, error) {
 
 	if !options.G.WhitelistOffOfPerson {
 		// 判断是否在白名单内
-		isAllowlist, err := Store.ExistAllowlist(to, wkproto.ChannelTypePerson, from)
+		isAllowlist, err := DatasourceManager.ExistAllowlist(to, wkproto.ChannelTypePerson, from)
 		if err != nil {
 			wklog.Error("ExistAllowlist error", zap.Error(err))
 			return wkproto.ReasonSystemError, err
@@ -43,3 +89,81 @@ func AllowSendForPerson(from, to string) (wkproto.ReasonCode, error) {
 
 	return wkproto.ReasonSuccess, nil
 }
+
+// MaxChannelInheritDepth 子频道逐级继承父频道的最大层级，超过则认为父频道的配置有误（例如成环）
+const MaxChannelInheritDepth = 8
+
+var ErrChannelInheritTooDeep = errors.New("channel inherit too deep")
+
+// InheritRootChannel 子频道继承父频道的订阅者和权限时，逐级向上查找最终提供订阅者和权限的祖先频道
+// ok为false表示频道没有继承父频道
+func InheritRootChannel(channelId string, channelType uint8) (rootChannelId string, rootChannelType uint8, ok bool, err error) {
+	rootChannelId, rootChannelType = channelId, channelType
+	for depth := 0; ; depth++ {
+		channelInfo, err := DatasourceManager.GetChannelInfo(rootChannelId, rootChannelType)
+		if err != nil {
+			return "", 0, false, err
+		}
+		if !channelInfo.Inherit || channelInfo.ParentChannelId == "" {
+			return rootChannelId, rootChannelType, depth > 0, nil
+		}
+		if depth >= MaxChannelInheritDepth {
+			return "", 0, false, ErrChannelInheritTooDeep
+		}
+		rootChannelId, rootChannelType = channelInfo.ParentChannelId, channelInfo.ParentChannelType
+	}
+}
+
+// 判断频道是否允许发送者发送消息（子频道继承父频道权限时，在最终提供权限的祖先频道的槽领导节点上调用）
+func AllowSendForChannel(channelId string, channelType uint8, from string) (wkproto.ReasonCode, error) {
+	channelInfo, err := DatasourceManager.GetChannelInfo(channelId, channelType)
+	if err != nil {
+		wklog.Error("GetChannelInfo error", zap.String("channelId", channelId), zap.Uint8("channelType", channelType), zap.Error(err))
+		return wkproto.ReasonSystemError, err
+	}
+	// 频道被封禁
+	if channelInfo.Ban {
+		return wkproto.ReasonBan, nil
+	}
+	// 频道已解散
+	if channelInfo.Disband {
+		return wkproto.ReasonDisband, nil
+	}
+	// 判断是否是黑名单内
+	isDenylist, err := DatasourceManager.ExistDenylist(channelId, channelType, from)
+	if err != nil {
+		wklog.Error("ExistDenylist error", zap.String("channelId", channelId), zap.String("from", from), zap.Error(err))
+		return wkproto.ReasonSystemError, err
+	}
+	if isDenylist {
+		return wkproto.ReasonInBlacklist, nil
+	}
+	// 判断是否是订阅者
+	isSubscriber, err := DatasourceManager.ExistSubscriber(channelId, channelType, from)
+	if err != nil {
+		wklog.Error("ExistSubscriber error", zap.String("channelId", channelId), zap.String("from", from), zap.Error(err))
+		return wkproto.ReasonSystemError, err
+	}
+	if !isSubscriber {
+		return wkproto.ReasonSubscriberNotExist, nil
+	}
+	// 判断是否在白名单内
+	if !options.G.WhitelistOffOfPerson {
+		hasAllowlist, err := DatasourceManager.HasAllowlist(channelId, channelType)
+		if err != nil {
+			wklog.Error("HasAllowlist error", zap.String("channelId", channelId), zap.Error(err))
+			return wkproto.ReasonSystemError, err
+		}
+		if hasAllowlist {
+			isAllowlist, err := DatasourceManager.ExistAllowlist(channelId, channelType, from)
+			if err != nil {
+				wklog.Error("ExistAllowlist error", zap.String("channelId", channelId), zap.String("from", from), zap.Error(err))
+				return wkproto.ReasonSystemError, err
+			}
+			if !isAllowlist {
+				return wkproto.ReasonNotInWhitelist, nil
+			}
+		}
+	}
+	return wkproto.ReasonSuccess, nil
+}
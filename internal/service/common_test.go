@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChannelDatasource struct {
+	IDatasourceManager
+	channels map[string]wkdb.ChannelInfo
+}
+
+func (t *testChannelDatasource) GetChannelInfo(channelId string, channelType uint8) (wkdb.ChannelInfo, error) {
+	return t.channels[channelId], nil
+}
+
+func TestInheritRootChannel(t *testing.T) {
+	DatasourceManager = &testChannelDatasource{
+		channels: map[string]wkdb.ChannelInfo{
+			"community": {ChannelId: "community", ChannelType: 2},
+			"topic":     {ChannelId: "topic", ChannelType: 2, ParentChannelId: "community", ParentChannelType: 2, Inherit: true},
+			"thread":    {ChannelId: "thread", ChannelType: 2, ParentChannelId: "topic", ParentChannelType: 2, Inherit: true},
+			"a":         {ChannelId: "a", ChannelType: 2, ParentChannelId: "b", ParentChannelType: 2, Inherit: true},
+			"b":         {ChannelId: "b", ChannelType: 2, ParentChannelId: "a", ParentChannelType: 2, Inherit: true},
+		},
+	}
+	defer func() {
+		DatasourceManager = nil
+	}()
+
+	// 没有继承父频道
+	_, _, ok, err := InheritRootChannel("community", 2)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// 逐级继承到最上级的父频道
+	rootId, rootType, ok, err := InheritRootChannel("thread", 2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "community", rootId)
+	assert.Equal(t, uint8(2), rootType)
+
+	// 父频道成环
+	_, _, _, err = InheritRootChannel("a", 2)
+	assert.Equal(t, ErrChannelInheritTooDeep, err)
+}
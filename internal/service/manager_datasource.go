@@ -0,0 +1,23 @@
+package service
+
+import "github.com/WuKongIM/WuKongIM/pkg/wkdb"
+
+var DatasourceManager IDatasourceManager
+
+// IDatasourceManager 频道数据的获取，开启了数据源则从第三方数据源获取（带缓存），否则从本地存储获取
+type IDatasourceManager interface {
+	// GetChannelInfo 获取频道信息
+	GetChannelInfo(channelId string, channelType uint8) (wkdb.ChannelInfo, error)
+	// GetSubscribers 获取频道的订阅者
+	GetSubscribers(channelId string, channelType uint8) ([]string, error)
+	// ExistSubscriber 是否是频道的订阅者
+	ExistSubscriber(channelId string, channelType uint8, uid string) (bool, error)
+	// ExistDenylist 是否在频道的黑名单内
+	ExistDenylist(channelId string, channelType uint8, uid string) (bool, error)
+	// HasAllowlist 频道是否有白名单
+	HasAllowlist(channelId string, channelType uint8) (bool, error)
+	// ExistAllowlist 是否在频道的白名单内
+	ExistAllowlist(channelId string, channelType uint8, uid string) (bool, error)
+	// Invalidate 使频道在本节点的数据源缓存失效
+	Invalidate(channelId string, channelType uint8)
+}
@@ -0,0 +1,20 @@
+package service
+
+import "github.com/WuKongIM/WuKongIM/internal/types"
+
+var DrainManager IDrainManager
+
+type IDrainManager interface {
+	// Drain 开始排空节点（不再接受新连接，等待重试消息完成后引导客户端连接到其他节点）
+	Drain() error
+	// Cancel 取消排空，恢复接受新连接
+	Cancel() error
+	// IsDraining 是否正在排空
+	IsDraining() bool
+	// DrainStatus 排空状态
+	DrainStatus() types.DrainStatus
+	// SuggestAddr 获取建议用户连接的其他节点地址
+	SuggestAddr(uid string, intranet bool) (*types.NodeIMAddr, error)
+	// ZoneAddr 获取指定可用区内建议用户连接的节点地址（优先用户所在的领导节点）
+	ZoneAddr(uid string, zone string, intranet bool) (*types.NodeIMAddr, error)
+}
@@ -0,0 +1,23 @@
+package service
+
+import "github.com/WuKongIM/WuKongIM/internal/types"
+
+var PresenceManager IPresenceManager
+
+// IPresenceManager 用户在线状态管理，用户的在线状态和订阅关系都在用户所在槽的领导节点上
+type IPresenceManager interface {
+	// Online 用户上线（第一个连接认证成功）
+	Online(uid string)
+	// ConnClosed 用户的连接关闭，没有连接了则用户下线
+	ConnClosed(uid string, nodeId uint64, connId int64)
+	// ConnRemoved 用户的逻辑连接已移除
+	ConnRemoved(uid string)
+	// SetStatus 设置用户的自定义状态
+	SetStatus(uid string, state types.PresenceState, statusText, emoji string) error
+	// Subscribe 订阅指定用户的在线状态变化，返回这些用户当前的在线状态
+	Subscribe(subscriber string, uids []string) ([]*types.Presence, error)
+	// Unsubscribe 取消订阅
+	Unsubscribe(subscriber string, uids []string)
+	// Presences 获取指定用户的在线状态
+	Presences(uids []string) ([]*types.Presence, error)
+}
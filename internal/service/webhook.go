@@ -9,10 +9,10 @@ import (
 var Webhook IWebhook
 
 type IWebhook interface {
-	// Online 设备上线
-	Online(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int)
-	// Offline 设备下线
-	Offline(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int)
+	// Online 设备上线 remoteIp为客户端的ip
+	Online(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int, remoteIp string)
+	// Offline 设备下线 remoteIp为客户端的ip
+	Offline(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int, remoteIp string)
 	// NotifyOfflineMsg 离线消息通知
 	NotifyOfflineMsg(events []*eventbus.Event)
 	// TriggerEvent 触发事件
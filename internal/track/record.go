@@ -0,0 +1,117 @@
+package track
+
+import (
+	"sync"
+	"time"
+)
+
+// Records 本节点的消息轨迹记录（没有配置loki时，通过各节点的轨迹记录来还原消息轨迹）
+var Records = NewRecorder(10000)
+
+const (
+	// ActionSendack 频道领导节点回应发送者
+	ActionSendack = "sendack"
+	// ActionPushOnline 推送在线消息
+	ActionPushOnline = "pushOnline"
+	// ActionRecvack 收到客户端的消息回执
+	ActionRecvack = "recvack"
+)
+
+// Record 消息轨迹记录
+type Record struct {
+	Action      string     `json:"action"`
+	NodeId      uint64     `json:"node_id"`       // 记录所在节点
+	MessageId   int64      `json:"message_id"`    // 消息id
+	MessageSeq  uint64     `json:"message_seq"`   // 消息序号
+	ClientMsgNo string     `json:"client_msg_no"` // 客户端消息编号
+	ChannelId   string     `json:"channel_id"`
+	ChannelType uint8      `json:"channel_type"`
+	Uid         string     `json:"uid"` // sendack为发送者，pushOnline和recvack为接收者
+	DeviceId    string     `json:"device_id"`
+	DeviceFlag  uint8      `json:"device_flag"`
+	DeviceLevel uint8      `json:"device_level"`
+	ConnId      int64      `json:"conn_id"`
+	ConnCount   int        `json:"conn_count"` // 接收者的连接数量
+	Path        uint16     `json:"path"`       // 消息路径
+	Cost        [16]uint16 `json:"cost"`       // 消息在各个位置的耗时（毫秒）
+	Time        time.Time  `json:"time"`       // 记录时间
+}
+
+// NewRecord 根据消息的轨迹创建记录
+func NewRecord(action string, nodeId uint64, m Message) Record {
+	return Record{
+		Action: action,
+		NodeId: nodeId,
+		Path:   m.Path,
+		Cost:   m.Cost,
+		Time:   time.Now(),
+	}
+}
+
+// Has 消息是否经过了指定位置
+func (r Record) Has(p Position) bool {
+	return r.Path&uint16(1<<(16-p)) > 0
+}
+
+// PositionTime 根据各个位置的耗时推算消息到达指定位置的时间
+func (r Record) PositionTime(p Position) time.Time {
+	var after time.Duration // 指定位置之后的耗时
+	for pos := p + 1; pos <= 16; pos++ {
+		if r.Has(pos) {
+			after += time.Duration(r.Cost[16-pos]) * time.Millisecond
+		}
+	}
+	return r.Time.Add(-after)
+}
+
+// Recorder 固定大小的环形记录，写满后覆盖最旧的记录
+type Recorder struct {
+	mu      sync.RWMutex
+	records []Record
+	next    int
+	full    bool
+}
+
+func NewRecorder(size int) *Recorder {
+	return &Recorder{
+		records: make([]Record, size),
+	}
+}
+
+func (r *Recorder) Add(record Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.records) == 0 {
+		return
+	}
+	r.records[r.next] = record
+	r.next++
+	if r.next >= len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Query 查询since之后满足条件的记录，按时间从旧到新返回
+func (r *Recorder) Query(since time.Time, filter func(record Record) bool) []Record {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []Record
+	size := r.next
+	start := 0
+	if r.full {
+		size = len(r.records)
+		start = r.next
+	}
+	for i := 0; i < size; i++ {
+		record := r.records[(start+i)%len(r.records)]
+		if record.Time.Before(since) {
+			continue
+		}
+		if filter(record) {
+			results = append(results, record)
+		}
+	}
+	return results
+}
@@ -0,0 +1,48 @@
+package track
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderQuery(t *testing.T) {
+	recorder := NewRecorder(3)
+	start := time.Now()
+	for i := 1; i <= 5; i++ {
+		recorder.Add(Record{
+			Action:      ActionSendack,
+			MessageId:   int64(i),
+			ClientMsgNo: fmt.Sprintf("no%d", i%2),
+			Time:        start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	// 写满后覆盖最旧的记录，按时间从旧到新返回
+	records := recorder.Query(time.Time{}, func(record Record) bool { return true })
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, int64(3), records[0].MessageId)
+	assert.Equal(t, int64(5), records[2].MessageId)
+
+	records = recorder.Query(start.Add(time.Second*4), func(record Record) bool {
+		return record.ClientMsgNo == "no1"
+	})
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, int64(5), records[0].MessageId)
+}
+
+func TestRecordPositionTime(t *testing.T) {
+	m := Message{}
+	m.Path = m.Path | uint16(1<<(16-PositionStart)) | uint16(1<<(16-PositionChannelPersist)) | uint16(1<<(16-PositionChannelSendack))
+	m.Cost[16-PositionChannelPersist] = 10
+	m.Cost[16-PositionChannelSendack] = 5
+
+	record := NewRecord(ActionSendack, 1, m)
+	assert.True(t, record.Has(PositionChannelPersist))
+	assert.False(t, record.Has(PositionChannelPermission))
+	assert.Equal(t, record.Time, record.PositionTime(PositionChannelSendack))
+	assert.Equal(t, record.Time.Add(-time.Millisecond*5), record.PositionTime(PositionChannelPersist))
+	assert.Equal(t, record.Time.Add(-time.Millisecond*15), record.PositionTime(PositionStart))
+}
@@ -0,0 +1,42 @@
+package types
+
+// DrainPhase 节点排空阶段
+type DrainPhase string
+
+const (
+	DrainPhaseNone          DrainPhase = ""              // 没有排空
+	DrainPhaseWaitRetry     DrainPhase = "wait_retry"    // 等待正在重试的消息完成
+	DrainPhaseDisconnecting DrainPhase = "disconnecting" // 正在断开连接
+	DrainPhaseDone          DrainPhase = "done"          // 排空完成
+	DrainPhaseCanceled      DrainPhase = "canceled"      // 已取消
+)
+
+// DrainStatus 节点排空状态
+type DrainStatus struct {
+	Draining          bool       `json:"draining"`           // 是否正在排空（排空中的节点不再接受新连接）
+	Phase             DrainPhase `json:"phase"`              // 排空阶段
+	StartedAt         int64      `json:"started_at"`         // 开始时间（秒）
+	FinishedAt        int64      `json:"finished_at"`        // 完成时间（秒）
+	TotalConns        int        `json:"total_conns"`        // 需要断开的连接数
+	DisconnectedConns int        `json:"disconnected_conns"` // 已发送断开的连接数
+	RemainingConns    int        `json:"remaining_conns"`    // 节点剩余的连接数
+	PendingRetries    int        `json:"pending_retries"`    // 正在重试的消息数
+}
+
+// NodeIMAddr 节点的长连接地址（/route 返回的地址）
+type NodeIMAddr struct {
+	NodeId   uint64 `json:"node_id"`
+	TCPAddr  string `json:"tcp_addr"`
+	WSAddr   string `json:"ws_addr"`
+	WSSAddr  string `json:"wss_addr"`
+	Draining bool   `json:"draining"` // 节点是否正在排空
+}
+
+// DrainDisconnectReason 排空断开连接时，disconnect包里携带的原因（json格式），客户端可以直接连接建议的地址
+type DrainDisconnectReason struct {
+	Reason  string `json:"reason"`
+	NodeId  uint64 `json:"node_id,omitempty"`
+	TCPAddr string `json:"tcp_addr,omitempty"`
+	WSAddr  string `json:"ws_addr,omitempty"`
+	WSSAddr string `json:"wss_addr,omitempty"`
+}
@@ -0,0 +1,36 @@
+package types
+
+// FederationMessagesReq 联邦同步消息请求（源集群推送给对端集群）
+type FederationMessagesReq struct {
+	SourceClusterId string               `json:"source_cluster_id"` // 源集群的联邦ID
+	Messages        []*FederationMessage `json:"messages"`          // 按源集群频道内顺序排列的消息
+}
+
+// FederationMessage 联邦同步的消息
+type FederationMessage struct {
+	Header      MessageHeader `json:"header"`        // 消息头
+	MessageId   int64         `json:"message_id"`    // 源集群的消息ID
+	MessageSeq  uint32        `json:"message_seq"`   // 源集群的消息序号
+	ClientMsgNo string        `json:"client_msg_no"` // 源集群的客户端消息编号
+	StreamNo    string        `json:"stream_no"`     // 消息流编号
+	FromUid     string        `json:"from_uid"`      // 发送者
+	ChannelId   string        `json:"channel_id"`    // 源集群的频道ID（个人频道为fake频道ID）
+	ChannelType uint8         `json:"channel_type"`  // 频道类型
+	Expire      uint32        `json:"expire"`        // 消息过期时间
+	Timestamp   int32         `json:"timestamp"`     // 源集群的消息时间
+	Payload     []byte        `json:"payload"`       // 消息内容
+}
+
+// FederationMessagesResp 联邦同步消息响应
+// 对端按顺序处理，某条消息处理失败时返回已处理消息的映射和失败的消息，之后的消息不再处理
+type FederationMessagesResp struct {
+	Messages        []*FederationMessageMapping `json:"messages"`                    // 消息ID映射
+	FailedMessageId int64                       `json:"failed_message_id,omitempty"` // 处理失败的源集群消息ID
+	Error           string                      `json:"error,omitempty"`             // 处理失败的原因
+}
+
+// FederationMessageMapping 源集群消息ID与本集群消息ID的映射
+type FederationMessageMapping struct {
+	SourceMessageId int64 `json:"source_message_id"` // 源集群的消息ID
+	MessageId       int64 `json:"message_id"`        // 本集群的消息ID
+}
@@ -0,0 +1,32 @@
+package types
+
+// PresenceState 用户自定义的在线状态
+type PresenceState string
+
+const (
+	PresenceStateAvailable PresenceState = ""     // 正常
+	PresenceStateAway      PresenceState = "away" // 离开
+	PresenceStateBusy      PresenceState = "busy" // 忙碌
+)
+
+func (p PresenceState) Valid() bool {
+	switch p {
+	case PresenceStateAvailable, PresenceStateAway, PresenceStateBusy:
+		return true
+	}
+	return false
+}
+
+// PresenceCmd 在线状态变化推送的cmd
+const PresenceCmd = "presence"
+
+// Presence 用户的在线状态
+type Presence struct {
+	Uid         string        `json:"uid"`
+	Online      int           `json:"online"`       // 是否在线（任意设备在线即为在线）
+	DeviceFlags []uint8       `json:"device_flags"` // 在线的设备
+	State       PresenceState `json:"state"`        // 自定义状态 away:离开 busy:忙碌 为空表示正常
+	StatusText  string        `json:"status_text"`  // 自定义状态文字
+	Emoji       string        `json:"emoji"`        // 自定义状态表情
+	LastSeen    int64         `json:"last_seen"`    // 最后上线或下线的时间（秒）
+}
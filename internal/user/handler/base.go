@@ -4,23 +4,38 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkserver/proto"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
+	// 设备登录信息记录
+	deviceLogin *deviceLoginRecorder
 	wklog.Log
 }
 
 func NewHandler() *Handler {
 	h := &Handler{
+		deviceLogin: newDeviceLoginRecorder(func(d wkdb.Device) error {
+			return service.Store.UpdateDeviceLogin(d)
+		}),
 		Log: wklog.NewWKLog("handler"),
 	}
 	h.routes()
 	return h
 }
 
+func (h *Handler) Start() error {
+	h.deviceLogin.start()
+	return nil
+}
+
+func (h *Handler) Stop() {
+	h.deviceLogin.stop()
+}
+
 func (h *Handler) routes() {
 	// 连接事件
 	eventbus.RegisterUserHandlers(eventbus.EventConnect, h.connect)
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"go.uber.org/zap"
+)
+
+const (
+	// 最多等待记录的设备数量，超过后新设备的登录信息直接丢弃
+	deviceLoginMaxPending = 10000
+	// 同时提交的设备登录信息数量
+	deviceLoginConcurrency = 16
+)
+
+// 设备登录信息记录器
+// 登录信息只是统计用途，放入有界的等待队列，同一设备多次登录只记录最后一次，由固定数量的协程提交，
+// 避免登录风暴时每次登录都启动一个协程去提议
+type deviceLoginRecorder struct {
+	mu      sync.Mutex
+	pending map[string]wkdb.Device // uid@deviceFlag -> 最后一次登录信息
+
+	update  func(d wkdb.Device) error
+	notifyC chan struct{}
+	stopC   chan struct{}
+	wklog.Log
+}
+
+func newDeviceLoginRecorder(update func(d wkdb.Device) error) *deviceLoginRecorder {
+	return &deviceLoginRecorder{
+		pending: make(map[string]wkdb.Device),
+		update:  update,
+		notifyC: make(chan struct{}, 1),
+		stopC:   make(chan struct{}),
+		Log:     wklog.NewWKLog("deviceLoginRecorder"),
+	}
+}
+
+func (r *deviceLoginRecorder) start() {
+	go r.loop()
+}
+
+func (r *deviceLoginRecorder) stop() {
+	close(r.stopC)
+}
+
+// 添加设备的登录信息，不阻塞调用方
+func (r *deviceLoginRecorder) add(d wkdb.Device) {
+	k := fmt.Sprintf("%s@%d", d.Uid, d.DeviceFlag)
+	r.mu.Lock()
+	if _, ok := r.pending[k]; !ok && len(r.pending) >= deviceLoginMaxPending {
+		r.mu.Unlock()
+		r.Warn("too many pending device logins, drop it", zap.String("uid", d.Uid), zap.Uint64("deviceFlag", d.DeviceFlag))
+		return
+	}
+	r.pending[k] = d
+	r.mu.Unlock()
+
+	select {
+	case r.notifyC <- struct{}{}:
+	default:
+	}
+}
+
+// 取出所有等待记录的登录信息
+func (r *deviceLoginRecorder) take() []wkdb.Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	devices := make([]wkdb.Device, 0, len(r.pending))
+	for _, d := range r.pending {
+		devices = append(devices, d)
+	}
+	r.pending = make(map[string]wkdb.Device)
+	return devices
+}
+
+func (r *deviceLoginRecorder) loop() {
+	for {
+		select {
+		case <-r.notifyC:
+			r.flush(r.take())
+		case <-r.stopC:
+			return
+		}
+	}
+}
+
+// 并发提交登录信息，并发数不超过deviceLoginConcurrency
+func (r *deviceLoginRecorder) flush(devices []wkdb.Device) {
+	if len(devices) == 0 {
+		return
+	}
+	var (
+		wg     sync.WaitGroup
+		limitC = make(chan struct{}, deviceLoginConcurrency)
+	)
+	for _, d := range devices {
+		limitC <- struct{}{}
+		wg.Add(1)
+		go func(d wkdb.Device) {
+			defer func() {
+				<-limitC
+				wg.Done()
+			}()
+			if err := r.update(d); err != nil {
+				r.Warn("update device login failed", zap.Error(err), zap.String("uid", d.Uid))
+			}
+		}(d)
+	}
+	wg.Wait()
+}
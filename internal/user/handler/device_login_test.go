@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceLoginRecorder(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		updated = make(map[string]wkdb.Device)
+	)
+	r := newDeviceLoginRecorder(func(d wkdb.Device) error {
+		mu.Lock()
+		defer mu.Unlock()
+		updated[fmt.Sprintf("%s@%d", d.Uid, d.DeviceFlag)] = d
+		return nil
+	})
+
+	// 同一设备多次登录只记录最后一次
+	r.add(wkdb.Device{Uid: "u1", DeviceFlag: 1, LastLoginIp: "1.1.1.1"})
+	r.add(wkdb.Device{Uid: "u1", DeviceFlag: 1, LastLoginIp: "2.2.2.2"})
+	r.add(wkdb.Device{Uid: "u1", DeviceFlag: 2, LastLoginIp: "3.3.3.3"})
+	devices := r.take()
+	assert.Len(t, devices, 2)
+	r.flush(devices)
+	assert.Equal(t, "2.2.2.2", updated["u1@1"].LastLoginIp)
+	assert.Equal(t, "3.3.3.3", updated["u1@2"].LastLoginIp)
+
+	// 等待队列满了后新设备直接丢弃
+	for i := 0; i < deviceLoginMaxPending+10; i++ {
+		r.add(wkdb.Device{Uid: fmt.Sprintf("u%d", i), DeviceFlag: 1})
+	}
+	assert.Len(t, r.take(), deviceLoginMaxPending)
+
+	// 启动后自动提交
+	r.start()
+	defer r.stop()
+	r.add(wkdb.Device{Uid: "u2", DeviceFlag: 0, LastLoginIp: "4.4.4.4"})
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return updated["u2@0"].LastLoginIp == "4.4.4.4"
+	}, time.Second, time.Millisecond*10)
+}
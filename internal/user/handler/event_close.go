@@ -41,6 +41,8 @@ func (h *Handler) closeConn(ctx *eventbus.UserContext) {
 func (h *Handler) removeConn(ctx *eventbus.UserContext) {
 	for _, event := range ctx.Events {
 		eventbus.User.RemoveConn(event.Conn)
+		// 在线状态
+		service.PresenceManager.ConnRemoved(event.Conn.Uid)
 	}
 
 }
@@ -8,9 +8,11 @@ import (
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
 	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -18,11 +20,14 @@ func (h *Handler) connect(ctx *eventbus.UserContext) {
 	for _, event := range ctx.Events {
 		conn := event.Conn
 		uid := event.Conn.Uid
+		start := time.Now()
 		reasonCode, packet, err := h.handleConnect(event)
 		if err != nil {
 			h.Error("handle connect err", zap.Error(err))
 			return
 		}
+		// 记录连接处理（认证、设备保存等）的span，连接事件没有轨迹，从开始处理计时
+		trace.RecordSpan(event.TraceContext, "Connect", start, attribute.String("uid", uid), attribute.String("device.id", conn.DeviceId), attribute.String("reason", reasonCode.String()))
 		if reasonCode == wkproto.ReasonSuccess {
 			ctx.AddConn(conn)
 		}
@@ -53,6 +58,7 @@ func (h *Handler) handleConnect(event *eventbus.Event) (wkproto.ReasonCode, *wkp
 		connectPacket = event.Frame.(*wkproto.ConnectPacket)
 		devceLevel    wkproto.DeviceLevel
 		uid           = connectPacket.UID
+		loginDevice   = wkdb.EmptyDevice // 登录的设备
 	)
 	// -------------------- token verify --------------------
 	if connectPacket.UID == options.G.ManagerUID {
@@ -76,6 +82,7 @@ func (h *Handler) handleConnect(event *eventbus.Event) (wkproto.ReasonCode, *wkp
 			return wkproto.ReasonAuthFail, nil, errors.New("token verify fail")
 		}
 		devceLevel = wkproto.DeviceLevel(device.DeviceLevel)
+		loginDevice = device
 	} else {
 		devceLevel = wkproto.DeviceLevelSlave // 默认都是slave设备
 	}
@@ -190,11 +197,16 @@ func (h *Handler) handleConnect(event *eventbus.Event) (wkproto.ReasonCode, *wkp
 		NodeId:        options.G.Cluster.NodeId,
 	}
 	connack.HasServerVersion = hasServerVersion
+
+	// -------------------- record device login --------------------
+	h.recordDeviceLogin(loginDevice, conn, connectPacket)
 	// -------------------- user online --------------------
 	// 在线webhook
 	deviceOnlineCount := eventbus.User.ConnCountByDeviceFlag(uid, connectPacket.DeviceFlag)
 	totalOnlineCount := eventbus.User.ConnCountByUid(uid)
-	service.Webhook.Online(uid, connectPacket.DeviceFlag, conn.ConnId, deviceOnlineCount, totalOnlineCount)
+	service.Webhook.Online(uid, connectPacket.DeviceFlag, conn.ConnId, deviceOnlineCount, totalOnlineCount, conn.RemoteIp())
+	// 在线状态
+	service.PresenceManager.Online(uid)
 
 	return wkproto.ReasonSuccess, connack, nil
 }
@@ -218,3 +230,32 @@ func (u *Handler) getClientAesKeyAndIV(clientKey string, dhServerPrivKey [32]byt
 	aesKey := wkutil.MD5(base64.StdEncoding.EncodeToString(shareKey[:]))[:16]
 	return []byte(aesKey), []byte(aesIV), nil
 }
+
+// 记录设备的最后一次登录信息（失败不影响登录）
+func (h *Handler) recordDeviceLogin(device wkdb.Device, conn *eventbus.Conn, connectPacket *wkproto.ConnectPacket) {
+	if connectPacket.UID == options.G.ManagerUID {
+		return
+	}
+	if device.Id == 0 {
+		var err error
+		device, err = service.Store.GetDevice(connectPacket.UID, connectPacket.DeviceFlag)
+		if err != nil {
+			if err != wkdb.ErrNotFound {
+				h.Warn("recordDeviceLogin: get device failed", zap.Error(err), zap.String("uid", connectPacket.UID))
+			}
+			return
+		}
+	}
+	loginIp := conn.RemoteIp()
+	now := time.Now()
+	loginDevice := wkdb.Device{
+		Id:          device.Id,
+		Uid:         device.Uid,
+		DeviceFlag:  device.DeviceFlag,
+		LastLoginAt: &now,
+		LastLoginIp: loginIp,
+		DeviceId:    connectPacket.DeviceID,
+		Version:     connectPacket.Version,
+	}
+	h.deviceLogin.add(loginDevice)
+}
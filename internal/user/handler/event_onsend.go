@@ -31,7 +31,7 @@ func (h *Handler) onSend(ctx *eventbus.UserContext) {
 func (h *Handler) handleOnSend(event *eventbus.Event) {
 
 	// 记录消息路径
-	event.Track.Record(track.PositionUserOnSend)
+	event.Record(track.PositionUserOnSend)
 
 	conn := event.Conn
 
@@ -63,11 +63,12 @@ func (h *Handler) handleOnSend(event *eventbus.Event) {
 	trace.GlobalTrace.Metrics.App().SendPacketBytesAdd(sendPacket.GetFrameSize())
 	// 添加消息到频道
 	eventbus.Channel.SendMessage(fakeChannelId, channelType, &eventbus.Event{
-		Type:      eventbus.EventChannelOnSend,
-		Conn:      conn,
-		Frame:     sendPacket,
-		MessageId: event.MessageId,
-		Track:     event.Track,
+		Type:         eventbus.EventChannelOnSend,
+		Conn:         conn,
+		Frame:        sendPacket,
+		MessageId:    event.MessageId,
+		Track:        event.Track,
+		TraceContext: event.TraceContext,
 	})
 	// 推进
 	eventbus.Channel.Advance(fakeChannelId, channelType)
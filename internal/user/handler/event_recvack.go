@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/WuKongIM/WuKongIM/internal/eventbus"
+	"github.com/WuKongIM/WuKongIM/internal/options"
 	"github.com/WuKongIM/WuKongIM/internal/service"
 	"github.com/WuKongIM/WuKongIM/internal/track"
 	"github.com/WuKongIM/WuKongIM/pkg/trace"
@@ -14,7 +15,16 @@ func (h *Handler) recvack(event *eventbus.Event) {
 	recvackPacket := event.Frame.(*wkproto.RecvackPacket)
 	persist := !recvackPacket.NoPersist // 是否需要持久化
 	// 记录消息路径
-	event.Track.Record(track.PositionUserRecvack)
+	event.Record(track.PositionUserRecvack)
+	if options.G.Logger.TraceOn {
+		record := track.NewRecord(track.ActionRecvack, options.G.Cluster.NodeId, event.Track)
+		record.MessageId = recvackPacket.MessageID
+		record.MessageSeq = uint64(recvackPacket.MessageSeq)
+		record.Uid = event.Conn.Uid
+		record.DeviceId = event.Conn.DeviceId
+		record.ConnId = event.Conn.ConnId
+		track.Records.Add(record)
+	}
 
 	trace.GlobalTrace.Metrics.App().RecvackPacketCountAdd(1)
 	trace.GlobalTrace.Metrics.App().RecvackPacketBytesAdd(recvackPacket.GetFrameSize())
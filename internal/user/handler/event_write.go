@@ -41,7 +41,7 @@ func (h *Handler) writeLocalFrame(event *eventbus.Event) {
 	}
 
 	// 记录消息路径
-	event.Track.Record(track.PositionConnWrite)
+	event.Record(track.PositionConnWrite)
 
 	// 统计发送消息数（TODO: 这里不准，暂时视包数为消息数）
 	conn.OutMsgCount.Add(1)
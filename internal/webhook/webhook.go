@@ -113,25 +113,33 @@ func (w *Webhook) Stop() {
 }
 
 // Online 用户设备上线通知
-func (w *Webhook) Online(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int) {
+func (w *Webhook) Online(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int, remoteIp string) {
 	w.onlinestatusLock.Lock()
 	defer w.onlinestatusLock.Unlock()
 	online := 1
-	w.onlinestatusList = append(w.onlinestatusList, fmt.Sprintf("%s-%d-%d-%d-%d-%d", uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount))
+	w.onlinestatusList = append(w.onlinestatusList, onlineStatus(uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount, remoteIp))
 
 	w.Debug("User online", zap.String("uid", uid), zap.String("deviceFlag", deviceFlag.String()), zap.Int64("id", connId))
 }
 
-func (w *Webhook) Offline(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int) {
+func (w *Webhook) Offline(uid string, deviceFlag wkproto.DeviceFlag, connId int64, deviceOnlineCount int, totalOnlineCount int, remoteIp string) {
 	w.onlinestatusLock.Lock()
 	defer w.onlinestatusLock.Unlock()
 	online := 0
-	// 用户ID-用户设备标记-在线状态-socket ID-当前设备标记下的设备在线数量-当前用户下的所有设备在线数量
-	w.onlinestatusList = append(w.onlinestatusList, fmt.Sprintf("%s-%d-%d-%d-%d-%d", uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount))
+	w.onlinestatusList = append(w.onlinestatusList, onlineStatus(uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount, remoteIp))
 
 	w.Debug("User offline", zap.String("uid", uid), zap.String("deviceFlag", deviceFlag.String()))
 }
 
+// 用户ID-用户设备标记-在线状态-socket ID-当前设备标记下的设备在线数量-当前用户下的所有设备在线数量
+// 开启了webhook.onlineStatusWithIp时末尾追加 -客户端ip
+func onlineStatus(uid string, deviceFlag wkproto.DeviceFlag, online int, connId int64, deviceOnlineCount int, totalOnlineCount int, remoteIp string) string {
+	if options.G.Webhook.OnlineStatusWithIp {
+		return fmt.Sprintf("%s-%d-%d-%d-%d-%d-%s", uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount, remoteIp)
+	}
+	return fmt.Sprintf("%s-%d-%d-%d-%d-%d", uid, deviceFlag, online, connId, deviceOnlineCount, totalOnlineCount)
+}
+
 // TriggerEvent 触发事件
 func (w *Webhook) TriggerEvent(event *types.Event) {
 	if !options.G.WebhookOn(event.Event) { // 没设置webhook直接忽略
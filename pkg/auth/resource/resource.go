@@ -4,24 +4,41 @@ type Id string
 
 // 槽位资源
 var Slot = slot{
-	Migrate: "slotMigrate", // 迁移槽位
+	Migrate:       "slotMigrate",       // 迁移槽位
+	Resize:        "slotResize",        // 调整槽位数量
+	ForceReconfig: "slotForceReconfig", // 强制重新配置槽副本（多数副本永久丢失时使用）
+}
+
+// 节点资源
+var Node = node{
+	Maintenance: "nodeMaintenance", // 节点维护模式
 }
 
 // 频道资源
 var ClusterChannel = channel{
-	Migrate: "clusterchannelMigrate", // 迁移频道
-	Start:   "clusterchannelStart",   // 启动频道
-	Stop:    "clusterchannelStop",    // 停止频道
+	Migrate:        "clusterchannelMigrate",        // 迁移频道
+	Start:          "clusterchannelStart",          // 启动频道
+	Stop:           "clusterchannelStop",           // 停止频道
+	TransferLeader: "clusterchannelTransferLeader", // 转移频道领导
+	ForceReconfig:  "clusterchannelForceReconfig",  // 强制重新配置频道副本（多数副本永久丢失时使用）
 }
 
 type slot struct {
-	Migrate Id
+	Migrate       Id
+	Resize        Id
+	ForceReconfig Id
+}
+
+type node struct {
+	Maintenance Id
 }
 
 type channel struct {
-	Migrate Id
-	Start   Id
-	Stop    Id
+	Migrate        Id
+	Start          Id
+	Stop           Id
+	TransferLeader Id
+	ForceReconfig  Id
 }
 
 var All Id = "*"
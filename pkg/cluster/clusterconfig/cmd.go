@@ -21,6 +21,11 @@ const (
 	CMDTypeSlotMigrate                       // 槽迁移
 	CMDTypeSlotUpdate                        // 槽更新
 	CMDTypeNodeStatusChange                  // 节点状态改变
+	CMDTypeNodeZoneChange                    // 节点可用区变更
+	CMDTypeSlotResizeStart                   // 开始调整槽数量
+	CMDTypeSlotResizeEnd                     // 结束调整槽数量
+	CMDTypeNodeMaintenanceChange             // 节点维护模式变更
+	CMDTypeSlotResizeFreeze                  // 冻结调整槽数量的源槽
 
 )
 
@@ -48,6 +53,16 @@ func (c CMDType) String() string {
 		return "CMDTypeSlotUpdate"
 	case CMDTypeNodeStatusChange:
 		return "CMDTypeNodeStatusChange"
+	case CMDTypeNodeZoneChange:
+		return "CMDTypeNodeZoneChange"
+	case CMDTypeSlotResizeStart:
+		return "CMDTypeSlotResizeStart"
+	case CMDTypeSlotResizeEnd:
+		return "CMDTypeSlotResizeEnd"
+	case CMDTypeNodeMaintenanceChange:
+		return "CMDTypeNodeMaintenanceChange"
+	case CMDTypeSlotResizeFreeze:
+		return "CMDTypeSlotResizeFreeze"
 	}
 	return "CMDTypeUnknown"
 }
@@ -169,6 +184,37 @@ func (c *CMD) CMDContent() (string, error) {
 			"nodeId": nodeId,
 			"status": status,
 		}), nil
+	case CMDTypeNodeZoneChange:
+		nodeId, zone, err := DecodeNodeZoneChange(c.Data)
+		if err != nil {
+			return "", err
+		}
+		return wkutil.ToJSON(map[string]interface{}{
+			"nodeId": nodeId,
+			"zone":   zone,
+		}), nil
+	case CMDTypeSlotResizeStart:
+		slotCount, slots, err := DecodeSlotResizeStart(c.Data)
+		if err != nil {
+			return "", err
+		}
+		return wkutil.ToJSON(map[string]interface{}{
+			"slotCount": slotCount,
+			"slots":     slots,
+		}), nil
+	case CMDTypeSlotResizeEnd:
+		return wkutil.ToJSON(map[string]interface{}{
+			"slotCount": binary.BigEndian.Uint32(c.Data),
+		}), nil
+	case CMDTypeNodeMaintenanceChange:
+		nodeId, maintenance, err := DecodeNodeMaintenanceChange(c.Data)
+		if err != nil {
+			return "", err
+		}
+		return wkutil.ToJSON(map[string]interface{}{
+			"nodeId":      nodeId,
+			"maintenance": maintenance,
+		}), nil
 	}
 
 	return "", nil
@@ -193,6 +239,54 @@ func DecodeApiServerAddrChange(data []byte) (uint64, string, error) {
 	return nodeId, apiServerAddr, err
 }
 
+func EncodeNodeZoneChange(nodeId uint64, zone string) ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(nodeId)
+	enc.WriteString(zone)
+	return enc.Bytes(), nil
+}
+
+func DecodeNodeZoneChange(data []byte) (uint64, string, error) {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	var nodeId uint64
+	if nodeId, err = dec.Uint64(); err != nil {
+		return 0, "", err
+	}
+	zone, err := dec.String()
+	return nodeId, zone, err
+}
+
+func EncodeSlotResizeStart(slotCount uint32, slots []*pb.Slot) ([]byte, error) {
+	slotData, err := pb.SlotSet(slots).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint32(slotCount)
+	enc.WriteBytes(slotData)
+	return enc.Bytes(), nil
+}
+
+func DecodeSlotResizeStart(data []byte) (uint32, []*pb.Slot, error) {
+	dec := wkproto.NewDecoder(data)
+	slotCount, err := dec.Uint32()
+	if err != nil {
+		return 0, nil, err
+	}
+	slotData, err := dec.BinaryAll()
+	if err != nil {
+		return 0, nil, err
+	}
+	slots := pb.SlotSet{}
+	if err := slots.Unmarshal(slotData); err != nil {
+		return 0, nil, err
+	}
+	return slotCount, slots, nil
+}
+
 func EncodeNodeOnlineStatusChange(nodeId uint64, online bool) ([]byte, error) {
 	enc := wkproto.NewEncoder()
 	defer enc.End()
@@ -212,6 +306,25 @@ func DecodeNodeOnlineStatusChange(data []byte) (uint64, bool, error) {
 	return nodeId, wkutil.Uint8ToBool(online), err
 }
 
+func EncodeNodeMaintenanceChange(nodeId uint64, maintenance bool) ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(nodeId)
+	enc.WriteUint8(wkutil.BoolToUint8(maintenance))
+	return enc.Bytes(), nil
+}
+
+func DecodeNodeMaintenanceChange(data []byte) (uint64, bool, error) {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	var nodeId uint64
+	if nodeId, err = dec.Uint64(); err != nil {
+		return 0, false, err
+	}
+	maintenance, err := dec.Uint8()
+	return nodeId, wkutil.Uint8ToBool(maintenance), err
+}
+
 func EncodeMigrateSlot(slotId uint32, fromNodeId, toNodeId uint64) ([]byte, error) {
 	enc := wkproto.NewEncoder()
 	defer enc.End()
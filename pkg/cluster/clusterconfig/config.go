@@ -182,13 +182,24 @@ func (c *Config) updateApiServerAddr(nodeId uint64, addr string) {
 	}
 }
 
+func (c *Config) updateNodeZone(nodeId uint64, zone string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, node := range c.cfg.Nodes {
+		if node.Id == nodeId {
+			node.Zone = zone
+			return
+		}
+	}
+}
+
 func (c *Config) updateNodeOnlineStatus(nodeId uint64, online bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	for _, node := range c.cfg.Nodes {
 		if node.Id == nodeId {
 			node.Online = online
-			if !online {
+			if !online && !node.Maintenance { // 维护中的节点下线是预期内的，不计入离线次数
 				node.OfflineCount++
 				node.LastOffline = time.Now().Unix()
 			}
@@ -197,6 +208,17 @@ func (c *Config) updateNodeOnlineStatus(nodeId uint64, online bool) {
 	}
 }
 
+func (c *Config) updateNodeMaintenance(nodeId uint64, maintenance bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, node := range c.cfg.Nodes {
+		if node.Id == nodeId {
+			node.Maintenance = maintenance
+			return
+		}
+	}
+}
+
 func (c *Config) updateNodeJoining(nodeId uint64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -207,6 +229,11 @@ func (c *Config) updateNodeJoining(nodeId uint64) {
 		}
 	}
 	c.cfg.Learners = wkutil.RemoveUint64(c.cfg.Learners, nodeId)
+	// 节点已经追上配置日志，结束迁移（不允许投票的节点会一直作为学习者，不清空的话会反复触发学习者转换）
+	if c.cfg.MigrateFrom == nodeId && c.cfg.MigrateTo == nodeId {
+		c.cfg.MigrateFrom = 0
+		c.cfg.MigrateTo = 0
+	}
 }
 
 func (c *Config) updateNodeJoined(nodeId uint64, slots []*pb.Slot) {
@@ -265,6 +292,61 @@ func (c *Config) updateSlots(slots []*pb.Slot) {
 	}
 }
 
+// 开始调整槽数量，添加新的槽（源槽在回放期间继续写入，最后追赶时才冻结）
+func (c *Config) updateSlotResizeStart(slotCount uint32, addSlots []*pb.Slot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, addSlot := range addSlots {
+		exist := false
+		for _, slot := range c.cfg.Slots {
+			if slot.Id == addSlot.Id {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			c.cfg.Slots = append(c.cfg.Slots, addSlot)
+		}
+	}
+	c.cfg.ResizeSlotCount = slotCount
+}
+
+// 冻结需要导出数据的源槽（暂停写入），等待最后的追赶回放完成后切换
+func (c *Config) updateSlotResizeFreeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cfg.ResizeSlotCount == 0 {
+		return
+	}
+	for _, slot := range c.cfg.Slots {
+		if len(pb.SlotResizeTargets(slot.Id, c.cfg.SlotCount, c.cfg.ResizeSlotCount)) > 0 {
+			slot.Status = pb.SlotStatus_SlotStatusResizing
+		}
+	}
+}
+
+// 结束调整槽数量，切换到新的槽数量并移除多余的槽
+func (c *Config) updateSlotResizeEnd(slotCount uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slots := make([]*pb.Slot, 0, slotCount)
+	for _, slot := range c.cfg.Slots {
+		if slot.Id >= slotCount {
+			continue
+		}
+		if slot.Status == pb.SlotStatus_SlotStatusResizing {
+			slot.Status = pb.SlotStatus_SlotStatusNormal
+		}
+		slots = append(slots, slot)
+	}
+	c.cfg.Slots = slots
+	c.cfg.SlotCount = slotCount
+	c.cfg.ResizeSlotCount = 0
+}
+
 func (c *Config) updateNodeStatus(nodeId uint64, status pb.NodeStatus) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,100 @@
+package clusterconfig
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotResizeFreeze(t *testing.T) {
+	c := &Config{
+		cfg: &pb.Config{
+			SlotCount: 2,
+			Slots: []*pb.Slot{
+				{Id: 0, Leader: 1, Replicas: []uint64{1, 2}},
+				{Id: 1, Leader: 2, Replicas: []uint64{1, 2}},
+			},
+		},
+	}
+
+	// 开始调整时源槽继续写入
+	c.updateSlotResizeStart(4, []*pb.Slot{
+		{Id: 2, Leader: 1, Replicas: []uint64{1, 2}},
+		{Id: 3, Leader: 2, Replicas: []uint64{1, 2}},
+	})
+	assert.Equal(t, uint32(4), c.cfg.ResizeSlotCount)
+	assert.Len(t, c.cfg.Slots, 4)
+	for _, slot := range c.cfg.Slots {
+		assert.Equal(t, pb.SlotStatus_SlotStatusNormal, slot.Status)
+	}
+
+	// 冻结后只有源槽暂停写入
+	c.updateSlotResizeFreeze()
+	assert.Equal(t, pb.SlotStatus_SlotStatusResizing, c.cfg.Slots[0].Status)
+	assert.Equal(t, pb.SlotStatus_SlotStatusResizing, c.cfg.Slots[1].Status)
+	assert.Equal(t, pb.SlotStatus_SlotStatusNormal, c.cfg.Slots[2].Status)
+	assert.Equal(t, pb.SlotStatus_SlotStatusNormal, c.cfg.Slots[3].Status)
+
+	// 切换后解除冻结
+	c.updateSlotResizeEnd(4)
+	assert.Equal(t, uint32(4), c.cfg.SlotCount)
+	assert.Equal(t, uint32(0), c.cfg.ResizeSlotCount)
+	for _, slot := range c.cfg.Slots {
+		assert.Equal(t, pb.SlotStatus_SlotStatusNormal, slot.Status)
+	}
+
+	// 没有在调整时冻结不生效
+	c.updateSlotResizeFreeze()
+	for _, slot := range c.cfg.Slots {
+		assert.Equal(t, pb.SlotStatus_SlotStatusNormal, slot.Status)
+	}
+}
+
+func TestUpdateNodeOnlineStatus(t *testing.T) {
+	c := &Config{
+		cfg: &pb.Config{
+			Nodes: []*pb.Node{
+				{Id: 1, Online: true},
+				{Id: 2, Online: true, Maintenance: true},
+			},
+		},
+	}
+
+	// 普通节点下线计入离线次数
+	c.updateNodeOnlineStatus(1, false)
+	assert.False(t, c.cfg.Nodes[0].Online)
+	assert.Equal(t, uint32(1), c.cfg.Nodes[0].OfflineCount)
+	assert.NotZero(t, c.cfg.Nodes[0].LastOffline)
+
+	// 维护中的节点下线不计入离线次数
+	c.updateNodeOnlineStatus(2, false)
+	assert.False(t, c.cfg.Nodes[1].Online)
+	assert.Equal(t, uint32(0), c.cfg.Nodes[1].OfflineCount)
+	assert.Zero(t, c.cfg.Nodes[1].LastOffline)
+
+	// 重新上线
+	c.updateNodeOnlineStatus(2, true)
+	assert.True(t, c.cfg.Nodes[1].Online)
+	assert.Equal(t, uint32(0), c.cfg.Nodes[1].OfflineCount)
+}
+
+func TestReplicaConfigOf(t *testing.T) {
+	cfg := &pb.Config{
+		Term:    2,
+		Version: 10,
+		Nodes: []*pb.Node{
+			{Id: 1, AllowVote: true},
+			{Id: 2, AllowVote: true},
+			{Id: 3, AllowVote: false, Role: pb.NodeRole_NodeRoleProxy}, // 代理节点
+			{Id: 4, AllowVote: true},
+		},
+		Learners: []uint64{4},
+	}
+	replicaCfg := replicaConfigOf(cfg)
+	// 不允许投票的节点只作为学习者
+	assert.Equal(t, []uint64{1, 2}, replicaCfg.Replicas)
+	assert.ElementsMatch(t, []uint64{4, 3}, replicaCfg.Learners)
+	assert.Equal(t, uint32(2), replicaCfg.Term)
+	assert.Equal(t, uint64(10), replicaCfg.Version)
+}
@@ -145,9 +145,80 @@ func (n *Node) Equal(v *Node) bool {
 	if n.ClusterAddr != v.ClusterAddr {
 		return false
 	}
+
+	if n.Zone != v.Zone {
+		return false
+	}
+
+	if n.Maintenance != v.Maintenance {
+		return false
+	}
 	return true
 }
 
+// SpreadNodesByZone 按可用区打散节点顺序，使排在前面的节点尽可能分布在不同的可用区（同可用区内保持原有顺序）
+// usedZones 为已经被占用的可用区，这些可用区的节点会尽量排在后面
+func SpreadNodesByZone(nodes []*Node, usedZones ...string) []*Node {
+	remaining := make([]*Node, len(nodes))
+	copy(remaining, nodes)
+
+	used := make(map[string]bool, len(usedZones))
+	for _, zone := range usedZones {
+		used[zone] = true
+	}
+
+	result := make([]*Node, 0, len(nodes))
+	for len(remaining) > 0 {
+		idx := -1
+		for i, node := range remaining {
+			if !used[node.Zone] {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 { // 所有可用区都已占用，开始新的一轮
+			used = make(map[string]bool)
+			idx = 0
+		}
+		node := remaining[idx]
+		used[node.Zone] = true
+		result = append(result, node)
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+	return result
+}
+
+// IsValidSlotResize 槽数量是否可以从slotCount调整为newSlotCount
+// 新的槽数量必须是原槽数量的整数倍（拆分）或者整除原槽数量（合并），这样每个槽的数据只会迁入确定的槽
+func IsValidSlotResize(slotCount, newSlotCount uint32) bool {
+	if slotCount == 0 || newSlotCount == 0 || slotCount == newSlotCount {
+		return false
+	}
+	if newSlotCount > slotCount {
+		return newSlotCount%slotCount == 0
+	}
+	return slotCount%newSlotCount == 0
+}
+
+// SlotResizeTargets 槽数量从slotCount调整为newSlotCount后，slotId的数据需要导入的目标槽（不包含自己）
+// 拆分时槽的数据会分布到 slotId + k*slotCount 的子槽，合并时 slotId >= newSlotCount 的槽会合并到 slotId % newSlotCount
+func SlotResizeTargets(slotId, slotCount, newSlotCount uint32) []uint32 {
+	if !IsValidSlotResize(slotCount, newSlotCount) {
+		return nil
+	}
+	var targets []uint32
+	if newSlotCount > slotCount {
+		for target := slotId + slotCount; target < newSlotCount; target += slotCount {
+			targets = append(targets, target)
+		}
+		return targets
+	}
+	if slotId >= newSlotCount {
+		targets = append(targets, slotId%newSlotCount)
+	}
+	return targets
+}
+
 type SlotSet []*Slot
 
 func (s SlotSet) Marshal() ([]byte, error) {
@@ -36,3 +36,47 @@ func TestSlotSetMarshal(t *testing.T) {
 	assert.Equal(t, len(slotSet), len(slotSet2))
 
 }
+
+func TestSpreadNodesByZone(t *testing.T) {
+	nodes := []*Node{
+		{Id: 1, Zone: "az1"},
+		{Id: 2, Zone: "az1"},
+		{Id: 3, Zone: "az2"},
+		{Id: 4, Zone: "az2"},
+		{Id: 5, Zone: "az3"},
+	}
+
+	var ids []uint64
+	for _, node := range SpreadNodesByZone(nodes) {
+		ids = append(ids, node.Id)
+	}
+	assert.Equal(t, []uint64{1, 3, 5, 2, 4}, ids)
+
+	// 已占用的可用区排在后面
+	ids = ids[:0]
+	for _, node := range SpreadNodesByZone(nodes, "az1") {
+		ids = append(ids, node.Id)
+	}
+	assert.Equal(t, []uint64{3, 5, 1, 4, 2}, ids)
+
+	// 节点序列化后可用区不丢失
+	data, err := nodes[0].Marshal()
+	assert.Nil(t, err)
+	node := &Node{}
+	err = node.Unmarshal(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "az1", node.Zone)
+}
+
+func TestSlotResizeTargets(t *testing.T) {
+	assert.True(t, IsValidSlotResize(64, 128))
+	assert.True(t, IsValidSlotResize(64, 32))
+	assert.False(t, IsValidSlotResize(64, 100))
+	assert.False(t, IsValidSlotResize(64, 64))
+
+	// 拆分
+	assert.Equal(t, []uint32{69, 133, 197}, SlotResizeTargets(5, 64, 256))
+	// 合并
+	assert.Equal(t, []uint32{5}, SlotResizeTargets(37, 64, 32))
+	assert.Nil(t, SlotResizeTargets(5, 64, 32))
+}
@@ -79,28 +79,37 @@ func New(opts *Options) *Server {
 
 func (s *Server) SwitchConfig(cfg *pb.Config) error {
 
+	err := s.configReactor.StepWait(s.handlerKey, replica.Message{
+		MsgType: replica.MsgConfigResp,
+		Config:  replicaConfigOf(cfg),
+	})
+	return err
+}
+
+// 分布式配置对应的副本配置
+func replicaConfigOf(cfg *pb.Config) replica.Config {
 	replicas := make([]uint64, 0, len(cfg.Nodes))
+	learners := make([]uint64, 0, len(cfg.Learners))
+	learners = append(learners, cfg.Learners...)
 	for _, node := range cfg.Nodes {
 		if len(cfg.Learners) > 0 && wkutil.ArrayContainsUint64(cfg.Learners, node.Id) {
 			continue
 		}
+		if !node.AllowVote { // 不允许投票的节点（比如代理节点）只作为学习者同步配置，不参与投票
+			learners = append(learners, node.Id)
+			continue
+		}
 		replicas = append(replicas, node.Id)
 	}
 
-	replicaCfg := replica.Config{
+	return replica.Config{
 		MigrateFrom: cfg.MigrateFrom,
 		MigrateTo:   cfg.MigrateTo,
-		Learners:    cfg.Learners,
+		Learners:    learners,
 		Replicas:    replicas,
 		Term:        cfg.Term,
 		Version:     cfg.Version,
 	}
-
-	err := s.configReactor.StepWait(s.handlerKey, replica.Message{
-		MsgType: replica.MsgConfigResp,
-		Config:  replicaCfg,
-	})
-	return err
 }
 
 func (s *Server) Start() error {
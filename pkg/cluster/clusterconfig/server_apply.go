@@ -69,6 +69,16 @@ func (s *Server) handleCmd(cmd *CMD) error {
 		return s.handleSlotUpdate(cmd)
 	case CMDTypeNodeStatusChange: // 节点状态改变
 		return s.handleNodeStatusChange(cmd)
+	case CMDTypeNodeZoneChange: // 节点可用区变更
+		return s.handleNodeZoneChange(cmd)
+	case CMDTypeSlotResizeStart: // 开始调整槽数量
+		return s.handleSlotResizeStart(cmd)
+	case CMDTypeSlotResizeFreeze: // 冻结调整槽数量的源槽
+		return s.handleSlotResizeFreeze(cmd)
+	case CMDTypeSlotResizeEnd: // 结束调整槽数量
+		return s.handleSlotResizeEnd(cmd)
+	case CMDTypeNodeMaintenanceChange: // 节点维护模式变更
+		return s.handleNodeMaintenanceChange(cmd)
 	}
 	return nil
 }
@@ -97,6 +107,47 @@ func (s *Server) handleApiServerAddrChange(cmd *CMD) error {
 	return nil
 }
 
+func (s *Server) handleNodeZoneChange(cmd *CMD) error {
+	nodeId, zone, err := DecodeNodeZoneChange(cmd.Data)
+	if err != nil {
+		s.Error("decode node zone change err", zap.Error(err))
+		return err
+	}
+
+	s.cfg.updateNodeZone(nodeId, zone)
+	return nil
+}
+
+func (s *Server) handleSlotResizeStart(cmd *CMD) error {
+	slotCount, addSlots, err := DecodeSlotResizeStart(cmd.Data)
+	if err != nil {
+		s.Error("decode slot resize start err", zap.Error(err))
+		return err
+	}
+	s.cfg.updateSlotResizeStart(slotCount, addSlots)
+	return nil
+}
+
+func (s *Server) handleSlotResizeFreeze(cmd *CMD) error {
+	s.cfg.updateSlotResizeFreeze()
+	return nil
+}
+
+func (s *Server) handleSlotResizeEnd(cmd *CMD) error {
+	s.cfg.updateSlotResizeEnd(binary.BigEndian.Uint32(cmd.Data))
+	return nil
+}
+
+func (s *Server) handleNodeMaintenanceChange(cmd *CMD) error {
+	nodeId, maintenance, err := DecodeNodeMaintenanceChange(cmd.Data)
+	if err != nil {
+		s.Error("decode node maintenance change err", zap.Error(err))
+		return err
+	}
+	s.cfg.updateNodeMaintenance(nodeId, maintenance)
+	return nil
+}
+
 func (s *Server) handleNodeJoin(cmd *CMD) error {
 
 	newNode := &pb.Node{}
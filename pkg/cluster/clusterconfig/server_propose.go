@@ -64,6 +64,62 @@ func (s *Server) ProposeApiServerAddr(nodeId uint64, apiServerAddr string) error
 	return nil
 }
 
+// ProposeNodeMaintenance 提案节点维护模式变更
+func (s *Server) ProposeNodeMaintenance(nodeId uint64, maintenance bool) error {
+
+	data, err := EncodeNodeMaintenanceChange(nodeId, maintenance)
+	if err != nil {
+		return err
+	}
+
+	cmd := NewCMD(CMDTypeNodeMaintenanceChange, data)
+	cmdBytes, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+
+	err = s.proposeAndWait([]replica.Log{
+		{
+			Id:   uint64(s.cfgGenId.Generate().Int64()),
+			Data: cmdBytes,
+		},
+	})
+	if err != nil {
+		s.Error("ProposeNodeMaintenance failed", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ProposeNodeZone 提案节点可用区变更
+func (s *Server) ProposeNodeZone(nodeId uint64, zone string) error {
+
+	data, err := EncodeNodeZoneChange(nodeId, zone)
+	if err != nil {
+		return err
+	}
+
+	cmd := NewCMD(CMDTypeNodeZoneChange, data)
+	cmdBytes, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+
+	err = s.proposeAndWait([]replica.Log{
+		{
+			Id:   uint64(s.cfgGenId.Generate().Int64()),
+			Data: cmdBytes,
+		},
+	})
+	if err != nil {
+		s.Error("ProposeNodeZone failed", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 // ProposeJoin 提案节点加入
 func (s *Server) ProposeJoin(node *pb.Node) error {
 
@@ -244,3 +300,69 @@ func (s *Server) ProposeNodeStatus(nodeId uint64, status pb.NodeStatus) error {
 	}
 	return nil
 }
+
+// ProposeSlotResizeStart 开始调整槽数量，addSlots为需要新增的槽（拆分时的子槽）
+func (s *Server) ProposeSlotResizeStart(slotCount uint32, addSlots []*pb.Slot) error {
+	data, err := EncodeSlotResizeStart(slotCount, addSlots)
+	if err != nil {
+		return err
+	}
+	cmd := NewCMD(CMDTypeSlotResizeStart, data)
+	cmdBytes, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+	err = s.proposeAndWait([]replica.Log{
+		{
+			Id:   uint64(s.cfgGenId.Generate().Int64()),
+			Data: cmdBytes,
+		},
+	})
+	if err != nil {
+		s.Error("ProposeSlotResizeStart failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ProposeSlotResizeFreeze 冻结调整槽数量的源槽（暂停写入），用于最后的追赶回放和切换
+func (s *Server) ProposeSlotResizeFreeze() error {
+	cmd := NewCMD(CMDTypeSlotResizeFreeze, nil)
+	cmdBytes, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+	err = s.proposeAndWait([]replica.Log{
+		{
+			Id:   uint64(s.cfgGenId.Generate().Int64()),
+			Data: cmdBytes,
+		},
+	})
+	if err != nil {
+		s.Error("ProposeSlotResizeFreeze failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ProposeSlotResizeEnd 结束调整槽数量，切换到slotCount（slotCount为原槽数量时表示取消调整）
+func (s *Server) ProposeSlotResizeEnd(slotCount uint32) error {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, slotCount)
+	cmd := NewCMD(CMDTypeSlotResizeEnd, data)
+	cmdBytes, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+	err = s.proposeAndWait([]replica.Log{
+		{
+			Id:   uint64(s.cfgGenId.Generate().Int64()),
+			Data: cmdBytes,
+		},
+	})
+	if err != nil {
+		s.Error("ProposeSlotResizeEnd failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package clusterevent
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -53,6 +54,13 @@ func (s *Server) checkClusterConfig() error {
 			return err
 		}
 
+		// 将维护中节点的槽领导迁移走
+		err = s.handleNodeMaintenance()
+		if err != nil {
+			s.Error("handleNodeMaintenance failed", zap.Error(err))
+			return err
+		}
+
 		// 检查和均衡槽领导
 		err = s.handleSlotLeaderAutoBalance()
 		if err != nil {
@@ -117,11 +125,14 @@ func (s *Server) handleClusterConfigInit() error {
 
 	nodes := make([]*pb.Node, 0, len(s.opts.InitNodes))
 
-	var replicas []uint64
 	for nodeId, addr := range s.opts.InitNodes {
 		apiAddr := ""
+		zone := s.opts.InitNodeZones[nodeId]
 		if nodeId == s.opts.NodeId {
 			apiAddr = s.opts.ApiServerAddr
+			if s.opts.Zone != "" {
+				zone = s.opts.Zone
+			}
 		}
 		nodes = append(nodes, &pb.Node{
 			Id:            nodeId,
@@ -132,11 +143,22 @@ func (s *Server) handleClusterConfigInit() error {
 			Role:          pb.NodeRole_NodeRoleReplica,
 			Status:        pb.NodeStatus_NodeStatusJoined,
 			CreatedAt:     time.Now().Unix(),
+			Zone:          zone,
 		})
-		replicas = append(replicas, nodeId)
 	}
 	cfg.Nodes = nodes
 
+	// 按可用区交错排列节点，使连续的节点尽量分布在不同的可用区，这样每个槽的副本会分散到不同的可用区
+	sortedNodes := make([]*pb.Node, len(nodes))
+	copy(sortedNodes, nodes)
+	sort.Slice(sortedNodes, func(i, j int) bool {
+		return sortedNodes[i].Id < sortedNodes[j].Id
+	})
+	var replicas []uint64
+	for _, node := range pb.SpreadNodesByZone(sortedNodes) {
+		replicas = append(replicas, node.Id)
+	}
+
 	if len(replicas) > 0 {
 		offset := 0
 		replicaCount := s.opts.SlotMaxReplicaCount
@@ -202,6 +224,18 @@ func (s *Server) handleCompareLocalClusterConfig() error {
 		}
 	}
 
+	// 如果配置里自己节点的可用区与配置的不同，则提案配置
+	if strings.TrimSpace(s.opts.Zone) != "" {
+		localNode := s.cfgServer.Node(s.opts.NodeId)
+		if localNode != nil && localNode.Zone != s.opts.Zone {
+			err := s.cfgServer.ProposeNodeZone(s.opts.NodeId, s.opts.Zone)
+			if err != nil {
+				s.Error("ProposeNodeZone failed", zap.Error(err))
+				return err
+			}
+		}
+	}
+
 	if s.IsLeader() {
 		// 节点在线状态改变
 		err := s.handleNodeOnlineStatusChange()
@@ -286,7 +320,7 @@ func (s *Server) autoBalanceSlotLeaders(cfg *pb.Config) []*pb.Slot {
 	var nodeOnline = func(nodeId uint64) bool {
 		for _, node := range cfg.Nodes {
 			if node.Id == nodeId {
-				return node.Online
+				return node.Online && !node.Maintenance // 维护中的节点不参与均衡
 			}
 		}
 		return false
@@ -349,9 +383,26 @@ func (s *Server) handleNodeJoining() error {
 		return nil
 	}
 
-	firstSlot := slots[0]
+	// 槽数量正在调整，等调整完成后再分配槽
+	if s.cfgServer.Config().ResizeSlotCount != 0 {
+		return nil
+	}
+
+	migrateSlots, needPropose := s.joiningMigrateSlots(joiningNode, slots)
+	if !needPropose {
+		return nil
+	}
+	return s.ProposeJoined(joiningNode.Id, migrateSlots)
+}
+
+// 计算加入中的节点需要迁入的槽，needPropose为true表示需要提案加入完成
+func (s *Server) joiningMigrateSlots(joiningNode *pb.Node, slots []*pb.Slot) (migrateSlots []*pb.Slot, needPropose bool) {
+	// 代理节点不存储槽数据，直接加入完成
+	if joiningNode.Role == pb.NodeRole_NodeRoleProxy || !joiningNode.AllowVote {
+		return nil, true
+	}
 
-	var migrateSlots []*pb.Slot // 迁移的槽列表
+	firstSlot := slots[0]
 
 	voteNodes := s.cfgServer.AllowVoteNodes()
 
@@ -429,7 +480,7 @@ func (s *Server) handleNodeJoining() error {
 
 				// ------------------- 分配槽领导 -------------------
 				allocSlotLeader := false // 是否已经分配完槽领导
-				if fromSlotCount > 0 && fromSlotLeaderCount > 0 && slot.Leader == node.Id {
+				if fromSlotCount > 0 && fromSlotLeaderCount > 0 && slot.Leader == node.Id && !s.reduceZoneSpread(slot.Replicas, node.Id, joiningNode.Id) {
 
 					allocSlotLeader = true
 					newSlot := slot.Clone()
@@ -447,7 +498,7 @@ func (s *Server) handleNodeJoining() error {
 
 				// ------------------- 分配槽副本 -------------------
 				if fromSlotCount > 0 && !allocSlotLeader {
-					if wkutil.ArrayContainsUint64(slot.Replicas, node.Id) {
+					if wkutil.ArrayContainsUint64(slot.Replicas, node.Id) && !s.reduceZoneSpread(slot.Replicas, node.Id, joiningNode.Id) {
 						newSlot := slot.Clone()
 						newSlot.MigrateFrom = node.Id
 						newSlot.MigrateTo = joiningNode.Id
@@ -464,15 +515,7 @@ func (s *Server) handleNodeJoining() error {
 
 	}
 
-	if len(migrateSlots) > 0 {
-		err := s.ProposeJoined(joiningNode.Id, migrateSlots)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-
+	return migrateSlots, len(migrateSlots) > 0
 }
 
 func (s *Server) handleNodeOnlineStatusChange() error {
@@ -497,6 +540,10 @@ func (s *Server) handleNodeOnlineChange(nodeId uint64, online bool) error {
 	if online { // 节点上线
 
 		s.Info("节点上线", zap.Uint64("nodeId", nodeId))
+
+		if s.nodeMaintenance(nodeId) { // 维护中的节点不迁入槽领导
+			return nil
+		}
 		slots := s.cfgServer.Slots()
 
 		onlineNodeCount := s.cfgServer.AllowVoteAndJoinedOnlineNodeCount()
@@ -553,7 +600,11 @@ func (s *Server) handleNodeOnlineChange(nodeId uint64, online bool) error {
 		}
 
 	} else { // 节点下线
-		s.Info("节点下线", zap.Uint64("nodeId", nodeId))
+		if s.nodeMaintenance(nodeId) { // 维护中的节点下线是预期内的
+			s.Debug("维护节点下线", zap.Uint64("nodeId", nodeId))
+		} else {
+			s.Info("节点下线", zap.Uint64("nodeId", nodeId))
+		}
 		slots := s.cfgServer.Slots()
 		onlineNodeCount := s.cfgServer.AllowVoteAndJoinedOnlineNodeCount()
 		avgSlotLeaderCount := s.cfgServer.Config().SlotCount / uint32(onlineNodeCount) // 平均每个节点的槽领导数量
@@ -586,6 +637,10 @@ func (s *Server) handleNodeOnlineChange(nodeId uint64, online bool) error {
 					continue
 				}
 
+				if s.nodeMaintenance(nId) { // 维护中的节点不作为新的领导
+					continue
+				}
+
 				if slot.MigrateFrom == 0 && slot.MigrateTo == 0 && slot.Status != pb.SlotStatus_SlotStatusCandidate {
 					newSlot := slot.Clone()
 					newSlot.Status = pb.SlotStatus_SlotStatusCandidate
@@ -609,6 +664,78 @@ func (s *Server) handleNodeOnlineChange(nodeId uint64, online bool) error {
 	return nil
 }
 
+// 将维护中节点的槽领导迁移到其他在线且未维护的副本上
+func (s *Server) handleNodeMaintenance() error {
+	newSlots := s.maintenanceSlots(s.cfgServer.Config())
+	if len(newSlots) == 0 {
+		return nil
+	}
+	err := s.ProposeSlots(newSlots)
+	if err != nil {
+		s.Error("handleNodeMaintenance failed,ProposeSlots failed", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// 需要迁移领导的槽（维护节点在线时平滑迁移，已下线时直接选举）
+func (s *Server) maintenanceSlots(cfg *pb.Config) []*pb.Slot {
+	var newSlots []*pb.Slot
+	for _, node := range cfg.Nodes {
+		if !node.Maintenance {
+			continue
+		}
+		for _, slot := range cfg.Slots {
+			if slot.Leader != node.Id || slot.MigrateFrom != 0 || slot.MigrateTo != 0 || slot.Status == pb.SlotStatus_SlotStatusCandidate {
+				continue
+			}
+			toNodeId := s.maintenanceTransferTarget(cfg, slot)
+			if toNodeId == 0 {
+				s.Warn("no available replica to take over slot leader from maintenance node", zap.Uint32("slotId", slot.Id), zap.Uint64("nodeId", node.Id))
+				continue
+			}
+			newSlot := slot.Clone()
+			if node.Online {
+				newSlot.MigrateFrom = node.Id
+				newSlot.MigrateTo = toNodeId
+			} else { // 维护节点已下线，直接选举新的领导
+				newSlot.Status = pb.SlotStatus_SlotStatusCandidate
+				newSlot.ExpectLeader = toNodeId
+			}
+			newSlots = append(newSlots, newSlot)
+		}
+	}
+	return newSlots
+}
+
+// 选择一个接管槽领导的副本（在线、允许投票且未维护，优先选择领导数量少的）
+func (s *Server) maintenanceTransferTarget(cfg *pb.Config, slot *pb.Slot) uint64 {
+	leaderCountMap := make(map[uint64]int)
+	for _, st := range cfg.Slots {
+		leaderCountMap[st.Leader]++
+	}
+	var toNodeId uint64
+	for _, replicaId := range slot.Replicas {
+		if replicaId == slot.Leader {
+			continue
+		}
+		node := s.cfgServer.Node(replicaId)
+		if node == nil || !node.Online || node.Maintenance || !node.AllowVote || node.Status != pb.NodeStatus_NodeStatusJoined {
+			continue
+		}
+		if toNodeId == 0 || leaderCountMap[replicaId] < leaderCountMap[toNodeId] {
+			toNodeId = replicaId
+		}
+	}
+	return toNodeId
+}
+
+// 节点是否处于维护模式
+func (s *Server) nodeMaintenance(nodeId uint64) bool {
+	node := s.cfgServer.Node(nodeId)
+	return node != nil && node.Maintenance
+}
+
 func (s *Server) handleSlotLeaderElection() error {
 	slots := s.cfgServer.Slots()
 	if len(slots) == 0 {
@@ -634,6 +761,11 @@ func (s *Server) handleSlotLeaderAutoBalance() error {
 
 	cfg := s.cfgServer.Config()
 
+	// 槽数量正在调整，则不进行自动均衡
+	if cfg.ResizeSlotCount != 0 {
+		return nil
+	}
+
 	// 有未加入的节点或者有槽正在迁移，则不进行自动均衡
 	for _, node := range cfg.Nodes {
 		if node.Status != pb.NodeStatus_NodeStatusJoined {
@@ -661,3 +793,29 @@ func (s *Server) handleSlotLeaderAutoBalance() error {
 	}
 	return nil
 }
+
+// 将副本从fromNodeId迁移到toNodeId是否会减少槽副本分布的可用区数量
+func (s *Server) reduceZoneSpread(replicas []uint64, fromNodeId, toNodeId uint64) bool {
+	newReplicas := make([]uint64, 0, len(replicas))
+	for _, replicaId := range replicas {
+		if replicaId == fromNodeId {
+			newReplicas = append(newReplicas, toNodeId)
+			continue
+		}
+		newReplicas = append(newReplicas, replicaId)
+	}
+	return s.zoneCount(newReplicas) < s.zoneCount(replicas)
+}
+
+// 节点分布的可用区数量（未配置可用区的节点不计算在内）
+func (s *Server) zoneCount(nodeIds []uint64) int {
+	zones := make(map[string]struct{}, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		node := s.cfgServer.Node(nodeId)
+		if node == nil || node.Zone == "" {
+			continue
+		}
+		zones[node.Zone] = struct{}{}
+	}
+	return len(zones)
+}
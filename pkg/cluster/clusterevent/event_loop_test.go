@@ -0,0 +1,79 @@
+package clusterevent
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, cfg *pb.Config) *Server {
+	s := New(NewOptions(WithNodeId(1), WithConfigDir(t.TempDir())))
+	s.cfgServer.Config().Nodes = cfg.Nodes
+	s.cfgServer.Config().Slots = cfg.Slots
+	return s
+}
+
+func TestHandleNodeMaintenance(t *testing.T) {
+	s := newTestServer(t, &pb.Config{
+		Nodes: []*pb.Node{
+			{Id: 1, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined, Maintenance: true},
+			{Id: 2, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined},
+			{Id: 3, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined},
+			{Id: 4, Online: false, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined, Maintenance: true},
+		},
+		Slots: []*pb.Slot{
+			{Id: 0, Leader: 1, Replicas: []uint64{1, 2, 3}},
+			{Id: 1, Leader: 2, Replicas: []uint64{1, 2, 3}},
+			{Id: 2, Leader: 4, Replicas: []uint64{4, 3}},
+			{Id: 3, Leader: 1, Replicas: []uint64{1, 2}, MigrateFrom: 1, MigrateTo: 2}, // 已经在迁移
+			{Id: 4, Leader: 1, Replicas: []uint64{1, 4}},                               // 没有可以接管的副本
+		},
+	})
+
+	slots := s.maintenanceSlots(s.cfgServer.Config())
+	assert.Len(t, slots, 2)
+
+	// 在线的维护节点平滑迁移到领导数量少的副本
+	assert.Equal(t, uint32(0), slots[0].Id)
+	assert.Equal(t, uint64(1), slots[0].MigrateFrom)
+	assert.Equal(t, uint64(3), slots[0].MigrateTo)
+	assert.Equal(t, pb.SlotStatus_SlotStatusNormal, slots[0].Status)
+
+	// 已下线的维护节点直接选举
+	assert.Equal(t, uint32(2), slots[1].Id)
+	assert.Equal(t, pb.SlotStatus_SlotStatusCandidate, slots[1].Status)
+	assert.Equal(t, uint64(3), slots[1].ExpectLeader)
+	assert.Equal(t, uint64(0), slots[1].MigrateTo)
+}
+
+func TestJoiningMigrateSlots(t *testing.T) {
+	s := newTestServer(t, &pb.Config{
+		Nodes: []*pb.Node{
+			{Id: 1, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined},
+			{Id: 2, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoined},
+			{Id: 3, Online: true, AllowVote: true, Status: pb.NodeStatus_NodeStatusJoining},
+			{Id: 4, Online: true, AllowVote: false, Role: pb.NodeRole_NodeRoleProxy, Status: pb.NodeStatus_NodeStatusJoining},
+		},
+		Slots: []*pb.Slot{
+			{Id: 0, Leader: 1, Replicas: []uint64{1, 2}},
+			{Id: 1, Leader: 2, Replicas: []uint64{1, 2}},
+		},
+	})
+	s.cfgServer.Config().SlotReplicaCount = 3
+	slots := s.cfgServer.Slots()
+
+	// 代理节点不分配槽，直接提案加入完成
+	migrateSlots, needPropose := s.joiningMigrateSlots(s.cfgServer.Node(4), slots)
+	assert.True(t, needPropose)
+	assert.Nil(t, migrateSlots)
+
+	// 槽的副本数量不足时，新节点作为学习者加入所有槽
+	migrateSlots, needPropose = s.joiningMigrateSlots(s.cfgServer.Node(3), slots)
+	assert.True(t, needPropose)
+	assert.Len(t, migrateSlots, 2)
+	for _, slot := range migrateSlots {
+		assert.Equal(t, uint64(3), slot.MigrateTo)
+		assert.Contains(t, slot.Learners, uint64(3))
+	}
+}
@@ -17,6 +17,8 @@ type Options struct {
 	ChannelMaxReplicaCount uint32 // 每个频道最大副本数量
 	ConfigDir              string
 	ApiServerAddr          string                       // api服务地址
+	Zone                   string                       // 当前节点所在的可用区/机架
+	InitNodeZones          map[uint64]string            // 初始节点所在的可用区，key为节点id
 	OnClusterConfigChange  func(cfg *pb.Config)         // 分布式配置改变
 	OnSlotElection         func(slots []*pb.Slot) error // 槽位选举
 	Send                   func(m reactor.Message)      // 发送消息
@@ -105,6 +107,18 @@ func WithApiServerAddr(apiServerAddr string) Option {
 	}
 }
 
+func WithZone(zone string) Option {
+	return func(o *Options) {
+		o.Zone = zone
+	}
+}
+
+func WithInitNodeZones(initNodeZones map[uint64]string) Option {
+	return func(o *Options) {
+		o.InitNodeZones = initNodeZones
+	}
+}
+
 func WithCluster(cluster icluster.Cluster) Option {
 	return func(o *Options) {
 		o.Cluster = cluster
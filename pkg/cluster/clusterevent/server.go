@@ -221,6 +221,30 @@ func (s *Server) ProposeJoined(nodeId uint64, slots []*pb.Slot) error {
 
 }
 
+// ProposeNodeMaintenance 提案节点维护模式变更
+func (s *Server) ProposeNodeMaintenance(nodeId uint64, maintenance bool) error {
+
+	return s.cfgServer.ProposeNodeMaintenance(nodeId, maintenance)
+}
+
+// ProposeSlotResizeStart 提案开始调整槽数量
+func (s *Server) ProposeSlotResizeStart(slotCount uint32, addSlots []*pb.Slot) error {
+
+	return s.cfgServer.ProposeSlotResizeStart(slotCount, addSlots)
+}
+
+// ProposeSlotResizeFreeze 提案冻结调整槽数量的源槽
+func (s *Server) ProposeSlotResizeFreeze() error {
+
+	return s.cfgServer.ProposeSlotResizeFreeze()
+}
+
+// ProposeSlotResizeEnd 提案结束调整槽数量
+func (s *Server) ProposeSlotResizeEnd(slotCount uint32) error {
+
+	return s.cfgServer.ProposeSlotResizeEnd(slotCount)
+}
+
 // GetLogsInReverseOrder 获取日志
 func (s *Server) GetLogsInReverseOrder(startLogIndex uint64, endLogIndex uint64, limit int) ([]replica.Log, error) {
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/pkg/auth"
@@ -14,6 +15,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -63,6 +65,11 @@ func (s *Server) channelMigrate(c *wkhttp.Context) {
 		return
 	}
 
+	if !s.allowVoteNode(req.MigrateTo) {
+		c.ResponseError(errors.New("migrateTo node not allow vote"))
+		return
+	}
+
 	newClusterConfig := clusterConfig.Clone()
 	if newClusterConfig.MigrateFrom != 0 || newClusterConfig.MigrateTo != 0 {
 		c.ResponseError(errors.New("migrate is in progress"))
@@ -112,6 +119,96 @@ func (s *Server) channelMigrate(c *wkhttp.Context) {
 
 }
 
+// 平滑转移频道领导
+func (s *Server) channelTransferLeader(c *wkhttp.Context) {
+	var req struct {
+		ChannelId   string `json:"channel_id"`
+		ChannelType uint8  `json:"channel_type"`
+		ToNodeId    uint64 `json:"to_node_id"` // 目标节点（为0则自动选择一个在线的追随者）
+		Preferred   bool   `json:"preferred"`  // 是否将目标节点设置为优先领导
+	}
+
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.ClusterChannel.TransferLeader, auth.ActionWrite) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("BindJSON error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if strings.TrimSpace(req.ChannelId) == "" {
+		c.ResponseError(errors.New("channel_id is empty"))
+		return
+	}
+
+	// 获取频道所属槽领导的id
+	nodeId, err := s.SlotLeaderIdOfChannel(req.ChannelId, req.ChannelType)
+	if err != nil {
+		s.Error("channelTransferLeader: LeaderIdOfChannel error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if nodeId != s.opts.NodeId {
+		c.ForwardWithBody(fmt.Sprintf("%s%s", s.clusterEventServer.Node(nodeId).ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	toNodeId, err := s.TransferChannelLeader(req.ChannelId, req.ChannelType, req.ToNodeId, req.Preferred)
+	if err != nil {
+		s.Error("channelTransferLeader: TransferChannelLeader error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"to_node_id": toNodeId,
+	})
+}
+
+// 强制重新配置频道副本（多数副本永久丢失，频道无法选出领导时使用）
+func (s *Server) channelForceReconfig(c *wkhttp.Context) {
+	var req struct {
+		LostReplicas []uint64 `json:"lost_replicas"` // 确认永久丢失的副本节点id（必须都不在线）
+		DryRun       bool     `json:"dry_run"`       // 是否只预演，不修改配置
+	}
+
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.ClusterChannel.ForceReconfig, auth.ActionWrite) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("BindJSON error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	channelId := c.Param("channel_id")
+	channelType := wkutil.ParseUint8(c.Param("channel_type"))
+
+	// 获取频道所属槽领导的id
+	nodeId, err := s.SlotLeaderIdOfChannel(channelId, channelType)
+	if err != nil {
+		s.Error("channelForceReconfig: LeaderIdOfChannel error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	if nodeId != s.opts.NodeId {
+		c.ForwardWithBody(fmt.Sprintf("%s%s", s.clusterEventServer.Node(nodeId).ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	resp, err := s.ForceChannelReconfig(channelId, channelType, req.LostReplicas, req.DryRun, c.Username())
+	if err != nil {
+		s.Error("channelForceReconfig: ForceChannelReconfig error", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		c.ResponseError(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func (s *Server) channelClusterConfig(c *wkhttp.Context) {
 
 	start := time.Now()
@@ -616,6 +713,78 @@ func (s *Server) requestChannelStatus(nodeId uint64, channels []*channelBase, he
 	return statusResp, nil
 }
 
+// 获取节点的复制健康状态（各个槽和活跃频道的副本落后情况、提交延迟、等待提交的提案和正在应用的日志）
+func (s *Server) replicationHealthGet(c *wkhttp.Context) {
+	nodeId := wkutil.ParseUint64(c.Query("node_id"))
+	if nodeId != 0 && nodeId != s.opts.NodeId {
+		node := s.clusterEventServer.Node(nodeId)
+		if node == nil {
+			s.Error("node not found", zap.Uint64("nodeId", nodeId))
+			c.ResponseError(ErrNodeNotFound)
+			return
+		}
+		c.Forward(fmt.Sprintf("%s%s", node.ApiServerAddr, c.Request.URL.Path))
+		return
+	}
+
+	lagThreshold := wkutil.ParseUint64(c.Query("lag_threshold")) // 只返回落后数量大于等于此值的槽和频道
+	limit := wkutil.ParseInt(c.Query("limit"))                   // 最多返回的频道数量
+	if limit <= 0 {
+		limit = s.opts.PageSize
+	}
+
+	c.JSON(http.StatusOK, s.replicationHealth(lagThreshold, limit))
+}
+
+// 获取节点记录的运维审计事件（按时间倒序）
+func (s *Server) auditEventsGet(c *wkhttp.Context) {
+	nodeId := wkutil.ParseUint64(c.Query("node_id"))
+	if nodeId != 0 && nodeId != s.opts.NodeId {
+		node := s.clusterEventServer.Node(nodeId)
+		if node == nil {
+			s.Error("node not found", zap.Uint64("nodeId", nodeId))
+			c.ResponseError(ErrNodeNotFound)
+			return
+		}
+		c.Forward(fmt.Sprintf("%s%s", node.ApiServerAddr, c.Request.URL.Path))
+		return
+	}
+
+	startId := wkutil.ParseUint64(c.Query("start_id")) // 从此id之前开始查询（不包含），0表示从最新的开始
+	limit := wkutil.ParseInt(c.Query("limit"))
+	if limit <= 0 {
+		limit = s.opts.PageSize
+	}
+	events, err := s.opts.DB.GetAuditEvents(startId, limit)
+	if err != nil {
+		s.Error("auditEventsGet: GetAuditEvents error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	resps := make([]*auditEventResp, 0, len(events))
+	for _, event := range events {
+		resps = append(resps, newAuditEventResp(event))
+	}
+	c.JSON(http.StatusOK, resps)
+}
+
+// 获取节点的日志校验状态（作为领导的槽和频道与副本的日志分歧）
+func (s *Server) logChecksumGet(c *wkhttp.Context) {
+	nodeId := wkutil.ParseUint64(c.Query("node_id"))
+	if nodeId != 0 && nodeId != s.opts.NodeId {
+		node := s.clusterEventServer.Node(nodeId)
+		if node == nil {
+			s.Error("node not found", zap.Uint64("nodeId", nodeId))
+			c.ResponseError(ErrNodeNotFound)
+			return
+		}
+		c.Forward(fmt.Sprintf("%s%s", node.ApiServerAddr, c.Request.URL.Path))
+		return
+	}
+
+	c.JSON(http.StatusOK, s.logChecksum.status())
+}
+
 func (s *Server) clusterInfoGet(c *wkhttp.Context) {
 
 	leaderId := s.clusterEventServer.LeaderId()
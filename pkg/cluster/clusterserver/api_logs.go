@@ -39,11 +39,19 @@ func (s *Server) messageTrace(c *wkhttp.Context) {
 
 	// ==================  请求轨迹日志 ==================
 
-	queryStr := `{trace = "1"} | json`
-	if clientMsgNo != "" {
-		queryStr = fmt.Sprintf(`%s | no="%s"`, queryStr, clientMsgNo)
+	var (
+		streamGroups []*StreamGroup
+		err          error
+	)
+	if s.opts.LokiUrl == "" { // 没有配置loki，从各节点的轨迹记录里还原
+		streamGroups, err = s.requestLocalTraceStreams(clientMsgNo, start)
+	} else {
+		queryStr := `{trace = "1"} | json`
+		if clientMsgNo != "" {
+			queryStr = fmt.Sprintf(`%s | no="%s"`, queryStr, clientMsgNo)
+		}
+		streamGroups, err = s.requestTraceStreams(queryStr, start, end)
 	}
-	streamGroups, err := s.requestTraceStreams(queryStr, start, end)
 	if err != nil {
 		s.Error("requestTraceStreams error", zap.Error(err))
 		c.ResponseError(err)
@@ -222,7 +230,12 @@ func (s *Server) messageTrace(c *wkhttp.Context) {
 	}
 
 	if deliverOnlineStreamGroup != nil && len(deliverOnlineStreamGroup.Streams) > 0 {
-		metris, err := s.requestTraceMetric(fmt.Sprintf(`sum by(messageId, nodeId, action) (count_over_time({trace="1"} | json | action = "processRecvack" | messageId = "%d" [1h]))`, messageId), start, end)
+		var metris []Stream
+		if s.opts.LokiUrl == "" {
+			metris, err = s.requestLocalRecvackMetrics(messageId, start)
+		} else {
+			metris, err = s.requestTraceMetric(fmt.Sprintf(`sum by(messageId, nodeId, action) (count_over_time({trace="1"} | json | action = "processRecvack" | messageId = "%d" [1h]))`, messageId), start, end)
+		}
 		if err != nil {
 			s.Error("requestTraceMetric error", zap.Error(err))
 			c.ResponseError(err)
@@ -295,7 +308,15 @@ func (s *Server) messageRecvackTrace(c *wkhttp.Context) {
 	start := time.Now().Add(-(time.Second * time.Duration(since)))
 	end := time.Now()
 
-	streamGroups, err := s.requestTraceStreams(fmt.Sprintf(`{trace="1"} | json | action = "processRecvack" | messageId = "%d"`, messageId), start, end)
+	var (
+		streamGroups []*StreamGroup
+		err          error
+	)
+	if s.opts.LokiUrl == "" {
+		streamGroups, err = s.requestLocalRecvackStreams(messageId, start)
+	} else {
+		streamGroups, err = s.requestTraceStreams(fmt.Sprintf(`{trace="1"} | json | action = "processRecvack" | messageId = "%d"`, messageId), start, end)
+	}
 	if err != nil {
 		s.Error("requestTraceStreams error", zap.Error(err))
 		c.ResponseError(err)
@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/WuKongIM/WuKongIM/pkg/auth"
+	"github.com/WuKongIM/WuKongIM/pkg/auth/resource"
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
@@ -297,3 +299,49 @@ func (s *Server) nodeChannelsGet(c *wkhttp.Context) {
 		Data:    channelClusterConfigResps,
 	})
 }
+
+// 设置节点维护模式
+func (s *Server) nodeMaintenanceSet(c *wkhttp.Context) {
+	var req struct {
+		Maintenance bool `json:"maintenance"` // 是否进入维护模式
+	}
+
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.Node.Maintenance, auth.ActionWrite) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		s.Error("id parse error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("bind json error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	if !s.clusterEventServer.IsLeader() {
+		leaderNode := s.clusterEventServer.Node(s.clusterEventServer.LeaderId())
+		if leaderNode == nil {
+			s.Error("leader not found", zap.Uint64("leaderId", s.clusterEventServer.LeaderId()))
+			c.ResponseError(errors.New("leader not found"))
+			return
+		}
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderNode.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	err = s.SetNodeMaintenance(id, req.Maintenance)
+	if err != nil {
+		s.Error("nodeMaintenance: SetNodeMaintenance error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	c.ResponseOK()
+}
@@ -196,6 +196,16 @@ func (s *Server) slotMigrate(c *wkhttp.Context) {
 		return
 	}
 
+	if !s.allowVoteNode(req.MigrateTo) {
+		c.ResponseError(errors.New("migrateTo node not allow vote"))
+		return
+	}
+
+	if s.clusterEventServer.Config().ResizeSlotCount != 0 {
+		c.ResponseError(ErrSlotResizing)
+		return
+	}
+
 	err = s.clusterEventServer.ProposeMigrateSlot(id, req.MigrateFrom, req.MigrateTo)
 	if err != nil {
 		s.Error("slotMigrate: ProposeMigrateSlot error", zap.Error(err))
@@ -207,6 +217,83 @@ func (s *Server) slotMigrate(c *wkhttp.Context) {
 
 }
 
+// 调整槽数量（拆分或合并槽）
+func (s *Server) slotsResize(c *wkhttp.Context) {
+	var req struct {
+		SlotCount uint32 `json:"slot_count"` // 新的槽数量
+	}
+
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.Slot.Resize, auth.ActionWrite) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("bind json error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	if !s.clusterEventServer.IsLeader() {
+		leaderNode := s.clusterEventServer.Node(s.clusterEventServer.LeaderId())
+		if leaderNode == nil {
+			s.Error("leader not found", zap.Uint64("leaderId", s.clusterEventServer.LeaderId()))
+			c.ResponseError(errors.New("leader not found"))
+			return
+		}
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderNode.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	if req.SlotCount == 0 {
+		c.ResponseError(errors.New("slot_count is 0"))
+		return
+	}
+
+	// 日志回放耗时较长，在后台执行，通过 GET /slots/resize 查询进度
+	err = s.StartResizeSlots(req.SlotCount)
+	if err != nil {
+		s.Error("slotsResize: StartResizeSlots error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+
+	c.ResponseOK()
+}
+
+// 槽数量调整的进度
+func (s *Server) slotsResizeGet(c *wkhttp.Context) {
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.Slot.Resize, auth.ActionRead) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if !s.clusterEventServer.IsLeader() {
+		leaderNode := s.clusterEventServer.Node(s.clusterEventServer.LeaderId())
+		if leaderNode == nil {
+			s.Error("leader not found", zap.Uint64("leaderId", s.clusterEventServer.LeaderId()))
+			c.ResponseError(errors.New("leader not found"))
+			return
+		}
+		c.Forward(fmt.Sprintf("%s%s", leaderNode.ApiServerAddr, c.Request.URL.Path))
+		return
+	}
+
+	cfg := s.clusterEventServer.Config()
+	resp := s.resizeTask.status()
+	if resp == nil {
+		resp = &slotResizeResp{
+			SlotCount: cfg.SlotCount,
+		}
+		if cfg.ResizeSlotCount != 0 { // 调整被中断，等待继续调整
+			resp.Status = slotResizeStatusFailed
+			resp.ResizeSlotCount = cfg.ResizeSlotCount
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func (s *Server) allSlotsGet(c *wkhttp.Context) {
 	leaderId := s.clusterEventServer.LeaderId()
 	if leaderId == 0 {
@@ -328,3 +415,43 @@ func (s *Server) getSlotMaxLogIndex(slotId uint32) (uint64, error) {
 	}
 	return 0, nil
 }
+
+// 强制重新配置槽副本（多数副本永久丢失，槽无法选出领导时使用）
+func (s *Server) slotForceReconfig(c *wkhttp.Context) {
+	var req struct {
+		LostReplicas []uint64 `json:"lost_replicas"` // 确认永久丢失的副本节点id（必须都不在线）
+		DryRun       bool     `json:"dry_run"`       // 是否只预演，不修改配置
+	}
+
+	if !s.opts.Auth.HasPermissionWithContext(c, resource.Slot.ForceReconfig, auth.ActionWrite) {
+		c.ResponseStatus(http.StatusUnauthorized)
+		return
+	}
+
+	bodyBytes, err := BindJSON(&req, c)
+	if err != nil {
+		s.Error("bind json error", zap.Error(err))
+		c.ResponseError(err)
+		return
+	}
+	id := wkutil.ParseUint32(c.Param("id"))
+
+	if !s.clusterEventServer.IsLeader() {
+		leaderNode := s.clusterEventServer.Node(s.clusterEventServer.LeaderId())
+		if leaderNode == nil {
+			s.Error("leader not found", zap.Uint64("leaderId", s.clusterEventServer.LeaderId()))
+			c.ResponseError(errors.New("leader not found"))
+			return
+		}
+		c.ForwardWithBody(fmt.Sprintf("%s%s", leaderNode.ApiServerAddr, c.Request.URL.Path), bodyBytes)
+		return
+	}
+
+	resp, err := s.ForceSlotReconfig(id, req.LostReplicas, req.DryRun, c.Username())
+	if err != nil {
+		s.Error("slotForceReconfig: ForceSlotReconfig error", zap.Error(err), zap.Uint32("slotId", id))
+		c.ResponseError(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
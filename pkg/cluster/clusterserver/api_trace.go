@@ -0,0 +1,242 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/internal/track"
+	"github.com/WuKongIM/WuKongIM/pkg/network"
+	"github.com/WuKongIM/WuKongIM/pkg/wkhttp"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// 没有配置loki时，消息轨迹从各个节点本地保存的轨迹记录（track.Records）还原
+
+// 获取本节点的消息轨迹记录（节点内部调用）
+func (s *Server) messageTraceRecords(c *wkhttp.Context) {
+	clientMsgNo := c.Query("client_msg_no")
+	messageId := wkutil.ParseInt64(c.Query("message_id"))
+	start := time.Unix(0, wkutil.ParseInt64(c.Query("start")))
+
+	c.JSON(http.StatusOK, s.localTraceRecords(clientMsgNo, messageId, start))
+}
+
+func (s *Server) localTraceRecords(clientMsgNo string, messageId int64, start time.Time) []track.Record {
+	records := track.Records.Query(start, func(record track.Record) bool {
+		if clientMsgNo != "" && record.ClientMsgNo == clientMsgNo {
+			return true
+		}
+		return messageId != 0 && record.MessageId == messageId
+	})
+	if records == nil {
+		records = make([]track.Record, 0)
+	}
+	return records
+}
+
+// 请求所有节点的消息轨迹记录
+func (s *Server) requestTraceRecords(clientMsgNo string, messageId int64, start time.Time) ([]track.Record, error) {
+	records := s.localTraceRecords(clientMsgNo, messageId, start)
+
+	timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ReqTimeout)
+	defer cancel()
+
+	var recordLock sync.Mutex
+	requestGroup, _ := errgroup.WithContext(timeoutCtx)
+	for _, node := range s.clusterEventServer.Nodes() {
+		if node.Id == s.opts.NodeId || !node.Online {
+			continue
+		}
+		apiServerAddr := node.ApiServerAddr
+		requestGroup.Go(func() error {
+			resp, err := network.Get(fmt.Sprintf("%s%s", apiServerAddr, s.formatPath("/message/trace/records")), map[string]string{
+				"client_msg_no": clientMsgNo,
+				"message_id":    fmt.Sprintf("%d", messageId),
+				"start":         fmt.Sprintf("%d", start.UnixNano()),
+			}, nil)
+			if err != nil {
+				return err
+			}
+			if err = handlerIMError(resp); err != nil {
+				return err
+			}
+			var nodeRecords []track.Record
+			if err = wkutil.ReadJSONByByte([]byte(resp.Body), &nodeRecords); err != nil {
+				return err
+			}
+			recordLock.Lock()
+			records = append(records, nodeRecords...)
+			recordLock.Unlock()
+			return nil
+		})
+	}
+	if err := requestGroup.Wait(); err != nil {
+		s.Error("requestTraceRecords failed", zap.Error(err))
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// 将轨迹记录转换为消息轨迹的StreamGroup（与loki查询出来的数据结构一致）
+func (s *Server) requestLocalTraceStreams(clientMsgNo string, start time.Time) ([]*StreamGroup, error) {
+	records, err := s.requestTraceRecords(clientMsgNo, 0, start)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*StreamGroup, 0)
+	addStream := func(action string, stream Stream) {
+		stream["action"] = action
+		for _, group := range groups {
+			if group.Action == action {
+				group.Streams = append(group.Streams, stream)
+				return
+			}
+		}
+		groups = append(groups, &StreamGroup{Action: action, Streams: []Stream{stream}})
+	}
+
+	var sendackRecord *track.Record
+	deliverRecords := make(map[uint64][]track.Record) // 每个节点的在线推送记录
+	for i, record := range records {
+		switch record.Action {
+		case track.ActionSendack:
+			if sendackRecord == nil {
+				sendackRecord = &records[i]
+			}
+		case track.ActionPushOnline:
+			deliverRecords[record.NodeId] = append(deliverRecords[record.NodeId], record)
+		}
+	}
+
+	if sendackRecord != nil {
+		r := sendackRecord
+		addStream("processMessage", Stream{
+			"nodeId":      fmt.Sprintf("%d", r.NodeId),
+			"time":        r.PositionTime(track.PositionStart).Format(time.RFC3339Nano),
+			"messageId":   fmt.Sprintf("%d", r.MessageId),
+			"messageSeq":  fmt.Sprintf("%d", r.MessageSeq),
+			"no":          r.ClientMsgNo,
+			"uid":         r.Uid,
+			"deviceId":    r.DeviceId,
+			"deviceFlag":  fmt.Sprintf("%d", r.DeviceFlag),
+			"deviceLevel": fmt.Sprintf("%d", r.DeviceLevel),
+			"channelId":   r.ChannelId,
+			"channelType": fmt.Sprintf("%d", r.ChannelType),
+		})
+		positionActions := []struct {
+			position track.Position
+			action   string
+		}{
+			{track.PositionChannelPermission, "processPermission"},
+			{track.PositionChannelPersist, "processStorage"},
+			{track.PositionChannelSendack, "processSendack"},
+		}
+		for _, pa := range positionActions {
+			if !r.Has(pa.position) {
+				continue
+			}
+			addStream(pa.action, Stream{
+				"nodeId": fmt.Sprintf("%d", r.NodeId),
+				"time":   r.PositionTime(pa.position).Format(time.RFC3339Nano),
+				"cost":   fmt.Sprintf("%d", r.Cost[16-pa.position]),
+			})
+		}
+	}
+
+	if len(deliverRecords) > 0 {
+		var deliverTime time.Time
+		for nodeId, nodeRecords := range deliverRecords {
+			uids := make([]string, 0, len(nodeRecords))
+			connCount := 0
+			for _, record := range nodeRecords {
+				uids = append(uids, record.Uid)
+				connCount += record.ConnCount
+			}
+			first := nodeRecords[0]
+			if deliverTime.IsZero() || first.PositionTime(track.PositionChannelDistribute).Before(deliverTime) {
+				deliverTime = first.PositionTime(track.PositionChannelDistribute)
+			}
+			addStream("deliverNode", Stream{
+				"nodeId":    fmt.Sprintf("%d", nodeId),
+				"time":      first.PositionTime(track.PositionPushOnline).Format(time.RFC3339Nano),
+				"userCount": fmt.Sprintf("%d", len(uids)),
+			})
+			addStream("deliverOnline", Stream{
+				"nodeId":    fmt.Sprintf("%d", nodeId),
+				"time":      first.Time.Format(time.RFC3339Nano),
+				"userCount": fmt.Sprintf("%d", len(uids)),
+				"connCount": fmt.Sprintf("%d", connCount),
+				"uids":      strings.Join(uids, ","),
+			})
+		}
+		deliverNodeId := s.opts.NodeId
+		if sendackRecord != nil {
+			deliverNodeId = sendackRecord.NodeId // 消息由频道领导节点投递
+		}
+		addStream("processDeliver", Stream{
+			"nodeId": fmt.Sprintf("%d", deliverNodeId),
+			"time":   deliverTime.Format(time.RFC3339Nano),
+		})
+	}
+	return groups, nil
+}
+
+// 各节点收到消息回执的数量（与loki的指标查询结果结构一致）
+func (s *Server) requestLocalRecvackMetrics(messageId int64, start time.Time) ([]Stream, error) {
+	records, err := s.requestTraceRecords("", messageId, start)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint64]int)
+	for _, record := range records {
+		if record.Action == track.ActionRecvack {
+			counts[record.NodeId]++
+		}
+	}
+	metrics := make([]Stream, 0, len(counts))
+	for nodeId, count := range counts {
+		metrics = append(metrics, Stream{
+			"action":    "processRecvack",
+			"nodeId":    fmt.Sprintf("%d", nodeId),
+			"messageId": fmt.Sprintf("%d", messageId),
+			"values":    []interface{}{[]interface{}{fmt.Sprintf("%d", time.Now().Unix()), fmt.Sprintf("%d", count)}},
+		})
+	}
+	return metrics, nil
+}
+
+// 收到消息回执的轨迹
+func (s *Server) requestLocalRecvackStreams(messageId int64, start time.Time) ([]*StreamGroup, error) {
+	records, err := s.requestTraceRecords("", messageId, start)
+	if err != nil {
+		return nil, err
+	}
+	group := &StreamGroup{Action: "processRecvack"}
+	for _, record := range records {
+		if record.Action != track.ActionRecvack {
+			continue
+		}
+		group.Streams = append(group.Streams, Stream{
+			"action":     "processRecvack",
+			"nodeId":     fmt.Sprintf("%d", record.NodeId),
+			"time":       record.Time.Format(time.RFC3339Nano),
+			"uid":        record.Uid,
+			"deviceId":   record.DeviceId,
+			"messageId":  fmt.Sprintf("%d", record.MessageId),
+			"messageSeq": fmt.Sprintf("%d", record.MessageSeq),
+			"connId":     fmt.Sprintf("%d", record.ConnId),
+		})
+	}
+	return []*StreamGroup{group}, nil
+}
@@ -140,6 +140,13 @@ func (c *channel) isLeader() bool {
 	return c.cfg.LeaderId == c.opts.NodeId
 }
 
+// 频道领导是否正在转移
+func (c *channel) migrating() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg.MigrateFrom != 0 || c.cfg.MigrateTo != 0
+}
+
 // --------------------------IHandler-------------------------------
 
 func (c *channel) LastLogIndexAndTerm() (uint64, uint32) {
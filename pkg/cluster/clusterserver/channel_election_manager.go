@@ -136,7 +136,7 @@ func (c *channelElectionManager) election(reqs []electionReq) {
 			}
 			continue
 		}
-		newLeaderId := c.channelLeaderIDByLogInfo(lastInfoResps) // 通过日志信息选举频道领导
+		newLeaderId := c.channelLeaderIDByLogInfo(lastInfoResps, req.cfg.PreferredLeader) // 通过日志信息选举频道领导
 		if newLeaderId == 0 {
 			select {
 			case req.resultC <- electionResp{
@@ -161,8 +161,8 @@ func (c *channelElectionManager) election(reqs []electionReq) {
 	}
 }
 
-// 通过日志高度选举频道领导
-func (c *channelElectionManager) channelLeaderIDByLogInfo(resps []*replicaChannelLastLogInfoResponse) uint64 {
+// 通过日志高度选举频道领导，日志同样新的情况下优先选择preferredLeader
+func (c *channelElectionManager) channelLeaderIDByLogInfo(resps []*replicaChannelLastLogInfoResponse, preferredLeader uint64) uint64 {
 
 	// 选出resps中最大的日志下标和任期的节点
 
@@ -190,6 +190,27 @@ func (c *channelElectionManager) channelLeaderIDByLogInfo(resps []*replicaChanne
 		}
 	}
 
+	// 日志同样新的副本
+	var candidates []uint64
+	for _, resp := range resps {
+		if resp.Term == maxTerm && resp.LogTerm == maxLogTerm && resp.LogIndex == maxLogIndex {
+			candidates = append(candidates, resp.replicaId)
+		}
+	}
+
+	if preferredLeader != 0 && preferredLeader != leaderID && wkutil.ArrayContainsUint64(candidates, preferredLeader) && !c.s.nodeMaintenance(preferredLeader) {
+		return preferredLeader
+	}
+
+	// 尽量不选维护中的节点
+	if c.s.nodeMaintenance(leaderID) {
+		for _, replicaId := range candidates {
+			if !c.s.nodeMaintenance(replicaId) {
+				return replicaId
+			}
+		}
+	}
+
 	return leaderID
 }
 
@@ -63,6 +63,17 @@ func (c *channelManager) remove(ch *channel) {
 	c.channelReactor.RemoveHandler(ch.key)
 }
 
+// 移除频道并等待正在执行的日志写入完成后执行fn，执行期间收到的频道消息不会重新创建频道
+func (c *channelManager) removeAndDo(ctx context.Context, channelId string, channelType uint8, fn func() error) error {
+	c.Lock()
+	defer c.Unlock()
+	handleKey := wkutil.ChannelToKey(channelId, channelType)
+	if err := c.channelReactor.RemoveHandlerAndWait(ctx, handleKey); err != nil {
+		return err
+	}
+	return fn()
+}
+
 func (c *channelManager) get(channelId string, channelType uint8) reactor.IHandler {
 	c.RLock()
 	defer c.RUnlock()
@@ -114,6 +125,23 @@ func (c *channelManager) channelCount() int {
 	return c.channelReactor.HandlerLen()
 }
 
+func (c *channelManager) iterate(f func(*channel) bool) {
+	c.RLock()
+	defer c.RUnlock()
+	c.channelReactor.IteratorHandler(func(h reactor.IHandler) bool {
+		return f(h.(*channel))
+	})
+}
+
+// 遍历频道，同时返回频道等待提交的提案数量
+func (c *channelManager) iterateWithPendingProposals(f func(ch *channel, pendingProposals int) bool) {
+	c.RLock()
+	defer c.RUnlock()
+	c.channelReactor.IteratorHandlerWithPendingProposals(func(h reactor.IHandler, pendingProposals int) bool {
+		return f(h.(*channel), pendingProposals)
+	})
+}
+
 func (c *channelManager) getWithHandleKey(handleKey string) reactor.IHandler {
 	c.RLock()
 	defer c.RUnlock()
@@ -0,0 +1,535 @@
+package cluster
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/replica"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"github.com/lni/goutils/syncutil"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// 日志校验
+// 冲突检查只能发现任期不一致的日志，发现不了相同下标、相同任期但内容不一致的日志（例如磁盘故障导致的静默分歧）。
+// 领导定期把已提交的日志按区间计算校验和，与各个副本同一区间的校验和比对，不一致时二分查找第一条分歧的日志，
+// 记录分歧（通过api和监控指标查看），开启自动修复并且多数副本（包含领导）的校验和与领导一致时，
+// 请求分歧的副本从分歧的日志开始截断，然后重新从领导同步；领导的日志没有得到多数副本确认时（领导自己可能已损坏）只记录分歧。
+// 校验一致的进度会持久化，已经校验一致的区间不会重复校验。
+type logChecksum struct {
+	opts    *Options
+	stopper *syncutil.Stopper
+
+	mu         sync.Mutex
+	mismatches map[string]*logChecksumMismatchResp // 未解决的日志分歧（key为分区key+副本id）
+
+	slotChecked    atomic.Int64 // 已校验的槽日志数量
+	channelChecked atomic.Int64 // 已校验的频道日志数量
+
+	leaderShards    func() []*logChecksumShard                                            // 本节点作为领导的槽和活跃频道
+	localChecksum   func(req *LogChecksumReq) (*LogChecksumResp, error)                   // 本节点的日志校验和
+	replicaChecksum func(replicaId uint64, req *LogChecksumReq) (*LogChecksumResp, error) // 副本的日志校验和
+	replicaRepair   func(replicaId uint64, req *LogRepairReq) error                       // 请求副本修复日志
+	checkedIndex    func(shard *logChecksumShard) (uint64, error)                         // 已校验一致的日志下标
+	setCheckedIndex func(shard *logChecksumShard, index uint64) error                     // 保存已校验一致的日志下标
+	onRepair        func(shard *logChecksumShard, mismatch *logChecksumMismatchResp)      // 发起修复后的回调
+	wklog.Log
+}
+
+func newLogChecksum(s *Server) *logChecksum {
+	l := &logChecksum{
+		opts:       s.opts,
+		stopper:    syncutil.NewStopper(),
+		mismatches: make(map[string]*logChecksumMismatchResp),
+		Log:        wklog.NewWKLog(fmt.Sprintf("logChecksum[%d]", s.opts.NodeId)),
+	}
+	l.leaderShards = s.logChecksumLeaderShards
+	l.localChecksum = s.localLogChecksum
+	l.replicaChecksum = func(replicaId uint64, req *LogChecksumReq) (*LogChecksumResp, error) {
+		return s.nodeManager.requestLogChecksum(s.cancelCtx, replicaId, req)
+	}
+	l.replicaRepair = func(replicaId uint64, req *LogRepairReq) error {
+		return s.nodeManager.requestLogRepair(s.cancelCtx, replicaId, req)
+	}
+	l.checkedIndex = func(shard *logChecksumShard) (uint64, error) {
+		return s.opts.DB.GetLogChecksumIndex(uint8(shard.shardType), shard.key)
+	}
+	l.setCheckedIndex = func(shard *logChecksumShard, index uint64) error {
+		return s.opts.DB.SetLogChecksumIndex(uint8(shard.shardType), shard.key, index)
+	}
+	l.onRepair = func(shard *logChecksumShard, mismatch *logChecksumMismatchResp) {
+		s.recordAuditEvent(auditActionLogRepair, shard.target(), "system", mismatch)
+	}
+	return l
+}
+
+func (l *logChecksum) start() {
+	if l.opts.LogChecksumInterval <= 0 {
+		return
+	}
+	l.stopper.RunWorker(l.loop)
+}
+
+func (l *logChecksum) stop() {
+	l.stopper.Stop()
+}
+
+func (l *logChecksum) loop() {
+	tk := time.NewTicker(l.opts.LogChecksumInterval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-tk.C:
+			l.checkRound()
+		case <-l.stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+// 需要校验的槽或频道（本节点是领导）
+type logChecksumShard struct {
+	shardType      ShardType
+	slotId         uint32
+	channelId      string
+	channelType    uint8
+	key            string
+	committedIndex uint64   // 领导已提交的日志下标
+	replicas       []uint64 // 需要比对的副本（包含学习者，不包含自己）
+}
+
+func (sd *logChecksumShard) checksumReq(startIndex, endIndex uint64) *LogChecksumReq {
+	return &LogChecksumReq{
+		ShardType:   sd.shardType,
+		SlotId:      sd.slotId,
+		ChannelId:   sd.channelId,
+		ChannelType: sd.channelType,
+		StartIndex:  startIndex,
+		EndIndex:    endIndex,
+	}
+}
+
+func (sd *logChecksumShard) mismatchKey(replicaId uint64) string {
+	return fmt.Sprintf("%d:%s:%d", sd.shardType, sd.key, replicaId)
+}
+
+func (sd *logChecksumShard) target() string {
+	if sd.shardType == ShardTypeSlot {
+		return fmt.Sprintf("slot:%d", sd.slotId)
+	}
+	return fmt.Sprintf("channel:%s:%d", sd.channelId, sd.channelType)
+}
+
+// 校验一轮本节点作为领导的槽和活跃频道，每个槽或频道校验一个区间
+func (l *logChecksum) checkRound() {
+	for _, shard := range l.leaderShards() {
+		select {
+		case <-l.stopper.ShouldStop():
+			return
+		default:
+		}
+		l.checkShard(shard)
+	}
+}
+
+// 本节点作为领导的槽和活跃频道（在反应堆协程外调用，只读取副本发布的进度快照）
+func (s *Server) logChecksumLeaderShards() []*logChecksumShard {
+	nodeId := s.opts.NodeId
+	others := func(replicas, learners []uint64) []uint64 {
+		var ids []uint64
+		for _, id := range replicas {
+			if id != nodeId {
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range learners {
+			if id != nodeId {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	var shards []*logChecksumShard
+	s.slotManager.iterate(func(st *slot) bool {
+		progress := st.rc.Progress()
+		if progress.Role != replica.RoleLeader {
+			return true
+		}
+		st.mu.Lock()
+		slotId, replicas, learners := st.st.Id, st.st.Replicas, st.st.Learners
+		st.mu.Unlock()
+		shards = append(shards, &logChecksumShard{
+			shardType:      ShardTypeSlot,
+			slotId:         slotId,
+			key:            st.key,
+			committedIndex: progress.CommittedIndex,
+			replicas:       others(replicas, learners),
+		})
+		return true
+	})
+	s.channelManager.iterate(func(ch *channel) bool {
+		progress := ch.rc.Progress()
+		if progress.Role != replica.RoleLeader {
+			return true
+		}
+		ch.mu.Lock()
+		replicas, learners := ch.cfg.Replicas, ch.cfg.Learners
+		ch.mu.Unlock()
+		shards = append(shards, &logChecksumShard{
+			shardType:      ShardTypeChannel,
+			channelId:      ch.channelId,
+			channelType:    ch.channelType,
+			key:            ch.key,
+			committedIndex: progress.CommittedIndex,
+			replicas:       others(replicas, learners),
+		})
+		return true
+	})
+	return shards
+}
+
+// 校验槽或频道的下一个区间 [checkedIndex+1, min(checkedIndex+rangeSize, committedIndex)]
+// 所有副本都校验一致后才推进校验进度，校验到最新的提交日志后等待新的日志提交
+func (l *logChecksum) checkShard(shard *logChecksumShard) {
+	committedIndex := shard.committedIndex
+	if committedIndex == 0 || len(shard.replicas) == 0 {
+		return
+	}
+	checkedIndex, err := l.checkedIndex(shard)
+	if err != nil {
+		l.Error("get log checksum index failed", zap.Error(err), zap.String("target", shard.target()))
+		return
+	}
+	startIndex := checkedIndex + 1
+	if startIndex > committedIndex {
+		return
+	}
+	rangeSize := l.opts.LogChecksumRangeSize
+	if rangeSize == 0 {
+		rangeSize = 1
+	}
+	endIndex := startIndex + rangeSize - 1
+	if endIndex > committedIndex {
+		endIndex = committedIndex
+	}
+
+	leaderResp, err := l.localChecksum(shard.checksumReq(startIndex, endIndex))
+	if err != nil {
+		l.Error("get local log checksum failed", zap.Error(err), zap.String("target", shard.target()), zap.Uint64("startIndex", startIndex), zap.Uint64("endIndex", endIndex))
+		return
+	}
+
+	nextIndex := endIndex
+	agreed := 1 // 校验和与领导一致的副本数量（包含领导）
+	replicaResps := make(map[uint64]*LogChecksumResp, len(shard.replicas))
+	for _, replicaId := range shard.replicas {
+		replicaResp, err := l.replicaChecksum(replicaId, shard.checksumReq(startIndex, endIndex))
+		if err != nil {
+			l.Warn("request log checksum failed", zap.Error(err), zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId))
+			nextIndex = min(nextIndex, startIndex-1)
+			continue
+		}
+		// 副本还没同步到这个区间，下一轮再校验
+		if replicaResp.LastIndex < endIndex {
+			nextIndex = min(nextIndex, startIndex-1)
+			continue
+		}
+		l.addChecked(shard.shardType, endIndex-startIndex+1)
+
+		if replicaResp.Checksum == leaderResp.Checksum && replicaResp.Count == leaderResp.Count {
+			agreed++
+			l.resolveMismatch(shard, replicaId, startIndex, endIndex)
+			continue
+		}
+		replicaResps[replicaId] = replicaResp
+	}
+	// 多数副本确认了领导的日志才认为领导是正确的，才能修复分歧的副本
+	leaderConfirmed := agreed >= (len(shard.replicas)+1)/2+1
+
+	for _, replicaId := range shard.replicas {
+		replicaResp := replicaResps[replicaId]
+		if replicaResp == nil {
+			continue
+		}
+
+		divergentIndex, err := l.firstDivergentIndex(shard, replicaId, startIndex, endIndex)
+		if err != nil {
+			l.Warn("find first divergent index failed", zap.Error(err), zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId))
+			divergentIndex = startIndex
+		}
+		// 分歧解决前不推进校验进度，下一轮从分歧的日志开始重新校验
+		nextIndex = min(nextIndex, divergentIndex-1)
+
+		mismatch := &logChecksumMismatchResp{
+			SlotId:          shard.slotId,
+			ChannelId:       shard.channelId,
+			ChannelType:     shard.channelType,
+			ReplicaId:       replicaId,
+			StartIndex:      startIndex,
+			EndIndex:        endIndex,
+			DivergentIndex:  divergentIndex,
+			LeaderChecksum:  leaderResp.Checksum,
+			ReplicaChecksum: replicaResp.Checksum,
+			DetectedAt:      time.Now().Unix(),
+		}
+		l.Error("replica log mismatch", zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId), zap.Uint64("startIndex", startIndex), zap.Uint64("endIndex", endIndex), zap.Uint64("divergentIndex", divergentIndex))
+
+		if l.opts.LogChecksumAutoRepair && !leaderConfirmed {
+			l.Error("leader log is not confirmed by a majority of replicas, skip repair", zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId), zap.Int("agreed", agreed), zap.Int("replicas", len(shard.replicas)+1))
+			mismatch.RepairError = ErrLogChecksumNoQuorum.Error()
+		} else if l.opts.LogChecksumAutoRepair {
+			err = l.replicaRepair(replicaId, &LogRepairReq{
+				ShardType:   shard.shardType,
+				SlotId:      shard.slotId,
+				ChannelId:   shard.channelId,
+				ChannelType: shard.channelType,
+				LeaderId:    l.opts.NodeId,
+				Index:       divergentIndex,
+			})
+			if err != nil {
+				l.Error("request log repair failed", zap.Error(err), zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId))
+				mismatch.RepairError = err.Error()
+			} else {
+				mismatch.Repaired = 1
+				l.onRepair(shard, mismatch)
+			}
+		}
+
+		l.mu.Lock()
+		l.mismatches[shard.mismatchKey(replicaId)] = mismatch
+		l.mu.Unlock()
+	}
+	if nextIndex > checkedIndex {
+		if err = l.setCheckedIndex(shard, nextIndex); err != nil {
+			l.Error("set log checksum index failed", zap.Error(err), zap.String("target", shard.target()), zap.Uint64("index", nextIndex))
+		}
+	}
+}
+
+// 二分查找第一条分歧的日志下标（[startIndex, endIndex]区间内存在分歧）
+func (l *logChecksum) firstDivergentIndex(shard *logChecksumShard, replicaId uint64, startIndex, endIndex uint64) (uint64, error) {
+	low, high := startIndex, endIndex
+	for low < high {
+		mid := low + (high-low)/2
+		leaderResp, err := l.localChecksum(shard.checksumReq(low, mid))
+		if err != nil {
+			return 0, err
+		}
+		replicaResp, err := l.replicaChecksum(replicaId, shard.checksumReq(low, mid))
+		if err != nil {
+			return 0, err
+		}
+		if leaderResp.Checksum == replicaResp.Checksum && leaderResp.Count == replicaResp.Count {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+// 区间校验一致，移除此区间内的分歧记录
+func (l *logChecksum) resolveMismatch(shard *logChecksumShard, replicaId uint64, startIndex, endIndex uint64) {
+	key := shard.mismatchKey(replicaId)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	mismatch := l.mismatches[key]
+	if mismatch == nil {
+		return
+	}
+	if mismatch.DivergentIndex >= startIndex && mismatch.DivergentIndex <= endIndex {
+		l.Info("replica log mismatch resolved", zap.String("target", shard.target()), zap.Uint64("replicaId", replicaId), zap.Uint64("divergentIndex", mismatch.DivergentIndex))
+		delete(l.mismatches, key)
+	}
+}
+
+func (l *logChecksum) addChecked(shardType ShardType, count uint64) {
+	if shardType == ShardTypeSlot {
+		l.slotChecked.Add(int64(count))
+	} else {
+		l.channelChecked.Add(int64(count))
+	}
+}
+
+func (l *logChecksum) mismatchList() []*logChecksumMismatchResp {
+	l.mu.Lock()
+	mismatches := make([]*logChecksumMismatchResp, 0, len(l.mismatches))
+	for _, mismatch := range l.mismatches {
+		mismatches = append(mismatches, mismatch)
+	}
+	l.mu.Unlock()
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].DetectedAt > mismatches[j].DetectedAt
+	})
+	return mismatches
+}
+
+// 本节点的日志校验状态
+func (l *logChecksum) status() *logChecksumResp {
+	return &logChecksumResp{
+		NodeId:         l.opts.NodeId,
+		On:             wkutil.BoolToInt(l.opts.LogChecksumInterval > 0),
+		AutoRepair:     wkutil.BoolToInt(l.opts.LogChecksumAutoRepair),
+		Interval:       l.opts.LogChecksumInterval.String(),
+		RangeSize:      l.opts.LogChecksumRangeSize,
+		SlotChecked:    l.slotChecked.Load(),
+		ChannelChecked: l.channelChecked.Load(),
+		Mismatches:     l.mismatchList(),
+	}
+}
+
+// 汇总日志校验状态，用于监控指标
+func (l *logChecksum) metrics(shardType ShardType) trace.LogChecksum {
+	var mismatches int64
+	l.mu.Lock()
+	for _, mismatch := range l.mismatches {
+		isSlot := mismatch.ChannelId == ""
+		if isSlot == (shardType == ShardTypeSlot) {
+			mismatches++
+		}
+	}
+	l.mu.Unlock()
+
+	checked := l.channelChecked.Load()
+	if shardType == ShardTypeSlot {
+		checked = l.slotChecked.Load()
+	}
+	return trace.LogChecksum{
+		Checked:    checked,
+		Mismatches: mismatches,
+	}
+}
+
+// 计算日志的校验和（日志id、下标、任期和数据都参与计算）
+func logsChecksum(logs []replica.Log) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 28)
+	for _, log := range logs {
+		binary.BigEndian.PutUint64(buf[0:], log.Id)
+		binary.BigEndian.PutUint64(buf[8:], log.Index)
+		binary.BigEndian.PutUint32(buf[16:], log.Term)
+		binary.BigEndian.PutUint64(buf[20:], uint64(len(log.Data)))
+		_, _ = h.Write(buf)
+		_, _ = h.Write(log.Data)
+	}
+	return h.Sum64()
+}
+
+// 槽或频道对应的日志存储
+func (s *Server) shardLogStorage(shardType ShardType, slotId uint32, channelId string, channelType uint8) (IShardLogStorage, string, error) {
+	switch shardType {
+	case ShardTypeSlot:
+		return s.opts.SlotLogStorage, SlotIdToKey(slotId), nil
+	case ShardTypeChannel:
+		return s.opts.MessageLogStorage, wkutil.ChannelToKey(channelId, channelType), nil
+	}
+	return nil, "", ErrInvalidShardType
+}
+
+// 计算本节点[StartIndex, EndIndex]区间的日志校验和
+func (s *Server) localLogChecksum(req *LogChecksumReq) (*LogChecksumResp, error) {
+	storage, shardNo, err := s.shardLogStorage(req.ShardType, req.SlotId, req.ChannelId, req.ChannelType)
+	if err != nil {
+		return nil, err
+	}
+	lastIndex, err := storage.LastIndex(shardNo)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := storage.Logs(shardNo, req.StartIndex, req.EndIndex+1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &LogChecksumResp{
+		Checksum:  logsChecksum(logs),
+		Count:     uint32(len(logs)),
+		LastIndex: lastIndex,
+	}, nil
+}
+
+// 副本截断从req.Index开始的分歧日志，然后重建槽或频道，重新从领导同步日志
+func (s *Server) repairReplicaLog(req *LogRepairReq) error {
+	if req.Index == 0 {
+		return fmt.Errorf("invalid repair index: %d", req.Index)
+	}
+	if req.LeaderId == s.opts.NodeId {
+		return ErrNotLeader
+	}
+	storage, shardNo, err := s.shardLogStorage(req.ShardType, req.SlotId, req.ChannelId, req.ChannelType)
+	if err != nil {
+		return err
+	}
+
+	switch req.ShardType {
+	case ShardTypeSlot:
+		st := s.clusterEventServer.Slot(req.SlotId)
+		if st == nil || !s.slotManager.exist(req.SlotId) {
+			return ErrSlotNotExist
+		}
+		if st.Leader != req.LeaderId {
+			return fmt.Errorf("slot leader is %d, not %d", st.Leader, req.LeaderId)
+		}
+		// 先移除槽并等待正在执行的日志写入完成，截断日志后用存储里的日志重建槽的副本
+		timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ReqTimeout)
+		defer cancel()
+		err = s.slotManager.removeAndWait(timeoutCtx, req.SlotId)
+		if err == nil {
+			err = s.truncateDivergentLog(storage, shardNo, req.Index)
+		}
+		s.addSlot(st)
+		return err
+	case ShardTypeChannel:
+		cfg, err := s.getChannelClusterConfig(req.ChannelId, req.ChannelType)
+		if err != nil {
+			return err
+		}
+		if cfg.LeaderId != req.LeaderId {
+			return fmt.Errorf("channel leader is %d, not %d", cfg.LeaderId, req.LeaderId)
+		}
+		timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ReqTimeout)
+		defer cancel()
+		err = s.channelManager.removeAndDo(timeoutCtx, req.ChannelId, req.ChannelType, func() error {
+			return s.truncateDivergentLog(storage, shardNo, req.Index)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = s.loadOrCreateChannel(s.cancelCtx, req.ChannelId, req.ChannelType)
+		return err
+	}
+	return ErrInvalidShardType
+}
+
+// 截断从index开始的日志，已应用的下标也回退到index-1（重新同步的日志会重新应用）
+// 调用前槽或频道必须已经移除，并且正在执行的日志写入已经完成
+func (s *Server) truncateDivergentLog(storage IShardLogStorage, shardNo string, index uint64) error {
+	// 先回退已应用的下标，存储不允许截断已应用的日志
+	appliedIndex, err := storage.AppliedIndex(shardNo)
+	if err != nil {
+		return err
+	}
+	if appliedIndex >= index {
+		err = storage.SetAppliedIndex(shardNo, index-1)
+		if err != nil {
+			return err
+		}
+	}
+	lastIndex, err := storage.LastIndex(shardNo)
+	if err != nil {
+		return err
+	}
+	s.Warn("truncate divergent log", zap.String("shardNo", shardNo), zap.Uint64("index", index), zap.Uint64("lastIndex", lastIndex), zap.Uint64("appliedIndex", appliedIndex))
+	if lastIndex >= index {
+		return storage.TruncateLogTo(shardNo, index)
+	}
+	return nil
+}
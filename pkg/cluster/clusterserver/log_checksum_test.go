@@ -0,0 +1,292 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/reactor"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/replica"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogsChecksum(t *testing.T) {
+	logs := []replica.Log{
+		{Id: 1, Index: 1, Term: 1, Data: []byte("hello")},
+		{Id: 2, Index: 2, Term: 1, Data: []byte("world")},
+	}
+	sum := logsChecksum(logs)
+	assert.Equal(t, sum, logsChecksum([]replica.Log{logs[0], logs[1]}))
+
+	// 数据不一致
+	diffData := []replica.Log{logs[0], {Id: 2, Index: 2, Term: 1, Data: []byte("worle")}}
+	assert.NotEqual(t, sum, logsChecksum(diffData))
+
+	// 任期不一致
+	diffTerm := []replica.Log{logs[0], {Id: 2, Index: 2, Term: 2, Data: []byte("world")}}
+	assert.NotEqual(t, sum, logsChecksum(diffTerm))
+
+	// 数据边界不一致
+	diffBoundary := []replica.Log{{Id: 1, Index: 1, Term: 1, Data: []byte("hellow")}, {Id: 2, Index: 2, Term: 1, Data: []byte("orld")}}
+	assert.NotEqual(t, sum, logsChecksum(diffBoundary))
+}
+
+func TestLogChecksumReqMarshal(t *testing.T) {
+	req := &LogChecksumReq{
+		ShardType:   ShardTypeChannel,
+		ChannelId:   "test",
+		ChannelType: 2,
+		StartIndex:  1,
+		EndIndex:    1000,
+	}
+	data, err := req.Marshal()
+	assert.NoError(t, err)
+
+	req2 := &LogChecksumReq{}
+	err = req2.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, req, req2)
+}
+
+func TestLogRepairReqMarshal(t *testing.T) {
+	req := &LogRepairReq{
+		ShardType: ShardTypeSlot,
+		SlotId:    12,
+		LeaderId:  1001,
+		Index:     100,
+	}
+	data, err := req.Marshal()
+	assert.NoError(t, err)
+
+	req2 := &LogRepairReq{}
+	err = req2.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, req, req2)
+}
+
+// 测试用的日志校验，领导和副本的日志都在内存里
+type testLogChecksum struct {
+	*logChecksum
+	leaderLogs    []replica.Log
+	replicaLogs   map[uint64][]replica.Log
+	checkedIndex  uint64
+	checksumReqs  int
+	repairReqs    []*LogRepairReq
+	repairRecords int
+}
+
+func newTestLogChecksum(opts *Options, leaderLogs []replica.Log, replicaLogs map[uint64][]replica.Log) *testLogChecksum {
+	tl := &testLogChecksum{
+		leaderLogs:  leaderLogs,
+		replicaLogs: replicaLogs,
+	}
+	checksum := func(logs []replica.Log, req *LogChecksumReq) *LogChecksumResp {
+		var rangeLogs []replica.Log
+		for _, lg := range logs {
+			if lg.Index >= req.StartIndex && lg.Index <= req.EndIndex {
+				rangeLogs = append(rangeLogs, lg)
+			}
+		}
+		var lastIndex uint64
+		if len(logs) > 0 {
+			lastIndex = logs[len(logs)-1].Index
+		}
+		return &LogChecksumResp{Checksum: logsChecksum(rangeLogs), Count: uint32(len(rangeLogs)), LastIndex: lastIndex}
+	}
+	tl.logChecksum = &logChecksum{
+		opts:       opts,
+		mismatches: make(map[string]*logChecksumMismatchResp),
+		Log:        wklog.NewWKLog("testLogChecksum"),
+		localChecksum: func(req *LogChecksumReq) (*LogChecksumResp, error) {
+			return checksum(tl.leaderLogs, req), nil
+		},
+		replicaChecksum: func(replicaId uint64, req *LogChecksumReq) (*LogChecksumResp, error) {
+			tl.checksumReqs++
+			return checksum(tl.replicaLogs[replicaId], req), nil
+		},
+		replicaRepair: func(replicaId uint64, req *LogRepairReq) error {
+			tl.repairReqs = append(tl.repairReqs, req)
+			return nil
+		},
+		checkedIndex: func(shard *logChecksumShard) (uint64, error) {
+			return tl.checkedIndex, nil
+		},
+		setCheckedIndex: func(shard *logChecksumShard, index uint64) error {
+			tl.checkedIndex = index
+			return nil
+		},
+		onRepair: func(shard *logChecksumShard, mismatch *logChecksumMismatchResp) {
+			tl.repairRecords++
+		},
+	}
+	return tl
+}
+
+func newTestLogs(count int) []replica.Log {
+	logs := make([]replica.Log, 0, count)
+	for i := 1; i <= count; i++ {
+		logs = append(logs, replica.Log{Id: uint64(i), Index: uint64(i), Term: 1, Data: []byte(fmt.Sprintf("data-%d", i))})
+	}
+	return logs
+}
+
+func TestLogChecksumCheckShard(t *testing.T) {
+	leaderLogs := newTestLogs(10)
+	divergentLogs := newTestLogs(10)
+	divergentLogs[6].Data = []byte("divergent") // 下标7的日志内容不一致
+
+	opts := NewOptions(WithLogChecksumRangeSize(100), WithLogChecksumAutoRepair(true))
+	tl := newTestLogChecksum(opts, leaderLogs, map[uint64][]replica.Log{
+		2: newTestLogs(10),
+		3: divergentLogs,
+	})
+	shard := &logChecksumShard{shardType: ShardTypeChannel, channelId: "test", channelType: 2, key: "test", committedIndex: 10, replicas: []uint64{2, 3}}
+
+	tl.checkShard(shard)
+
+	// 找到第一条分歧的日志并请求修复，校验进度停在分歧的日志之前
+	mismatches := tl.mismatchList()
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, uint64(3), mismatches[0].ReplicaId)
+	assert.Equal(t, uint64(7), mismatches[0].DivergentIndex)
+	assert.Equal(t, 1, mismatches[0].Repaired)
+	assert.Len(t, tl.repairReqs, 1)
+	assert.Equal(t, uint64(7), tl.repairReqs[0].Index)
+	assert.Equal(t, 1, tl.repairRecords)
+	assert.Equal(t, uint64(6), tl.checkedIndex)
+
+	// 副本修复后重新校验一致，分歧移除，校验进度推进到最新的提交日志
+	tl.replicaLogs[3] = newTestLogs(10)
+	tl.checkShard(shard)
+	assert.Len(t, tl.mismatchList(), 0)
+	assert.Equal(t, uint64(10), tl.checkedIndex)
+
+	// 没有新的提交日志，不再重复校验
+	reqs := tl.checksumReqs
+	tl.checkShard(shard)
+	assert.Equal(t, reqs, tl.checksumReqs)
+
+	// 新的提交日志只校验新的区间
+	tl.leaderLogs = newTestLogs(15)
+	tl.replicaLogs[2] = newTestLogs(15)
+	tl.replicaLogs[3] = newTestLogs(15)
+	shard.committedIndex = 15
+	tl.checkShard(shard)
+	assert.Equal(t, uint64(15), tl.checkedIndex)
+	assert.Equal(t, int64(10*2+4*2+5*2), tl.channelChecked.Load())
+}
+
+func TestLogChecksumReplicaBehind(t *testing.T) {
+	opts := NewOptions(WithLogChecksumRangeSize(4))
+	tl := newTestLogChecksum(opts, newTestLogs(10), map[uint64][]replica.Log{
+		2: newTestLogs(10),
+		3: newTestLogs(6),
+	})
+	shard := &logChecksumShard{shardType: ShardTypeSlot, slotId: 1, key: "1", committedIndex: 10, replicas: []uint64{2, 3}}
+
+	tl.checkShard(shard) // [1,4]
+	tl.checkShard(shard) // [5,8] 副本3还没同步到8
+	assert.Equal(t, uint64(4), tl.checkedIndex)
+	assert.Equal(t, int64(4*2+4), tl.slotChecked.Load())
+
+	// 副本追上后继续校验
+	tl.replicaLogs[3] = newTestLogs(10)
+	tl.checkShard(shard) // [5,8]
+	tl.checkShard(shard) // [9,10]
+	assert.Equal(t, uint64(10), tl.checkedIndex)
+	assert.Len(t, tl.mismatchList(), 0)
+}
+
+func TestLogChecksumMismatchWithoutRepair(t *testing.T) {
+	replicaLogs := newTestLogs(8)
+	replicaLogs[2].Term = 2 // 下标3的日志任期不一致
+
+	opts := NewOptions(WithLogChecksumRangeSize(100))
+	tl := newTestLogChecksum(opts, newTestLogs(8), map[uint64][]replica.Log{2: replicaLogs})
+	shard := &logChecksumShard{shardType: ShardTypeSlot, slotId: 1, key: "1", committedIndex: 8, replicas: []uint64{2}}
+
+	tl.checkShard(shard)
+	assert.Len(t, tl.repairReqs, 0)
+	mismatches := tl.mismatchList()
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, uint64(3), mismatches[0].DivergentIndex)
+	assert.Equal(t, 0, mismatches[0].Repaired)
+	assert.Equal(t, uint64(2), tl.checkedIndex)
+	assert.Equal(t, int64(1), tl.metrics(ShardTypeSlot).Mismatches)
+	assert.Equal(t, int64(0), tl.metrics(ShardTypeChannel).Mismatches)
+}
+
+func TestLogChecksumLeaderNotConfirmed(t *testing.T) {
+	leaderLogs := newTestLogs(10)
+	leaderLogs[4].Data = []byte("corrupted") // 领导下标5的日志损坏
+
+	opts := NewOptions(WithLogChecksumRangeSize(100), WithLogChecksumAutoRepair(true))
+	tl := newTestLogChecksum(opts, leaderLogs, map[uint64][]replica.Log{
+		2: newTestLogs(10),
+		3: newTestLogs(10),
+	})
+	shard := &logChecksumShard{shardType: ShardTypeSlot, slotId: 1, key: "1", committedIndex: 10, replicas: []uint64{2, 3}}
+
+	tl.checkShard(shard)
+
+	// 多数副本与领导不一致，不能用领导的日志覆盖副本，只记录分歧
+	assert.Len(t, tl.repairReqs, 0)
+	assert.Equal(t, 0, tl.repairRecords)
+	mismatches := tl.mismatchList()
+	assert.Len(t, mismatches, 2)
+	for _, mismatch := range mismatches {
+		assert.Equal(t, uint64(5), mismatch.DivergentIndex)
+		assert.Equal(t, 0, mismatch.Repaired)
+		assert.Equal(t, ErrLogChecksumNoQuorum.Error(), mismatch.RepairError)
+	}
+	assert.Equal(t, uint64(4), tl.checkedIndex)
+}
+
+func TestLogChecksumFirstDivergentIndex(t *testing.T) {
+	for _, divergent := range []int{1, 2, 50, 99, 100} {
+		replicaLogs := newTestLogs(100)
+		replicaLogs[divergent-1].Data = []byte("divergent")
+		tl := newTestLogChecksum(NewOptions(), newTestLogs(100), map[uint64][]replica.Log{2: replicaLogs})
+		shard := &logChecksumShard{shardType: ShardTypeSlot, slotId: 1, key: "1", committedIndex: 100, replicas: []uint64{2}}
+
+		index, err := tl.firstDivergentIndex(shard, 2, 1, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(divergent), index)
+	}
+
+	// 副本缺少日志
+	tl := newTestLogChecksum(NewOptions(), newTestLogs(100), map[uint64][]replica.Log{2: newTestLogs(60)})
+	shard := &logChecksumShard{shardType: ShardTypeSlot, slotId: 1, key: "1", committedIndex: 100, replicas: []uint64{2}}
+	index, err := tl.firstDivergentIndex(shard, 2, 1, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(61), index)
+}
+
+// 截断已应用的分歧日志（存储不允许截断已应用的日志，需要先回退已应用的下标）
+func TestTruncateDivergentLog(t *testing.T) {
+	trace.SetGlobalTrace(trace.New(context.Background(), trace.NewOptions(trace.WithServiceName("test"), trace.WithServiceHostName("host"))))
+
+	storage := NewPebbleShardLogStorage(t.TempDir(), 1)
+	err := storage.Open()
+	assert.NoError(t, err)
+	defer storage.Close()
+
+	shardNo := "1"
+	err = storage.Append(reactor.AppendLogReq{HandleKey: shardNo, Logs: newTestLogs(10)})
+	assert.NoError(t, err)
+	err = storage.SetAppliedIndex(shardNo, 10)
+	assert.NoError(t, err)
+
+	s := &Server{opts: NewOptions(), Log: wklog.NewWKLog("test")}
+	err = s.truncateDivergentLog(storage, shardNo, 7)
+	assert.NoError(t, err)
+
+	lastIndex, err := storage.LastIndex(shardNo)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), lastIndex)
+	appliedIndex, err := storage.AppliedIndex(shardNo)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), appliedIndex)
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"go.uber.org/zap"
+)
+
+// 维护模式下转移频道领导的最大轮数
+const maintenanceTransferMaxRound = 30
+
+// SetNodeMaintenance 设置节点的维护模式（只能在配置领导节点上调用）
+// 进入维护模式后，节点上的槽领导和频道领导会被转移走，/route不再把新连接分配到该节点，节点下线也不再计入离线次数。
+func (s *Server) SetNodeMaintenance(nodeId uint64, maintenance bool) error {
+	if !s.clusterEventServer.IsLeader() {
+		return ErrNotIsLeader
+	}
+	node := s.clusterEventServer.Node(nodeId)
+	if node == nil {
+		return ErrNodeNotFound
+	}
+	if node.Maintenance == maintenance {
+		return nil
+	}
+	if maintenance {
+		// 至少保留一个可以接管领导的节点
+		available := false
+		for _, n := range s.clusterEventServer.Nodes() {
+			if n.Id != nodeId && n.Online && n.AllowVote && !n.Maintenance {
+				available = true
+				break
+			}
+		}
+		if !available {
+			return errors.New("no available node to take over leaders")
+		}
+	}
+	err := s.clusterEventServer.ProposeNodeMaintenance(nodeId, maintenance)
+	if err != nil {
+		s.Error("SetNodeMaintenance: ProposeNodeMaintenance failed", zap.Error(err), zap.Uint64("nodeId", nodeId))
+		return err
+	}
+	s.Info("node maintenance changed", zap.Uint64("nodeId", nodeId), zap.Bool("maintenance", maintenance))
+	return nil
+}
+
+// 本节点进入维护模式后，将本节点上已加载的频道领导转移走（槽领导由配置领导负责转移，
+// 没有加载的频道在槽领导加载频道配置时转移，见needMaintenanceTransfer）
+func (s *Server) handleLocalNodeMaintenance() {
+	if !s.nodeMaintenance(s.opts.NodeId) {
+		s.maintenance.Store(false)
+		return
+	}
+	if !s.maintenance.CompareAndSwap(false, true) {
+		return
+	}
+	go s.transferChannelLeadersForMaintenance()
+}
+
+func (s *Server) transferChannelLeadersForMaintenance() {
+	for round := 0; round < maintenanceTransferMaxRound; round++ {
+		if s.stopped.Load() || !s.nodeMaintenance(s.opts.NodeId) {
+			return
+		}
+		var channels []*channel
+		s.channelManager.iterate(func(ch *channel) bool {
+			if ch.isLeader() && !ch.migrating() {
+				channels = append(channels, ch)
+			}
+			return true
+		})
+		if len(channels) == 0 {
+			return
+		}
+		s.Info("transfer channel leaders for maintenance", zap.Int("count", len(channels)), zap.Int("round", round))
+		for _, ch := range channels {
+			err := s.requestChannelTransferLeader(ch.channelId, ch.channelType)
+			if err != nil {
+				s.Warn("transfer channel leader for maintenance failed", zap.Error(err), zap.String("channelId", ch.channelId), zap.Uint8("channelType", ch.channelType))
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	s.Warn("some channel leaders are still on the maintenance node")
+}
+
+// 频道领导在线但处于维护模式，需要转移领导（领导下线后由needElection重新选举）
+func (s *Server) needMaintenanceTransfer(cfg wkdb.ChannelClusterConfig) bool {
+	if cfg.LeaderId == 0 || cfg.MigrateFrom != 0 || cfg.MigrateTo != 0 {
+		return false
+	}
+	return s.nodeMaintenance(cfg.LeaderId) && s.clusterEventServer.NodeOnline(cfg.LeaderId)
+}
+
+// 在槽领导上转移维护节点上的频道领导（目标副本追上日志后才切换，所以异步调用）
+func (s *Server) transferChannelLeaderForMaintenance(channelId string, channelType uint8) {
+	toNodeId, err := s.TransferChannelLeader(channelId, channelType, 0, false)
+	if err != nil {
+		s.Warn("transfer channel leader for maintenance failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return
+	}
+	s.Info("transfer channel leader for maintenance", zap.String("channelId", channelId), zap.Uint8("channelType", channelType), zap.Uint64("toNodeId", toNodeId))
+}
+
+// 请求频道所属槽的领导转移频道领导
+func (s *Server) requestChannelTransferLeader(channelId string, channelType uint8) error {
+	slotId := s.getSlotId(channelId)
+	slot := s.clusterEventServer.Slot(slotId)
+	if slot == nil {
+		return ErrSlotNotExist
+	}
+	if slot.Leader == s.opts.NodeId {
+		_, err := s.TransferChannelLeader(channelId, channelType, 0, false)
+		return err
+	}
+	leaderNode := s.nodeManager.node(slot.Leader)
+	if leaderNode == nil {
+		return ErrSlotLeaderNotFound
+	}
+	timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ReqTimeout)
+	defer cancel()
+	return leaderNode.requestChannelTransferLeader(timeoutCtx, &ChannelClusterConfigReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		From:        s.opts.NodeId,
+	})
+}
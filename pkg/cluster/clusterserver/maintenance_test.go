@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterevent"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wklog"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试用的服务，只带有指定节点的分布式配置
+func newTestMaintenanceServer(t *testing.T, nodes []*pb.Node) *Server {
+	s := &Server{
+		clusterEventServer: clusterevent.New(clusterevent.NewOptions(clusterevent.WithNodeId(1), clusterevent.WithConfigDir(t.TempDir()))),
+		Log:                wklog.NewWKLog("testMaintenance"),
+	}
+	s.clusterEventServer.Config().Nodes = nodes
+	return s
+}
+
+func TestNeedMaintenanceTransfer(t *testing.T) {
+	s := newTestMaintenanceServer(t, []*pb.Node{
+		{Id: 1, Online: true, Maintenance: true},
+		{Id: 2, Online: true},
+		{Id: 3, Online: false, Maintenance: true},
+	})
+
+	// 领导在线且在维护中需要转移
+	assert.True(t, s.needMaintenanceTransfer(wkdb.ChannelClusterConfig{LeaderId: 1, Replicas: []uint64{1, 2}}))
+	// 已经在转移中
+	assert.False(t, s.needMaintenanceTransfer(wkdb.ChannelClusterConfig{LeaderId: 1, Replicas: []uint64{1, 2}, MigrateFrom: 1, MigrateTo: 2}))
+	// 领导不在维护中
+	assert.False(t, s.needMaintenanceTransfer(wkdb.ChannelClusterConfig{LeaderId: 2, Replicas: []uint64{1, 2}}))
+	// 维护中的领导已下线，由选举处理
+	assert.False(t, s.needMaintenanceTransfer(wkdb.ChannelClusterConfig{LeaderId: 3, Replicas: []uint64{3, 2}}))
+	assert.True(t, s.needElection(wkdb.ChannelClusterConfig{LeaderId: 3, Replicas: []uint64{3, 2}}))
+}
+
+func TestChannelLeaderIDByLogInfoSkipMaintenance(t *testing.T) {
+	s := newTestMaintenanceServer(t, []*pb.Node{
+		{Id: 1, Online: true, Maintenance: true},
+		{Id: 2, Online: true},
+		{Id: 3, Online: true},
+	})
+	c := &channelElectionManager{s: s}
+	resp := func(replicaId uint64, logIndex uint64) *replicaChannelLastLogInfoResponse {
+		return &replicaChannelLastLogInfoResponse{
+			replicaId:                  replicaId,
+			ChannelLastLogInfoResponse: &ChannelLastLogInfoResponse{LogIndex: logIndex, LogTerm: 1, Term: 1},
+		}
+	}
+
+	// 日志同样新的情况下不选维护中的节点
+	assert.Equal(t, uint64(2), c.channelLeaderIDByLogInfo([]*replicaChannelLastLogInfoResponse{resp(1, 10), resp(2, 10), resp(3, 9)}, 0))
+	// 维护中的节点不能作为优先领导
+	assert.Equal(t, uint64(2), c.channelLeaderIDByLogInfo([]*replicaChannelLastLogInfoResponse{resp(1, 10), resp(2, 10), resp(3, 10)}, 1))
+	// 只有维护中的节点日志最新时仍然选它，避免丢失日志
+	assert.Equal(t, uint64(1), c.channelLeaderIDByLogInfo([]*replicaChannelLastLogInfoResponse{resp(1, 10), resp(2, 9), resp(3, 9)}, 0))
+}
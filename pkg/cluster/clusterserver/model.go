@@ -42,10 +42,20 @@ var (
 	ErrNoLeader                     = errors.New("no leader")
 	ErrChannelElectionCIsFull       = errors.New("channel election c is full")
 	ErrNoAllowVoteNode              = errors.New("no allow vote node")
+	ErrNodeNotAllowVote             = errors.New("node not allow vote")
+	ErrSlotResizing                 = errors.New("slot resizing")
+	ErrSlotNotResizing              = errors.New("slot not resizing")
+	ErrInvalidSlotCount             = errors.New("invalid slot count")
+	ErrSlotMigrating                = errors.New("slot migrating")
 	ErrNodeNotExist                 = errors.New("node not exist")
 	ErrSlotLeaderNotFound           = errors.New("slot leader not found")
 	ErrEmptyRequest                 = errors.New("empty request")
 	ErrChannelClusterConfigNotFound = errors.New("channel cluster config not found")
+	ErrQuorumNotLost                = errors.New("quorum is not lost, use migrate or transfer leader instead")
+	ErrNoSurvivalReplica            = errors.New("no surviving replica")
+	ErrLostReplicasRequired         = errors.New("lost replicas is required")
+	ErrInvalidShardType             = errors.New("invalid shard type")
+	ErrLogChecksumNoQuorum          = errors.New("leader log checksum is not confirmed by a majority of replicas")
 )
 
 // // 频道分布式配置
@@ -544,6 +554,66 @@ func (s *SlotProposeResp) Unmarshal(data []byte) error {
 	return nil
 }
 
+// SlotResizeReplayReq 请求源槽领导将日志回放到目标槽
+type SlotResizeReplayReq struct {
+	SlotId     uint32 // 源槽id
+	StartIndex uint64 // 从此日志下标开始回放，为0时由源槽领导决定
+	Final      bool   // 是否是源槽冻结后的追赶回放
+}
+
+func (s *SlotResizeReplayReq) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint32(s.SlotId)
+	enc.WriteUint64(s.StartIndex)
+	enc.WriteUint8(uint8(wkutil.BoolToInt(s.Final)))
+	return enc.Bytes(), nil
+}
+
+func (s *SlotResizeReplayReq) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if s.SlotId, err = dec.Uint32(); err != nil {
+		return err
+	}
+	if s.StartIndex, err = dec.Uint64(); err != nil {
+		return err
+	}
+	var final uint8
+	if final, err = dec.Uint8(); err != nil {
+		return err
+	}
+	s.Final = wkutil.IntToBool(int(final))
+	return nil
+}
+
+type SlotResizeReplayResp struct {
+	NextIndex uint64 // 下次回放的开始下标
+	Done      bool   // 是否已全部回放完成
+}
+
+func (s *SlotResizeReplayResp) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(s.NextIndex)
+	enc.WriteUint8(uint8(wkutil.BoolToInt(s.Done)))
+	return enc.Bytes(), nil
+}
+
+func (s *SlotResizeReplayResp) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if s.NextIndex, err = dec.Uint64(); err != nil {
+		return err
+	}
+	var done uint8
+	if done, err = dec.Uint8(); err != nil {
+		return err
+	}
+	s.Done = wkutil.IntToBool(int(done))
+	return nil
+}
+
 type SlotLogInfoReq struct {
 	SlotIds []uint32
 }
@@ -627,10 +697,137 @@ func (s *SlotLogInfoResp) Unmarshal(data []byte) error {
 	return nil
 }
 
+// LogChecksumReq 请求副本计算槽或频道的日志校验和
+type LogChecksumReq struct {
+	ShardType   ShardType // 分区类型（槽或频道）
+	SlotId      uint32    // 槽id（分区类型为槽时有效）
+	ChannelId   string    // 频道id（分区类型为频道时有效）
+	ChannelType uint8     // 频道类型（分区类型为频道时有效）
+	StartIndex  uint64    // 开始日志下标（包含）
+	EndIndex    uint64    // 结束日志下标（包含）
+}
+
+func (l *LogChecksumReq) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint8(uint8(l.ShardType))
+	enc.WriteUint32(l.SlotId)
+	enc.WriteString(l.ChannelId)
+	enc.WriteUint8(l.ChannelType)
+	enc.WriteUint64(l.StartIndex)
+	enc.WriteUint64(l.EndIndex)
+	return enc.Bytes(), nil
+}
+
+func (l *LogChecksumReq) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	var shardType uint8
+	if shardType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	l.ShardType = ShardType(shardType)
+	if l.SlotId, err = dec.Uint32(); err != nil {
+		return err
+	}
+	if l.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if l.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if l.StartIndex, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if l.EndIndex, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type LogChecksumResp struct {
+	Checksum  uint64 // 日志校验和
+	Count     uint32 // 参与计算的日志数量
+	LastIndex uint64 // 副本最新的日志下标
+}
+
+func (l *LogChecksumResp) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(l.Checksum)
+	enc.WriteUint32(l.Count)
+	enc.WriteUint64(l.LastIndex)
+	return enc.Bytes(), nil
+}
+
+func (l *LogChecksumResp) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if l.Checksum, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if l.Count, err = dec.Uint32(); err != nil {
+		return err
+	}
+	if l.LastIndex, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LogRepairReq 领导请求副本从分歧的日志下标开始截断日志，然后重新从领导同步
+type LogRepairReq struct {
+	ShardType   ShardType // 分区类型（槽或频道）
+	SlotId      uint32    // 槽id（分区类型为槽时有效）
+	ChannelId   string    // 频道id（分区类型为频道时有效）
+	ChannelType uint8     // 频道类型（分区类型为频道时有效）
+	LeaderId    uint64    // 发起修复的领导id
+	Index       uint64    // 第一条分歧的日志下标（从此下标开始截断）
+}
+
+func (l *LogRepairReq) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint8(uint8(l.ShardType))
+	enc.WriteUint32(l.SlotId)
+	enc.WriteString(l.ChannelId)
+	enc.WriteUint8(l.ChannelType)
+	enc.WriteUint64(l.LeaderId)
+	enc.WriteUint64(l.Index)
+	return enc.Bytes(), nil
+}
+
+func (l *LogRepairReq) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	var shardType uint8
+	if shardType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	l.ShardType = ShardType(shardType)
+	if l.SlotId, err = dec.Uint32(); err != nil {
+		return err
+	}
+	if l.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if l.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if l.LeaderId, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if l.Index, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
 type ClusterJoinReq struct {
 	NodeId     uint64
 	ServerAddr string
 	Role       pb.NodeRole
+	Zone       string // 节点所在的可用区/机架
 }
 
 func (c *ClusterJoinReq) Marshal() ([]byte, error) {
@@ -639,6 +836,7 @@ func (c *ClusterJoinReq) Marshal() ([]byte, error) {
 	enc.WriteUint64(c.NodeId)
 	enc.WriteString(c.ServerAddr)
 	enc.WriteUint32(uint32(c.Role))
+	enc.WriteString(c.Zone)
 	return enc.Bytes(), nil
 
 }
@@ -657,6 +855,12 @@ func (c *ClusterJoinReq) Unmarshal(data []byte) error {
 		return err
 	}
 	c.Role = pb.NodeRole(role)
+
+	if dec.Len() > 0 {
+		if c.Zone, err = dec.String(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1059,10 +1263,12 @@ type NodeConfig struct {
 	Role            pb.NodeRole    `json:"role"`                        // 节点角色
 	ClusterAddr     string         `json:"cluster_addr"`                // 集群地址
 	ApiServerAddr   string         `json:"api_server_addr,omitempty"`   // API服务地址
+	Zone            string         `json:"zone,omitempty"`              // 可用区/机架
 	Online          int            `json:"online,omitempty"`            // 是否在线
 	OfflineCount    int            `json:"offline_count,omitempty"`     // 下线次数
 	LastOffline     string         `json:"last_offline,omitempty"`      // 最后一次下线时间
 	AllowVote       int            `json:"allow_vote"`                  // 是否允许投票
+	Maintenance     int            `json:"maintenance,omitempty"`       // 是否处于维护模式
 	SlotCount       int            `json:"slot_count,omitempty"`        // 槽位数量
 	Term            uint32         `json:"term,omitempty"`              // 任期
 	SlotLeaderCount int            `json:"slot_leader_count,omitempty"` // 槽位领导者数量
@@ -1097,10 +1303,12 @@ func NewNodeConfigFromNode(n *pb.Node) *NodeConfig {
 		Role:          n.Role,
 		ClusterAddr:   n.ClusterAddr,
 		ApiServerAddr: n.ApiServerAddr,
+		Zone:          n.Zone,
 		Online:        wkutil.BoolToInt(n.Online),
 		OfflineCount:  int(n.OfflineCount),
 		LastOffline:   lastOffline,
 		AllowVote:     wkutil.BoolToInt(n.AllowVote),
+		Maintenance:   wkutil.BoolToInt(n.Maintenance),
 		Status:        n.Status,
 		StatusFormat:  status,
 	}
@@ -1773,6 +1981,42 @@ type channelReplicaDetailResp struct {
 
 }
 
+// 节点的复制健康状态
+type replicationHealthResp struct {
+	NodeId   uint64                `json:"node_id"`  // 节点id
+	Slot     reactor.Stats         `json:"slot"`     // 槽的提案和应用统计
+	Channel  reactor.Stats         `json:"channel"`  // 频道的提案和应用统计
+	Replicas []*replicaHealthResp  `json:"replicas"` // 各个副本节点的落后情况
+	Slots    []*replicationLagResp `json:"slots"`    // 本节点作为领导的槽
+	Channels []*replicationLagResp `json:"channels"` // 本节点作为领导的活跃频道（按落后数量倒序）
+}
+
+// 副本节点的落后情况汇总
+type replicaHealthResp struct {
+	ReplicaId    uint64 `json:"replica_id"`    // 副本节点id
+	MaxLag       uint64 `json:"max_lag"`       // 最大落后的日志数量
+	LaggingCount int    `json:"lagging_count"` // 落后的槽和频道数量
+}
+
+// 槽或频道的复制落后情况
+type replicationLagResp struct {
+	SlotId           uint32            `json:"slot_id,omitempty"`      // 槽id
+	ChannelId        string            `json:"channel_id,omitempty"`   // 频道id
+	ChannelType      uint8             `json:"channel_type,omitempty"` // 频道类型
+	LastIndex        uint64            `json:"last_index"`             // 领导最新日志下标
+	CommittedIndex   uint64            `json:"committed_index"`        // 已提交的日志下标
+	PendingProposals int               `json:"pending_proposals"`      // 等待提交的提案数量
+	MaxLag           uint64            `json:"max_lag"`                // 副本最大落后的日志数量
+	Replicas         []*replicaLagResp `json:"replicas"`               // 各个副本的落后情况
+}
+
+type replicaLagResp struct {
+	ReplicaId uint64 `json:"replica_id"` // 副本节点id
+	LastIndex uint64 `json:"last_index"` // 副本最新日志下标
+	Lag       uint64 `json:"lag"`        // 落后的日志数量
+	Learner   int    `json:"learner"`    // 是否是学习者
+}
+
 type ping struct {
 	no        string // ping唯一编号
 	from      uint64 // 发起节点
@@ -1848,3 +2092,93 @@ func unmarshalPong(data []byte) (*pong, error) {
 		startMill: startMill,
 	}, nil
 }
+
+// 强制重新配置副本的结果（多数副本永久丢失时的恢复）
+type forceReconfigResp struct {
+	DryRun       bool                    `json:"dry_run"`                // 是否只是预演（没有真正修改配置）
+	SlotId       uint32                  `json:"slot_id,omitempty"`      // 槽id
+	ChannelId    string                  `json:"channel_id,omitempty"`   // 频道id
+	ChannelType  uint8                   `json:"channel_type,omitempty"` // 频道类型
+	OldLeader    uint64                  `json:"old_leader"`             // 原领导
+	NewLeader    uint64                  `json:"new_leader"`             // 新领导（存活副本中日志最新的）
+	OldReplicas  []uint64                `json:"old_replicas"`           // 原副本
+	NewReplicas  []uint64                `json:"new_replicas"`           // 新副本（存活的副本）
+	LostReplicas []uint64                `json:"lost_replicas"`          // 丢失的副本
+	Term         uint32                  `json:"term"`                   // 新的任期
+	ReplicaLogs  []*forceReconfigLogResp `json:"replica_logs"`           // 存活副本的日志信息
+	AuditId      uint64                  `json:"audit_id,omitempty"`     // 审计事件id
+}
+
+type forceReconfigLogResp struct {
+	ReplicaId uint64 `json:"replica_id"` // 副本节点id
+	LogIndex  uint64 `json:"log_index"`  // 最新日志下标
+	LogTerm   uint32 `json:"log_term"`   // 最新日志任期
+}
+
+// 运维审计事件
+type auditEventResp struct {
+	Id        string          `json:"id"`         // 事件id（字符串，避免js精度丢失）
+	Action    string          `json:"action"`     // 操作
+	Target    string          `json:"target"`     // 操作对象
+	Operator  string          `json:"operator"`   // 操作者
+	NodeId    uint64          `json:"node_id"`    // 执行操作的节点
+	Detail    json.RawMessage `json:"detail"`     // 操作详情
+	CreatedAt int64           `json:"created_at"` // 创建时间（秒）
+}
+
+func newAuditEventResp(event wkdb.AuditEvent) *auditEventResp {
+	detail := json.RawMessage(event.Detail)
+	if !json.Valid(detail) {
+		detail, _ = json.Marshal(event.Detail)
+	}
+	return &auditEventResp{
+		Id:        strconv.FormatUint(event.Id, 10),
+		Action:    event.Action,
+		Target:    event.Target,
+		Operator:  event.Operator,
+		NodeId:    event.NodeId,
+		Detail:    detail,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// 槽数量调整任务的状态
+type slotResizeResp struct {
+	Status          string   `json:"status"`            // 状态 running:调整中 done:调整完成 failed:调整失败
+	SlotCount       uint32   `json:"slot_count"`        // 原槽数量
+	ResizeSlotCount uint32   `json:"resize_slot_count"` // 新的槽数量
+	Total           int      `json:"total"`             // 需要回放日志的源槽数量
+	Replayed        int      `json:"replayed"`          // 已回放完成的源槽数量
+	ReplayedSlots   []uint32 `json:"replayed_slots"`    // 已回放完成的源槽
+	Error           string   `json:"error,omitempty"`   // 失败原因
+	StartedAt       int64    `json:"started_at"`        // 开始时间（秒）
+	FinishedAt      int64    `json:"finished_at"`       // 结束时间（秒）
+}
+
+// 本节点的日志校验状态
+type logChecksumResp struct {
+	NodeId         uint64                     `json:"node_id"`         // 节点id
+	On             int                        `json:"on"`              // 是否开启了日志校验
+	AutoRepair     int                        `json:"auto_repair"`     // 是否自动修复分歧的副本
+	Interval       string                     `json:"interval"`        // 校验间隔
+	RangeSize      uint64                     `json:"range_size"`      // 每次校验的日志数量
+	SlotChecked    int64                      `json:"slot_checked"`    // 已校验的槽日志数量（每个副本分别统计）
+	ChannelChecked int64                      `json:"channel_checked"` // 已校验的频道日志数量（每个副本分别统计）
+	Mismatches     []*logChecksumMismatchResp `json:"mismatches"`      // 未解决的日志分歧
+}
+
+// 领导与副本的日志分歧
+type logChecksumMismatchResp struct {
+	SlotId          uint32 `json:"slot_id,omitempty"`      // 槽id
+	ChannelId       string `json:"channel_id,omitempty"`   // 频道id
+	ChannelType     uint8  `json:"channel_type,omitempty"` // 频道类型
+	ReplicaId       uint64 `json:"replica_id"`             // 分歧的副本
+	StartIndex      uint64 `json:"start_index"`            // 校验的开始日志下标
+	EndIndex        uint64 `json:"end_index"`              // 校验的结束日志下标
+	DivergentIndex  uint64 `json:"divergent_index"`        // 第一条分歧的日志下标
+	LeaderChecksum  uint64 `json:"leader_checksum"`        // 领导的校验和
+	ReplicaChecksum uint64 `json:"replica_checksum"`       // 副本的校验和
+	Repaired        int    `json:"repaired"`               // 是否已发起修复
+	RepairError     string `json:"repair_error,omitempty"` // 修复失败的原因
+	DetectedAt      int64  `json:"detected_at"`            // 发现时间（秒）
+}
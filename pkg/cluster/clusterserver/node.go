@@ -258,6 +258,85 @@ func (n *node) requestSlotPropose(ctx context.Context, req *SlotProposeReq) (*Sl
 	return proposeMessageResp, nil
 }
 
+func (n *node) requestSlotResizeReplay(ctx context.Context, req *SlotResizeReplayReq) (*SlotResizeReplayResp, error) {
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.client.RequestWithContext(ctx, "/slot/resize/replay", data)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != proto.StatusOK {
+		return nil, fmt.Errorf("requestSlotResizeReplay is failed, status:%d", resp.Status)
+	}
+	replayResp := &SlotResizeReplayResp{}
+	err = replayResp.Unmarshal(resp.Body)
+	return replayResp, err
+}
+
+func (n *node) requestChannelTransferLeader(ctx context.Context, req *ChannelClusterConfigReq) error {
+	data, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.RequestWithContext(ctx, "/channel/transferLeader", data)
+	if err != nil {
+		return err
+	}
+	if resp.Status != proto.StatusOK {
+		return fmt.Errorf("requestChannelTransferLeader is failed, status:%d", resp.Status)
+	}
+	return nil
+}
+
+func (n *node) requestLogChecksum(ctx context.Context, req *LogChecksumReq) (*LogChecksumResp, error) {
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.client.RequestWithContext(ctx, "/log/checksum", data)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != proto.StatusOK {
+		return nil, fmt.Errorf("requestLogChecksum is failed, status:%d", resp.Status)
+	}
+	checksumResp := &LogChecksumResp{}
+	err = checksumResp.Unmarshal(resp.Body)
+	return checksumResp, err
+}
+
+func (n *node) requestLogRepair(ctx context.Context, req *LogRepairReq) error {
+	data, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.RequestWithContext(ctx, "/log/repair", data)
+	if err != nil {
+		return err
+	}
+	if resp.Status != proto.StatusOK {
+		return fmt.Errorf("requestLogRepair is failed, status:%d", resp.Status)
+	}
+	return nil
+}
+
+func (n *node) requestAuditEventAdd(ctx context.Context, event wkdb.AuditEvent) error {
+	data, err := event.Marshal()
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.RequestWithContext(ctx, "/audit/add", data)
+	if err != nil {
+		return err
+	}
+	if resp.Status != proto.StatusOK {
+		return fmt.Errorf("requestAuditEventAdd is failed, status:%d", resp.Status)
+	}
+	return nil
+}
+
 func (n *node) requestClusterJoin(ctx context.Context, req *ClusterJoinReq) (*ClusterJoinResp, error) {
 	data, err := req.Marshal()
 	if err != nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 )
 
 type nodeManager struct {
@@ -93,6 +95,36 @@ func (n *nodeManager) requestSlotLogInfo(ctx context.Context, to uint64, req *Sl
 	return node.requestSlotLogInfo(timeoutCtx, req)
 }
 
+func (n *nodeManager) requestLogChecksum(ctx context.Context, to uint64, req *LogChecksumReq) (*LogChecksumResp, error) {
+	node := n.node(to)
+	if node == nil {
+		return nil, fmt.Errorf("node[%d] not found", to)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, n.opts.ReqTimeout)
+	defer cancel()
+	return node.requestLogChecksum(timeoutCtx, req)
+}
+
+func (n *nodeManager) requestLogRepair(ctx context.Context, to uint64, req *LogRepairReq) error {
+	node := n.node(to)
+	if node == nil {
+		return fmt.Errorf("node[%d] not found", to)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, n.opts.ReqTimeout)
+	defer cancel()
+	return node.requestLogRepair(timeoutCtx, req)
+}
+
+func (n *nodeManager) requestAuditEventAdd(ctx context.Context, to uint64, event wkdb.AuditEvent) error {
+	node := n.node(to)
+	if node == nil {
+		return fmt.Errorf("node[%d] not found", to)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, n.opts.ReqTimeout)
+	defer cancel()
+	return node.requestAuditEventAdd(timeoutCtx, event)
+}
+
 func (n *nodeManager) requestClusterJoin(to uint64, req *ClusterJoinReq) (*ClusterJoinResp, error) {
 	node := n.node(to)
 	if node == nil {
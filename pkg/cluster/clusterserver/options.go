@@ -18,11 +18,14 @@ type Options struct {
 	Addr          string      // 分布式监听地址
 	ServerAddr    string      // 分布式可访问地址
 	ApiServerAddr string      // api服务地址
+	Zone          string      // 节点所在的可用区/机架
 	JaegerApiUrl  string      // jaeger api地址
 	ServiceName   string      // 服务名称
 	AppVersion    string      // 当前应用版本
 	// InitNodes 集群初始节点，key为节点id，value为节点内网通信地址
 	InitNodes map[uint64]string
+	// InitNodeZones 集群初始节点所在的可用区，key为节点id
+	InitNodeZones map[uint64]string
 	// SlotCount 槽位数量
 	SlotCount uint32
 	// SlotMaxReplicaCount 每个槽位最大副本数量
@@ -61,6 +64,11 @@ type Options struct {
 	// MessageLogStorage 消息日志存储
 	MessageLogStorage IShardLogStorage
 	OnSlotApply       func(slotId uint32, logs []replica.Log) error
+	// EncodeSlotImport 调整槽数量时，将源槽的日志数据包装成导入目标槽的数据
+	EncodeSlotImport func(sourceSlotId uint32, data []byte) ([]byte, error)
+	// SlotKeysOfLog 调整槽数量时，解析槽日志涉及的数据key（uid或channelId），用于只回放属于目标槽的日志
+	// 返回的keys为空表示日志属于固定的槽，不需要回放；ok为false表示无法解析，回放到所有目标槽
+	SlotKeysOfLog func(data []byte) (keys []string, ok bool)
 	// Send 发送消息
 	Send func(shardType ShardType, m reactor.Message)
 	// ChannelElectionPoolSize 频道选举协程池大小(意味着同时在选举的频道数量)
@@ -102,6 +110,12 @@ type Options struct {
 
 	LogDetailOn bool // 是否开启详细日志
 
+	// LogChecksumInterval 日志校验间隔，领导每隔这个时间比对一次自己与副本的日志校验和（0表示不校验）
+	LogChecksumInterval time.Duration
+	// LogChecksumRangeSize 每个槽或频道每次校验的日志数量
+	LogChecksumRangeSize uint64
+	// LogChecksumAutoRepair 发现副本日志分歧时，是否自动截断副本的分歧日志并重新从领导同步
+	LogChecksumAutoRepair bool
 }
 
 func NewOptions(opt ...Option) *Options {
@@ -136,6 +150,8 @@ func NewOptions(opt ...Option) *Options {
 		SlotDbShardNum:         8,
 
 		LokiJob: "wk",
+
+		LogChecksumRangeSize: 1000,
 	}
 	for _, o := range opt {
 		o(opts)
@@ -157,6 +173,13 @@ func WithInitNodes(initNodes map[uint64]string) Option {
 	}
 
 }
+
+func WithInitNodeZones(initNodeZones map[uint64]string) Option {
+	return func(o *Options) {
+		o.InitNodeZones = initNodeZones
+	}
+}
+
 func WithSlotCount(slotCount uint32) Option {
 	return func(o *Options) {
 		o.SlotCount = slotCount
@@ -174,6 +197,18 @@ func WithOnSlotApply(fn func(slotId uint32, logs []replica.Log) error) Option {
 	}
 }
 
+func WithEncodeSlotImport(fn func(sourceSlotId uint32, data []byte) ([]byte, error)) Option {
+	return func(o *Options) {
+		o.EncodeSlotImport = fn
+	}
+}
+
+func WithSlotKeysOfLog(fn func(data []byte) (keys []string, ok bool)) Option {
+	return func(o *Options) {
+		o.SlotKeysOfLog = fn
+	}
+}
+
 func WithLogSyncLimitSizeOfEach(size int) Option {
 	return func(o *Options) {
 		o.LogSyncLimitSizeOfEach = size
@@ -321,6 +356,12 @@ func WithApiServerAddr(addr string) Option {
 	}
 }
 
+func WithZone(zone string) Option {
+	return func(o *Options) {
+		o.Zone = zone
+	}
+}
+
 func WithLogLevel(level zapcore.Level) Option {
 	return func(o *Options) {
 		o.LogLevel = level
@@ -429,3 +470,21 @@ func WithLokiJob(job string) Option {
 		o.LokiJob = job
 	}
 }
+
+func WithLogChecksumInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.LogChecksumInterval = interval
+	}
+}
+
+func WithLogChecksumRangeSize(size uint64) Option {
+	return func(o *Options) {
+		o.LogChecksumRangeSize = size
+	}
+}
+
+func WithLogChecksumAutoRepair(autoRepair bool) Option {
+	return func(o *Options) {
+		o.LogChecksumAutoRepair = autoRepair
+	}
+}
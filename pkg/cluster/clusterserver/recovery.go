@@ -0,0 +1,296 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
+)
+
+// 审计事件的操作类型
+const (
+	auditActionSlotForceReconfig    = "slot_force_reconfig"
+	auditActionChannelForceReconfig = "channel_force_reconfig"
+	auditActionLogRepair            = "log_repair"
+)
+
+// ForceSlotReconfig 多数副本永久丢失后，强制把槽的副本改为存活的副本，并由存活副本中日志最新的节点担任领导（只能在配置领导节点上调用）
+// lostReplicas为运维人员确认永久丢失的副本，必须都不在线，并且剩下的副本不足多数时才允许执行；dryRun为true时只返回将要修改的配置，不做任何修改
+// 注意：丢失副本上已提交但还没同步到存活副本的日志会永久丢失
+func (s *Server) ForceSlotReconfig(slotId uint32, lostReplicas []uint64, dryRun bool, operator string) (*forceReconfigResp, error) {
+	if !s.clusterEventServer.IsLeader() {
+		return nil, ErrNotIsLeader
+	}
+	slot := s.clusterEventServer.Slot(slotId)
+	if slot == nil {
+		return nil, ErrSlotNotExist
+	}
+
+	survivors, err := s.splitSurvivalReplicas(slot.Replicas, lostReplicas)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取存活副本的日志信息
+	waitSlots := make(map[uint64][]uint32, len(survivors))
+	for _, replicaId := range survivors {
+		waitSlots[replicaId] = []uint32{slotId}
+	}
+	slotInfoResps, err := s.requestSlotInfos(waitSlots)
+	if err != nil {
+		return nil, err
+	}
+	replicaLogs := make([]*forceReconfigLogResp, 0, len(slotInfoResps))
+	for _, resp := range slotInfoResps {
+		for _, slotInfo := range resp.Slots {
+			if slotInfo.SlotId != slotId {
+				continue
+			}
+			replicaLogs = append(replicaLogs, &forceReconfigLogResp{
+				ReplicaId: resp.NodeId,
+				LogIndex:  slotInfo.LogIndex,
+				LogTerm:   slotInfo.LogTerm,
+			})
+		}
+	}
+	newLeader := s.highestLogReplica(replicaLogs)
+	if newLeader == 0 {
+		return nil, errors.New("no log info from surviving replicas")
+	}
+
+	newSlot := slot.Clone()
+	newSlot.Replicas = survivors
+	newSlot.Learners = s.survivalLearners(slot.Learners, survivors)
+	newSlot.Leader = newLeader
+	newSlot.Term++
+	newSlot.ExpectLeader = 0
+	newSlot.MigrateFrom = 0
+	newSlot.MigrateTo = 0
+	newSlot.Status = pb.SlotStatus_SlotStatusNormal
+
+	result := &forceReconfigResp{
+		DryRun:       dryRun,
+		SlotId:       slotId,
+		OldLeader:    slot.Leader,
+		NewLeader:    newLeader,
+		OldReplicas:  slot.Replicas,
+		NewReplicas:  newSlot.Replicas,
+		LostReplicas: lostReplicas,
+		Term:         newSlot.Term,
+		ReplicaLogs:  replicaLogs,
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	err = s.clusterEventServer.ProposeSlots([]*pb.Slot{newSlot})
+	if err != nil {
+		s.Error("ForceSlotReconfig: ProposeSlots failed", zap.Error(err), zap.Uint32("slotId", slotId))
+		return nil, err
+	}
+	result.AuditId = s.recordAuditEvent(auditActionSlotForceReconfig, fmt.Sprintf("slot:%d", slotId), operator, result)
+	return result, nil
+}
+
+// ForceChannelReconfig 多数副本永久丢失后，强制把频道的副本改为存活的副本，并由存活副本中日志最新的节点担任领导（只能在频道所属槽的领导节点上调用）
+// 频道配置保存在槽里，如果槽本身也失去了多数副本，需要先执行ForceSlotReconfig
+func (s *Server) ForceChannelReconfig(channelId string, channelType uint8, lostReplicas []uint64, dryRun bool, operator string) (*forceReconfigResp, error) {
+	cfg, err := s.getChannelClusterConfig(channelId, channelType)
+	if err != nil {
+		return nil, err
+	}
+	if wkdb.IsEmptyChannelClusterConfig(cfg) {
+		return nil, ErrChannelClusterConfigNotFound
+	}
+
+	survivors, err := s.splitSurvivalReplicas(cfg.Replicas, lostReplicas)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取存活副本的日志信息（不在线的副本不会被请求）
+	logInfoMap, err := s.channelElectionManager.requestChannelLastLogInfos([]electionReq{{cfg: cfg}})
+	if err != nil {
+		return nil, err
+	}
+	replicaLogs := make([]*forceReconfigLogResp, 0, len(logInfoMap))
+	lastInfoResps := make([]*replicaChannelLastLogInfoResponse, 0, len(logInfoMap))
+	for _, replicaId := range survivors {
+		for _, resp := range logInfoMap[replicaId] {
+			if resp.ChannelId != channelId || resp.ChannelType != channelType {
+				continue
+			}
+			replicaLogs = append(replicaLogs, &forceReconfigLogResp{
+				ReplicaId: replicaId,
+				LogIndex:  resp.LogIndex,
+				LogTerm:   resp.LogTerm,
+			})
+			lastInfoResps = append(lastInfoResps, &replicaChannelLastLogInfoResponse{
+				replicaId:                  replicaId,
+				ChannelLastLogInfoResponse: resp,
+			})
+		}
+	}
+	if len(lastInfoResps) == 0 {
+		return nil, errors.New("no log info from surviving replicas")
+	}
+	newLeader := s.channelElectionManager.channelLeaderIDByLogInfo(lastInfoResps, cfg.PreferredLeader)
+
+	newCfg := cfg.Clone()
+	newCfg.Replicas = survivors
+	newCfg.Learners = s.survivalLearners(cfg.Learners, survivors)
+	newCfg.LeaderId = newLeader
+	newCfg.Term++
+	newCfg.MigrateFrom = 0
+	newCfg.MigrateTo = 0
+	newCfg.Status = wkdb.ChannelClusterStatusNormal
+	if !wkutil.ArrayContainsUint64(survivors, newCfg.PreferredLeader) {
+		newCfg.PreferredLeader = 0
+	}
+	newCfg.ConfVersion = uint64(time.Now().UnixNano())
+
+	result := &forceReconfigResp{
+		DryRun:       dryRun,
+		ChannelId:    channelId,
+		ChannelType:  channelType,
+		OldLeader:    cfg.LeaderId,
+		NewLeader:    newLeader,
+		OldReplicas:  cfg.Replicas,
+		NewReplicas:  newCfg.Replicas,
+		LostReplicas: lostReplicas,
+		Term:         newCfg.Term,
+		ReplicaLogs:  replicaLogs,
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	err = s.opts.ChannelClusterStorage.Propose(newCfg)
+	if err != nil {
+		s.Error("ForceChannelReconfig: propose failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return nil, err
+	}
+
+	// 通知存活的副本使用新配置
+	for _, replicaId := range survivors {
+		if replicaId == s.opts.NodeId {
+			s.UpdateChannelClusterConfig(newCfg)
+			continue
+		}
+		err = s.SendChannelClusterConfigUpdate(channelId, channelType, replicaId)
+		if err != nil {
+			// 发送失败也没关系，频道领导会间隔比对自己与槽领导的配置
+			s.Warn("ForceChannelReconfig: sendChannelClusterConfigUpdate failed", zap.Error(err), zap.Uint64("replicaId", replicaId))
+		}
+	}
+	result.AuditId = s.recordAuditEvent(auditActionChannelForceReconfig, fmt.Sprintf("channel:%s:%d", channelId, channelType), operator, result)
+	return result, nil
+}
+
+// 去掉运维人员确认丢失的副本，返回存活的副本
+// 丢失的副本必须是当前的副本且都不在线，存活的副本不足多数时才允许强制重新配置
+func (s *Server) splitSurvivalReplicas(replicas []uint64, lostReplicas []uint64) ([]uint64, error) {
+	if len(lostReplicas) == 0 {
+		return nil, ErrLostReplicasRequired
+	}
+	for _, lostId := range lostReplicas {
+		if !wkutil.ArrayContainsUint64(replicas, lostId) {
+			return nil, fmt.Errorf("lost replica[%d] is not a replica", lostId)
+		}
+		if s.clusterEventServer.NodeOnline(lostId) {
+			return nil, fmt.Errorf("lost replica[%d] is online", lostId)
+		}
+	}
+	survivors := make([]uint64, 0, len(replicas))
+	for _, replicaId := range replicas {
+		if !wkutil.ArrayContainsUint64(lostReplicas, replicaId) {
+			survivors = append(survivors, replicaId)
+		}
+	}
+	if len(survivors) == 0 {
+		return nil, ErrNoSurvivalReplica
+	}
+	if len(survivors) > len(replicas)/2 {
+		return nil, ErrQuorumNotLost
+	}
+	return survivors, nil
+}
+
+// 只保留在线且不在副本里的学习者
+func (s *Server) survivalLearners(learners []uint64, replicas []uint64) []uint64 {
+	var newLearners []uint64
+	for _, learnerId := range learners {
+		if s.clusterEventServer.NodeOnline(learnerId) && !wkutil.ArrayContainsUint64(replicas, learnerId) {
+			newLearners = append(newLearners, learnerId)
+		}
+	}
+	return newLearners
+}
+
+// 选出日志最新的副本（先比较日志任期再比较日志下标，同样新的优先选非维护中的节点）
+func (s *Server) highestLogReplica(replicaLogs []*forceReconfigLogResp) uint64 {
+	sort.Slice(replicaLogs, func(i, j int) bool {
+		if replicaLogs[i].LogTerm != replicaLogs[j].LogTerm {
+			return replicaLogs[i].LogTerm > replicaLogs[j].LogTerm
+		}
+		if replicaLogs[i].LogIndex != replicaLogs[j].LogIndex {
+			return replicaLogs[i].LogIndex > replicaLogs[j].LogIndex
+		}
+		return replicaLogs[i].ReplicaId < replicaLogs[j].ReplicaId
+	})
+	if len(replicaLogs) == 0 {
+		return 0
+	}
+	first := replicaLogs[0]
+	for _, replicaLog := range replicaLogs {
+		if replicaLog.LogTerm != first.LogTerm || replicaLog.LogIndex != first.LogIndex {
+			break
+		}
+		if !s.nodeMaintenance(replicaLog.ReplicaId) {
+			return replicaLog.ReplicaId
+		}
+	}
+	return first.ReplicaId
+}
+
+// 记录审计事件，返回审计事件id（记录失败返回0，不影响已经完成的操作）
+// 审计事件会写到本节点，并异步同步到其他所有在线节点，任意节点都能查到
+func (s *Server) recordAuditEvent(action, target, operator string, detail interface{}) uint64 {
+	detailData, _ := json.Marshal(detail)
+	s.Warn("audit", zap.String("action", action), zap.String("target", target), zap.String("operator", operator), zap.ByteString("detail", detailData))
+	event := wkdb.AuditEvent{
+		Id:        s.opts.DB.NextPrimaryKey(), // id由节点id生成，各节点不会重复
+		Action:    action,
+		Target:    target,
+		Operator:  operator,
+		NodeId:    s.opts.NodeId,
+		Detail:    string(detailData),
+		CreatedAt: time.Now().Unix(),
+	}
+	id, err := s.opts.DB.AddAuditEvent(event)
+	if err != nil {
+		s.Error("record audit event failed", zap.Error(err), zap.String("action", action), zap.String("target", target))
+		return 0
+	}
+	go s.broadcastAuditEvent(event)
+	return id
+}
+
+// 同步审计事件到其他节点
+func (s *Server) broadcastAuditEvent(event wkdb.AuditEvent) {
+	for _, node := range s.clusterEventServer.Nodes() {
+		if node.Id == s.opts.NodeId || !node.Online {
+			continue
+		}
+		err := s.nodeManager.requestAuditEventAdd(s.cancelCtx, node.Id, event)
+		if err != nil {
+			s.Warn("broadcast audit event failed", zap.Error(err), zap.Uint64("nodeId", node.Id), zap.Uint64("auditId", event.Id))
+		}
+	}
+}
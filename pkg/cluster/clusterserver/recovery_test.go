@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSurvivalReplicas(t *testing.T) {
+	s := newTestMaintenanceServer(t, []*pb.Node{
+		{Id: 1, Online: true},
+		{Id: 2, Online: false},
+		{Id: 3, Online: false},
+		{Id: 4, Online: true},
+	})
+
+	// 必须指定丢失的副本
+	_, err := s.splitSurvivalReplicas([]uint64{1, 2, 3}, nil)
+	assert.Equal(t, ErrLostReplicasRequired, err)
+
+	// 丢失的副本在线时拒绝
+	_, err = s.splitSurvivalReplicas([]uint64{1, 2, 3}, []uint64{1, 2})
+	assert.Error(t, err)
+
+	// 丢失的副本不是当前的副本
+	_, err = s.splitSurvivalReplicas([]uint64{1, 2, 3}, []uint64{2, 5})
+	assert.Error(t, err)
+
+	// 剩下的副本还是多数，不需要强制重新配置
+	_, err = s.splitSurvivalReplicas([]uint64{1, 2, 4}, []uint64{2})
+	assert.Equal(t, ErrQuorumNotLost, err)
+
+	// 副本全部丢失
+	_, err = s.splitSurvivalReplicas([]uint64{2, 3}, []uint64{2, 3})
+	assert.Equal(t, ErrNoSurvivalReplica, err)
+
+	// 多数副本丢失
+	survivors, err := s.splitSurvivalReplicas([]uint64{1, 2, 3}, []uint64{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1}, survivors)
+
+	// 偶数副本丢失一半也是失去多数
+	survivors, err = s.splitSurvivalReplicas([]uint64{1, 2, 3, 4}, []uint64{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 4}, survivors)
+}
+
+func TestHighestLogReplica(t *testing.T) {
+	s := newTestMaintenanceServer(t, []*pb.Node{
+		{Id: 1, Online: true},
+		{Id: 2, Online: true, Maintenance: true},
+		{Id: 3, Online: true},
+	})
+
+	assert.Equal(t, uint64(0), s.highestLogReplica(nil))
+
+	// 先比较日志任期
+	assert.Equal(t, uint64(3), s.highestLogReplica([]*forceReconfigLogResp{
+		{ReplicaId: 1, LogIndex: 100, LogTerm: 1},
+		{ReplicaId: 3, LogIndex: 50, LogTerm: 2},
+	}))
+
+	// 任期相同比较日志下标
+	assert.Equal(t, uint64(1), s.highestLogReplica([]*forceReconfigLogResp{
+		{ReplicaId: 1, LogIndex: 100, LogTerm: 2},
+		{ReplicaId: 3, LogIndex: 50, LogTerm: 2},
+	}))
+
+	// 同样新的优先选非维护中的节点
+	assert.Equal(t, uint64(3), s.highestLogReplica([]*forceReconfigLogResp{
+		{ReplicaId: 2, LogIndex: 100, LogTerm: 2},
+		{ReplicaId: 3, LogIndex: 100, LogTerm: 2},
+	}))
+
+	// 维护中的节点日志最新时仍然选它
+	assert.Equal(t, uint64(2), s.highestLogReplica([]*forceReconfigLogResp{
+		{ReplicaId: 2, LogIndex: 101, LogTerm: 2},
+		{ReplicaId: 3, LogIndex: 100, LogTerm: 2},
+	}))
+}
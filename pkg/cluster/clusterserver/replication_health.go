@@ -0,0 +1,162 @@
+package cluster
+
+import (
+	"sort"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/reactor"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/replica"
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+)
+
+// 获取本节点的复制健康状态
+// lagThreshold 只返回副本最大落后数量大于等于此值的槽和频道
+// channelLimit 最多返回的频道数量（按落后数量倒序）
+func (s *Server) replicationHealth(lagThreshold uint64, channelLimit int) *replicationHealthResp {
+	resp := &replicationHealthResp{
+		NodeId:  s.opts.NodeId,
+		Slot:    s.slotManager.slotReactor.Stats(),
+		Channel: s.channelManager.channelReactor.Stats(),
+	}
+
+	replicaMap := make(map[uint64]*replicaHealthResp)
+	collect := func(lag *replicationLagResp) {
+		for _, r := range lag.Replicas {
+			rh := replicaMap[r.ReplicaId]
+			if rh == nil {
+				rh = &replicaHealthResp{ReplicaId: r.ReplicaId}
+				replicaMap[r.ReplicaId] = rh
+			}
+			if r.Lag > rh.MaxLag {
+				rh.MaxLag = r.Lag
+			}
+			if r.Lag > 0 {
+				rh.LaggingCount++
+			}
+		}
+	}
+
+	for _, lag := range s.slotReplicationLags() {
+		collect(lag)
+		if lag.MaxLag >= lagThreshold {
+			resp.Slots = append(resp.Slots, lag)
+		}
+	}
+	for _, lag := range s.channelReplicationLags() {
+		collect(lag)
+		if lag.MaxLag >= lagThreshold {
+			resp.Channels = append(resp.Channels, lag)
+		}
+	}
+
+	sort.Slice(resp.Slots, func(i, j int) bool {
+		return resp.Slots[i].SlotId < resp.Slots[j].SlotId
+	})
+	sort.Slice(resp.Channels, func(i, j int) bool {
+		return resp.Channels[i].MaxLag > resp.Channels[j].MaxLag
+	})
+	if channelLimit > 0 && len(resp.Channels) > channelLimit {
+		resp.Channels = resp.Channels[:channelLimit]
+	}
+
+	for _, rh := range replicaMap {
+		resp.Replicas = append(resp.Replicas, rh)
+	}
+	sort.Slice(resp.Replicas, func(i, j int) bool {
+		return resp.Replicas[i].MaxLag > resp.Replicas[j].MaxLag
+	})
+	return resp
+}
+
+// 本节点作为领导的槽的复制落后情况
+func (s *Server) slotReplicationLags() []*replicationLagResp {
+	var lags []*replicationLagResp
+	s.slotManager.iterateWithPendingProposals(func(st *slot, pendingProposals int) bool {
+		progress := st.rc.Progress()
+		if progress.Role != replica.RoleLeader {
+			return true
+		}
+		st.mu.Lock()
+		slotId, replicas, learners := st.st.Id, st.st.Replicas, st.st.Learners
+		st.mu.Unlock()
+
+		lag := newReplicationLag(progress, replicas, learners, s.opts.NodeId)
+		lag.SlotId = slotId
+		lag.PendingProposals = pendingProposals
+		lags = append(lags, lag)
+		return true
+	})
+	return lags
+}
+
+// 本节点作为领导的活跃频道的复制落后情况
+func (s *Server) channelReplicationLags() []*replicationLagResp {
+	var lags []*replicationLagResp
+	s.channelManager.iterateWithPendingProposals(func(ch *channel, pendingProposals int) bool {
+		progress := ch.rc.Progress()
+		if progress.Role != replica.RoleLeader {
+			return true
+		}
+		ch.mu.Lock()
+		replicas, learners := ch.cfg.Replicas, ch.cfg.Learners
+		ch.mu.Unlock()
+
+		lag := newReplicationLag(progress, replicas, learners, s.opts.NodeId)
+		lag.ChannelId = ch.channelId
+		lag.ChannelType = ch.channelType
+		lag.PendingProposals = pendingProposals
+		lags = append(lags, lag)
+		return true
+	})
+	return lags
+}
+
+// 对比领导的最新日志下标和各个副本的最新日志下标
+// 在反应堆协程外调用，只能读取副本发布的进度快照
+func newReplicationLag(progress *replica.Progress, replicas, learners []uint64, nodeId uint64) *replicationLagResp {
+	lastIndex := progress.LastLogIndex
+	lag := &replicationLagResp{
+		LastIndex:      lastIndex,
+		CommittedIndex: progress.CommittedIndex,
+	}
+	add := func(replicaId uint64, learner bool) {
+		if replicaId == nodeId {
+			return
+		}
+		replicaLastIndex := progress.ReplicaLastLog(nodeId, replicaId)
+		var replicaLag uint64
+		if lastIndex > replicaLastIndex {
+			replicaLag = lastIndex - replicaLastIndex
+		}
+		if replicaLag > lag.MaxLag {
+			lag.MaxLag = replicaLag
+		}
+		lag.Replicas = append(lag.Replicas, &replicaLagResp{
+			ReplicaId: replicaId,
+			LastIndex: replicaLastIndex,
+			Lag:       replicaLag,
+			Learner:   wkutil.BoolToInt(learner),
+		})
+	}
+	for _, replicaId := range replicas {
+		add(replicaId, false)
+	}
+	for _, learnerId := range learners {
+		add(learnerId, true)
+	}
+	return lag
+}
+
+// 汇总复制健康状态，用于监控指标
+func replicationHealthMetrics(stats reactor.Stats, lags []*replicationLagResp) trace.ReplicationHealth {
+	health := trace.ReplicationHealth{
+		PendingProposals: stats.PendingProposals,
+		InflightApplies:  stats.InflightApplies,
+	}
+	for _, lag := range lags {
+		if int64(lag.MaxLag) > health.MaxReplicaLag {
+			health.MaxReplicaLag = int64(lag.MaxLag)
+		}
+	}
+	return health
+}
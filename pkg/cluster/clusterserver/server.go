@@ -44,6 +44,7 @@ type Server struct {
 	netServer              *wkserver.Server        // 节点之间通讯的网络服务
 	channelElectionPool    *ants.Pool              // 频道选举的协程池
 	channelElectionManager *channelElectionManager // 频道选举管理者
+	logChecksum            *logChecksum            // 日志校验
 	cancelCtx              context.Context
 	cancelFnc              context.CancelFunc
 	onMessageFnc           func(fromNodeId uint64, msg *proto.Message) // 上层处理消息的函数
@@ -55,6 +56,10 @@ type Server struct {
 	stopped atomic.Bool
 	stopper *syncutil.Stopper
 
+	resizing    atomic.Bool     // 是否正在调整槽数量
+	resizeTask  *slotResizeTask // 槽数量调整任务的状态
+	maintenance atomic.Bool     // 本节点是否已进入维护模式（已开始转移频道领导）
+
 	channelClusterCache *lru.Cache // 缓存最热的ChannelClusterConfig
 	// 缓存的数据版本，这个版本是全局分布式配置版本，当全局分布式配置的版本大于当前时，应当清除缓存
 	channelClusterCacheVersion uint64
@@ -127,6 +132,8 @@ func New(opts *Options) *Server {
 		clusterevent.WithSend(s.onSend),
 		clusterevent.WithConfigDir(cfgDir),
 		clusterevent.WithApiServerAddr(opts.ApiServerAddr),
+		clusterevent.WithZone(opts.Zone),
+		clusterevent.WithInitNodeZones(opts.InitNodeZones),
 		clusterevent.WithCluster(s),
 		clusterevent.WithElectionIntervalTick(opts.ElectionIntervalTick),
 		clusterevent.WithHeartbeatIntervalTick(opts.HeartbeatIntervalTick),
@@ -152,6 +159,8 @@ func New(opts *Options) *Server {
 		}),
 	)
 	s.channelElectionManager = newChannelElectionManager(s)
+	s.logChecksum = newLogChecksum(s)
+	s.resizeTask = &slotResizeTask{}
 	s.cancelCtx, s.cancelFnc = context.WithCancel(context.Background())
 
 	go s.loopChannelQueue()
@@ -233,6 +242,9 @@ func (s *Server) Start() error {
 		s.stopper.RunWorker(s.joinLoop)
 	}
 
+	// 日志校验
+	s.logChecksum.start()
+
 	// 设置监控数据的observer
 	s.setObservers()
 
@@ -251,6 +263,26 @@ func (s *Server) setObservers() {
 
 		return s.nodeManager.sending()
 	})
+
+	// 收集复制健康状态
+	trace.GlobalTrace.Metrics.Cluster().ObserverReplicationHealth(trace.ClusterKindSlot, func() trace.ReplicationHealth {
+
+		return replicationHealthMetrics(s.slotManager.slotReactor.Stats(), s.slotReplicationLags())
+	})
+	trace.GlobalTrace.Metrics.Cluster().ObserverReplicationHealth(trace.ClusterKindChannel, func() trace.ReplicationHealth {
+
+		return replicationHealthMetrics(s.channelManager.channelReactor.Stats(), s.channelReplicationLags())
+	})
+
+	// 收集日志校验状态
+	trace.GlobalTrace.Metrics.Cluster().ObserverLogChecksum(trace.ClusterKindSlot, func() trace.LogChecksum {
+
+		return s.logChecksum.metrics(ShardTypeSlot)
+	})
+	trace.GlobalTrace.Metrics.Cluster().ObserverLogChecksum(trace.ClusterKindChannel, func() trace.LogChecksum {
+
+		return s.logChecksum.metrics(ShardTypeChannel)
+	})
 }
 
 func (s *Server) Stop() {
@@ -260,6 +292,7 @@ func (s *Server) Stop() {
 	s.stopper.Stop()
 	s.nodeManager.stop()
 	s.channelElectionManager.stop()
+	s.logChecksum.stop()
 	s.netServer.Stop()
 	s.clusterEventServer.Stop()
 	s.slotManager.stop()
@@ -282,10 +315,33 @@ func (s *Server) GetConfig() *pb.Config {
 
 // 迁移槽
 func (s *Server) MigrateSlot(slotId uint32, fromNodeId, toNodeId uint64) error {
-
+	if !s.allowVoteNode(toNodeId) { // 代理节点等不允许投票的节点不能存储槽数据
+		return ErrNodeNotAllowVote
+	}
+	if s.clusterEventServer.Config().ResizeSlotCount != 0 { // 调整槽数量期间不允许迁移槽
+		return ErrSlotResizing
+	}
 	return s.clusterEventServer.ProposeMigrateSlot(slotId, fromNodeId, toNodeId)
 }
 
+// 节点是否允许投票（只有允许投票的节点才能成为槽和频道的副本）
+func (s *Server) allowVoteNode(nodeId uint64) bool {
+	node := s.clusterEventServer.Node(nodeId)
+	return node != nil && node.AllowVote
+}
+
+// 节点是否处于维护模式
+func (s *Server) nodeMaintenance(nodeId uint64) bool {
+	node := s.clusterEventServer.Node(nodeId)
+	return node != nil && node.Maintenance
+}
+
+// 槽是否因为调整槽数量而被冻结（源槽只在最后的追赶回放和切换期间不允许写入）
+func (s *Server) slotResizing(slotId uint32) bool {
+	slot := s.clusterEventServer.Slot(slotId)
+	return slot != nil && slot.Status == pb.SlotStatus_SlotStatusResizing
+}
+
 func (s *Server) AddSlotMessage(m reactor.Message) {
 
 	// 统计引入的消息
@@ -587,6 +643,7 @@ func (s *Server) joinLoop() {
 		NodeId:     s.opts.NodeId,
 		ServerAddr: s.opts.ServerAddr,
 		Role:       s.opts.Role,
+		Zone:       s.opts.Zone,
 	}
 	for {
 		select {
@@ -11,10 +11,11 @@ func (s *Server) ServerAPI(route *wkhttp.WKHttp, prefix string) {
 	s.apiPrefix = prefix
 
 	// ================== 节点 ==================
-	route.GET(s.formatPath("/nodes"), s.nodesGet)                     // 获取所有节点
-	route.GET(s.formatPath("/node"), s.nodeGet)                       // 获取当前节点信息
-	route.GET(s.formatPath("/simpleNodes"), s.simpleNodesGet)         // 获取简单节点信息
-	route.GET(s.formatPath("/nodes/:id/channels"), s.nodeChannelsGet) // 获取节点的所有频道信息
+	route.GET(s.formatPath("/nodes"), s.nodesGet)                            // 获取所有节点
+	route.GET(s.formatPath("/node"), s.nodeGet)                              // 获取当前节点信息
+	route.GET(s.formatPath("/simpleNodes"), s.simpleNodesGet)                // 获取简单节点信息
+	route.GET(s.formatPath("/nodes/:id/channels"), s.nodeChannelsGet)        // 获取节点的所有频道信息
+	route.POST(s.formatPath("/nodes/:id/maintenance"), s.nodeMaintenanceSet) // 设置节点维护模式
 
 	// ================== slot ==================
 	// route.GET(s.formatPath("/channels/:channel_id/:channel_type/config"), s.channelClusterConfigGet) // 获取频道分布式配置
@@ -23,6 +24,12 @@ func (s *Server) ServerAPI(route *wkhttp.WKHttp, prefix string) {
 	route.GET(s.formatPath("/slots/:id/config"), s.slotClusterConfigGet) // 槽分布式配置
 	route.GET(s.formatPath("/slots/:id/channels"), s.slotChannelsGet)    // 获取某个槽的所有频道信息
 	route.POST(s.formatPath("/slots/:id/migrate"), s.slotMigrate)        // 迁移槽
+	route.POST(s.formatPath("/slots/resize"), s.slotsResize)             // 调整槽数量
+	route.GET(s.formatPath("/slots/resize"), s.slotsResizeGet)           // 槽数量调整的进度
+
+	// 多数副本永久丢失时的恢复（先 dry_run 预演）
+	route.POST(s.formatPath("/slots/:id/force_reconfig"), s.slotForceReconfig)                             // 强制重新配置槽副本
+	route.POST(s.formatPath("/channels/:channel_id/:channel_type/force_reconfig"), s.channelForceReconfig) // 强制重新配置频道副本
 
 	// ================== message ==================
 	route.GET(s.formatPath("/messages"), s.messageSearch) // 搜索消息
@@ -42,11 +49,15 @@ func (s *Server) ServerAPI(route *wkhttp.WKHttp, prefix string) {
 
 	// ================== cluster ==================
 
-	route.GET(s.formatPath("/info"), s.clusterInfoGet) // 获取集群信息
-	route.GET(s.formatPath("/logs"), s.clusterLogs)    // 获取节点日志
+	route.GET(s.formatPath("/info"), s.clusterInfoGet)              // 获取集群信息
+	route.GET(s.formatPath("/logs"), s.clusterLogs)                 // 获取节点日志
+	route.GET(s.formatPath("/replication"), s.replicationHealthGet) // 获取节点的复制健康状态
+	route.GET(s.formatPath("/audits"), s.auditEventsGet)            // 获取节点的运维审计事件
+	route.GET(s.formatPath("/log_checksum"), s.logChecksumGet)      // 获取节点的日志校验状态
 
 	// ================== cluster channel ==================
 	route.POST(s.formatPath("/channels/:channel_id/:channel_type/migrate"), s.channelMigrate)          // 迁移频道
+	route.POST(s.formatPath("/channels/transfer_leader"), s.channelTransferLeader)                     // 平滑转移频道领导
 	route.GET(s.formatPath("/channels/:channel_id/:channel_type/config"), s.channelClusterConfig)      // 获取频道的分布式配置
 	route.POST(s.formatPath("/channels/:channel_id/:channel_type/start"), s.channelStart)              // 开始频道
 	route.POST(s.formatPath("/channels/:channel_id/:channel_type/stop"), s.channelStop)                // 停止频道
@@ -57,6 +68,7 @@ func (s *Server) ServerAPI(route *wkhttp.WKHttp, prefix string) {
 	// ================== logs ==================
 	route.GET(s.formatPath("/message/trace"), s.messageTrace)                // 获取消息轨迹
 	route.GET(s.formatPath("/message/trace/recvack"), s.messageRecvackTrace) // 获取收到消息回执轨迹
+	route.GET(s.formatPath("/message/trace/records"), s.messageTraceRecords) // 获取本节点的消息轨迹记录(节点内部调用)
 	route.GET(s.formatPath("/logs/tail"), s.logsTail)                        // tail日志 websocket接口
 
 	// ================== debug ==================
@@ -210,6 +210,11 @@ func (s *Server) ProposeToSlot(ctx context.Context, slotId uint32, logs []replic
 		return nil, ErrSlotNotExist
 	}
 
+	if s.slotResizing(slotId) {
+		s.Error("ProposeToSlot failed, slot resizing", zap.Uint32("slotId", slotId))
+		return nil, ErrSlotResizing
+	}
+
 	var results []reactor.ProposeResult
 	var err error
 	if slot.Leader != s.opts.NodeId {
@@ -501,19 +506,22 @@ func (s *Server) loadOrCreateChannelClusterConfigNoLock(ctx context.Context, cha
 		s.Info("loadOrCreateChannelClusterConfig: need add new node to replicas", zap.String("channelId", channelId), zap.Uint8("channelType", channelType), zap.Int("currentReplicaCount", currentReplicaCount), zap.Uint64s("replicas", clusterCfg.Replicas), zap.Uint16("replicaMaxCount", clusterCfg.ReplicaMaxCount), zap.Int("allowVoteAndJoinedNodeCount", allowVoteAndJoinedNodeCount))
 
 		nodes := s.clusterEventServer.AllowVoteAndJoinedNodes()
-		newReplicaIds := make([]uint64, 0, allowVoteAndJoinedNodeCount-len(clusterCfg.Replicas))
+		newReplicaNodes := make([]*pb.Node, 0, allowVoteAndJoinedNodeCount-len(clusterCfg.Replicas))
 		for _, node := range nodes {
 			if !wkutil.ArrayContainsUint64(clusterCfg.Replicas, node.Id) {
-				newReplicaIds = append(newReplicaIds, node.Id)
+				newReplicaNodes = append(newReplicaNodes, node)
 			}
 		}
 		// 打乱顺序，防止每次都是相同的节点加入
-		rand.Shuffle(len(newReplicaIds), func(i, j int) {
-			newReplicaIds[i], newReplicaIds[j] = newReplicaIds[j], newReplicaIds[i]
+		rand.Shuffle(len(newReplicaNodes), func(i, j int) {
+			newReplicaNodes[i], newReplicaNodes[j] = newReplicaNodes[j], newReplicaNodes[i]
 		})
+		// 优先选择副本还未覆盖的可用区的节点
+		newReplicaNodes = pb.SpreadNodesByZone(newReplicaNodes, s.nodeZones(clusterCfg.Replicas)...)
 
 		// 将新节点加入到学习者列表
-		for _, newReplicaId := range newReplicaIds {
+		for _, newReplicaNode := range newReplicaNodes {
+			newReplicaId := newReplicaNode.Id
 			clusterCfg.MigrateFrom = newReplicaId
 			clusterCfg.MigrateTo = newReplicaId
 			clusterCfg.Learners = append(clusterCfg.Learners, newReplicaId)
@@ -533,6 +541,11 @@ func (s *Server) loadOrCreateChannelClusterConfigNoLock(ctx context.Context, cha
 		}
 	}
 
+	// 频道领导在维护中的节点上（不管频道是否在维护节点上加载过），将频道领导平滑转移到其他副本
+	if s.needMaintenanceTransfer(clusterCfg) {
+		go s.transferChannelLeaderForMaintenance(channelId, channelType)
+	}
+
 	s.channelClusterCache.Add(channelKey, clusterCfg)
 
 	return clusterCfg, needProposeCfg, nil
@@ -547,6 +560,10 @@ func (s *Server) needElection(cfg wkdb.ChannelClusterConfig) bool {
 	}
 	// 如果频道领导不在线，说明需要选举领导
 	if !s.clusterEventServer.NodeOnline(cfg.LeaderId) {
+		if s.nodeMaintenance(cfg.LeaderId) { // 维护中的节点下线是预期内的
+			s.Debug("leaderId is in maintenance and offline, need election...", zap.Uint64("leaderId", cfg.LeaderId), zap.String("channelId", cfg.ChannelId), zap.Uint8("channelType", cfg.ChannelType))
+			return true
+		}
 		s.Foucs("leaderId is offline, need election...", zap.Uint64("leaderId", cfg.LeaderId), zap.String("channelId", cfg.ChannelId), zap.Uint8("channelType", cfg.ChannelType))
 		return true
 	}
@@ -598,6 +615,19 @@ func (s *Server) loadOrCreateChannel(ctx context.Context, channelId string, chan
 	return ch, nil
 }
 
+// 节点所在的可用区（未配置可用区的节点忽略）
+func (s *Server) nodeZones(nodeIds []uint64) []string {
+	zones := make([]string, 0, len(nodeIds))
+	for _, nodeId := range nodeIds {
+		node := s.clusterEventServer.Node(nodeId)
+		if node == nil || node.Zone == "" {
+			continue
+		}
+		zones = append(zones, node.Zone)
+	}
+	return zones
+}
+
 // 创建一个频道的分布式配置
 func (s *Server) createChannelClusterConfig(channelId string, channelType uint8) (wkdb.ChannelClusterConfig, error) {
 	allowVoteNodes := s.clusterEventServer.AllowVoteAndJoinedNodes() // 获取允许投票的在线节点
@@ -625,6 +655,8 @@ func (s *Server) createChannelClusterConfig(channelId string, channelType uint8)
 	rand.Shuffle(len(newAllowVoteNodes), func(i, j int) {
 		newAllowVoteNodes[i], newAllowVoteNodes[j] = newAllowVoteNodes[j], newAllowVoteNodes[i]
 	})
+	// 副本尽量分布在不同的可用区
+	newAllowVoteNodes = pb.SpreadNodesByZone(newAllowVoteNodes, s.nodeZones([]uint64{s.opts.NodeId})...)
 
 	for _, allowVoteNode := range newAllowVoteNodes {
 		if allowVoteNode.Id == s.opts.NodeId {
@@ -682,6 +714,86 @@ func (s *Server) createChannelClusterConfig(channelId string, channelType uint8)
 // 	return clusterCfg, updated, nil
 // }
 
+// TransferChannelLeader 将频道领导平滑转移到指定副本（只能在频道所属槽的领导节点上调用）
+// 目标副本追上领导的日志后才切换领导，不会触发选举；toNodeId为0时自动选择一个在线的追随者。
+// preferred为true时将目标节点设置为频道的优先领导，之后的选举在日志同样新的情况下优先选它。
+func (s *Server) TransferChannelLeader(channelId string, channelType uint8, toNodeId uint64, preferred bool) (uint64, error) {
+	clusterConfig, err := s.getChannelClusterConfig(channelId, channelType)
+	if err != nil {
+		return 0, err
+	}
+	if wkdb.IsEmptyChannelClusterConfig(clusterConfig) {
+		return 0, ErrChannelClusterConfigNotFound
+	}
+	if clusterConfig.MigrateFrom != 0 || clusterConfig.MigrateTo != 0 {
+		return 0, errors.New("migrate is in progress")
+	}
+
+	if toNodeId == 0 {
+		for _, replicaId := range clusterConfig.Replicas {
+			if replicaId == clusterConfig.LeaderId || !s.clusterEventServer.NodeOnline(replicaId) || !s.allowVoteNode(replicaId) || s.nodeMaintenance(replicaId) {
+				continue
+			}
+			if toNodeId == 0 || replicaId == clusterConfig.PreferredLeader {
+				toNodeId = replicaId
+			}
+		}
+		if toNodeId == 0 {
+			return 0, ErrNoAllowVoteNode
+		}
+	}
+
+	if !wkutil.ArrayContainsUint64(clusterConfig.Replicas, toNodeId) {
+		return 0, errors.New("target node not in replicas")
+	}
+	if !s.allowVoteNode(toNodeId) {
+		return 0, ErrNodeNotAllowVote
+	}
+	if !s.clusterEventServer.NodeOnline(toNodeId) {
+		return 0, errors.New("target node is offline")
+	}
+
+	newClusterConfig := clusterConfig.Clone()
+	if preferred {
+		newClusterConfig.PreferredLeader = toNodeId
+	} else if newClusterConfig.PreferredLeader == clusterConfig.LeaderId {
+		// 领导从优先领导上移走，清除优先领导，避免之后的选举又选回来
+		newClusterConfig.PreferredLeader = 0
+	}
+	if toNodeId != clusterConfig.LeaderId {
+		newClusterConfig.MigrateFrom = clusterConfig.LeaderId
+		newClusterConfig.MigrateTo = toNodeId
+	} else if newClusterConfig.PreferredLeader == clusterConfig.PreferredLeader {
+		return toNodeId, nil // 已经是领导了
+	}
+	newClusterConfig.ConfVersion = uint64(time.Now().UnixNano())
+
+	err = s.opts.ChannelClusterStorage.Propose(newClusterConfig)
+	if err != nil {
+		s.Error("TransferChannelLeader: propose failed", zap.Error(err), zap.String("channelId", channelId), zap.Uint8("channelType", channelType))
+		return 0, err
+	}
+
+	// 发送最新配置给频道领导，由频道领导等目标副本追上日志后再切换领导
+	if newClusterConfig.LeaderId != s.opts.NodeId {
+		err = s.SendChannelClusterConfigUpdate(channelId, channelType, newClusterConfig.LeaderId)
+		if err != nil {
+			s.Error("TransferChannelLeader: sendChannelClusterConfigUpdate failed", zap.Error(err))
+			return 0, err
+		}
+	} else {
+		s.UpdateChannelClusterConfig(newClusterConfig)
+	}
+	if toNodeId != s.opts.NodeId && toNodeId != newClusterConfig.LeaderId {
+		err = s.SendChannelClusterConfigUpdate(channelId, channelType, toNodeId)
+		if err != nil {
+			s.Error("TransferChannelLeader: sendChannelClusterConfigUpdate failed", zap.Error(err))
+			return 0, err
+		}
+	}
+	return toNodeId, nil
+}
+
 func (s *Server) getChannelClusterConfig(channelId string, channelType uint8) (wkdb.ChannelClusterConfig, error) {
 	return s.opts.ChannelClusterStorage.Get(channelId, channelType)
 }
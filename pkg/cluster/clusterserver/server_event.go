@@ -21,6 +21,8 @@ func (s *Server) onClusterConfigChange(cfg *pb.Config) {
 	if err != nil {
 		s.Error("handleClusterConfigChange failed", zap.Error(err))
 	}
+	// 调整槽数量被中断（例如配置领导切换），由新的配置领导继续调整
+	s.resumeResizeSlots(cfg)
 }
 
 // 处理槽选举
@@ -103,6 +105,9 @@ func (s *Server) handleClusterConfigNodeChange(cfg *pb.Config) error {
 		}
 	}
 
+	// 本节点维护模式变更
+	s.handleLocalNodeMaintenance()
+
 	return nil
 }
 
@@ -121,15 +126,22 @@ func (s *Server) handleClusterConfigSlotChange(cfg *pb.Config) error {
 
 	// 移除不属于此节点的槽
 	removeSlotIds := make([]uint32, 0)
+	mergedSlotIds := make([]uint32, 0)
 	s.slotManager.iterate(func(slot *slot) bool {
+		exist := false
 		for _, cfgSlot := range cfg.Slots {
 			if slot.st.Id == cfgSlot.Id {
+				exist = true
 				if !wkutil.ArrayContainsUint64(cfgSlot.Replicas, s.opts.NodeId) && !wkutil.ArrayContainsUint64(cfgSlot.Learners, s.opts.NodeId) {
 					removeSlotIds = append(removeSlotIds, slot.st.Id)
 				}
 				break
 			}
 		}
+		// 槽已被合并，配置里不存在了
+		if !exist && len(cfg.Slots) > 0 {
+			mergedSlotIds = append(mergedSlotIds, slot.st.Id)
+		}
 		return true
 	})
 	if len(removeSlotIds) > 0 {
@@ -137,6 +149,9 @@ func (s *Server) handleClusterConfigSlotChange(cfg *pb.Config) error {
 			s.slotManager.remove(slotId)
 		}
 	}
+	for _, slotId := range mergedSlotIds {
+		go s.deleteMergedSlot(slotId)
+	}
 
 	// 处理槽修改
 	for _, cfgSlot := range cfg.Slots {
@@ -154,6 +169,28 @@ func (s *Server) handleClusterConfigSlotChange(cfg *pb.Config) error {
 	return nil
 }
 
+// 删除已合并的槽的日志数据（数据已回放到目标槽）
+func (s *Server) deleteMergedSlot(slotId uint32) {
+	timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ReqTimeout)
+	defer cancel()
+	// 等待正在执行的日志写入完成，避免删除后又被写入
+	err := s.slotManager.removeAndWait(timeoutCtx, slotId)
+	if err != nil {
+		s.Warn("deleteMergedSlot: wait slot removed failed", zap.Error(err), zap.Uint32("slotId", slotId))
+		return
+	}
+	deleter, ok := s.opts.SlotLogStorage.(IShardLogDeleter)
+	if !ok {
+		return
+	}
+	err = deleter.DeleteShard(SlotIdToKey(slotId))
+	if err != nil {
+		s.Error("deleteMergedSlot: delete slot logs failed", zap.Error(err), zap.Uint32("slotId", slotId))
+		return
+	}
+	s.Info("merged slot logs deleted", zap.Uint32("slotId", slotId))
+}
+
 func (s *Server) handleSlotElection(slots []*pb.Slot) error {
 	if len(slots) == 0 {
 		return nil
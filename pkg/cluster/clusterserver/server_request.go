@@ -9,6 +9,7 @@ import (
 
 	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
 	"github.com/WuKongIM/WuKongIM/pkg/cluster/reactor"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 	"github.com/WuKongIM/WuKongIM/pkg/wkserver"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	"go.uber.org/zap"
@@ -34,6 +35,20 @@ func (s *Server) setRoutes() {
 
 	// 获取槽日志信息
 	s.netServer.Route("/slot/logInfo", s.handleSlotLogInfo)
+
+	// 调整槽数量时，源槽领导回放日志到目标槽
+	s.netServer.Route("/slot/resize/replay", s.handleSlotResizeReplay)
+
+	// 维护中的节点请求槽领导转移频道领导
+	s.netServer.Route("/channel/transferLeader", s.handleChannelTransferLeader)
+
+	// 领导请求副本计算日志校验和
+	s.netServer.Route("/log/checksum", s.handleLogChecksum)
+	// 领导请求副本截断分歧的日志，然后重新从领导同步
+	s.netServer.Route("/log/repair", s.handleLogRepair)
+
+	// 其他节点同步过来的运维审计事件
+	s.netServer.Route("/audit/add", s.handleAuditEventAdd)
 }
 
 func (s *Server) handleChannelLastLogInfo(c *wkserver.Context) {
@@ -227,6 +242,12 @@ func (s *Server) handleSlotPropose(c *wkserver.Context) {
 		return
 	}
 
+	if s.slotResizing(req.SlotId) {
+		s.Error("slot resizing,handleSlotPropose failed", zap.Uint32("slotId", req.SlotId))
+		c.WriteErr(ErrSlotResizing)
+		return
+	}
+
 	results, err := s.slotManager.proposeAndWait(s.cancelCtx, req.SlotId, req.Logs)
 	if err != nil {
 		s.Error("proposeAndWait failed", zap.Error(err))
@@ -293,6 +314,7 @@ func (s *Server) handleClusterJoin(c *wkserver.Context) {
 		Join:        true,
 		Online:      true,
 		Role:        req.Role,
+		Zone:        req.Zone,
 		AllowVote:   allowVote,
 		CreatedAt:   time.Now().Unix(),
 		Status:      pb.NodeStatus_NodeStatusWillJoin,
@@ -427,3 +449,107 @@ func (s *Server) handleSlotLogInfo(c *wkserver.Context) {
 	}
 	c.Write(data)
 }
+
+func (s *Server) handleSlotResizeReplay(c *wkserver.Context) {
+	req := &SlotResizeReplayReq{}
+	if err := req.Unmarshal(c.Body()); err != nil {
+		s.Error("unmarshal SlotResizeReplayReq failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	resp, err := s.replaySlotLogs(req.SlotId, req.StartIndex, req.Final)
+	if err != nil {
+		s.Error("replaySlotLogs failed", zap.Error(err), zap.Uint32("slotId", req.SlotId))
+		c.WriteErr(err)
+		return
+	}
+	data, err := resp.Marshal()
+	if err != nil {
+		s.Error("marshal SlotResizeReplayResp failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	c.Write(data)
+}
+
+func (s *Server) handleChannelTransferLeader(c *wkserver.Context) {
+	req := &ChannelClusterConfigReq{}
+	if err := req.Unmarshal(c.Body()); err != nil {
+		s.Error("unmarshal ChannelClusterConfigReq failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	slotId := s.getSlotId(req.ChannelId)
+	slot := s.clusterEventServer.Slot(slotId)
+	if slot == nil {
+		s.Error("slot not found", zap.Uint32("slotId", slotId))
+		c.WriteErr(ErrSlotNotFound)
+		return
+	}
+	if slot.Leader != s.opts.NodeId {
+		s.Error("not leader,handleChannelTransferLeader failed", zap.Uint64("leader", slot.Leader), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(ErrNotIsLeader)
+		return
+	}
+	_, err := s.TransferChannelLeader(req.ChannelId, req.ChannelType, 0, false)
+	if err != nil {
+		s.Error("handleChannelTransferLeader: TransferChannelLeader failed", zap.Error(err), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(err)
+		return
+	}
+	c.WriteOk()
+}
+
+func (s *Server) handleLogChecksum(c *wkserver.Context) {
+	req := &LogChecksumReq{}
+	if err := req.Unmarshal(c.Body()); err != nil {
+		s.Error("unmarshal LogChecksumReq failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	resp, err := s.localLogChecksum(req)
+	if err != nil {
+		s.Error("localLogChecksum failed", zap.Error(err), zap.Uint8("shardType", uint8(req.ShardType)), zap.Uint32("slotId", req.SlotId), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType))
+		c.WriteErr(err)
+		return
+	}
+	data, err := resp.Marshal()
+	if err != nil {
+		s.Error("marshal LogChecksumResp failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	c.Write(data)
+}
+
+func (s *Server) handleLogRepair(c *wkserver.Context) {
+	req := &LogRepairReq{}
+	if err := req.Unmarshal(c.Body()); err != nil {
+		s.Error("unmarshal LogRepairReq failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	err := s.repairReplicaLog(req)
+	if err != nil {
+		s.Error("repairReplicaLog failed", zap.Error(err), zap.Uint8("shardType", uint8(req.ShardType)), zap.Uint32("slotId", req.SlotId), zap.String("channelId", req.ChannelId), zap.Uint8("channelType", req.ChannelType), zap.Uint64("index", req.Index))
+		c.WriteErr(err)
+		return
+	}
+	c.WriteOk()
+}
+
+func (s *Server) handleAuditEventAdd(c *wkserver.Context) {
+	event := wkdb.AuditEvent{}
+	if err := event.Unmarshal(c.Body()); err != nil {
+		s.Error("unmarshal AuditEvent failed", zap.Error(err))
+		c.WriteErr(err)
+		return
+	}
+	_, err := s.opts.DB.AddAuditEvent(event)
+	if err != nil {
+		s.Error("AddAuditEvent failed", zap.Error(err), zap.Uint64("auditId", event.Id), zap.String("action", event.Action))
+		c.WriteErr(err)
+		return
+	}
+	c.WriteOk()
+}
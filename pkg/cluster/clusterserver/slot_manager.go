@@ -66,6 +66,11 @@ func (s *slotManager) remove(slotId uint32) {
 	s.slotReactor.RemoveHandler(SlotIdToKey(slotId))
 }
 
+// removeAndWait 移除槽，并等待正在执行的日志写入完成
+func (s *slotManager) removeAndWait(ctx context.Context, slotId uint32) error {
+	return s.slotReactor.RemoveHandlerAndWait(ctx, SlotIdToKey(slotId))
+}
+
 func (s *slotManager) addMessage(m reactor.Message) {
 	s.slotReactor.AddMessage(m)
 }
@@ -76,6 +81,13 @@ func (s *slotManager) iterate(f func(*slot) bool) {
 	})
 }
 
+// 遍历槽，同时返回槽等待提交的提案数量
+func (s *slotManager) iterateWithPendingProposals(f func(st *slot, pendingProposals int) bool) {
+	s.slotReactor.IteratorHandlerWithPendingProposals(func(h reactor.IHandler, pendingProposals int) bool {
+		return f(h.(*slot), pendingProposals)
+	})
+}
+
 func (s *slotManager) exist(slotId uint32) bool {
 	return s.slotReactor.ExistHandler(SlotIdToKey(slotId))
 }
@@ -0,0 +1,448 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/replica"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"go.uber.org/zap"
+)
+
+// 回放请求失败的最大重试次数
+const slotResizeReplayMaxRetry = 20
+
+// 槽数量调整任务的状态
+const (
+	slotResizeStatusRunning = "running" // 调整中
+	slotResizeStatusDone    = "done"    // 调整完成
+	slotResizeStatusFailed  = "failed"  // 调整失败（已回滚或等待继续调整）
+)
+
+// 槽数量调整任务（只在配置领导节点上记录）
+type slotResizeTask struct {
+	mu   sync.RWMutex
+	resp *slotResizeResp
+}
+
+func (t *slotResizeTask) start(slotCount, resizeSlotCount uint32, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resp = &slotResizeResp{
+		Status:          slotResizeStatusRunning,
+		SlotCount:       slotCount,
+		ResizeSlotCount: resizeSlotCount,
+		Total:           total,
+		StartedAt:       time.Now().Unix(),
+	}
+}
+
+func (t *slotResizeTask) replayed(slotId uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resp == nil {
+		return
+	}
+	t.resp.Replayed++
+	t.resp.ReplayedSlots = append(t.resp.ReplayedSlots, slotId)
+}
+
+func (t *slotResizeTask) finish(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resp == nil {
+		return
+	}
+	t.resp.Status = slotResizeStatusDone
+	if err != nil {
+		t.resp.Status = slotResizeStatusFailed
+		t.resp.Error = err.Error()
+	}
+	t.resp.FinishedAt = time.Now().Unix()
+}
+
+// 当前任务状态的副本，没有任务时返回nil
+func (t *slotResizeTask) status() *slotResizeResp {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.resp == nil {
+		return nil
+	}
+	resp := *t.resp
+	resp.ReplayedSlots = append([]uint32(nil), t.resp.ReplayedSlots...)
+	return &resp
+}
+
+// ResizeSlots 调整槽数量（只能在配置领导节点上调用），等待调整完成后返回
+// 新的槽数量必须是原槽数量的倍数（拆分）或约数（合并）。
+// 源槽领导先在源槽继续写入的情况下将已应用的日志回放到目标槽，然后冻结源槽（暂停写入），
+// 追赶回放冻结前新写入的日志后切换到新的槽数量，源槽只在最后的追赶和切换期间暂停写入。
+// 回放失败会回滚到原槽数量；如果调整被中断（例如配置领导切换），再次调用可以继续调整，传入原槽数量则放弃调整。
+func (s *Server) ResizeSlots(slotCount uint32) error {
+	oldSlotCount, finished, err := s.prepareResizeSlots(slotCount)
+	if err != nil || finished {
+		return err
+	}
+	defer s.resizing.Store(false)
+	return s.runResizeSlots(oldSlotCount, slotCount)
+}
+
+// StartResizeSlots 开始调整槽数量，回放和切换在后台执行，进度通过 resizeStatus 查询
+func (s *Server) StartResizeSlots(slotCount uint32) error {
+	oldSlotCount, finished, err := s.prepareResizeSlots(slotCount)
+	if err != nil || finished {
+		return err
+	}
+	go func() {
+		defer s.resizing.Store(false)
+		err := s.runResizeSlots(oldSlotCount, slotCount)
+		if err != nil {
+			s.Error("StartResizeSlots: resize slots failed", zap.Error(err), zap.Uint32("slotCount", oldSlotCount), zap.Uint32("newSlotCount", slotCount))
+		}
+	}()
+	return nil
+}
+
+// 校验并提案开始调整，成功后持有resizing标记（finished为true表示已放弃调整，不需要继续执行）
+func (s *Server) prepareResizeSlots(slotCount uint32) (oldSlotCount uint32, finished bool, err error) {
+	if !s.clusterEventServer.IsLeader() {
+		return 0, false, ErrNotIsLeader
+	}
+	if !s.resizing.CompareAndSwap(false, true) {
+		return 0, false, ErrSlotResizing
+	}
+
+	cfg := s.clusterEventServer.Config()
+	oldSlotCount = cfg.SlotCount
+	if cfg.ResizeSlotCount != 0 {
+		if slotCount == oldSlotCount { // 放弃调整
+			err = s.clusterEventServer.ProposeSlotResizeEnd(oldSlotCount)
+			s.resizing.Store(false)
+			return oldSlotCount, true, err
+		}
+		if slotCount != cfg.ResizeSlotCount {
+			s.resizing.Store(false)
+			return 0, false, ErrSlotResizing
+		}
+	} else {
+		addSlots, err := s.resizeAddSlots(cfg, slotCount)
+		if err != nil {
+			s.resizing.Store(false)
+			return 0, false, err
+		}
+		err = s.clusterEventServer.ProposeSlotResizeStart(slotCount, addSlots)
+		if err != nil {
+			s.Error("ResizeSlots: ProposeSlotResizeStart failed", zap.Error(err))
+			s.resizing.Store(false)
+			return 0, false, err
+		}
+	}
+	return oldSlotCount, false, nil
+}
+
+// 回放源槽的日志，冻结源槽并追赶回放后切换到新的槽数量
+func (s *Server) runResizeSlots(oldSlotCount, slotCount uint32) (err error) {
+	var sourceSlotIds []uint32
+	for slotId := uint32(0); slotId < oldSlotCount; slotId++ {
+		if len(pb.SlotResizeTargets(slotId, oldSlotCount, slotCount)) == 0 {
+			continue
+		}
+		sourceSlotIds = append(sourceSlotIds, slotId)
+	}
+	s.resizeTask.start(oldSlotCount, slotCount, len(sourceSlotIds))
+	defer func() {
+		s.resizeTask.finish(err)
+	}()
+
+	s.Info("start resize slots", zap.Uint32("slotCount", oldSlotCount), zap.Uint32("newSlotCount", slotCount))
+
+	rollback := func() {
+		if rerr := s.clusterEventServer.ProposeSlotResizeEnd(oldSlotCount); rerr != nil {
+			s.Error("ResizeSlots: rollback failed", zap.Error(rerr))
+		}
+	}
+
+	// 源槽继续写入，回放到源槽领导已应用的位置
+	nextIndexes := make(map[uint32]uint64, len(sourceSlotIds))
+	for _, slotId := range sourceSlotIds {
+		nextIndexes[slotId], err = s.replaySlot(slotId, 0, false)
+		if err != nil {
+			s.Error("ResizeSlots: replay slot failed, rollback", zap.Error(err), zap.Uint32("slotId", slotId))
+			rollback()
+			return err
+		}
+	}
+
+	// 冻结源槽，追赶回放期间新写入的日志
+	err = s.clusterEventServer.ProposeSlotResizeFreeze()
+	if err != nil {
+		s.Error("ResizeSlots: ProposeSlotResizeFreeze failed, rollback", zap.Error(err))
+		rollback()
+		return err
+	}
+	for _, slotId := range sourceSlotIds {
+		_, err = s.replaySlot(slotId, nextIndexes[slotId], true)
+		if err != nil {
+			s.Error("ResizeSlots: catch up slot failed, rollback", zap.Error(err), zap.Uint32("slotId", slotId))
+			rollback()
+			return err
+		}
+		s.resizeTask.replayed(slotId)
+	}
+
+	err = s.clusterEventServer.ProposeSlotResizeEnd(slotCount)
+	if err != nil {
+		s.Error("ResizeSlots: ProposeSlotResizeEnd failed", zap.Error(err))
+		return err
+	}
+	s.Info("resize slots finished", zap.Uint32("slotCount", slotCount))
+	return nil
+}
+
+// 配置领导切换后继续未完成的槽数量调整
+func (s *Server) resumeResizeSlots(cfg *pb.Config) {
+	if cfg.ResizeSlotCount == 0 || s.resizing.Load() || !s.clusterEventServer.IsLeader() {
+		return
+	}
+	go func() {
+		err := s.StartResizeSlots(cfg.ResizeSlotCount)
+		if err != nil && err != ErrSlotResizing {
+			s.Warn("resume resize slots failed", zap.Error(err), zap.Uint32("resizeSlotCount", cfg.ResizeSlotCount))
+		}
+	}()
+}
+
+// 校验调整请求，并生成拆分时新增的槽（新增的槽沿用父槽的副本和领导）
+func (s *Server) resizeAddSlots(cfg *pb.Config, slotCount uint32) ([]*pb.Slot, error) {
+	if !pb.IsValidSlotResize(cfg.SlotCount, slotCount) {
+		return nil, ErrInvalidSlotCount
+	}
+	if uint32(len(cfg.Slots)) != cfg.SlotCount {
+		return nil, ErrSlotNotExist
+	}
+	for _, slot := range cfg.Slots {
+		if slot.MigrateFrom != 0 || slot.MigrateTo != 0 {
+			return nil, ErrSlotMigrating
+		}
+		if len(pb.SlotResizeTargets(slot.Id, cfg.SlotCount, slotCount)) == 0 {
+			continue
+		}
+		if slot.Leader == 0 || !s.clusterEventServer.NodeOnline(slot.Leader) {
+			return nil, ErrSlotLeaderNotFound
+		}
+	}
+
+	var addSlots []*pb.Slot
+	for slotId := cfg.SlotCount; slotId < slotCount; slotId++ {
+		parent := cfg.Slots[slotId%cfg.SlotCount]
+		addSlots = append(addSlots, &pb.Slot{
+			Id:       slotId,
+			Leader:   parent.Leader,
+			Term:     1,
+			Replicas: append([]uint64{}, parent.Replicas...),
+		})
+	}
+	return addSlots, nil
+}
+
+// 请求源槽领导将日志逐批回放到目标槽，返回下次回放的开始下标
+// startIndex为0时由源槽领导决定开始下标；final为true时是冻结后的追赶回放，要回放完源槽的全部日志
+func (s *Server) replaySlot(slotId uint32, startIndex uint64, final bool) (uint64, error) {
+	var (
+		retry     int
+		confirmed bool
+	)
+	for {
+		resp, err := s.requestSlotResizeReplay(slotId, startIndex, final)
+		if err != nil {
+			retry++
+			if retry > slotResizeReplayMaxRetry {
+				return 0, err
+			}
+			s.Warn("replay slot failed, retry", zap.Error(err), zap.Uint32("slotId", slotId), zap.Uint64("startIndex", startIndex), zap.Int("retry", retry))
+			time.Sleep(time.Millisecond * 500)
+			continue
+		}
+		retry = 0
+		if resp.Done {
+			// 冻结前已经通过检查的提案可能还没追加到日志，稍后再确认一次
+			if !final || confirmed {
+				return resp.NextIndex, nil
+			}
+			confirmed = true
+			time.Sleep(time.Millisecond * 100)
+			startIndex = resp.NextIndex
+			continue
+		}
+		confirmed = false
+		if resp.NextIndex == startIndex { // 等待日志应用
+			time.Sleep(time.Millisecond * 100)
+		}
+		startIndex = resp.NextIndex
+	}
+}
+
+func (s *Server) requestSlotResizeReplay(slotId uint32, startIndex uint64, final bool) (*SlotResizeReplayResp, error) {
+	slot := s.clusterEventServer.Slot(slotId)
+	if slot == nil {
+		return nil, ErrSlotNotExist
+	}
+	if slot.Leader == s.opts.NodeId {
+		return s.replaySlotLogs(slotId, startIndex, final)
+	}
+	leaderNode := s.nodeManager.node(slot.Leader)
+	if leaderNode == nil {
+		return nil, ErrSlotLeaderNotFound
+	}
+	timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ProposeTimeout)
+	defer cancel()
+	return leaderNode.requestSlotResizeReplay(timeoutCtx, &SlotResizeReplayReq{
+		SlotId:     slotId,
+		StartIndex: startIndex,
+		Final:      final,
+	})
+}
+
+// 将源槽从startIndex开始的一批已应用的日志提案到目标槽（只能在源槽领导上执行）
+// 源槽未冻结时回放到已应用的位置就算完成；final为true时要等本节点应用了冻结配置，并回放完全部日志
+func (s *Server) replaySlotLogs(slotId uint32, startIndex uint64, final bool) (*SlotResizeReplayResp, error) {
+	slot := s.clusterEventServer.Slot(slotId)
+	if slot == nil {
+		return nil, ErrSlotNotExist
+	}
+	if slot.Leader != s.opts.NodeId {
+		return nil, ErrNotIsLeader
+	}
+	cfg := s.clusterEventServer.Config()
+	targets := pb.SlotResizeTargets(slotId, cfg.SlotCount, cfg.ResizeSlotCount)
+	if len(targets) == 0 {
+		return nil, ErrSlotNotResizing
+	}
+	if final && !s.slotResizing(slotId) { // 本节点还没应用冻结配置，源槽还可能有新的写入
+		return &SlotResizeReplayResp{NextIndex: startIndex}, nil
+	}
+
+	shardNo := SlotIdToKey(slotId)
+	appliedIdx, err := s.opts.SlotLogStorage.AppliedIndex(shardNo)
+	if err != nil {
+		return nil, err
+	}
+	lastIdx, err := s.opts.SlotLogStorage.LastIndex(shardNo)
+	if err != nil {
+		return nil, err
+	}
+	if startIndex == 0 {
+		startIndex = slotResizeStartIndex(slot, s.resizeTargetSlots(targets), appliedIdx)
+	}
+	if startIndex > appliedIdx {
+		return &SlotResizeReplayResp{
+			NextIndex: startIndex,
+			Done:      !final || appliedIdx >= lastIdx,
+		}, nil
+	}
+
+	logs, err := s.opts.SlotLogStorage.Logs(shardNo, startIndex, appliedIdx+1, uint64(s.opts.LogSyncLimitSizeOfEach))
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return &SlotResizeReplayResp{NextIndex: startIndex}, nil
+	}
+
+	targetDatas := make(map[uint32][][]byte, len(targets))
+	for _, log := range logs {
+		if len(log.Data) == 0 {
+			continue
+		}
+		logTargets := slotResizeLogTargets(log.Data, cfg.ResizeSlotCount, targets, s.opts.SlotKeysOfLog)
+		if len(logTargets) == 0 {
+			continue
+		}
+		data := log.Data
+		if s.opts.EncodeSlotImport != nil {
+			data, err = s.opts.EncodeSlotImport(slotId, log.Data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, target := range logTargets {
+			targetDatas[target] = append(targetDatas[target], data)
+		}
+	}
+
+	for _, target := range targets {
+		datas := targetDatas[target]
+		if len(datas) == 0 {
+			continue
+		}
+		targetLogs := make([]replica.Log, 0, len(datas))
+		for _, data := range datas {
+			targetLogs = append(targetLogs, replica.Log{
+				Id:   uint64(s.logIdGen.Generate().Int64()),
+				Data: data,
+			})
+		}
+		timeoutCtx, cancel := context.WithTimeout(s.cancelCtx, s.opts.ProposeTimeout)
+		_, err = s.ProposeToSlot(timeoutCtx, target, targetLogs)
+		cancel()
+		if err != nil {
+			s.Error("replaySlotLogs: propose to target slot failed", zap.Error(err), zap.Uint32("slotId", slotId), zap.Uint32("targetSlotId", target))
+			return nil, err
+		}
+	}
+
+	return &SlotResizeReplayResp{
+		NextIndex: logs[len(logs)-1].Index + 1,
+	}, nil
+}
+
+func (s *Server) resizeTargetSlots(targets []uint32) []*pb.Slot {
+	slots := make([]*pb.Slot, 0, len(targets))
+	for _, target := range targets {
+		if slot := s.clusterEventServer.Slot(target); slot != nil {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// 回放的开始下标
+// 目标槽的副本都是源槽的副本时（例如拆分出的子槽沿用父槽的副本），这些节点已经有源槽应用过的数据，导入时也会跳过，
+// 只需要回放源槽当前应用位置之后的日志；否则需要从第一条日志开始回放
+func slotResizeStartIndex(source *pb.Slot, targetSlots []*pb.Slot, appliedIdx uint64) uint64 {
+	for _, target := range targetSlots {
+		for _, replicaId := range target.Replicas {
+			if !wkutil.ArrayContainsUint64(source.Replicas, replicaId) {
+				return 1
+			}
+		}
+	}
+	return appliedIdx + 1
+}
+
+// 日志需要回放到的目标槽，只回放数据key在新槽数量下落在目标槽里的日志
+func slotResizeLogTargets(data []byte, resizeSlotCount uint32, targets []uint32, keysOfLog func(data []byte) ([]string, bool)) []uint32 {
+	if keysOfLog == nil {
+		return targets
+	}
+	keys, ok := keysOfLog(data)
+	if !ok {
+		return targets
+	}
+	var logTargets []uint32
+	for _, key := range keys {
+		slotId := wkutil.GetSlotNum(int(resizeSlotCount), key)
+		if !wkutil.ArrayContainsUint32(targets, slotId) || wkutil.ArrayContainsUint32(logTargets, slotId) {
+			continue
+		}
+		logTargets = append(logTargets, slotId)
+	}
+	return logTargets
+}
+
+// IsLocalSlot 槽是否在本节点上有副本
+func (s *Server) IsLocalSlot(slotId uint32) bool {
+	return s.slotManager.exist(slotId)
+}
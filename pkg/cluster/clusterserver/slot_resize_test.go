@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterconfig/pb"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotResizeLogTargets(t *testing.T) {
+	var (
+		slotCount    uint32 = 4
+		newSlotCount uint32 = 8
+	)
+	keysOfLog := func(data []byte) ([]string, bool) {
+		switch string(data) {
+		case "fixed":
+			return nil, true
+		case "unknown":
+			return nil, false
+		}
+		return []string{string(data)}, true
+	}
+
+	// 找到源槽1在拆分后留在原槽和迁到新槽的key
+	var stayKey, moveKey string
+	for i := 0; stayKey == "" || moveKey == ""; i++ {
+		key := wkutil.Uint64ToString(uint64(i))
+		if wkutil.GetSlotNum(int(slotCount), key) != 1 {
+			continue
+		}
+		if wkutil.GetSlotNum(int(newSlotCount), key) == 1 {
+			stayKey = key
+		} else {
+			moveKey = key
+		}
+	}
+	targets := pb.SlotResizeTargets(1, slotCount, newSlotCount)
+
+	// 留在源槽的数据不回放
+	assert.Equal(t, 0, len(slotResizeLogTargets([]byte(stayKey), newSlotCount, targets, keysOfLog)))
+	// 只回放到数据所属的目标槽
+	assert.Equal(t, []uint32{5}, slotResizeLogTargets([]byte(moveKey), newSlotCount, targets, keysOfLog))
+	// 固定槽的数据不回放
+	assert.Equal(t, 0, len(slotResizeLogTargets([]byte("fixed"), newSlotCount, targets, keysOfLog)))
+	// 无法解析的数据回放到所有目标槽
+	assert.Equal(t, targets, slotResizeLogTargets([]byte("unknown"), newSlotCount, targets, keysOfLog))
+	assert.Equal(t, targets, slotResizeLogTargets([]byte(moveKey), newSlotCount, targets, nil))
+}
+
+func TestSlotResizeTask(t *testing.T) {
+	task := &slotResizeTask{}
+	assert.Nil(t, task.status())
+
+	task.start(4, 8, 2)
+	task.replayed(1)
+	resp := task.status()
+	assert.Equal(t, slotResizeStatusRunning, resp.Status)
+	assert.Equal(t, 1, resp.Replayed)
+	assert.Equal(t, []uint32{1}, resp.ReplayedSlots)
+
+	task.finish(ErrSlotLeaderNotFound)
+	resp = task.status()
+	assert.Equal(t, slotResizeStatusFailed, resp.Status)
+	assert.Equal(t, ErrSlotLeaderNotFound.Error(), resp.Error)
+	assert.NotZero(t, resp.FinishedAt)
+}
+
+func TestSlotResizeStartIndex(t *testing.T) {
+	source := &pb.Slot{Id: 1, Replicas: []uint64{1, 2, 3}}
+
+	// 拆分出的子槽沿用源槽的副本，只回放源槽当前应用位置之后的日志
+	targets := []*pb.Slot{{Id: 5, Replicas: []uint64{1, 2, 3}}}
+	assert.Equal(t, uint64(101), slotResizeStartIndex(source, targets, 100))
+
+	// 目标槽有不是源槽副本的节点，需要从头回放
+	targets = append(targets, &pb.Slot{Id: 9, Replicas: []uint64{2, 3, 4}})
+	assert.Equal(t, uint64(1), slotResizeStartIndex(source, targets, 100))
+}
+
+func TestSlotResizeReplayReq(t *testing.T) {
+	req := &SlotResizeReplayReq{SlotId: 3, StartIndex: 10, Final: true}
+	data, err := req.Marshal()
+	assert.NoError(t, err)
+
+	result := &SlotResizeReplayReq{}
+	err = result.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, req, result)
+}
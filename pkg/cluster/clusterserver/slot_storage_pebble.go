@@ -566,6 +566,30 @@ func (p *PebbleShardLogStorage) DeleteLeaderTermStartIndexGreaterThanTerm(shardN
 	return batch.Commit(p.wo)
 }
 
+// DeleteShard 删除分区的全部数据
+func (p *PebbleShardLogStorage) DeleteShard(shardNo string) error {
+	db := p.shardDB(shardNo)
+	batch := db.NewBatch()
+	defer batch.Close()
+	err := batch.DeleteRange(key.NewLogKey(shardNo, 0), key.NewLogKey(shardNo, math.MaxUint64), p.wo)
+	if err != nil {
+		return err
+	}
+	err = batch.DeleteRange(key.NewLeaderTermStartIndexKey(shardNo, 0), key.NewLeaderTermStartIndexKey(shardNo, math.MaxUint32), p.wo)
+	if err != nil {
+		return err
+	}
+	err = batch.Delete(key.NewMaxIndexKey(shardNo), p.wo)
+	if err != nil {
+		return err
+	}
+	err = batch.Delete(key.NewAppliedIndexKey(shardNo), p.wo)
+	if err != nil {
+		return err
+	}
+	return batch.Commit(p.wo)
+}
+
 func (p *PebbleShardLogStorage) saveMaxIndex(shardNo string, index uint64) error {
 
 	batch := p.shardBatchDB(shardNo).NewBatch()
@@ -573,7 +597,8 @@ func (p *PebbleShardLogStorage) saveMaxIndex(shardNo string, index uint64) error
 	if err != nil {
 		return err
 	}
-	return batch.Commit()
+	// 等待写入完成，截断日志后重建副本时需要读到最新的下标
+	return batch.CommitWait()
 }
 
 func (p *PebbleShardLogStorage) saveMaxIndexWrite(shardNo string, index uint64, w *wkdb.Batch) error {
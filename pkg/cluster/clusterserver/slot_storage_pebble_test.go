@@ -129,3 +129,61 @@ func TestLeaderTermSequence(t *testing.T) {
 	})
 
 }
+
+func TestDeleteShard(t *testing.T) {
+
+	traceObj := trace.New(
+		context.Background(),
+		trace.NewOptions(
+			trace.WithServiceName("test"),
+			trace.WithServiceHostName("host"),
+		))
+	trace.SetGlobalTrace(traceObj)
+
+	dir := t.TempDir()
+	s := NewPebbleShardLogStorage(dir, 8)
+	defer s.Close()
+	err := s.Open()
+	assert.Nil(t, err)
+
+	shardNo := "1"
+	otherShardNo := "2"
+	for _, no := range []string{shardNo, otherShardNo} {
+		err = s.Append(reactor.AppendLogReq{
+			HandleKey: no,
+			Logs: []replica.Log{
+				{Index: 1, Term: 1, Data: []byte("data-1")},
+				{Index: 2, Term: 1, Data: []byte("data-2")},
+			},
+		})
+		assert.Nil(t, err)
+		err = s.SetAppliedIndex(no, 2)
+		assert.Nil(t, err)
+		err = s.SetLeaderTermStartIndex(no, 1, 1)
+		assert.Nil(t, err)
+	}
+
+	err = s.DeleteShard(shardNo)
+	assert.Nil(t, err)
+
+	logs, err := s.Logs(shardNo, 1, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(logs))
+	lastIdx, err := s.LastIndex(shardNo)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), lastIdx)
+	appliedIdx, err := s.AppliedIndex(shardNo)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), appliedIdx)
+	term, err := s.LeaderLastTerm(shardNo)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), term)
+
+	// 其他分区的数据不受影响
+	logs, err = s.Logs(otherShardNo, 1, 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(logs))
+	appliedIdx, err = s.AppliedIndex(otherShardNo)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), appliedIdx)
+}
@@ -51,6 +51,12 @@ type IShardLogStorage interface {
 	Close() error
 }
 
+// IShardLogDeleter 可删除整个分区数据的日志存储（槽被合并后删除源槽的数据）
+type IShardLogDeleter interface {
+	// DeleteShard 删除分区的日志、最大索引、已应用索引和领导任期记录
+	DeleteShard(shardNo string) error
+}
+
 type MemoryShardLogStorage struct {
 	storage                 map[string][]replica.Log
 	leaderTermStartIndexMap map[string]map[uint32]uint64
@@ -161,6 +167,12 @@ func (m *MemoryShardLogStorage) DeleteLeaderTermStartIndexGreaterThanTerm(shardN
 	return nil
 }
 
+func (m *MemoryShardLogStorage) DeleteShard(shardNo string) error {
+	delete(m.storage, shardNo)
+	delete(m.leaderTermStartIndexMap, shardNo)
+	return nil
+}
+
 func (m *MemoryShardLogStorage) Open() error {
 	return nil
 }
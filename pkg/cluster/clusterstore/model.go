@@ -89,6 +89,20 @@ const (
 	CMDAddOrUpdateTester
 	// 移除测试机
 	CMDRemoveTester
+	// 设置设备同步位置
+	CMDSetDeviceSyncCursors
+	// 重置设备同步位置
+	CMDResetDeviceSyncCursors
+	// 设置用户在线状态
+	CMDSetPresence
+	// 添加子频道
+	CMDAddChannelChild
+	// 移除子频道
+	CMDRemoveChannelChild
+	// 更新设备的最后一次登录信息
+	CMDUpdateDeviceLogin
+	// 导入其他槽的数据（调整槽数量时使用）
+	CMDSlotImport
 )
 
 func (c CMDType) Uint16() uint16 {
@@ -171,6 +185,20 @@ func (c CMDType) String() string {
 		return "CMDAddOrUpdateTester"
 	case CMDRemoveTester:
 		return "CMDRemoveTester"
+	case CMDSetDeviceSyncCursors:
+		return "CMDSetDeviceSyncCursors"
+	case CMDResetDeviceSyncCursors:
+		return "CMDResetDeviceSyncCursors"
+	case CMDSetPresence:
+		return "CMDSetPresence"
+	case CMDAddChannelChild:
+		return "CMDAddChannelChild"
+	case CMDRemoveChannelChild:
+		return "CMDRemoveChannelChild"
+	case CMDUpdateDeviceLogin:
+		return "CMDUpdateDeviceLogin"
+	case CMDSlotImport:
+		return "CMDSlotImport"
 	default:
 		return fmt.Sprintf("CMDUnknown[%d]", c)
 	}
@@ -242,6 +270,12 @@ func (c *CMD) CMDContent() (string, error) {
 			return "", err
 		}
 		return wkutil.ToJSON(device), nil
+	case CMDUpdateDeviceLogin:
+		device, err := c.DecodeCMDDeviceLogin()
+		if err != nil {
+			return "", err
+		}
+		return wkutil.ToJSON(device), nil
 	case CMDAddUser:
 		user, err := c.DecodeCMDUser()
 		if err != nil {
@@ -460,6 +494,24 @@ func (c *CMD) CMDContent() (string, error) {
 			return "", err
 		}
 		return wkutil.ToJSON(conversations), nil
+	case CMDSlotImport:
+		sourceSlotId, data, err := DecodeCMDSlotImport(c.Data)
+		if err != nil {
+			return "", err
+		}
+		cmd := &CMD{}
+		if err := cmd.Unmarshal(data); err != nil {
+			return "", err
+		}
+		content, err := cmd.CMDContent()
+		if err != nil {
+			return "", err
+		}
+		return wkutil.ToJSON(map[string]interface{}{
+			"sourceSlotId": sourceSlotId,
+			"cmdType":      cmd.CmdType.String(),
+			"content":      content,
+		}), nil
 
 	}
 
@@ -796,6 +848,15 @@ func EncodeChannelInfo(c wkdb.ChannelInfo, version CmdVersion) ([]byte, error) {
 	if version > 0 {
 		enc.WriteString(c.Webhook)
 	}
+	if version > 2 {
+		enc.WriteString(c.ParentChannelId)
+		enc.WriteUint8(c.ParentChannelType)
+		enc.WriteUint8(wkutil.BoolToUint8(c.Inherit))
+	}
+	if version > 3 {
+		enc.WriteUint8(c.HistoryVisibility)
+		enc.WriteUint32(c.HistoryCount)
+	}
 	return enc.Bytes(), nil
 }
 
@@ -851,6 +912,31 @@ func (c *CMD) DecodeChannelInfo() (wkdb.ChannelInfo, error) {
 		}
 	}
 
+	// 父频道（旧版本数据没有）
+	if c.version > 0 && dec.Len() > 0 {
+		if channelInfo.ParentChannelId, err = dec.String(); err != nil {
+			return channelInfo, err
+		}
+		if channelInfo.ParentChannelType, err = dec.Uint8(); err != nil {
+			return channelInfo, err
+		}
+		var inherit uint8
+		if inherit, err = dec.Uint8(); err != nil {
+			return channelInfo, err
+		}
+		channelInfo.Inherit = wkutil.Uint8ToBool(inherit)
+	}
+
+	// 历史消息可见策略（旧版本数据没有）
+	if c.version > 0 && dec.Len() > 0 {
+		if channelInfo.HistoryVisibility, err = dec.Uint8(); err != nil {
+			return channelInfo, err
+		}
+		if channelInfo.HistoryCount, err = dec.Uint32(); err != nil {
+			return channelInfo, err
+		}
+	}
+
 	return channelInfo, err
 }
 
@@ -1289,6 +1375,268 @@ func (c *CMD) DecodeCMDRemoveTester() (no string, err error) {
 	return
 }
 
+func EncodeCMDSetDeviceSyncCursors(cursors []wkdb.DeviceSyncCursor) ([]byte, error) {
+	encoder := wkproto.NewEncoder()
+	defer encoder.End()
+	encoder.WriteUint32(uint32(len(cursors)))
+	for _, cursor := range cursors {
+		data, err := cursor.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		encoder.WriteBinary(data)
+	}
+	return encoder.Bytes(), nil
+}
+
+func (c *CMD) DecodeCMDSetDeviceSyncCursors() (cursors []wkdb.DeviceSyncCursor, err error) {
+	decoder := wkproto.NewDecoder(c.Data)
+	var count uint32
+	if count, err = decoder.Uint32(); err != nil {
+		return
+	}
+	for i := uint32(0); i < count; i++ {
+		var data []byte
+		if data, err = decoder.Binary(); err != nil {
+			return
+		}
+		var cursor wkdb.DeviceSyncCursor
+		if err = cursor.Unmarshal(data); err != nil {
+			return
+		}
+		cursors = append(cursors, cursor)
+	}
+	return
+}
+
+func EncodeCMDResetDeviceSyncCursors(uid string, deviceFlag uint8) []byte {
+	encoder := wkproto.NewEncoder()
+	defer encoder.End()
+	encoder.WriteString(uid)
+	encoder.WriteUint8(deviceFlag)
+	return encoder.Bytes()
+}
+
+func (c *CMD) DecodeCMDResetDeviceSyncCursors() (uid string, deviceFlag uint8, err error) {
+	decoder := wkproto.NewDecoder(c.Data)
+	if uid, err = decoder.String(); err != nil {
+		return
+	}
+	if deviceFlag, err = decoder.Uint8(); err != nil {
+		return
+	}
+	return
+}
+
+func EncodeCMDSetPresence(presence wkdb.Presence) ([]byte, error) {
+	return presence.Marshal()
+}
+
+func (c *CMD) DecodeCMDSetPresence() (presence wkdb.Presence, err error) {
+	err = presence.Unmarshal(c.Data)
+	return
+}
+
+func EncodeCMDChannelChild(parentChannelId string, parentChannelType uint8, child wkdb.ChannelChild) []byte {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(parentChannelId)
+	enc.WriteUint8(parentChannelType)
+	enc.WriteString(child.ChannelId)
+	enc.WriteUint8(child.ChannelType)
+	enc.WriteUint8(wkutil.BoolToUint8(child.Inherit))
+	return enc.Bytes()
+}
+
+func (c *CMD) DecodeCMDChannelChild() (parentChannelId string, parentChannelType uint8, child wkdb.ChannelChild, err error) {
+	dec := wkproto.NewDecoder(c.Data)
+	if parentChannelId, err = dec.String(); err != nil {
+		return
+	}
+	if parentChannelType, err = dec.Uint8(); err != nil {
+		return
+	}
+	if child.ChannelId, err = dec.String(); err != nil {
+		return
+	}
+	if child.ChannelType, err = dec.Uint8(); err != nil {
+		return
+	}
+	var inherit uint8
+	if inherit, err = dec.Uint8(); err != nil {
+		return
+	}
+	child.Inherit = wkutil.Uint8ToBool(inherit)
+	return
+}
+
+func EncodeCMDDeviceLogin(d wkdb.Device) []byte {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(d.Id)
+	enc.WriteString(d.Uid)
+	if d.LastLoginAt != nil {
+		enc.WriteUint64(uint64(d.LastLoginAt.UnixNano()))
+	} else {
+		enc.WriteUint64(0)
+	}
+	enc.WriteString(d.LastLoginIp)
+	enc.WriteString(d.DeviceId)
+	enc.WriteUint8(d.Version)
+	return enc.Bytes()
+}
+
+func (c *CMD) DecodeCMDDeviceLogin() (d wkdb.Device, err error) {
+	dec := wkproto.NewDecoder(c.Data)
+	if d.Id, err = dec.Uint64(); err != nil {
+		return
+	}
+	if d.Uid, err = dec.String(); err != nil {
+		return
+	}
+	var lastLoginAt uint64
+	if lastLoginAt, err = dec.Uint64(); err != nil {
+		return
+	}
+	if lastLoginAt > 0 {
+		t := time.Unix(int64(lastLoginAt/1e9), int64(lastLoginAt%1e9))
+		d.LastLoginAt = &t
+	}
+	if d.LastLoginIp, err = dec.String(); err != nil {
+		return
+	}
+	if d.DeviceId, err = dec.String(); err != nil {
+		return
+	}
+	if d.Version, err = dec.Uint8(); err != nil {
+		return
+	}
+	return
+}
+
+// EncodeCMDSlotImport 将源槽的日志数据包装为导入命令
+func EncodeCMDSlotImport(sourceSlotId uint32, data []byte) ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint32(sourceSlotId)
+	enc.WriteBytes(data)
+	cmd := NewCMD(CMDSlotImport, enc.Bytes())
+	return cmd.Marshal()
+}
+
+func DecodeCMDSlotImport(data []byte) (sourceSlotId uint32, cmdData []byte, err error) {
+	dec := wkproto.NewDecoder(data)
+	if sourceSlotId, err = dec.Uint32(); err != nil {
+		return
+	}
+	cmdData, err = dec.BinaryAll()
+	return
+}
+
+// CMDSlotKeys 日志数据计算槽时使用的key（频道ID或uid），调整槽数量时用于把源槽的日志只回放到数据所属的目标槽
+// 固定存储在0槽的数据（系统uid、测试机）返回空的keys，不能识别的命令ok为false
+func CMDSlotKeys(data []byte) (keys []string, ok bool) {
+	cmd := &CMD{}
+	if err := cmd.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	var err error
+	switch cmd.CmdType {
+	case CMDSlotImport:
+		var cmdData []byte
+		if _, cmdData, err = DecodeCMDSlotImport(cmd.Data); err != nil {
+			return nil, false
+		}
+		return CMDSlotKeys(cmdData)
+	case CMDAddDevice, CMDUpdateDevice:
+		var d wkdb.Device
+		d, err = cmd.DecodeCMDDevice()
+		keys = []string{d.Uid}
+	case CMDUpdateDeviceLogin:
+		var d wkdb.Device
+		d, err = cmd.DecodeCMDDeviceLogin()
+		keys = []string{d.Uid}
+	case CMDAddUser, CMDUpdateUser:
+		var u wkdb.User
+		u, err = cmd.DecodeCMDUser()
+		keys = []string{u.Uid}
+	case CMDAddChannelInfo, CMDUpdateChannelInfo:
+		var channelInfo wkdb.ChannelInfo
+		channelInfo, err = cmd.DecodeChannelInfo()
+		keys = []string{channelInfo.ChannelId}
+	case CMDAddSubscribers, CMDAddDenylist, CMDAddAllowlist:
+		var channelId string
+		channelId, _, _, err = cmd.DecodeMembers()
+		keys = []string{channelId}
+	case CMDRemoveSubscribers, CMDRemoveDenylist, CMDRemoveAllowlist:
+		var channelId string
+		channelId, _, _, err = cmd.DecodeChannelUids()
+		keys = []string{channelId}
+	case CMDRemoveAllSubscriber, CMDRemoveAllDenylist, CMDRemoveAllAllowlist, CMDDeleteChannel, CMDDeleteChannelAndClearMessages:
+		var channelId string
+		channelId, _, err = cmd.DecodeChannel()
+		keys = []string{channelId}
+	case CMDAddOrUpdateUserConversations:
+		var uid string
+		uid, _, err = cmd.DecodeCMDAddOrUpdateUserConversations()
+		keys = []string{uid}
+	case CMDDeleteConversation:
+		var uid string
+		uid, _, _, err = cmd.DecodeCMDDeleteConversation()
+		keys = []string{uid}
+	case CMDDeleteConversations:
+		var uid string
+		uid, _, err = cmd.DecodeCMDDeleteConversations()
+		keys = []string{uid}
+	case CMDAddOrUpdateConversations:
+		var conversations wkdb.ConversationSet
+		conversations, err = cmd.DecodeCMDAddOrUpdateConversations()
+		for _, c := range conversations {
+			keys = append(keys, c.Uid)
+		}
+	case CMDChannelClusterConfigSave:
+		var channelId string
+		channelId, _, _, err = cmd.DecodeCMDChannelClusterConfigSave()
+		keys = []string{channelId}
+	case CMDAddStreamMeta:
+		var streamMeta *wkdb.StreamMeta
+		streamMeta, err = cmd.DecodeCMDAddStreamMeta()
+		if err == nil {
+			keys = []string{streamMeta.ChannelId}
+		}
+	case CMDStreamEnd:
+		var channelId string
+		channelId, _, _, err = cmd.DecodeCMDStreamEnd()
+		keys = []string{channelId}
+	case CMDSetDeviceSyncCursors:
+		var cursors []wkdb.DeviceSyncCursor
+		cursors, err = cmd.DecodeCMDSetDeviceSyncCursors()
+		for _, cursor := range cursors {
+			keys = append(keys, cursor.Uid)
+		}
+	case CMDResetDeviceSyncCursors:
+		var uid string
+		uid, _, err = cmd.DecodeCMDResetDeviceSyncCursors()
+		keys = []string{uid}
+	case CMDSetPresence:
+		var presence wkdb.Presence
+		presence, err = cmd.DecodeCMDSetPresence()
+		keys = []string{presence.Uid}
+	case CMDAddChannelChild, CMDRemoveChannelChild:
+		var parentChannelId string
+		parentChannelId, _, _, err = cmd.DecodeCMDChannelChild()
+		keys = []string{parentChannelId}
+	case CMDSystemUIDsAdd, CMDSystemUIDsRemove, CMDAddOrUpdateTester, CMDRemoveTester: // 固定在0槽
+		return nil, true
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return keys, true
+}
+
 var ErrStoreStopped = fmt.Errorf("store stopped")
 
 type applyReq struct {
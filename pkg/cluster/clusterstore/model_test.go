@@ -0,0 +1,45 @@
+package clusterstore_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/cluster/clusterstore"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCMDSlotKeys(t *testing.T) {
+	marshal := func(cmdType clusterstore.CMDType, data []byte) []byte {
+		cmdData, err := clusterstore.NewCMD(cmdType, data).Marshal()
+		assert.Nil(t, err)
+		return cmdData
+	}
+
+	// 用户数据按uid分槽
+	userData := marshal(clusterstore.CMDAddUser, clusterstore.EncodeCMDUser(wkdb.User{Uid: "u1"}))
+	keys, ok := clusterstore.CMDSlotKeys(userData)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"u1"}, keys)
+
+	// 频道数据按channelId分槽
+	membersData := marshal(clusterstore.CMDAddSubscribers, clusterstore.EncodeMembers("g1", 2, []wkdb.Member{{Uid: "u1"}}))
+	keys, ok = clusterstore.CMDSlotKeys(membersData)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"g1"}, keys)
+
+	// 导入的日志解析原始命令
+	importData, err := clusterstore.EncodeCMDSlotImport(3, userData)
+	assert.Nil(t, err)
+	keys, ok = clusterstore.CMDSlotKeys(importData)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"u1"}, keys)
+
+	// 固定在0槽的数据
+	keys, ok = clusterstore.CMDSlotKeys(marshal(clusterstore.CMDSystemUIDsAdd, clusterstore.EncodeCMDSystemUIDs([]string{"sys"})))
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(keys))
+
+	// 无法解析
+	_, ok = clusterstore.CMDSlotKeys([]byte("invalid"))
+	assert.False(t, ok)
+}
@@ -1,6 +1,8 @@
 package clusterstore
 
 import (
+	"time"
+
 	"github.com/WuKongIM/WuKongIM/pkg/cluster/icluster"
 )
 
@@ -12,11 +14,19 @@ type Options struct {
 
 	GetSlotId func(uid string) uint32
 
+	IsLocalSlot func(slotId uint32) bool // 槽是否在本节点（本节点是槽的副本或学习者）
+
 	IsCmdChannel func(string) bool // 是否是cmd频道
 
+	IsFederationMessage func(channelId string, channelType uint8, clientMsgNo string) bool // 消息是否需要联邦同步
+
+	DedupWindow time.Duration // 消息去重的时间窗口，0为不写去重索引
+
 	Db struct {
-		ShardNum     int // 分片数量
-		MemTableSize int // MemTable大小
+		ShardNum               int    // 分片数量
+		MemTableSize           int    // MemTable大小
+		EncryptionMasterKey    []byte // 消息payload加密的主密钥，为空表示不开启加密
+		EncryptionOldMasterKey []byte // 旧的主密钥（主密钥轮换时使用）
 	}
 }
 
@@ -33,8 +43,10 @@ func newOptions() *Options {
 	return &Options{
 		SlotCount: 64,
 		Db: struct {
-			ShardNum     int
-			MemTableSize int
+			ShardNum               int
+			MemTableSize           int
+			EncryptionMasterKey    []byte
+			EncryptionOldMasterKey []byte
 		}{
 			ShardNum:     8,
 			MemTableSize: 16 * 1024 * 1024,
@@ -68,12 +80,30 @@ func WithIsCmdChannel(f func(string) bool) Option {
 	}
 }
 
+func WithIsFederationMessage(f func(channelId string, channelType uint8, clientMsgNo string) bool) Option {
+	return func(o *Options) {
+		o.IsFederationMessage = f
+	}
+}
+
+func WithDedupWindow(dedupWindow time.Duration) Option {
+	return func(o *Options) {
+		o.DedupWindow = dedupWindow
+	}
+}
+
 func WithGetSlotId(f func(uid string) uint32) Option {
 	return func(o *Options) {
 		o.GetSlotId = f
 	}
 }
 
+func WithIsLocalSlot(f func(slotId uint32) bool) Option {
+	return func(o *Options) {
+		o.IsLocalSlot = f
+	}
+}
+
 func WithDbShardNum(num int) Option {
 	return func(o *Options) {
 		o.Db.ShardNum = num
@@ -85,3 +115,10 @@ func WithDbMemTableSize(size int) Option {
 		o.Db.MemTableSize = size
 	}
 }
+
+func WithDbEncryptionMasterKey(masterKey, oldMasterKey []byte) Option {
+	return func(o *Options) {
+		o.Db.EncryptionMasterKey = masterKey
+		o.Db.EncryptionOldMasterKey = oldMasterKey
+	}
+}
@@ -46,11 +46,15 @@ func NewStore(opts *Options) *Store {
 	s.wdb = wkdb.NewWukongDB(
 		wkdb.NewOptions(
 			wkdb.WithIsCmdChannel(opts.IsCmdChannel),
+			wkdb.WithIsFederationMessage(opts.IsFederationMessage),
+			wkdb.WithDedupWindow(opts.DedupWindow),
 			wkdb.WithShardNum(opts.Db.ShardNum),
 			wkdb.WithDir(opts.DataDir),
 			wkdb.WithNodeId(opts.NodeID),
 			wkdb.WithMemTableSize(opts.Db.MemTableSize),
 			wkdb.WithSlotCount(int(opts.SlotCount)),
+			wkdb.WithGetSlotId(opts.GetSlotId),
+			wkdb.WithEncryptionMasterKey(opts.Db.EncryptionMasterKey, opts.Db.EncryptionOldMasterKey),
 		),
 	)
 
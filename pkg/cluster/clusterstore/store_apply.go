@@ -83,6 +83,17 @@ func (s *Store) onMetaApply(logs []replica.Log) error {
 			return err
 		}
 
+		if cmd.CmdType == CMDSlotImport {
+			cmd, err = s.unwrapSlotImport(cmd)
+			if err != nil {
+				s.Error("unwrap slot import err", zap.Error(err), zap.Uint64("index", log.Index))
+				return err
+			}
+			if cmd == nil {
+				continue
+			}
+		}
+
 		if _, exists := cmdMap[cmd.CmdType]; !exists {
 			cmdOrder = append(cmdOrder, cmd.CmdType)
 		}
@@ -99,6 +110,32 @@ func (s *Store) onMetaApply(logs []replica.Log) error {
 	return nil
 }
 
+// 解开导入命令，本节点已经有源槽数据的（本节点是源槽的副本）不需要再导入，返回nil
+func (s *Store) unwrapSlotImport(cmd *CMD) (*CMD, error) {
+	sourceSlotId, data, err := DecodeCMDSlotImport(cmd.Data)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.IsLocalSlot != nil && s.opts.IsLocalSlot(sourceSlotId) {
+		return nil, nil
+	}
+	importCmd := &CMD{}
+	if err := importCmd.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	// 多次调整槽数量时会出现嵌套的导入命令，内层的源槽是旧的槽布局，不能再用来判断是否跳过
+	for importCmd.CmdType == CMDSlotImport {
+		if _, data, err = DecodeCMDSlotImport(importCmd.Data); err != nil {
+			return nil, err
+		}
+		importCmd = &CMD{}
+		if err := importCmd.Unmarshal(data); err != nil {
+			return nil, err
+		}
+	}
+	return importCmd, nil
+}
+
 func (s *Store) execCMDs(cmdType CMDType, cmds []*CMD) error {
 	switch cmdType {
 	case CMDAddOrUpdateConversations:
@@ -174,6 +211,18 @@ func (s *Store) execCMD(cmd *CMD) error {
 		return s.handleAddOrUpdateTester(cmd)
 	case CMDRemoveTester: // 移除测试机
 		return s.handleRemoveTester(cmd)
+	case CMDSetDeviceSyncCursors: // 设置设备同步位置
+		return s.handleSetDeviceSyncCursors(cmd)
+	case CMDResetDeviceSyncCursors: // 重置设备同步位置
+		return s.handleResetDeviceSyncCursors(cmd)
+	case CMDSetPresence: // 设置用户在线状态
+		return s.handleSetPresence(cmd)
+	case CMDAddChannelChild: // 添加子频道
+		return s.handleAddChannelChild(cmd)
+	case CMDRemoveChannelChild: // 移除子频道
+		return s.handleRemoveChannelChild(cmd)
+	case CMDUpdateDeviceLogin: // 更新设备的最后一次登录信息
+		return s.handleUpdateDeviceLogin(cmd)
 
 	}
 	return nil
@@ -228,6 +277,14 @@ func (s *Store) handleUpdateDevice(cmd *CMD) error {
 	return s.wdb.UpdateDevice(u)
 }
 
+func (s *Store) handleUpdateDeviceLogin(cmd *CMD) error {
+	d, err := cmd.DecodeCMDDeviceLogin()
+	if err != nil {
+		return err
+	}
+	return s.wdb.UpdateDeviceLogin(d)
+}
+
 func (s *Store) handleAddChannelInfo(cmd *CMD) error {
 	channelInfo, err := cmd.DecodeChannelInfo()
 	if err != nil {
@@ -450,3 +507,43 @@ func (s *Store) handleRemoveTester(cmd *CMD) error {
 	}
 	return s.wdb.RemoveTester(no)
 }
+
+func (s *Store) handleSetDeviceSyncCursors(cmd *CMD) error {
+	cursors, err := cmd.DecodeCMDSetDeviceSyncCursors()
+	if err != nil {
+		return err
+	}
+	return s.wdb.SetDeviceSyncCursors(cursors)
+}
+
+func (s *Store) handleResetDeviceSyncCursors(cmd *CMD) error {
+	uid, deviceFlag, err := cmd.DecodeCMDResetDeviceSyncCursors()
+	if err != nil {
+		return err
+	}
+	return s.wdb.ResetDeviceSyncCursors(uid, deviceFlag)
+}
+
+func (s *Store) handleSetPresence(cmd *CMD) error {
+	presence, err := cmd.DecodeCMDSetPresence()
+	if err != nil {
+		return err
+	}
+	return s.wdb.SetPresence(presence)
+}
+
+func (s *Store) handleAddChannelChild(cmd *CMD) error {
+	parentChannelId, parentChannelType, child, err := cmd.DecodeCMDChannelChild()
+	if err != nil {
+		return err
+	}
+	return s.wdb.AddChannelChild(parentChannelId, parentChannelType, child)
+}
+
+func (s *Store) handleRemoveChannelChild(cmd *CMD) error {
+	parentChannelId, parentChannelType, child, err := cmd.DecodeCMDChannelChild()
+	if err != nil {
+		return err
+	}
+	return s.wdb.RemoveChannelChild(parentChannelId, parentChannelType, child)
+}
@@ -22,6 +22,10 @@ func (s *Store) AddSubscribers(channelId string, channelType uint8, subscribers
 	return err
 }
 
+func (s *Store) GetSubscriber(channelId string, channelType uint8, uid string) (wkdb.Member, error) {
+	return s.wdb.GetSubscriber(channelId, channelType, uid)
+}
+
 func (s *Store) ExistSubscriber(channelId string, channelType uint8, uid string) (bool, error) {
 	return s.wdb.ExistSubscriber(channelId, channelType, uid)
 }
@@ -235,3 +239,29 @@ func (s *Store) HasAllowlist(channelId string, channelType uint8) (bool, error)
 // 	_, err = s.opts.Cluster.ProposeChannelMeta(s.ctx, channelID, channelType, cmdData)
 // 	return err
 // }
+
+// AddChannelChild 添加父频道的子频道（保存在父频道所在的槽）
+func (s *Store) AddChannelChild(parentChannelId string, parentChannelType uint8, child wkdb.ChannelChild) error {
+	return s.proposeChannelChild(CMDAddChannelChild, parentChannelId, parentChannelType, child)
+}
+
+// RemoveChannelChild 移除父频道的子频道
+func (s *Store) RemoveChannelChild(parentChannelId string, parentChannelType uint8, child wkdb.ChannelChild) error {
+	return s.proposeChannelChild(CMDRemoveChannelChild, parentChannelId, parentChannelType, child)
+}
+
+func (s *Store) proposeChannelChild(cmdType CMDType, parentChannelId string, parentChannelType uint8, child wkdb.ChannelChild) error {
+	cmd := NewCMD(cmdType, EncodeCMDChannelChild(parentChannelId, parentChannelType, child))
+	cmdData, err := cmd.Marshal()
+	if err != nil {
+		return err
+	}
+	slotId := s.opts.GetSlotId(parentChannelId)
+	_, err = s.opts.Cluster.ProposeDataToSlot(slotId, cmdData)
+	return err
+}
+
+// GetChannelChildren 获取父频道的所有子频道
+func (s *Store) GetChannelChildren(parentChannelId string, parentChannelType uint8) ([]wkdb.ChannelChild, error) {
+	return s.wdb.GetChannelChildren(parentChannelId, parentChannelType)
+}
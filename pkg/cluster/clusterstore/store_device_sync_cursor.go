@@ -0,0 +1,44 @@
+package clusterstore
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"go.uber.org/zap"
+)
+
+// SetDeviceSyncCursors 设置用户设备的同步位置（数据在用户所在的槽位上）
+func (s *Store) SetDeviceSyncCursors(uid string, cursors []wkdb.DeviceSyncCursor) error {
+	if len(cursors) == 0 {
+		return nil
+	}
+	data, err := EncodeCMDSetDeviceSyncCursors(cursors)
+	if err != nil {
+		return err
+	}
+	cmd := NewCMD(CMDSetDeviceSyncCursors, data)
+	cmdData, err := cmd.Marshal()
+	if err != nil {
+		s.Error("SetDeviceSyncCursors: marshal cmd failed", zap.Error(err))
+		return err
+	}
+	slotId := s.opts.GetSlotId(uid)
+	_, err = s.opts.Cluster.ProposeDataToSlot(slotId, cmdData)
+	return err
+}
+
+func (s *Store) GetDeviceSyncCursors(uid string, deviceFlag uint8) ([]wkdb.DeviceSyncCursor, error) {
+	return s.wdb.GetDeviceSyncCursors(uid, deviceFlag)
+}
+
+// ResetDeviceSyncCursors 重置用户设备的同步位置
+func (s *Store) ResetDeviceSyncCursors(uid string, deviceFlag uint8) error {
+	data := EncodeCMDResetDeviceSyncCursors(uid, deviceFlag)
+	cmd := NewCMD(CMDResetDeviceSyncCursors, data)
+	cmdData, err := cmd.Marshal()
+	if err != nil {
+		s.Error("ResetDeviceSyncCursors: marshal cmd failed", zap.Error(err))
+		return err
+	}
+	slotId := s.opts.GetSlotId(uid)
+	_, err = s.opts.Cluster.ProposeDataToSlot(slotId, cmdData)
+	return err
+}
@@ -48,6 +48,16 @@ func (s *Store) LoadMsg(channelID string, channelType uint8, seq uint64) (wkdb.M
 	return s.wdb.LoadMsg(channelID, channelType, seq)
 }
 
+// GetDedupMessage 获取发送者在频道内指定client_msg_no的消息
+func (s *Store) GetDedupMessage(channelId string, channelType uint8, fromUid string, clientMsgNo string) (wkdb.Message, error) {
+	return s.wdb.GetDedupMessage(channelId, channelType, fromUid, clientMsgNo)
+}
+
+// GetMentionStat 获取用户在频道内从startMessageSeq开始被提醒（@）的次数和最后一次被提醒的消息序号
+func (s *Store) GetMentionStat(channelId string, channelType uint8, uid string, startMessageSeq uint64) (int, uint64, error) {
+	return s.wdb.GetMentionStat(channelId, channelType, uid, startMessageSeq)
+}
+
 func (s *Store) LoadLastMsgs(channelID string, channelType uint8, limit int) ([]wkdb.Message, error) {
 	return s.wdb.LoadLastMsgs(channelID, channelType, limit)
 }
@@ -77,6 +87,18 @@ func (s *Store) RemoveMessagesOfNotifyQueue(messageIDs []int64) error {
 	return s.wdb.RemoveMessagesOfNotifyQueue(messageIDs)
 }
 
+func (s *Store) GetFederationQueueChannels() ([]wkdb.Channel, error) {
+	return s.wdb.GetFederationQueueChannels()
+}
+
+func (s *Store) GetMessagesOfFederationQueue(channelId string, channelType uint8, startMessageSeq uint64, count int) ([]wkdb.Message, error) {
+	return s.wdb.GetMessagesOfFederationQueue(channelId, channelType, startMessageSeq, count)
+}
+
+func (s *Store) RemoveMessagesOfFederationQueue(channelId string, channelType uint8, endMessageSeq uint64) error {
+	return s.wdb.RemoveMessagesOfFederationQueue(channelId, channelType, endMessageSeq)
+}
+
 func (s *Store) GetMessageShardLogStorage() *MessageShardLogStorage {
 	return s.messageShardLogStorage
 }
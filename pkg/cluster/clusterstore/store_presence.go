@@ -0,0 +1,27 @@
+package clusterstore
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"go.uber.org/zap"
+)
+
+// SetPresence 设置用户的在线状态（数据在用户所在的槽位上）
+func (s *Store) SetPresence(presence wkdb.Presence) error {
+	data, err := EncodeCMDSetPresence(presence)
+	if err != nil {
+		return err
+	}
+	cmd := NewCMD(CMDSetPresence, data)
+	cmdData, err := cmd.Marshal()
+	if err != nil {
+		s.Error("SetPresence: marshal cmd failed", zap.Error(err))
+		return err
+	}
+	slotId := s.opts.GetSlotId(presence.Uid)
+	_, err = s.opts.Cluster.ProposeDataToSlot(slotId, cmdData)
+	return err
+}
+
+func (s *Store) GetPresence(uid string) (wkdb.Presence, error) {
+	return s.wdb.GetPresence(uid)
+}
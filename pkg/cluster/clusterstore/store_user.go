@@ -52,6 +52,21 @@ func (s *Store) UpdateDevice(d wkdb.Device) error {
 	return err
 }
 
+// UpdateDeviceLogin 更新设备的最后一次登录信息
+func (s *Store) UpdateDeviceLogin(d wkdb.Device) error {
+	data := EncodeCMDDeviceLogin(d)
+	cmd := NewCMD(CMDUpdateDeviceLogin, data)
+	cmdData, err := cmd.Marshal()
+	if err != nil {
+		s.Error("marshal cmd failed", zap.Error(err))
+		return err
+	}
+
+	slotId := s.opts.GetSlotId(d.Uid)
+	_, err = s.opts.Cluster.ProposeDataToSlot(slotId, cmdData)
+	return err
+}
+
 func (s *Store) AddDevice(d wkdb.Device) error {
 	data := EncodeCMDDevice(d)
 	cmd := NewCMD(CMDAddDevice, data)
@@ -70,6 +85,10 @@ func (s *Store) GetDevice(uid string, deviceFlag wkproto.DeviceFlag) (wkdb.Devic
 	return s.wdb.GetDevice(uid, uint64(deviceFlag))
 }
 
+func (s *Store) GetDevices(uid string) ([]wkdb.Device, error) {
+	return s.wdb.GetDevices(uid)
+}
+
 func (s *Store) NextPrimaryKey() uint64 {
 	return s.wdb.NextPrimaryKey()
 }
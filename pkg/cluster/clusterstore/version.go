@@ -4,7 +4,8 @@ type CmdVersion uint16
 
 const (
 	// CmdVersionChannelInfo is the version of the command that contains channel info
-	CmdVersionChannelInfo CmdVersion = 2
+	// 2: webhook 3: parent channel 4: history visibility
+	CmdVersionChannelInfo CmdVersion = 4
 )
 
 func (c CmdVersion) Uint16() uint16 {
@@ -92,6 +92,9 @@ type handler struct {
 
 	syncTimeoutTick int // 同步超时tick次数
 
+	removed  atomic.Bool  // 是否已经等待移除（移除后不再写入日志）
+	inflight atomic.Int64 // 正在执行的日志写入（追加、应用、截断）数量
+
 	wklog.Log
 	r *Reactor
 }
@@ -121,6 +124,21 @@ func (h *handler) reset() {
 
 }
 
+// acquire 开始写入日志，handler已经移除时返回false
+func (h *handler) acquire() bool {
+	h.inflight.Inc()
+	if h.removed.Load() {
+		h.inflight.Dec()
+		return false
+	}
+	return true
+}
+
+// release 日志写入完成
+func (h *handler) release() {
+	h.inflight.Dec()
+}
+
 func (h *handler) ready() replica.Ready {
 	return h.handler.Ready()
 }
@@ -161,6 +179,14 @@ func (h *handler) removeWait(key string) {
 	h.proposeWait.remove(key)
 }
 
+// 等待提交的提案数量
+func (h *handler) pendingProposals() int {
+	if h.proposeWait == nil {
+		return 0
+	}
+	return h.proposeWait.len()
+}
+
 func (h *handler) lastLogIndexAndTerm() (uint64, uint32) {
 	return h.handler.LastLogIndexAndTerm()
 }
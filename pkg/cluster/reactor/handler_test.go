@@ -0,0 +1,21 @@
+package reactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerAcquire(t *testing.T) {
+	h := &handler{}
+	assert.True(t, h.acquire())
+	assert.Equal(t, int64(1), h.inflight.Load())
+
+	// 移除后不能再开始写入，已经开始的写入不受影响
+	h.removed.Store(true)
+	assert.False(t, h.acquire())
+	assert.Equal(t, int64(1), h.inflight.Load())
+
+	h.release()
+	assert.Equal(t, int64(0), h.inflight.Load())
+}
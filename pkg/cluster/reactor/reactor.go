@@ -11,6 +11,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	"github.com/lni/goutils/syncutil"
 	"github.com/panjf2000/ants/v2"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 )
 
@@ -34,6 +35,10 @@ type Reactor struct {
 	stopper *syncutil.Stopper
 
 	request IRequest
+
+	pendingProposals atomic.Int64      // 等待提交的提案数量
+	inflightApplies  atomic.Int64      // 正在应用的日志请求数量
+	commitLatency    *latencyHistogram // 日志追加到领导到提交的延迟
 }
 
 func New(opts *Options) *Reactor {
@@ -51,6 +56,7 @@ func New(opts *Options) *Reactor {
 		processLearnerToLeaderC:   make(chan *learnerToLeaderReq, 1024),
 		processFollowerToLeaderC:  make(chan *followerToLeaderReq, 1024),
 		request:                   opts.Request,
+		commitLatency:             newLatencyHistogram(),
 	}
 
 	for i := 0; i < int(r.opts.SubReactorNum); i++ {
@@ -159,6 +165,30 @@ func (r *Reactor) RemoveHandler(key string) {
 	// }
 }
 
+// RemoveHandlerAndWait 移除handler，并等待正在执行的日志写入（追加、应用、截断）完成
+// 返回后不会再有这个handler的日志写入存储，可以安全的修改它的日志
+func (r *Reactor) RemoveHandlerAndWait(ctx context.Context, key string) error {
+	r.mu.Lock()
+	sub := r.reactorSub(key)
+	hd := sub.removeHandler(key)
+	r.mu.Unlock()
+	if hd == nil {
+		return nil
+	}
+	hd.removed.Store(true)
+
+	tk := time.NewTicker(time.Millisecond * 10)
+	defer tk.Stop()
+	for hd.inflight.Load() > 0 {
+		select {
+		case <-tk.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
 func (r *Reactor) Handler(key string) IHandler {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
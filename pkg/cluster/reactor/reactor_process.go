@@ -209,6 +209,11 @@ func (r *Reactor) processConflictChecks(reqs []*conflictCheckReq) {
 
 func (r *Reactor) processConflictCheck(req *conflictCheckReq) {
 
+	if !req.h.acquire() { // handler已经移除
+		return
+	}
+	defer req.h.release()
+
 	if req.leaderLastTerm == 0 { // 本地没有任期，说明本地还没有日志
 		r.Debug("local has no log,no conflict", zap.String("handlerKey", req.h.key))
 		req.sub.mustAddMessage(Message{
@@ -403,6 +408,11 @@ func (r *Reactor) processStoreAppends(reqs []AppendLogReq) {
 
 func (r *Reactor) processStoreAppend(req AppendLogReq) {
 
+	if !req.handler.acquire() { // handler已经移除
+		return
+	}
+	defer req.handler.release()
+
 	err := r.request.Append(req)
 	if err != nil {
 		r.Error("append logs failed", zap.Error(err))
@@ -623,12 +633,15 @@ func (r *Reactor) processApplyLogs(reqs []*applyLogReq) {
 
 	var err error
 	for _, req := range reqs {
+		r.inflightApplies.Inc()
 		err = r.processGoPool.Submit(func(rq *applyLogReq) func() {
 			return func() {
+				defer r.inflightApplies.Dec()
 				r.processApplyLog(rq)
 			}
 		}(req))
 		if err != nil {
+			r.inflightApplies.Dec()
 			r.Error("processApplyLogs failed, submit error", zap.Error(err))
 			req.sub.mustAddMessage(Message{
 				HandlerKey: req.h.key,
@@ -645,6 +658,11 @@ func (r *Reactor) processApplyLogs(reqs []*applyLogReq) {
 
 func (r *Reactor) processApplyLog(req *applyLogReq) {
 
+	if !req.h.acquire() { // handler已经移除
+		return
+	}
+	defer req.h.release()
+
 	if !r.opts.IsCommittedAfterApplied {
 		// 提交日志
 		if req.leaderId == r.opts.NodeId {
@@ -135,8 +135,10 @@ func (r *ReactorSub) proposeAndWait(ctx context.Context, handleKey string, logs
 	}
 
 	// -------------------- 延迟统计 --------------------
+	r.mr.pendingProposals.Inc()
 	startTime := time.Now()
 	defer func() {
+		r.mr.pendingProposals.Dec()
 		end := time.Since(startTime)
 		switch r.opts.ReactorType {
 		case ReactorTypeSlot:
@@ -199,6 +201,15 @@ func (r *ReactorSub) proposeAndWait(ctx context.Context, handleKey string, logs
 		if err != nil {
 			return nil, err
 		}
+		// 只统计反应堆内日志追加到提交的延迟，提案的整体延迟由ProposeLatencyAdd统计
+		commitLatency := progress.commitLatency()
+		r.mr.commitLatency.record(commitLatency)
+		switch r.opts.ReactorType {
+		case ReactorTypeSlot:
+			trace.GlobalTrace.Metrics.Cluster().CommitLatencyAdd(trace.ClusterKindSlot, commitLatency)
+		case ReactorTypeChannel:
+			trace.GlobalTrace.Metrics.Cluster().CommitLatencyAdd(trace.ClusterKindChannel, commitLatency)
+		}
 		results := make([]ProposeResult, 0)
 		for i, lg := range logs {
 			results = append(results, ProposeResult{
@@ -501,7 +512,7 @@ func (r *ReactorSub) addHandler(handler *handler) {
 
 func (r *ReactorSub) removeHandler(key string) *handler {
 	hd := r.handlers.remove(key)
-	if r.opts.Event.OnHandlerRemove != nil {
+	if hd != nil && r.opts.Event.OnHandlerRemove != nil {
 		r.opts.Event.OnHandlerRemove(hd.handler)
 	}
 	return hd
@@ -0,0 +1,92 @@
+package reactor
+
+import (
+	"go.uber.org/atomic"
+)
+
+// 提交延迟直方图的桶上限（毫秒）
+var commitLatencyBuckets = []int64{5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000}
+
+// Stats 反应堆的复制统计
+type Stats struct {
+	PendingProposals int64            `json:"pending_proposals"` // 等待提交的提案数量
+	InflightApplies  int64            `json:"inflight_applies"`  // 正在应用的日志请求数量
+	CommitLatency    LatencyHistogram `json:"commit_latency"`    // 日志追加到领导到提交的延迟
+}
+
+// LatencyHistogram 延迟直方图
+type LatencyHistogram struct {
+	Buckets []LatencyBucket `json:"buckets"` // 各个桶的数量（累计）
+	Count   int64           `json:"count"`   // 总数量
+	SumMs   int64           `json:"sum_ms"`  // 总延迟（毫秒）
+}
+
+// LatencyBucket 延迟直方图的桶
+type LatencyBucket struct {
+	Le    int64 `json:"le"`    // 桶上限（毫秒），-1表示+Inf
+	Count int64 `json:"count"` // 延迟小于等于上限的数量
+}
+
+type latencyHistogram struct {
+	counts []atomic.Int64 // 每个桶的数量（最后一个为+Inf）
+	count  atomic.Int64
+	sum    atomic.Int64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{
+		counts: make([]atomic.Int64, len(commitLatencyBuckets)+1),
+	}
+}
+
+func (h *latencyHistogram) record(ms int64) {
+	i := 0
+	for ; i < len(commitLatencyBuckets); i++ {
+		if ms <= commitLatencyBuckets[i] {
+			break
+		}
+	}
+	h.counts[i].Inc()
+	h.count.Inc()
+	h.sum.Add(ms)
+}
+
+func (h *latencyHistogram) snapshot() LatencyHistogram {
+	buckets := make([]LatencyBucket, 0, len(h.counts))
+	var cumulative int64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		le := int64(-1)
+		if i < len(commitLatencyBuckets) {
+			le = commitLatencyBuckets[i]
+		}
+		buckets = append(buckets, LatencyBucket{
+			Le:    le,
+			Count: cumulative,
+		})
+	}
+	return LatencyHistogram{
+		Buckets: buckets,
+		Count:   h.count.Load(),
+		SumMs:   h.sum.Load(),
+	}
+}
+
+// Stats 获取反应堆的复制统计
+func (r *Reactor) Stats() Stats {
+	return Stats{
+		PendingProposals: r.pendingProposals.Load(),
+		InflightApplies:  r.inflightApplies.Load(),
+		CommitLatency:    r.commitLatency.snapshot(),
+	}
+}
+
+// IteratorHandlerWithPendingProposals 遍历处理者，同时返回处理者等待提交的提案数量
+// 直接读取正在遍历的处理者，遍历期间不能再查找处理者（会重复获取处理者列表的读锁）
+func (r *Reactor) IteratorHandlerWithPendingProposals(f func(h IHandler, pendingProposals int) bool) {
+	for _, sub := range r.subReactors {
+		sub.iterator(func(h *handler) bool {
+			return f(h.handler, h.pendingProposals())
+		})
+	}
+}
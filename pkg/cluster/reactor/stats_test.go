@@ -0,0 +1,30 @@
+package reactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := newLatencyHistogram()
+	h.record(3)
+	h.record(15)
+	h.record(15)
+	h.record(9000)
+
+	snapshot := h.snapshot()
+	assert.Equal(t, int64(4), snapshot.Count)
+	assert.Equal(t, int64(9033), snapshot.SumMs)
+	assert.Equal(t, len(commitLatencyBuckets)+1, len(snapshot.Buckets))
+
+	assert.Equal(t, int64(5), snapshot.Buckets[0].Le)
+	assert.Equal(t, int64(1), snapshot.Buckets[0].Count)
+	assert.Equal(t, int64(20), snapshot.Buckets[2].Le)
+	assert.Equal(t, int64(3), snapshot.Buckets[2].Count)
+	assert.Equal(t, int64(3), snapshot.Buckets[len(commitLatencyBuckets)-1].Count)
+
+	last := snapshot.Buckets[len(snapshot.Buckets)-1]
+	assert.Equal(t, int64(-1), last.Le)
+	assert.Equal(t, int64(4), last.Count)
+}
@@ -23,6 +23,13 @@ func newProposeWait(key string) *proposeWait {
 	}
 }
 
+// 等待提交的提案数量
+func (m *proposeWait) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.progresses)
+}
+
 // TODO: 此方法返回创建ProposeResult导致内存过高，需要优化
 func (m *proposeWait) add(key string, minId, maxId uint64) *proposeProgress {
 	m.mu.Lock()
@@ -67,6 +74,7 @@ func (m *proposeWait) didPropose(key string, minIndex uint64, maxIndex uint64, t
 			progress.maxIndex = maxIndex
 			progress.didPropose = true
 			progress.term = term
+			progress.proposeMilli = time.Now().UnixMilli()
 			exist = true
 			break
 		}
@@ -120,6 +128,7 @@ func (m *proposeWait) didCommit(startLogIndex uint64, endLogIndex uint64) {
 			if !progress.done {
 				progress.didCommit = true
 				progress.done = true
+				progress.commitMilli = time.Now().UnixMilli()
 				progress.waitC <- nil
 			}
 
@@ -166,7 +175,9 @@ type proposeProgress struct {
 
 	progressIndex uint64
 
-	startMilli int64 // 开始时间
+	startMilli   int64 // 开始时间
+	proposeMilli int64 // 日志追加到领导的时间（didPropose）
+	commitMilli  int64 // 日志提交的时间（didCommit）
 
 	didPropose bool // 是否已经didPropose
 	didAppend  bool // 是否已经didAppend
@@ -188,6 +199,14 @@ func newProposeProgress(key string, minId uint64, maxId uint64, minIndex uint64,
 	}
 }
 
+// 日志追加到领导到提交的延迟（毫秒），不包含提案排队进入反应堆的时间
+func (p *proposeProgress) commitLatency() int64 {
+	if p.proposeMilli == 0 || p.commitMilli < p.proposeMilli {
+		return 0
+	}
+	return p.commitMilli - p.proposeMilli
+}
+
 func (p *proposeProgress) String() string {
 	return fmt.Sprintf("key:%s startMilli:%d term:%d minId:%d maxId:%d minIndex:%d maxIndex:%d progressIndex:%d didPropose:%t didAppend:%t didCommit:%t done:%t", p.key, p.startMilli, p.term, p.minId, p.maxId, p.minIndex, p.maxIndex, p.progressIndex, p.didPropose, p.didAppend, p.didCommit, p.done)
 }
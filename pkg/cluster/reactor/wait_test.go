@@ -3,6 +3,7 @@ package reactor
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -88,3 +89,24 @@ func BenchmarkMessageWait(b *testing.B) {
 // type testReq struct {
 // 	no string
 // }
+
+func TestProposeProgressCommitLatency(t *testing.T) {
+	m := newProposeWait("test")
+	key := "test"
+	progress := m.add(key, 1, 1)
+
+	// 还没有追加到日志
+	assert.Equal(t, int64(0), progress.commitLatency())
+
+	// 排队的时间不计入提交延迟
+	time.Sleep(time.Millisecond * 50)
+	m.didPropose(key, 1, 1, 1)
+	time.Sleep(time.Millisecond * 10)
+	m.didCommit(1, 2)
+
+	<-progress.waitC
+	latency := progress.commitLatency()
+	assert.GreaterOrEqual(t, latency, int64(10))
+	assert.Less(t, latency, int64(50))
+	assert.Equal(t, 1, m.len())
+}
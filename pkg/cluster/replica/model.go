@@ -794,3 +794,19 @@ func (r *ReadyTimeoutState) Reset() {
 func (r *ReadyTimeoutState) SetIntervalTick(intervalTick int) {
 	r.intervalTick = intervalTick
 }
+
+// Progress 副本进度快照
+type Progress struct {
+	Role            Role              // 副本角色
+	LastLogIndex    uint64            // 最新日志下标
+	CommittedIndex  uint64            // 已提交的日志下标
+	ReplicaLastLogs map[uint64]uint64 // 其他副本的最新日志下标（领导节点才有这个信息），发布后不再修改
+}
+
+// ReplicaLastLog 获取某个副本的最新日志下标
+func (p *Progress) ReplicaLastLog(nodeId, replicaId uint64) uint64 {
+	if replicaId == nodeId {
+		return p.LastLogIndex
+	}
+	return p.ReplicaLastLogs[replicaId]
+}
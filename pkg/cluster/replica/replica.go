@@ -2,6 +2,7 @@ package replica
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
@@ -27,11 +28,14 @@ type Replica struct {
 	uncommittedSize logEncodingSize // 未提交的日志大小
 
 	stopPropose                  bool // 是否停止提案
+	stopProposeTick              int  // 停止提案等待目标副本追赶日志的计时器
 	isRoleTransitioning          bool // 是否角色转换中
 	roleTransitioningTimeoutTick int  // 角色转换超时计时器
 
 	handleSyncReplicaMap map[uint64]bool // 领导正在处理同步中的副本记录，防止重复处理同步请求，导致系统变慢
 
+	progress atomic.Pointer[Progress] // 副本进度快照，供非反应堆协程读取
+
 	replicas []uint64 // 副本节点ID集合（不包含本节点）
 	// -------------------- 节点状态 --------------------
 	leader uint64 // 领导者id
@@ -82,6 +86,8 @@ func New(nodeId uint64, optList ...Option) *Replica {
 	rc.storageState = NewReadyState(opts.RetryTick)
 	rc.applyState = NewReadyState(opts.RetryTick)
 
+	rc.publishProgress()
+
 	return rc
 }
 
@@ -305,12 +311,19 @@ func (r *Replica) Tick() {
 	if r.tickFnc != nil {
 		r.tickFnc()
 	}
+
+	r.publishProgress()
 }
 
 func (r *Replica) LastLogIndex() uint64 {
 	return r.replicaLog.lastLogIndex
 }
 
+// CommittedIndex 已提交的日志下标
+func (r *Replica) CommittedIndex() uint64 {
+	return r.replicaLog.committedIndex
+}
+
 func (r *Replica) Term() uint32 {
 	return r.term
 }
@@ -385,6 +398,7 @@ func (r *Replica) initLeaderInfo() {
 	r.isRoleTransitioning = false
 	r.roleTransitioningTimeoutTick = 0
 	r.stopPropose = false
+	r.stopProposeTick = 0
 
 	r.lastSyncInfoMap = make(map[uint64]*SyncInfo)
 	r.replicas = nil
@@ -500,6 +514,7 @@ func (r *Replica) reset(term uint32) {
 	r.votes = make(map[uint64]bool)
 	r.msgs = nil
 	r.stopPropose = false
+	r.stopProposeTick = 0
 	r.isRoleTransitioning = false
 	r.roleTransitioningTimeoutTick = 0
 	r.leader = None
@@ -572,6 +587,13 @@ func (r *Replica) tickHeartbeat() {
 		if r.roleTransitioningTimeoutTick >= r.opts.LearnerToTimeoutTick {
 			r.isRoleTransitioning = false
 		}
+	} else if r.stopPropose {
+		// 目标副本迟迟追不上领导的日志，先恢复提案，避免频道长时间不可写，等下次日志差距达到预期时再停止提案
+		r.stopProposeTick++
+		if r.stopProposeTick >= r.opts.LearnerToTimeoutTick {
+			r.stopPropose = false
+			r.stopProposeTick = 0
+		}
 	}
 
 	if r.opts.ElectionOn { // 是否开启自动选举
@@ -692,6 +714,42 @@ func (r *Replica) GetReplicaLastLog(replicaId uint64) uint64 {
 	return 0
 }
 
+// Progress 获取副本进度快照（每次tick发布一次，可以在其他协程安全读取）
+func (r *Replica) Progress() *Progress {
+	return r.progress.Load()
+}
+
+// publishProgress 发布副本进度快照，只能在副本所在的协程调用
+func (r *Replica) publishProgress() {
+	old := r.progress.Load()
+	if old != nil && old.Role == r.role && old.LastLogIndex == r.replicaLog.lastLogIndex && old.CommittedIndex == r.replicaLog.committedIndex && r.sameReplicaLastLogs(old.ReplicaLastLogs) {
+		return
+	}
+	p := &Progress{
+		Role:            r.role,
+		LastLogIndex:    r.replicaLog.lastLogIndex,
+		CommittedIndex:  r.replicaLog.committedIndex,
+		ReplicaLastLogs: make(map[uint64]uint64, len(r.lastSyncInfoMap)),
+	}
+	for replicaId := range r.lastSyncInfoMap {
+		p.ReplicaLastLogs[replicaId] = r.GetReplicaLastLog(replicaId)
+	}
+	r.progress.Store(p)
+}
+
+func (r *Replica) sameReplicaLastLogs(lastLogs map[uint64]uint64) bool {
+	if len(lastLogs) != len(r.lastSyncInfoMap) {
+		return false
+	}
+	for replicaId := range r.lastSyncInfoMap {
+		lastLog, ok := lastLogs[replicaId]
+		if !ok || lastLog != r.GetReplicaLastLog(replicaId) {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Replica) NewProposeMessage(data []byte) Message {
 	return Message{
 		MsgType: MsgPropose,
@@ -854,6 +912,14 @@ func (r *Replica) newMsgLearnerToLeader(learnerId uint64) Message {
 	}
 }
 
+// 停止提案，等待目标副本追上领导的日志
+func (r *Replica) pausePropose() {
+	if !r.stopPropose {
+		r.stopPropose = true
+		r.stopProposeTick = 0
+	}
+}
+
 func (r *Replica) newFollowerToLeader(followerNodeId uint64) Message {
 	return Message{
 		MsgType:    MsgFollowerToLeader,
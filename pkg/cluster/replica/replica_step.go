@@ -230,7 +230,7 @@ func (r *Replica) stepLeader(m Message) error {
 						// 发送学习者转为领导者
 						r.send(r.newMsgLearnerToLeader(m.From))
 					} else if m.Index+r.opts.LearnerToLeaderMinLogGap > r.replicaLog.lastLogIndex { // 如果日志差距达到预期，则当前领导停止接受任何提案，等待学习者日志完全追赶上
-						r.stopPropose = true // 停止提案
+						r.pausePropose() // 停止提案
 					}
 
 				} else { // 学习者转追随者
@@ -242,14 +242,14 @@ func (r *Replica) stepLeader(m Message) error {
 						r.send(r.newMsgLearnerToFollower(m.From))
 					}
 				}
-			} else if !isLearner && r.cfg.MigrateFrom == r.leader && r.cfg.MigrateTo == m.From { // 追随者转为领导者
+			} else if !isLearner && !r.isRoleTransitioning && r.cfg.MigrateFrom == r.leader && r.cfg.MigrateTo == m.From { // 追随者转为领导者（领导转移）
 				if m.Index >= r.replicaLog.lastLogIndex+1 {
 					r.isRoleTransitioning = true // 追随者转让中
 					r.roleTransitioningTimeoutTick = 0
 					// 发送追随者转为领导者
 					r.send(r.newFollowerToLeader(m.From))
-				} else if m.Index+r.opts.FollowerToLeaderMinLogGap > r.replicaLog.lastLogIndex { // 如果日志差距达到预期，则当前领导停止接受任何提案，等待学习者日志完全追赶上
-					r.stopPropose = true // 停止提案
+				} else if m.Index+r.opts.FollowerToLeaderMinLogGap > r.replicaLog.lastLogIndex { // 如果日志差距达到预期，则当前领导停止接受任何提案，等待追随者日志完全追赶上
+					r.pausePropose() // 停止提案
 				}
 			}
 
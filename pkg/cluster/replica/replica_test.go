@@ -524,6 +524,47 @@ func TestFollowerToLeader(t *testing.T) {
 	assert.True(t, hasMsg(rd.Messages, MsgFollowerToLeader))
 }
 
+// 领导转移时目标副本迟迟追不上，领导恢复提案
+func TestFollowerToLeaderStopProposeTimeout(t *testing.T) {
+	r := New(1, WithAutoRoleSwith(true), WithLearnerToTimeoutTick(2))
+
+	r.appendLog(Log{Index: 1, Term: 1, Data: []byte("hello")})
+	r.appendLog(Log{Index: 2, Term: 1, Data: []byte("world")})
+	_ = r.Ready()
+
+	err := r.Step(Message{
+		MsgType: MsgInitResp,
+		Config: Config{
+			Role:        RoleLeader,
+			Term:        1,
+			Replicas:    []uint64{1, 2, 3},
+			MigrateFrom: 1,
+			MigrateTo:   3,
+		},
+	})
+	assert.NoError(t, err)
+
+	err = r.Step(Message{
+		MsgType: MsgSyncReq,
+		Index:   2,
+		From:    3,
+	})
+	assert.NoError(t, err)
+
+	rd := r.Ready()
+	assert.False(t, hasMsg(rd.Messages, MsgFollowerToLeader))
+
+	// 日志差距达到预期，停止提案
+	err = r.Propose([]byte("test"))
+	assert.Equal(t, ErrProposalDropped, err)
+
+	r.Tick()
+	r.Tick()
+
+	err = r.Propose([]byte("test"))
+	assert.NoError(t, err)
+}
+
 func TestReplica(t *testing.T) {
 
 	interval := 2
@@ -654,3 +695,26 @@ func TestLogAppendResp(t *testing.T) {
 
 	})
 }
+
+// 测试副本进度快照
+func TestProgress(t *testing.T) {
+	r := New(1)
+	assert.NotNil(t, r.Progress())
+
+	initReplica(r, Config{Role: RoleLeader, Term: 1, Replicas: []uint64{1, 2}}, t)
+	r.replicaLog.appendLog(Log{Index: 1, Term: 1, Data: []byte("hello")})
+
+	// tick之前还是旧的快照
+	assert.Equal(t, uint64(0), r.Progress().LastLogIndex)
+
+	r.Tick()
+	progress := r.Progress()
+	assert.Equal(t, RoleLeader, progress.Role)
+	assert.Equal(t, uint64(1), progress.LastLogIndex)
+	assert.Equal(t, uint64(1), progress.ReplicaLastLog(1, 1))
+	assert.Equal(t, uint64(0), progress.ReplicaLastLog(1, 2))
+
+	// 没有变化不重新发布
+	r.Tick()
+	assert.True(t, progress == r.Progress())
+}
@@ -486,9 +486,31 @@ type IClusterMetrics interface {
 	// ProposeFailedCountAdd 提案失败的次数
 	ProposeFailedCountAdd(kind ClusterKind, v int64)
 
+	// CommitLatencyAdd 日志提交延迟统计（反应堆内日志追加到领导到提交成功，不包含提案排队的时间，单位毫秒）
+	CommitLatencyAdd(kind ClusterKind, v int64)
+
 	// ObserverNodeRequesting 节点请求中的数量
 	ObserverNodeRequesting(f func() int64)
 
 	// ObserverNodeSending 节点消息发送中的数量
 	ObserverNodeSending(f func() int64)
+
+	// ObserverReplicationHealth 复制健康状态（本节点作为领导的槽或频道）
+	ObserverReplicationHealth(kind ClusterKind, f func() ReplicationHealth)
+
+	// ObserverLogChecksum 日志校验状态（本节点作为领导的槽或频道）
+	ObserverLogChecksum(kind ClusterKind, f func() LogChecksum)
+}
+
+// ReplicationHealth 复制健康状态
+type ReplicationHealth struct {
+	PendingProposals int64 // 等待提交的提案数量
+	InflightApplies  int64 // 正在应用的日志请求数量
+	MaxReplicaLag    int64 // 副本最大落后的日志数量
+}
+
+// LogChecksum 日志校验状态
+type LogChecksum struct {
+	Checked    int64 // 已校验的日志数量（每个副本分别统计）
+	Mismatches int64 // 未解决的副本日志分歧数量
 }
@@ -88,9 +88,21 @@ type clusterMetrics struct {
 
 	slotProposeLatency metric.Int64Histogram
 
+	// commit
+	channelCommitLatency metric.Int64Histogram
+	slotCommitLatency    metric.Int64Histogram
+
 	// node
 	observerNodeRequesting func() int64 // 节点请求中的数量
 	observerNodeSending    func() int64 // 节点发送中的数量
+
+	// replication
+	observerSlotReplication    func() ReplicationHealth // 槽复制健康状态
+	observerChannelReplication func() ReplicationHealth // 频道复制健康状态
+
+	// log checksum
+	observerSlotLogChecksum    func() LogChecksum // 槽日志校验状态
+	observerChannelLogChecksum func() LogChecksum // 频道日志校验状态
 }
 
 func newClusterMetrics(opts *Options) IClusterMetrics {
@@ -236,6 +248,19 @@ func newClusterMetrics(opts *Options) IClusterMetrics {
 		c.Panic("cluster_slot_propose_latency error", zap.Error(err))
 
 	}
+	// commit
+	c.channelCommitLatency, err = meter.Int64Histogram(
+		"cluster_channel_commit_latency",
+	)
+	if err != nil {
+		c.Panic("cluster_channel_commit_latency error", zap.Error(err))
+	}
+	c.slotCommitLatency, err = meter.Int64Histogram(
+		"cluster_slot_commit_latency",
+	)
+	if err != nil {
+		c.Panic("cluster_slot_commit_latency error", zap.Error(err))
+	}
 	channelProposeCount := NewInt64ObservableCounter("cluster_channel_propose_count")
 	channelProposeFailedCount := NewInt64ObservableCounter("cluster_channel_propose_failed_count")
 	channelProposeLatencyOver500ms := NewInt64ObservableCounter("cluster_channel_propose_latency_over_500ms")
@@ -263,6 +288,48 @@ func newClusterMetrics(opts *Options) IClusterMetrics {
 		return nil
 	}, nodeRequestingCount, nodeSendingCount)
 
+	// replication
+	slotPendingProposals := NewInt64ObservableGauge("cluster_slot_pending_proposals")
+	slotInflightApplies := NewInt64ObservableGauge("cluster_slot_inflight_applies")
+	slotMaxReplicaLag := NewInt64ObservableGauge("cluster_slot_max_replica_lag")
+	channelPendingProposals := NewInt64ObservableGauge("cluster_channel_pending_proposals")
+	channelInflightApplies := NewInt64ObservableGauge("cluster_channel_inflight_applies")
+	channelMaxReplicaLag := NewInt64ObservableGauge("cluster_channel_max_replica_lag")
+	RegisterCallback(func(ctx context.Context, obs metric.Observer) error {
+		if c.observerSlotReplication != nil {
+			health := c.observerSlotReplication()
+			obs.ObserveInt64(slotPendingProposals, health.PendingProposals)
+			obs.ObserveInt64(slotInflightApplies, health.InflightApplies)
+			obs.ObserveInt64(slotMaxReplicaLag, health.MaxReplicaLag)
+		}
+		if c.observerChannelReplication != nil {
+			health := c.observerChannelReplication()
+			obs.ObserveInt64(channelPendingProposals, health.PendingProposals)
+			obs.ObserveInt64(channelInflightApplies, health.InflightApplies)
+			obs.ObserveInt64(channelMaxReplicaLag, health.MaxReplicaLag)
+		}
+		return nil
+	}, slotPendingProposals, slotInflightApplies, slotMaxReplicaLag, channelPendingProposals, channelInflightApplies, channelMaxReplicaLag)
+
+	// log checksum
+	slotLogChecked := NewInt64ObservableGauge("cluster_slot_log_checksum_checked")
+	slotLogMismatches := NewInt64ObservableGauge("cluster_slot_log_checksum_mismatches")
+	channelLogChecked := NewInt64ObservableGauge("cluster_channel_log_checksum_checked")
+	channelLogMismatches := NewInt64ObservableGauge("cluster_channel_log_checksum_mismatches")
+	RegisterCallback(func(ctx context.Context, obs metric.Observer) error {
+		if c.observerSlotLogChecksum != nil {
+			checksum := c.observerSlotLogChecksum()
+			obs.ObserveInt64(slotLogChecked, checksum.Checked)
+			obs.ObserveInt64(slotLogMismatches, checksum.Mismatches)
+		}
+		if c.observerChannelLogChecksum != nil {
+			checksum := c.observerChannelLogChecksum()
+			obs.ObserveInt64(channelLogChecked, checksum.Checked)
+			obs.ObserveInt64(channelLogMismatches, checksum.Mismatches)
+		}
+		return nil
+	}, slotLogChecked, slotLogMismatches, channelLogChecked, channelLogMismatches)
+
 	return c
 }
 
@@ -542,6 +609,15 @@ func (c *clusterMetrics) ProposeLatencyAdd(kind ClusterKind, v int64) {
 	}
 }
 
+func (c *clusterMetrics) CommitLatencyAdd(kind ClusterKind, v int64) {
+	switch kind {
+	case ClusterKindChannel:
+		c.channelCommitLatency.Record(c.ctx, v)
+	case ClusterKindSlot:
+		c.slotCommitLatency.Record(c.ctx, v)
+	}
+}
+
 func (c *clusterMetrics) ProposeFailedCountAdd(kind ClusterKind, v int64) {
 	switch kind {
 	case ClusterKindChannel:
@@ -557,3 +633,21 @@ func (c *clusterMetrics) ObserverNodeRequesting(f func() int64) {
 func (c *clusterMetrics) ObserverNodeSending(f func() int64) {
 	c.observerNodeSending = f
 }
+
+func (c *clusterMetrics) ObserverReplicationHealth(kind ClusterKind, f func() ReplicationHealth) {
+	switch kind {
+	case ClusterKindSlot:
+		c.observerSlotReplication = f
+	case ClusterKindChannel:
+		c.observerChannelReplication = f
+	}
+}
+
+func (c *clusterMetrics) ObserverLogChecksum(kind ClusterKind, f func() LogChecksum) {
+	switch kind {
+	case ClusterKindSlot:
+		c.observerSlotLogChecksum = f
+	case ClusterKindChannel:
+		c.observerChannelLogChecksum = f
+	}
+}
@@ -13,6 +13,10 @@ import (
 
 type Options struct {
 	// Endpoint is the address of the collector to which the exporter will send the spans.
+	Endpoint         string  // OTLP/HTTP collector地址 例如：127.0.0.1:4318，为空则不开启链路追踪
+	Insecure         bool    // 是否使用http连接collector
+	SampleRatio      float64 // 链路采样率 0-1
+	NodeId           uint64
 	ServiceName      string
 	ServiceHostName  string
 	PrometheusApiUrl string
@@ -28,6 +32,8 @@ func NewOptions(opt ...Option) *Options {
 		ServiceHostName:  "wukongim",
 		PrometheusApiUrl: "http://127.0.0.1:9090",
 		ReqTimeout:       5 * time.Second,
+		Insecure:         true,
+		SampleRatio:      1.0,
 	}
 
 	for _, o := range opt {
@@ -39,6 +45,30 @@ func NewOptions(opt ...Option) *Options {
 
 type Option func(*Options)
 
+func WithEndpoint(endpoint string) Option {
+	return func(o *Options) {
+		o.Endpoint = endpoint
+	}
+}
+
+func WithInsecure(insecure bool) Option {
+	return func(o *Options) {
+		o.Insecure = insecure
+	}
+}
+
+func WithSampleRatio(ratio float64) Option {
+	return func(o *Options) {
+		o.SampleRatio = ratio
+	}
+}
+
+func WithNodeId(nodeId uint64) Option {
+	return func(o *Options) {
+		o.NodeId = nodeId
+	}
+}
+
 func WithServiceName(name string) Option {
 	return func(o *Options) {
 		o.ServiceName = name
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	prometheusExp "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 func (t *Trace) setupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, err error) {
@@ -33,19 +39,22 @@ func (t *Trace) setupOTelSDK(ctx context.Context) (shutdown func(context.Context
 	prop := newPropagator()
 	otel.SetTextMapPropagator(prop)
 
-	// 设置 trace provider.
-	// tracerProvider, err := newTraceProvider()
-	// if traceOn {
-	// 	var tracerProvider *trace.TracerProvider
-	// 	tracerProvider, err = newJaegerTraceProvider(ctx, t.opts.Endpoint, t.opts.ServiceName, t.opts.ServiceHostName, nodeId)
-	// 	if err != nil {
-	// 		fmt.Println("newJaegerTraceProvider err---->", err)
-	// 		handleErr(err)
-	// 		return
-	// 	}
-	// 	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
-	// 	otel.SetTracerProvider(tracerProvider)
-	// }
+	// 设置 trace provider.（配置了OTLP collector地址才开启链路追踪）
+	if t.opts.Endpoint != "" {
+		var tracerProvider *sdktrace.TracerProvider
+		tracerProvider, err = newOtlpTraceProvider(ctx, t.opts)
+		if err != nil {
+			handleErr(err)
+			return
+		}
+		shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+		otel.SetTracerProvider(tracerProvider)
+		spanOn.Store(true)
+		shutdownFuncs = append(shutdownFuncs, func(ctx context.Context) error {
+			spanOn.Store(false)
+			return nil
+		})
+	}
 
 	// 设置 meter provider.
 	meterProvider, err := newMeterProvider()
@@ -55,7 +64,7 @@ func (t *Trace) setupOTelSDK(ctx context.Context) (shutdown func(context.Context
 	}
 	shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
 	otel.SetMeterProvider(meterProvider)
-	return nil, nil
+	return shutdown, nil
 }
 
 func newPropagator() propagation.TextMapPropagator {
@@ -65,52 +74,40 @@ func newPropagator() propagation.TextMapPropagator {
 	)
 }
 
-// func newJaegerTraceProvider(ctx context.Context, endpoint string, serviceName, serviceHostname string, nodeId uint64) (*trace.TracerProvider, error) {
-// 	// 创建一个使用 HTTP 协议连接本机Jaeger的 Exporter
-// 	traceExporter, err := otlptracehttp.New(ctx,
-// 		otlptracehttp.WithEndpoint(endpoint),
-// 		otlptracehttp.WithInsecure())
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	res, err := resource.New(ctx,
-// 		resource.WithFromEnv(),
-// 		resource.WithProcess(),
-// 		resource.WithTelemetrySDK(),
-// 		resource.WithHost(),
-// 		resource.WithAttributes(
-// 			// 在可观测链路 OpenTelemetry 版后端显示的服务名称。
-// 			semconv.ServiceNameKey.String(serviceName),
-// 			semconv.HostNameKey.String(serviceHostname),
-// 			attribute.Int64("node.id", int64(nodeId)),
-// 		),
-// 	)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	traceProvider := trace.NewTracerProvider(
-// 		trace.WithResource(res),
-// 		trace.WithSampler(trace.TraceIDRatioBased(1.0)), // 采样率
-// 		trace.WithBatcher(traceExporter,
-// 			trace.WithBatchTimeout(time.Second*5)),
-// 	)
-// 	return traceProvider, nil
-// }
-
-// func newTraceProvider() (*trace.TracerProvider, error) {
-// 	traceExporter, err := stdouttrace.New(
-// 		stdouttrace.WithPrettyPrint())
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-//		traceProvider := trace.NewTracerProvider(
-//			trace.WithBatcher(traceExporter,
-//				// 默认为 5s。为便于演示，设置为 1s。
-//				trace.WithBatchTimeout(time.Second)),
-//		)
-//		return traceProvider, nil
-//	}
+// 创建通过OTLP/HTTP协议导出span的trace provider（Jaeger、Tempo、otel-collector等都支持OTLP）
+func newOtlpTraceProvider(ctx context.Context, opts *Options) (*sdktrace.TracerProvider, error) {
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(opts.Endpoint),
+	}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	traceExporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithProcess(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String(opts.ServiceName),
+			semconv.HostNameKey.String(opts.ServiceHostName),
+			attribute.Int64("node.id", int64(opts.NodeId)),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+	traceProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))), // 采样率（上游服务已经采样的链路会继续采样）
+		sdktrace.WithBatcher(traceExporter,
+			sdktrace.WithBatchTimeout(time.Second*5)),
+	)
+	return traceProvider, nil
+}
 
 func newMeterProvider() (*metric.MeterProvider, error) {
 
@@ -0,0 +1,62 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// 链路追踪的上下文以W3C traceparent（例如：00-{traceId}-{spanId}-01）的形式在事件里传递，跨节点转发时一起编码
+
+const traceparentKey = "traceparent"
+
+var (
+	spanOn     atomic.Bool // 是否开启了链路追踪
+	propagator = propagation.TraceContext{}
+)
+
+// SpanOn 是否开启了链路追踪
+func SpanOn() bool {
+	return spanOn.Load()
+}
+
+// RecordSpan 记录一个从start开始，到现在结束的span
+// parent 父span的traceparent，为空则作为根span
+// 返回新span的traceparent，作为下一个阶段的父级
+func RecordSpan(parent string, name string, start time.Time, attrs ...attribute.KeyValue) string {
+	if !SpanOn() {
+		return parent
+	}
+	if start.IsZero() {
+		start = time.Now()
+	}
+	ctx := ContextWithTraceparent(context.Background(), parent)
+	ctx, span := tracer.Start(ctx, name, oteltrace.WithTimestamp(start), oteltrace.WithAttributes(attrs...))
+	span.End()
+	return Traceparent(ctx)
+}
+
+// ContextWithTraceparent 将traceparent解析到context里
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{traceparentKey: traceparent})
+}
+
+// Traceparent 获取context里span的traceparent
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier[traceparentKey]
+}
+
+// TraceparentFromHeader 获取上游服务通过http头传递过来的traceparent
+func TraceparentFromHeader(header http.Header) string {
+	return Traceparent(propagator.Extract(context.Background(), propagation.HeaderCarrier(header)))
+}
@@ -0,0 +1,48 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecordSpan(t *testing.T) {
+	// 没有开启链路追踪，原样返回
+	assert.Equal(t, "", RecordSpan("", "Start", time.Now()))
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	spanOn.Store(true)
+	defer spanOn.Store(false)
+
+	// 延续上游服务的链路
+	upstream := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	start := time.Now().Add(-time.Millisecond * 10)
+	parent := RecordSpan(upstream, "Start", start)
+	child := RecordSpan(parent, "ChannelPersist", time.Now())
+	assert.NotEqual(t, parent, child)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, start.UnixNano(), spans[0].StartTime().UnixNano())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+}
+
+func TestTraceStopShutdownErr(t *testing.T) {
+	tr := New(context.Background(), NewOptions())
+	tr.shutdown = func(context.Context) error {
+		return errors.New("otlp exporter unavailable")
+	}
+	// 导出器关闭失败只记录日志，不影响进程退出
+	assert.NotPanics(t, tr.Stop)
+}
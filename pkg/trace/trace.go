@@ -8,6 +8,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/pkg/wklog"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
 )
 
 var GlobalTrace *Trace
@@ -53,7 +54,7 @@ func (t *Trace) Stop() {
 	if t.shutdown != nil {
 		err := t.shutdown(t.ctx)
 		if err != nil {
-			panic(err)
+			t.Warn("shutdown otel sdk failed", zap.Error(err))
 		}
 	}
 }
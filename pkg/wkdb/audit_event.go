@@ -0,0 +1,52 @@
+package wkdb
+
+import (
+	"math"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+	"go.uber.org/zap"
+)
+
+func (wk *wukongDB) AddAuditEvent(event AuditEvent) (uint64, error) {
+	if event.Id == 0 {
+		event.Id = wk.NextPrimaryKey()
+	}
+	if event.CreatedAt == 0 {
+		event.CreatedAt = time.Now().Unix()
+	}
+	data, err := event.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	batch := wk.defaultShardBatchDB().NewBatch()
+	batch.Set(key.NewAuditEventKey(event.Id), data)
+	if err = batch.CommitWait(); err != nil {
+		return 0, err
+	}
+	return event.Id, nil
+}
+
+func (wk *wukongDB) GetAuditEvents(startId uint64, limit int) ([]AuditEvent, error) {
+	upperId := uint64(math.MaxUint64)
+	if startId > 0 {
+		upperId = startId
+	}
+	iter := wk.defaultShardDB().NewIter(&pebble.IterOptions{
+		LowerBound: key.NewAuditEventKey(0),
+		UpperBound: key.NewAuditEventKey(upperId),
+	})
+	defer iter.Close()
+
+	events := make([]AuditEvent, 0, limit)
+	for iter.Last(); iter.Valid() && len(events) < limit; iter.Prev() {
+		var event AuditEvent
+		if err := event.Unmarshal(iter.Value()); err != nil {
+			wk.Warn("audit event unmarshal failed", zap.Error(err))
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
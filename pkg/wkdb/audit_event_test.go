@@ -0,0 +1,45 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEvent(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	ids := make([]uint64, 0, 3)
+	for _, target := range []string{"slot:1", "slot:2", "channel:g1:2"} {
+		id, err := d.AddAuditEvent(wkdb.AuditEvent{
+			Action:   "force_reconfig",
+			Target:   target,
+			Operator: "admin",
+			NodeId:   1,
+			Detail:   "{}",
+		})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	events, err := d.GetAuditEvents(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, "channel:g1:2", events[0].Target)
+	assert.Equal(t, "slot:1", events[2].Target)
+	assert.Equal(t, "admin", events[0].Operator)
+	assert.NotZero(t, events[0].CreatedAt)
+
+	events, err = d.GetAuditEvents(ids[2], 1)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "slot:2", events[0].Target)
+}
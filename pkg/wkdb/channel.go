@@ -549,6 +549,33 @@ func (wk *wukongDB) writeChannelInfo(primaryKey uint64, channelInfo ChannelInfo,
 		return err
 	}
 
+	// parentChannelId
+	if err = w.Set(key.NewChannelInfoColumnKey(primaryKey, key.TableChannelInfo.Column.ParentChannelId), []byte(channelInfo.ParentChannelId), wk.noSync); err != nil {
+		return err
+	}
+
+	// parentChannelType
+	if err = w.Set(key.NewChannelInfoColumnKey(primaryKey, key.TableChannelInfo.Column.ParentChannelType), []byte{channelInfo.ParentChannelType}, wk.noSync); err != nil {
+		return err
+	}
+
+	// inherit
+	if err = w.Set(key.NewChannelInfoColumnKey(primaryKey, key.TableChannelInfo.Column.Inherit), []byte{wkutil.BoolToUint8(channelInfo.Inherit)}, wk.noSync); err != nil {
+		return err
+	}
+
+	// historyVisibility
+	if err = w.Set(key.NewChannelInfoColumnKey(primaryKey, key.TableChannelInfo.Column.HistoryVisibility), []byte{channelInfo.HistoryVisibility}, wk.noSync); err != nil {
+		return err
+	}
+
+	// historyCount
+	historyCountBytes := make([]byte, 4)
+	wk.endian.PutUint32(historyCountBytes, channelInfo.HistoryCount)
+	if err = w.Set(key.NewChannelInfoColumnKey(primaryKey, key.TableChannelInfo.Column.HistoryCount), historyCountBytes, wk.noSync); err != nil {
+		return err
+	}
+
 	// createdAt
 	if channelInfo.CreatedAt != nil {
 		ct := uint64(channelInfo.CreatedAt.UnixNano())
@@ -707,6 +734,16 @@ func (wk *wukongDB) iterChannelInfo(iter *pebble.Iterator, iterFnc func(channelI
 			preChannelInfo.AllowlistCount = int(wk.endian.Uint32(iter.Value()))
 		case key.TableChannelInfo.Column.DenylistCount:
 			preChannelInfo.DenylistCount = int(wk.endian.Uint32(iter.Value()))
+		case key.TableChannelInfo.Column.ParentChannelId:
+			preChannelInfo.ParentChannelId = string(iter.Value())
+		case key.TableChannelInfo.Column.ParentChannelType:
+			preChannelInfo.ParentChannelType = iter.Value()[0]
+		case key.TableChannelInfo.Column.Inherit:
+			preChannelInfo.Inherit = wkutil.Uint8ToBool(iter.Value()[0])
+		case key.TableChannelInfo.Column.HistoryVisibility:
+			preChannelInfo.HistoryVisibility = iter.Value()[0]
+		case key.TableChannelInfo.Column.HistoryCount:
+			preChannelInfo.HistoryCount = wk.endian.Uint32(iter.Value())
 		case key.TableChannelInfo.Column.CreatedAt:
 			tm := int64(wk.endian.Uint64(iter.Value()))
 			if tm > 0 {
@@ -0,0 +1,57 @@
+package wkdb
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/cockroachdb/pebble"
+)
+
+func (wk *wukongDB) AddChannelChild(parentChannelId string, parentChannelType uint8, child ChannelChild) error {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(child.ChannelId)
+	enc.WriteUint8(child.ChannelType)
+	enc.WriteUint8(wkutil.BoolToUint8(child.Inherit))
+
+	batch := wk.channelBatchDb(parentChannelId, parentChannelType).NewBatch()
+	batch.Set(key.NewChannelChildKey(parentChannelId, parentChannelType, child.ChannelId, child.ChannelType), enc.Bytes())
+	return batch.CommitWait()
+}
+
+func (wk *wukongDB) RemoveChannelChild(parentChannelId string, parentChannelType uint8, child ChannelChild) error {
+	batch := wk.channelBatchDb(parentChannelId, parentChannelType).NewBatch()
+	batch.Delete(key.NewChannelChildKey(parentChannelId, parentChannelType, child.ChannelId, child.ChannelType))
+	return batch.CommitWait()
+}
+
+func (wk *wukongDB) GetChannelChildren(parentChannelId string, parentChannelType uint8) ([]ChannelChild, error) {
+	iter := wk.channelDb(parentChannelId, parentChannelType).NewIter(&pebble.IterOptions{
+		LowerBound: key.NewChannelChildLowKey(parentChannelId, parentChannelType),
+		UpperBound: key.NewChannelChildHighKey(parentChannelId, parentChannelType),
+	})
+	defer iter.Close()
+
+	children := make([]ChannelChild, 0)
+	for iter.First(); iter.Valid(); iter.Next() {
+		dec := wkproto.NewDecoder(iter.Value())
+		channelId, err := dec.String()
+		if err != nil {
+			return nil, err
+		}
+		channelType, err := dec.Uint8()
+		if err != nil {
+			return nil, err
+		}
+		inherit, err := dec.Uint8()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, ChannelChild{
+			ChannelId:   channelId,
+			ChannelType: channelType,
+			Inherit:     wkutil.Uint8ToBool(inherit),
+		})
+	}
+	return children, nil
+}
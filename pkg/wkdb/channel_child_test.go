@@ -0,0 +1,63 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelParent(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	_, err = d.AddChannel(wkdb.ChannelInfo{
+		ChannelId:         "topic1",
+		ChannelType:       2,
+		ParentChannelId:   "community",
+		ParentChannelType: 2,
+		Inherit:           true,
+	})
+	assert.NoError(t, err)
+
+	channelInfo, err := d.GetChannel("topic1", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "community", channelInfo.ParentChannelId)
+	assert.Equal(t, uint8(2), channelInfo.ParentChannelType)
+	assert.True(t, channelInfo.Inherit)
+}
+
+func TestChannelChildren(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	err = d.AddChannelChild("community", 2, wkdb.ChannelChild{ChannelId: "topic1", ChannelType: 2, Inherit: true})
+	assert.NoError(t, err)
+	err = d.AddChannelChild("community", 2, wkdb.ChannelChild{ChannelId: "topic2", ChannelType: 2})
+	assert.NoError(t, err)
+	err = d.AddChannelChild("other", 2, wkdb.ChannelChild{ChannelId: "topic3", ChannelType: 2})
+	assert.NoError(t, err)
+
+	children, err := d.GetChannelChildren("community", 2)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []wkdb.ChannelChild{{ChannelId: "topic1", ChannelType: 2, Inherit: true}, {ChannelId: "topic2", ChannelType: 2}}, children)
+
+	err = d.RemoveChannelChild("community", 2, wkdb.ChannelChild{ChannelId: "topic1", ChannelType: 2, Inherit: true})
+	assert.NoError(t, err)
+
+	children, err = d.GetChannelChildren("community", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []wkdb.ChannelChild{{ChannelId: "topic2", ChannelType: 2}}, children)
+}
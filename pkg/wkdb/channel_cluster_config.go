@@ -459,6 +459,11 @@ func (wk *wukongDB) writeChannelClusterConfig(primaryKey uint64, channelClusterC
 	binary.BigEndian.PutUint64(migrateToBytes, channelClusterConfig.MigrateTo)
 	w.Set(key.NewChannelClusterConfigColumnKey(primaryKey, key.TableChannelClusterConfig.Column.MigrateTo), migrateToBytes)
 
+	// preferredLeader
+	preferredLeaderBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(preferredLeaderBytes, channelClusterConfig.PreferredLeader)
+	w.Set(key.NewChannelClusterConfigColumnKey(primaryKey, key.TableChannelClusterConfig.Column.PreferredLeader), preferredLeaderBytes)
+
 	// status
 	statusBytes := make([]byte, 1)
 	statusBytes[0] = uint8(channelClusterConfig.Status)
@@ -600,6 +605,8 @@ func (wk *wukongDB) iteratorChannelClusterConfig(iter *pebble.Iterator, iterFnc
 			preChannelClusterConfig.MigrateFrom = wk.endian.Uint64(iter.Value())
 		case key.TableChannelClusterConfig.Column.MigrateTo:
 			preChannelClusterConfig.MigrateTo = wk.endian.Uint64(iter.Value())
+		case key.TableChannelClusterConfig.Column.PreferredLeader:
+			preChannelClusterConfig.PreferredLeader = wk.endian.Uint64(iter.Value())
 		case key.TableChannelClusterConfig.Column.Status:
 			preChannelClusterConfig.Status = ChannelClusterStatus(iter.Value()[0])
 		case key.TableChannelClusterConfig.Column.ConfVersion:
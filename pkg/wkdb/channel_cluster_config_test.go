@@ -102,6 +102,7 @@ func TestGetChannelClusterConfig(t *testing.T) {
 		Replicas:        []uint64{1, 2, 3},
 		LeaderId:        1001,
 		Term:            1,
+		PreferredLeader: 2,
 		CreatedAt:       &createdAt,
 		UpdatedAt:       &updatedAt,
 	}
@@ -118,6 +119,7 @@ func TestGetChannelClusterConfig(t *testing.T) {
 	assert.Equal(t, config.LeaderId, config2.LeaderId)
 	assert.Equal(t, config.Term, config2.Term)
 	assert.Equal(t, config.Replicas, config2.Replicas)
+	assert.Equal(t, config.PreferredLeader, config2.PreferredLeader)
 	assert.Equal(t, config.CreatedAt.Unix(), config2.CreatedAt.Unix())
 	assert.Equal(t, config.UpdatedAt.Unix(), config2.UpdatedAt.Unix())
 
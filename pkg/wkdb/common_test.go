@@ -8,7 +8,7 @@ import (
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
 )
 
-func newTestDB(t testing.TB) wkdb.DB {
+func newTestDB(t testing.TB, opts ...wkdb.Option) wkdb.DB {
 	dr := t.TempDir()
 
 	traceObj := trace.New(
@@ -19,5 +19,5 @@ func newTestDB(t testing.TB) wkdb.DB {
 		))
 	trace.SetGlobalTrace(traceObj)
 
-	return wkdb.NewWukongDB(wkdb.NewOptions(wkdb.WithDir(dr), wkdb.WithShardNum(1)))
+	return wkdb.NewWukongDB(wkdb.NewOptions(append([]wkdb.Option{wkdb.WithDir(dr), wkdb.WithShardNum(1)}, opts...)...))
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
 	"github.com/cockroachdb/pebble"
 	"go.uber.org/zap"
 )
@@ -577,6 +578,29 @@ func (wk *wukongDB) writeConversation(conversation Conversation, w *Batch) error
 		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.UpdatedAt), updatedAtBytes)
 	}
 
+	// 会话设置只有在设置了更新时间时才写入，避免普通的会话更新覆盖掉用户的设置
+	if conversation.SettingUpdatedAt != nil {
+		// pinned
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.Pinned), []byte{wkutil.BoolToUint8(conversation.Pinned)})
+
+		// mute
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.Mute), []byte{wkutil.BoolToUint8(conversation.Mute)})
+
+		// archived
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.Archived), []byte{wkutil.BoolToUint8(conversation.Archived)})
+
+		// folder
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.Folder), []byte(conversation.Folder))
+
+		// draft
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.Draft), []byte(conversation.Draft))
+
+		// settingUpdatedAt
+		settingUpdatedAtBytes := make([]byte, 8)
+		wk.endian.PutUint64(settingUpdatedAtBytes, uint64(conversation.SettingUpdatedAt.UnixNano()))
+		w.Set(key.NewConversationColumnKey(uid, id, key.TableConversation.Column.SettingUpdatedAt), settingUpdatedAtBytes)
+	}
+
 	// write index
 	if err = wk.writeConversationIndex(conversation, w); err != nil {
 		return err
@@ -682,6 +706,22 @@ func (wk *wukongDB) iterateConversation(iter *pebble.Iterator, iterFnc func(conv
 				t := time.Unix(tm/1e9, tm%1e9)
 				preConversation.UpdatedAt = &t
 			}
+		case key.TableConversation.Column.Pinned:
+			preConversation.Pinned = wkutil.Uint8ToBool(iter.Value()[0])
+		case key.TableConversation.Column.Mute:
+			preConversation.Mute = wkutil.Uint8ToBool(iter.Value()[0])
+		case key.TableConversation.Column.Archived:
+			preConversation.Archived = wkutil.Uint8ToBool(iter.Value()[0])
+		case key.TableConversation.Column.Folder:
+			preConversation.Folder = string(iter.Value())
+		case key.TableConversation.Column.Draft:
+			preConversation.Draft = string(iter.Value())
+		case key.TableConversation.Column.SettingUpdatedAt:
+			tm := int64(wk.endian.Uint64(iter.Value()))
+			if tm > 0 {
+				t := time.Unix(tm/1e9, tm%1e9)
+				preConversation.SettingUpdatedAt = &t
+			}
 
 		}
 		hasData = true
@@ -143,6 +143,72 @@ func TestDeleteConversation(t *testing.T) {
 	assert.Equal(t, conversations[1], conversations2[0])
 }
 
+func TestConversationSetting(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	uid := "test1"
+	settingUpdatedAt := time.Now()
+	conversation := wkdb.Conversation{
+		Id:               1,
+		Uid:              uid,
+		ChannelId:        "1234",
+		ChannelType:      2,
+		ReadToMsgSeq:     1,
+		Pinned:           true,
+		Mute:             true,
+		Folder:           "work",
+		Draft:            "hello",
+		SettingUpdatedAt: &settingUpdatedAt,
+	}
+	err = d.AddOrUpdateConversationsWithUser(uid, []wkdb.Conversation{conversation})
+	assert.NoError(t, err)
+
+	// 不带会话设置的更新不会覆盖已有的设置
+	err = d.AddOrUpdateConversations([]wkdb.Conversation{
+		{
+			Uid:          uid,
+			ChannelId:    "1234",
+			ChannelType:  2,
+			ReadToMsgSeq: 10,
+		},
+	})
+	assert.NoError(t, err)
+
+	conversation2, err := d.GetConversation(uid, "1234", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), conversation2.ReadToMsgSeq)
+	assert.True(t, conversation2.Pinned)
+	assert.True(t, conversation2.Mute)
+	assert.False(t, conversation2.Archived)
+	assert.Equal(t, "work", conversation2.Folder)
+	assert.Equal(t, "hello", conversation2.Draft)
+	assert.Equal(t, settingUpdatedAt.UnixNano(), conversation2.SettingUpdatedAt.UnixNano())
+
+	// 带会话设置的更新
+	settingUpdatedAt = time.Now()
+	conversation2.Mute = false
+	conversation2.Archived = true
+	conversation2.Draft = ""
+	conversation2.SettingUpdatedAt = &settingUpdatedAt
+	err = d.AddOrUpdateConversationsWithUser(uid, []wkdb.Conversation{conversation2})
+	assert.NoError(t, err)
+
+	conversation3, err := d.GetConversation(uid, "1234", 2)
+	assert.NoError(t, err)
+	assert.True(t, conversation3.Pinned)
+	assert.False(t, conversation3.Mute)
+	assert.True(t, conversation3.Archived)
+	assert.Equal(t, "work", conversation3.Folder)
+	assert.Equal(t, "", conversation3.Draft)
+}
+
 // func TestGetConversationBySessionIds(t *testing.T) {
 // 	d := newTestDB(t)
 // 	err := d.Open()
@@ -31,6 +31,22 @@ type DB interface {
 	StreamDB
 	// 测试机
 	TesterDB
+	// 静态加密
+	EncryptionDB
+	// 设备同步位置
+	DeviceSyncCursorDB
+	// 用户在线状态
+	PresenceDB
+	// 消息去重
+	MessageDedupDB
+	// 消息提醒（@）
+	MentionDB
+	// 子频道
+	ChannelChildDB
+	// 运维审计
+	AuditEventDB
+	// 副本日志校验进度
+	LogChecksumDB
 }
 
 type MessageDB interface {
@@ -82,6 +98,15 @@ type MessageDB interface {
 	// RemoveMessagesOfNotifyQueueCount 移除指定数量的通知队列的消息
 	RemoveMessagesOfNotifyQueueCount(count int) error
 
+	// GetFederationQueueChannels 获取联邦同步队列里有待同步消息的频道（需要联邦同步的消息写入时同时写入了队列）
+	GetFederationQueueChannels() ([]Channel, error)
+
+	// GetMessagesOfFederationQueue 按消息序号顺序获取频道联邦同步队列里大于startMessageSeq的消息
+	GetMessagesOfFederationQueue(channelId string, channelType uint8, startMessageSeq uint64, count int) ([]Message, error)
+
+	// RemoveMessagesOfFederationQueue 移除频道联邦同步队列里小于等于endMessageSeq的消息
+	RemoveMessagesOfFederationQueue(channelId string, channelType uint8, endMessageSeq uint64) error
+
 	// 搜索消息
 	SearchMessages(req MessageSearchReq) ([]Message, error)
 }
@@ -101,6 +126,9 @@ type DeviceDB interface {
 
 	// UpdateDevice 更新设备
 	UpdateDevice(device Device) error
+
+	// UpdateDeviceLogin 更新设备的最后一次登录信息（只更新登录相关字段）
+	UpdateDeviceLogin(device Device) error
 }
 
 type UserDB interface {
@@ -139,6 +167,9 @@ type ChannelDB interface {
 	// GetSubscribers 获取订阅者
 	GetSubscribers(channelId string, channelType uint8) ([]Member, error)
 
+	// GetSubscriber 获取订阅者，不存在返回ErrNotFound
+	GetSubscriber(channelId string, channelType uint8, uid string) (Member, error)
+
 	// GetSubscriberCount 获取订阅者数量
 	GetSubscriberCount(channelId string, channelType uint8) (int, error)
 
@@ -393,6 +424,69 @@ type TesterDB interface {
 	RemoveTester(no string) error
 }
 
+type EncryptionDB interface {
+	// RotateDataKey 轮换所有分区的数据密钥，新写入的消息将使用新的数据密钥加密
+	RotateDataKey() error
+
+	// StartReencrypt 开始后台重加密任务，将明文或使用旧数据密钥加密的payload用当前数据密钥重新加密
+	StartReencrypt() error
+
+	// GetEncryptionStatus 获取加密状态
+	GetEncryptionStatus() EncryptionStatus
+}
+
+type DeviceSyncCursorDB interface {
+	// SetDeviceSyncCursors 设置设备的同步位置
+	SetDeviceSyncCursors(cursors []DeviceSyncCursor) error
+
+	// GetDeviceSyncCursors 获取用户指定设备的所有同步位置
+	GetDeviceSyncCursors(uid string, deviceFlag uint8) ([]DeviceSyncCursor, error)
+
+	// ResetDeviceSyncCursors 重置用户指定设备的同步位置
+	ResetDeviceSyncCursors(uid string, deviceFlag uint8) error
+}
+
+type PresenceDB interface {
+	// SetPresence 设置用户的在线状态
+	SetPresence(presence Presence) error
+
+	// GetPresence 获取用户的在线状态，不存在返回空的在线状态
+	GetPresence(uid string) (Presence, error)
+}
+
+type MessageDedupDB interface {
+	// GetDedupMessage 获取发送者在频道内指定client_msg_no的消息（用于消息去重），不存在返回ErrNotFound
+	GetDedupMessage(channelId string, channelType uint8, fromUid string, clientMsgNo string) (Message, error)
+}
+
+type ChannelChildDB interface {
+	// AddChannelChild 添加父频道的子频道
+	AddChannelChild(parentChannelId string, parentChannelType uint8, child ChannelChild) error
+	// RemoveChannelChild 移除父频道的子频道
+	RemoveChannelChild(parentChannelId string, parentChannelType uint8, child ChannelChild) error
+	// GetChannelChildren 获取父频道的所有子频道
+	GetChannelChildren(parentChannelId string, parentChannelType uint8) ([]ChannelChild, error)
+}
+
+type AuditEventDB interface {
+	// AddAuditEvent 添加运维审计事件（id为0时自动生成）
+	AddAuditEvent(event AuditEvent) (uint64, error)
+	// GetAuditEvents 按时间倒序获取运维审计事件，startId为0表示从最新的开始（不包含startId）
+	GetAuditEvents(startId uint64, limit int) ([]AuditEvent, error)
+}
+
+type LogChecksumDB interface {
+	// SetLogChecksumIndex 设置槽或频道已校验一致的日志下标（本节点作为领导时的校验进度）
+	SetLogChecksumIndex(shardType uint8, shardNo string, index uint64) error
+	// GetLogChecksumIndex 获取槽或频道已校验一致的日志下标，没有校验过返回0
+	GetLogChecksumIndex(shardType uint8, shardNo string) (uint64, error)
+}
+
+type MentionDB interface {
+	// GetMentionStat 获取用户在频道内从startMessageSeq（包含）开始被提醒（@）的次数和最后一次被提醒的消息序号
+	GetMentionStat(channelId string, channelType uint8, uid string, startMessageSeq uint64) (count int, lastMessageSeq uint64, err error)
+}
+
 type MessageSearchReq struct {
 	MessageId        int64
 	FromUid          string // 发送者uid
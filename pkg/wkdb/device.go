@@ -199,6 +199,35 @@ func (wk *wukongDB) UpdateDevice(d Device) error {
 	return nil
 }
 
+func (wk *wukongDB) UpdateDeviceLogin(d Device) error {
+
+	if d.Id == 0 {
+		return ErrInvalidDeviceId
+	}
+	if d.LastLoginAt == nil {
+		return nil
+	}
+
+	batch := wk.shardDB(d.Uid).NewBatch()
+	defer batch.Close()
+
+	lastLoginAt := make([]byte, 8)
+	wk.endian.PutUint64(lastLoginAt, uint64(d.LastLoginAt.UnixNano()))
+	if err := batch.Set(key.NewDeviceColumnKey(d.Id, key.TableDevice.Column.LastLoginAt), lastLoginAt, wk.noSync); err != nil {
+		return err
+	}
+	if err := batch.Set(key.NewDeviceColumnKey(d.Id, key.TableDevice.Column.LastLoginIp), []byte(d.LastLoginIp), wk.noSync); err != nil {
+		return err
+	}
+	if err := batch.Set(key.NewDeviceColumnKey(d.Id, key.TableDevice.Column.DeviceId), []byte(d.DeviceId), wk.noSync); err != nil {
+		return err
+	}
+	if err := batch.Set(key.NewDeviceColumnKey(d.Id, key.TableDevice.Column.Version), []byte{d.Version}, wk.noSync); err != nil {
+		return err
+	}
+	return batch.Commit(wk.sync)
+}
+
 func (wk *wukongDB) SearchDevice(req DeviceSearchReq) ([]Device, error) {
 
 	wk.metrics.SearchDeviceAdd(1)
@@ -487,6 +516,18 @@ func (wk *wukongDB) iterDevice(iter *pebble.Iterator, iterFnc func(d Device) boo
 				t := time.Unix(tm/1e9, tm%1e9)
 				preDevice.UpdatedAt = &t
 			}
+		case key.TableDevice.Column.LastLoginAt:
+			tm := int64(wk.endian.Uint64(iter.Value()))
+			if tm > 0 {
+				t := time.Unix(tm/1e9, tm%1e9)
+				preDevice.LastLoginAt = &t
+			}
+		case key.TableDevice.Column.LastLoginIp:
+			preDevice.LastLoginIp = string(iter.Value())
+		case key.TableDevice.Column.DeviceId:
+			preDevice.DeviceId = string(iter.Value())
+		case key.TableDevice.Column.Version:
+			preDevice.Version = iter.Value()[0]
 
 		}
 		lastNeedAppend = true
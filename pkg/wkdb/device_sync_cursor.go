@@ -0,0 +1,62 @@
+package wkdb
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+)
+
+func (wk *wukongDB) SetDeviceSyncCursors(cursors []DeviceSyncCursor) error {
+	if len(cursors) == 0 {
+		return nil
+	}
+
+	batchMap := make(map[uint32]*Batch)
+	for _, cursor := range cursors {
+		shardId := wk.shardId(cursor.Uid)
+		batch := batchMap[shardId]
+		if batch == nil {
+			batch = wk.shardBatchDBById(shardId).NewBatch()
+			batchMap[shardId] = batch
+		}
+		data, err := cursor.Marshal()
+		if err != nil {
+			return err
+		}
+		batch.Set(key.NewDeviceSyncCursorKey(cursor.Uid, cursor.DeviceFlag, cursor.ChannelId, cursor.ChannelType), data)
+	}
+
+	batchs := make([]*Batch, 0, len(batchMap))
+	for _, batch := range batchMap {
+		batchs = append(batchs, batch)
+	}
+	return Commits(batchs)
+}
+
+func (wk *wukongDB) GetDeviceSyncCursors(uid string, deviceFlag uint8) ([]DeviceSyncCursor, error) {
+
+	iter := wk.shardDB(uid).NewIter(&pebble.IterOptions{
+		LowerBound: key.NewDeviceSyncCursorLowKey(uid, deviceFlag),
+		UpperBound: key.NewDeviceSyncCursorHighKey(uid, deviceFlag),
+	})
+	defer iter.Close()
+
+	var cursors []DeviceSyncCursor
+	for iter.First(); iter.Valid(); iter.Next() {
+		var cursor DeviceSyncCursor
+		if err := cursor.Unmarshal(iter.Value()); err != nil {
+			return nil, err
+		}
+		if cursor.Uid != uid { // uid hash冲突
+			continue
+		}
+		cursors = append(cursors, cursor)
+	}
+	return cursors, nil
+}
+
+func (wk *wukongDB) ResetDeviceSyncCursors(uid string, deviceFlag uint8) error {
+
+	batch := wk.sharedBatchDB(uid).NewBatch()
+	batch.DeleteRange(key.NewDeviceSyncCursorLowKey(uid, deviceFlag), key.NewDeviceSyncCursorHighKey(uid, deviceFlag))
+	return batch.CommitWait()
+}
@@ -0,0 +1,61 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceSyncCursor(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	cursors := []wkdb.DeviceSyncCursor{
+		{Uid: "u1", DeviceFlag: 0, ChannelId: "c1", ChannelType: 1, MessageSeq: 10},
+		{Uid: "u1", DeviceFlag: 0, ChannelId: "c2", ChannelType: 2, MessageSeq: 20},
+		{Uid: "u1", DeviceFlag: 1, ChannelId: "c1", ChannelType: 1, MessageSeq: 5},
+	}
+
+	t.Run("SetDeviceSyncCursors", func(t *testing.T) {
+		err := d.SetDeviceSyncCursors(cursors)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetDeviceSyncCursors", func(t *testing.T) {
+		appCursors, err := d.GetDeviceSyncCursors("u1", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(appCursors))
+
+		webCursors, err := d.GetDeviceSyncCursors("u1", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(webCursors))
+		assert.Equal(t, cursors[2], webCursors[0])
+
+		// 覆盖更新
+		err = d.SetDeviceSyncCursors([]wkdb.DeviceSyncCursor{{Uid: "u1", DeviceFlag: 1, ChannelId: "c1", ChannelType: 1, MessageSeq: 8}})
+		assert.NoError(t, err)
+		webCursors, err = d.GetDeviceSyncCursors("u1", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(8), webCursors[0].MessageSeq)
+	})
+
+	t.Run("ResetDeviceSyncCursors", func(t *testing.T) {
+		err := d.ResetDeviceSyncCursors("u1", 0)
+		assert.NoError(t, err)
+
+		appCursors, err := d.GetDeviceSyncCursors("u1", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, appCursors)
+
+		webCursors, err := d.GetDeviceSyncCursors("u1", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(webCursors))
+	})
+}
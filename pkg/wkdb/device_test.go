@@ -62,6 +62,49 @@ func TestGetDevice(t *testing.T) {
 	assert.Equal(t, u.DeviceLevel, u2.DeviceLevel)
 }
 
+func TestUpdateDeviceLogin(t *testing.T) {
+	d := wkdb.NewWukongDB(wkdb.NewOptions(wkdb.WithDir(t.TempDir())))
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	u := wkdb.Device{
+		Id:          1,
+		Uid:         "test",
+		Token:       "token",
+		DeviceFlag:  2,
+		DeviceLevel: 1,
+	}
+	err = d.AddDevice(u)
+	assert.NoError(t, err)
+
+	lastLoginAt := time.Unix(time.Now().Unix(), 0)
+	err = d.UpdateDeviceLogin(wkdb.Device{
+		Id:          1,
+		Uid:         "test",
+		LastLoginAt: &lastLoginAt,
+		LastLoginIp: "127.0.0.1",
+		DeviceId:    "device1",
+		Version:     4,
+	})
+	assert.NoError(t, err)
+
+	u2, err := d.GetDevice("test", 2)
+	assert.NoError(t, err)
+
+	// 登录信息不会覆盖token等信息
+	assert.Equal(t, u.Token, u2.Token)
+	assert.Equal(t, u.DeviceLevel, u2.DeviceLevel)
+	assert.Equal(t, lastLoginAt.Unix(), u2.LastLoginAt.Unix())
+	assert.Equal(t, "127.0.0.1", u2.LastLoginIp)
+	assert.Equal(t, "device1", u2.DeviceId)
+	assert.Equal(t, uint8(4), u2.Version)
+}
+
 func TestGetDevices(t *testing.T) {
 	d := wkdb.NewWukongDB(wkdb.NewOptions(wkdb.WithDir(t.TempDir())))
 	err := d.Open()
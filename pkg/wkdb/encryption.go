@@ -0,0 +1,511 @@
+package wkdb
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+	"go.uber.org/zap"
+)
+
+// 消息payload静态加密（信封加密）
+// 每个分区拥有独立的数据密钥（AES-256-GCM），数据密钥被主密钥加密后保存在分区的TableDataKey表中，
+// 加密后的payload格式为:
+// ---------------------
+// | shardId  | keyVersion | nonce   | ciphertext |
+// | 4 byte   | 4 byte     | 12 byte | n byte     |
+// ---------------------
+
+var (
+	ErrEncryptionOff        = errors.New("encryption is off")
+	ErrReencryptRunning     = errors.New("reencrypt job is running")
+	ErrPayloadEncrypted     = errors.New("payload is encrypted but encryption is off")
+	ErrDataKeyNotFound      = errors.New("data key not found")
+	ErrInvalidEncryptedData = errors.New("invalid encrypted payload")
+)
+
+const (
+	dataKeySize            = 32
+	encryptedPayloadHeader = 4 + 4 // shardId + keyVersion
+)
+
+type payloadCrypto struct {
+	master    cipher.AEAD // 主密钥
+	oldMaster cipher.AEAD // 旧的主密钥（主密钥轮换时使用，用于解密旧的数据密钥然后用新的主密钥重新加密）
+	shards    []*shardDataKeys
+
+	reencrypting     atomic.Bool
+	reencryptedCount atomic.Int64
+	reencryptErr     atomic.Value // string
+	reencryptAt      atomic.Int64 // 最后一次重加密完成时间
+}
+
+// 分区的数据密钥
+type shardDataKeys struct {
+	sync.RWMutex
+	current uint32                 // 当前使用的数据密钥版本
+	keys    map[uint32]cipher.AEAD // 所有版本的数据密钥
+}
+
+func newPayloadCrypto(masterKey, oldMasterKey []byte) (*payloadCrypto, error) {
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	c := &payloadCrypto{
+		master: master,
+	}
+	if len(oldMasterKey) > 0 {
+		c.oldMaster, err = newAEAD(oldMasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid old master key: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func newAEAD(k []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealWith(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openWith(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrInvalidEncryptedData
+	}
+	nonce := data[:aead.NonceSize()]
+	return aead.Open(nil, nonce, data[aead.NonceSize():], additionalData)
+}
+
+// 加载分区的数据密钥，如果分区没有数据密钥则生成一个
+func (c *payloadCrypto) loadShard(shardId uint32, db *pebble.DB) error {
+	sk := &shardDataKeys{
+		keys: make(map[uint32]cipher.AEAD),
+	}
+
+	iter := db.NewIter(&pebble.IterOptions{
+		LowerBound: key.NewDataKeyColumnKey(0, key.MinColumnKey),
+		UpperBound: key.NewDataKeyColumnKey(math.MaxUint32, key.MaxColumnKey),
+	})
+	defer iter.Close()
+
+	rewraps := make(map[uint32][]byte)
+	for iter.First(); iter.Valid(); iter.Next() {
+		version, columnName, err := key.ParseDataKeyColumnKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		if columnName != key.TableDataKey.Column.Key {
+			continue
+		}
+		dataKey, err := openWith(c.master, iter.Value(), dataKeyAdditionalData(shardId, version))
+		if err != nil {
+			if c.oldMaster == nil {
+				return fmt.Errorf("shard[%d] decrypt data key[%d] failed, master key mismatch: %w", shardId, version, err)
+			}
+			dataKey, err = openWith(c.oldMaster, iter.Value(), dataKeyAdditionalData(shardId, version))
+			if err != nil {
+				return fmt.Errorf("shard[%d] decrypt data key[%d] failed with master key and old master key: %w", shardId, version, err)
+			}
+			rewraps[version] = dataKey
+		}
+		aead, err := newAEAD(dataKey)
+		if err != nil {
+			return err
+		}
+		sk.keys[version] = aead
+	}
+
+	// 使用新的主密钥重新加密旧的数据密钥
+	if len(rewraps) > 0 {
+		batch := db.NewBatch()
+		defer batch.Close()
+		for version, dataKey := range rewraps {
+			wrapped, err := sealWith(c.master, dataKey, dataKeyAdditionalData(shardId, version))
+			if err != nil {
+				return err
+			}
+			if err := batch.Set(key.NewDataKeyColumnKey(version, key.TableDataKey.Column.Key), wrapped, pebble.NoSync); err != nil {
+				return err
+			}
+		}
+		if err := batch.Commit(pebble.Sync); err != nil {
+			return err
+		}
+	}
+
+	result, closer, err := db.Get(key.NewDataKeyCurrentVersionKey())
+	if err != nil && err != pebble.ErrNotFound {
+		return err
+	}
+	if err == nil {
+		sk.current = binary.BigEndian.Uint32(result)
+		closer.Close()
+	}
+
+	if _, ok := sk.keys[sk.current]; !ok || sk.current == 0 {
+		if sk.current != 0 {
+			return fmt.Errorf("shard[%d] current data key[%d]: %w", shardId, sk.current, ErrDataKeyNotFound)
+		}
+		if err := c.newDataKey(shardId, db, sk); err != nil {
+			return err
+		}
+	}
+
+	for uint32(len(c.shards)) <= shardId {
+		c.shards = append(c.shards, nil)
+	}
+	c.shards[shardId] = sk
+	return nil
+}
+
+// 生成新的数据密钥并设置为当前密钥
+func (c *payloadCrypto) newDataKey(shardId uint32, db *pebble.DB, sk *shardDataKeys) error {
+	var version uint32 = 1
+	for v := range sk.keys {
+		if v >= version {
+			version = v + 1
+		}
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	wrapped, err := sealWith(c.master, dataKey, dataKeyAdditionalData(shardId, version))
+	if err != nil {
+		return err
+	}
+
+	batch := db.NewBatch()
+	defer batch.Close()
+
+	createdAt := make([]byte, 8)
+	binary.BigEndian.PutUint64(createdAt, uint64(time.Now().UnixNano()))
+	versionBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(versionBytes, version)
+
+	if err := batch.Set(key.NewDataKeyColumnKey(version, key.TableDataKey.Column.Key), wrapped, pebble.NoSync); err != nil {
+		return err
+	}
+	if err := batch.Set(key.NewDataKeyColumnKey(version, key.TableDataKey.Column.CreatedAt), createdAt, pebble.NoSync); err != nil {
+		return err
+	}
+	if err := batch.Set(key.NewDataKeyCurrentVersionKey(), versionBytes, pebble.NoSync); err != nil {
+		return err
+	}
+	if err := batch.Commit(pebble.Sync); err != nil {
+		return err
+	}
+
+	sk.keys[version] = aead
+	sk.current = version
+	return nil
+}
+
+func (c *payloadCrypto) shard(shardId uint32) (*shardDataKeys, error) {
+	if int(shardId) >= len(c.shards) || c.shards[shardId] == nil {
+		return nil, fmt.Errorf("shard[%d]: %w", shardId, ErrDataKeyNotFound)
+	}
+	return c.shards[shardId], nil
+}
+
+func (c *payloadCrypto) encrypt(shardId uint32, payload []byte) ([]byte, error) {
+	sk, err := c.shard(shardId)
+	if err != nil {
+		return nil, err
+	}
+	sk.RLock()
+	version := sk.current
+	aead := sk.keys[version]
+	sk.RUnlock()
+
+	header := make([]byte, encryptedPayloadHeader)
+	binary.BigEndian.PutUint32(header, shardId)
+	binary.BigEndian.PutUint32(header[4:], version)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, encryptedPayloadHeader+len(nonce)+len(payload)+aead.Overhead())
+	data = append(data, header...)
+	data = append(data, nonce...)
+	return aead.Seal(data, nonce, payload, header), nil
+}
+
+func (c *payloadCrypto) decrypt(data []byte) ([]byte, error) {
+	shardId, version, err := parseEncryptedPayloadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := c.shard(shardId)
+	if err != nil {
+		return nil, err
+	}
+	sk.RLock()
+	aead := sk.keys[version]
+	sk.RUnlock()
+	if aead == nil {
+		return nil, fmt.Errorf("shard[%d] data key[%d]: %w", shardId, version, ErrDataKeyNotFound)
+	}
+	return openWith(aead, data[encryptedPayloadHeader:], data[:encryptedPayloadHeader])
+}
+
+func (c *payloadCrypto) currentVersion(shardId uint32) uint32 {
+	sk, err := c.shard(shardId)
+	if err != nil {
+		return 0
+	}
+	sk.RLock()
+	defer sk.RUnlock()
+	return sk.current
+}
+
+func parseEncryptedPayloadHeader(data []byte) (shardId uint32, version uint32, err error) {
+	if len(data) < encryptedPayloadHeader {
+		err = ErrInvalidEncryptedData
+		return
+	}
+	shardId = binary.BigEndian.Uint32(data)
+	version = binary.BigEndian.Uint32(data[4:])
+	return
+}
+
+func dataKeyAdditionalData(shardId uint32, version uint32) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data, shardId)
+	binary.BigEndian.PutUint32(data[4:], version)
+	return data
+}
+
+// 打开加密（在分区db打开后调用）
+func (wk *wukongDB) openEncryption() error {
+	if len(wk.opts.EncryptionMasterKey) == 0 {
+		return nil
+	}
+	crypto, err := newPayloadCrypto(wk.opts.EncryptionMasterKey, wk.opts.EncryptionOldMasterKey)
+	if err != nil {
+		return err
+	}
+	for i, db := range wk.dbs {
+		if err := crypto.loadShard(uint32(i), db); err != nil {
+			return err
+		}
+	}
+	wk.crypto = crypto
+	return nil
+}
+
+// 解密消息的payload
+func (wk *wukongDB) decryptPayload(data []byte) ([]byte, error) {
+	if wk.crypto == nil {
+		return nil, ErrPayloadEncrypted
+	}
+	return wk.crypto.decrypt(data)
+}
+
+func (wk *wukongDB) RotateDataKey() error {
+	if wk.crypto == nil {
+		return ErrEncryptionOff
+	}
+	for i, db := range wk.dbs {
+		sk, err := wk.crypto.shard(uint32(i))
+		if err != nil {
+			return err
+		}
+		sk.Lock()
+		err = wk.crypto.newDataKey(uint32(i), db, sk)
+		sk.Unlock()
+		if err != nil {
+			return err
+		}
+		wk.Info("rotate data key", zap.Int("shardId", i), zap.Uint32("version", wk.crypto.currentVersion(uint32(i))))
+	}
+	return nil
+}
+
+func (wk *wukongDB) StartReencrypt() error {
+	if wk.crypto == nil {
+		return ErrEncryptionOff
+	}
+	if !wk.crypto.reencrypting.CompareAndSwap(false, true) {
+		return ErrReencryptRunning
+	}
+	wk.crypto.reencryptedCount.Store(0)
+	wk.crypto.reencryptErr.Store("")
+
+	go func() {
+		defer wk.crypto.reencrypting.Store(false)
+		start := time.Now()
+		for i := range wk.dbs {
+			if err := wk.reencryptShard(wk.cancelCtx, uint32(i)); err != nil {
+				wk.Error("reencrypt shard failed", zap.Error(err), zap.Int("shardId", i))
+				wk.crypto.reencryptErr.Store(err.Error())
+				return
+			}
+		}
+		wk.crypto.reencryptAt.Store(time.Now().UnixNano())
+		wk.Info("reencrypt done", zap.Duration("cost", time.Since(start)), zap.Int64("count", wk.crypto.reencryptedCount.Load()))
+	}()
+	return nil
+}
+
+// 重新加密时每批最多扫描的key数量（每批持有分区的消息锁）
+const reencryptMaxScanPerBatch = 100000
+
+// 将分区内明文的payload或者使用旧数据密钥加密的payload用当前数据密钥重新加密
+func (wk *wukongDB) reencryptShard(ctx context.Context, shardId uint32) error {
+	startKey := key.NewMessageTableLowKey()
+	for {
+		nextKey, err := wk.reencryptShardBatch(ctx, shardId, startKey)
+		if err != nil {
+			return err
+		}
+		if nextKey == nil {
+			return nil
+		}
+		startKey = nextKey
+	}
+}
+
+// 重新加密分区内从startKey开始的一批payload，返回下一批的开始key，nil表示分区已处理完
+// 每批都持有分区的消息锁并重新创建迭代器，防止和截断消息并发时把已删除的消息重新写入
+func (wk *wukongDB) reencryptShardBatch(ctx context.Context, shardId uint32, startKey []byte) ([]byte, error) {
+	wk.dblock.messageShardLock.lock(shardId)
+	defer wk.dblock.messageShardLock.unlock(shardId)
+
+	db := wk.shardDBById(shardId)
+	currentVersion := wk.crypto.currentVersion(shardId)
+
+	iter := db.NewIter(&pebble.IterOptions{
+		LowerBound: startKey,
+		UpperBound: key.NewMessageTableHighKey(),
+	})
+	defer iter.Close()
+
+	var (
+		batch   = wk.shardBatchDBById(shardId).NewBatch()
+		count   int
+		scanned int
+		nextKey []byte
+	)
+	for iter.First(); iter.Valid(); iter.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if count >= wk.opts.BatchPerSize || scanned >= reencryptMaxScanPerBatch {
+			nextKey = append([]byte(nil), iter.Key()...)
+			break
+		}
+		scanned++
+
+		k := iter.Key()
+		if len(k) != key.TableMessage.Size {
+			continue
+		}
+		_, columnName, err := key.ParseMessageColumnKey(k)
+		if err != nil {
+			return nil, err
+		}
+
+		var payload []byte
+		switch columnName {
+		case key.TableMessage.Column.Payload:
+			payload = make([]byte, len(iter.Value()))
+			copy(payload, iter.Value())
+		case key.TableMessage.Column.EncryptedPayload:
+			_, version, err := parseEncryptedPayloadHeader(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			if version == currentVersion {
+				continue
+			}
+			payload, err = wk.crypto.decrypt(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		encrypted, err := wk.crypto.encrypt(shardId, payload)
+		if err != nil {
+			return nil, err
+		}
+		encKey, err := key.NewMessageColumnKeyWithColumnKey(k, key.TableMessage.Column.EncryptedPayload)
+		if err != nil {
+			return nil, err
+		}
+		if columnName == key.TableMessage.Column.Payload {
+			batch.Delete(append([]byte(nil), k...))
+		}
+		batch.Set(encKey, encrypted)
+		count++
+	}
+	if count > 0 {
+		if err := batch.CommitWait(); err != nil {
+			return nil, err
+		}
+		wk.crypto.reencryptedCount.Add(int64(count))
+	}
+	return nextKey, nil
+}
+
+func (wk *wukongDB) GetEncryptionStatus() EncryptionStatus {
+	status := EncryptionStatus{}
+	if wk.crypto == nil {
+		return status
+	}
+	status.On = true
+	status.Reencrypting = wk.crypto.reencrypting.Load()
+	status.ReencryptedCount = wk.crypto.reencryptedCount.Load()
+	if errStr, ok := wk.crypto.reencryptErr.Load().(string); ok {
+		status.ReencryptErr = errStr
+	}
+	status.LastReencryptAt = wk.crypto.reencryptAt.Load()
+	for i := range wk.dbs {
+		sk, err := wk.crypto.shard(uint32(i))
+		if err != nil {
+			continue
+		}
+		sk.RLock()
+		status.Shards = append(status.Shards, ShardDataKeyStatus{
+			ShardId:        uint32(i),
+			CurrentVersion: sk.current,
+			KeyCount:       len(sk.keys),
+		})
+		sk.RUnlock()
+	}
+	return status
+}
@@ -0,0 +1,208 @@
+package wkdb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestEncryptionDB(t testing.TB, dir string, masterKey, oldMasterKey []byte) wkdb.DB {
+	traceObj := trace.New(
+		context.Background(),
+		trace.NewOptions(
+			trace.WithServiceName("test"),
+			trace.WithServiceHostName("host"),
+		))
+	trace.SetGlobalTrace(traceObj)
+
+	return wkdb.NewWukongDB(wkdb.NewOptions(wkdb.WithDir(dir), wkdb.WithShardNum(2), wkdb.WithEncryptionMasterKey(masterKey, oldMasterKey)))
+}
+
+func newTestEncryptionMessages(channelId string, channelType uint8, startSeq, num int) []wkdb.Message {
+	messages := make([]wkdb.Message, 0, num)
+	for i := 0; i < num; i++ {
+		messages = append(messages, wkdb.Message{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   int64(startSeq + i),
+				ChannelID:   channelId,
+				ChannelType: channelType,
+				MessageSeq:  uint32(startSeq + i),
+				Payload:     []byte("hello"),
+			},
+		})
+	}
+	return messages
+}
+
+func TestEncryptionLoadMessages(t *testing.T) {
+	dir := t.TempDir()
+	d := newTestEncryptionDB(t, dir, testMasterKey, nil)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	channelId := "channel"
+	channelType := uint8(2)
+
+	err = d.AppendMessages(channelId, channelType, newTestEncryptionMessages(channelId, channelType, 1, 10))
+	assert.NoError(t, err)
+
+	msgs, err := d.LoadNextRangeMsgs(channelId, channelType, 1, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 10)
+	for _, m := range msgs {
+		assert.Equal(t, []byte("hello"), m.Payload)
+	}
+
+	msgs, err = d.LoadNextRangeMsgsForSize(channelId, channelType, 1, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 10)
+	assert.Equal(t, []byte("hello"), msgs[9].Payload)
+
+	err = d.Close()
+	assert.NoError(t, err)
+
+	// 错误的主密钥无法打开
+	d = newTestEncryptionDB(t, dir, []byte("fedcba9876543210fedcba9876543210"), nil)
+	err = d.Open()
+	assert.Error(t, err)
+	_ = d.Close()
+
+	// 主密钥轮换
+	newMasterKey := []byte("fedcba9876543210fedcba9876543210")
+	d = newTestEncryptionDB(t, dir, newMasterKey, testMasterKey)
+	err = d.Open()
+	assert.NoError(t, err)
+	err = d.Close()
+	assert.NoError(t, err)
+
+	d = newTestEncryptionDB(t, dir, newMasterKey, nil)
+	err = d.Open()
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	msg, err := d.LoadMsg(channelId, channelType, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), msg.Payload)
+}
+
+func TestEncryptionRotateAndReencrypt(t *testing.T) {
+	dir := t.TempDir()
+	channelId := "channel"
+	channelType := uint8(2)
+
+	// 未开启加密时写入明文
+	d := newTestEncryptionDB(t, dir, nil, nil)
+	err := d.Open()
+	assert.NoError(t, err)
+	err = d.AppendMessages(channelId, channelType, newTestEncryptionMessages(channelId, channelType, 1, 10))
+	assert.NoError(t, err)
+	err = d.Close()
+	assert.NoError(t, err)
+
+	d = newTestEncryptionDB(t, dir, testMasterKey, nil)
+	err = d.Open()
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	err = d.AppendMessages(channelId, channelType, newTestEncryptionMessages(channelId, channelType, 11, 10))
+	assert.NoError(t, err)
+
+	err = d.RotateDataKey()
+	assert.NoError(t, err)
+
+	status := d.GetEncryptionStatus()
+	assert.True(t, status.On)
+	assert.Len(t, status.Shards, 2)
+	assert.Equal(t, uint32(2), status.Shards[0].CurrentVersion)
+
+	err = d.StartReencrypt()
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		if !d.GetEncryptionStatus().Reencrypting {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	status = d.GetEncryptionStatus()
+	assert.False(t, status.Reencrypting)
+	assert.Equal(t, "", status.ReencryptErr)
+	assert.Equal(t, int64(20), status.ReencryptedCount)
+
+	msgs, err := d.LoadNextRangeMsgs(channelId, channelType, 1, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 20)
+	for _, m := range msgs {
+		assert.Equal(t, []byte("hello"), m.Payload)
+	}
+
+	results, err := d.SearchMessages(wkdb.MessageSearchReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		Payload:     []byte("hello"),
+		Limit:       100,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 20)
+}
+
+func TestEncryptionReencryptInBatches(t *testing.T) {
+	dir := t.TempDir()
+	channelId := "channel"
+	channelType := uint8(2)
+
+	d := newTestEncryptionDB(t, dir, nil, nil)
+	err := d.Open()
+	assert.NoError(t, err)
+	err = d.AppendMessages(channelId, channelType, newTestEncryptionMessages(channelId, channelType, 1, 10))
+	assert.NoError(t, err)
+	err = d.Close()
+	assert.NoError(t, err)
+
+	// 每批只重新加密3条，分多批完成
+	opts := wkdb.NewOptions(wkdb.WithDir(dir), wkdb.WithShardNum(2), wkdb.WithEncryptionMasterKey(testMasterKey, nil))
+	opts.BatchPerSize = 3
+	d = wkdb.NewWukongDB(opts)
+	err = d.Open()
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	// 截断的消息不会被重新加密写回
+	err = d.TruncateLogTo(channelId, channelType, 9)
+	assert.NoError(t, err)
+
+	err = d.StartReencrypt()
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		if !d.GetEncryptionStatus().Reencrypting {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	status := d.GetEncryptionStatus()
+	assert.Equal(t, "", status.ReencryptErr)
+	assert.Equal(t, int64(8), status.ReencryptedCount)
+
+	msgs, err := d.LoadNextRangeMsgs(channelId, channelType, 1, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 8)
+	for _, m := range msgs {
+		assert.Equal(t, []byte("hello"), m.Payload)
+	}
+}
@@ -118,6 +118,16 @@ func NewChannelLastMessageSeqKey(channelId string, channelType uint8) []byte {
 
 }
 
+// NewMessageColumnKeyWithColumnKey 同一条消息另一列的key
+func NewMessageColumnKeyWithColumnKey(columnKey []byte, columnName [2]byte) ([]byte, error) {
+	if len(columnKey) != TableMessage.Size {
+		return nil, fmt.Errorf("message: invalid key length, keyLen: %d", len(columnKey))
+	}
+	var primary [16]byte
+	copy(primary[:], columnKey[4:20])
+	return NewMessageColumnKeyWithPrimary(primary, columnName), nil
+}
+
 func ParseMessageColumnKey(key []byte) (messageSeq uint64, columnName [2]byte, err error) {
 	if len(key) != TableMessage.Size {
 		err = fmt.Errorf("message: invalid key length, keyLen: %d", len(key))
@@ -770,6 +780,58 @@ func NewMessageNotifyQueueKey(messageId uint64) []byte {
 	return key
 }
 
+// ---------------------- MessageFederationQueue ----------------------
+
+func NewMessageFederationQueueKey(channelId string, channelType uint8, messageSeq uint64) []byte {
+	return NewMessageFederationQueueKeyWithChannelNum(ChannelToNum(channelId, channelType), messageSeq)
+}
+
+func NewMessageFederationQueueKeyWithChannelNum(channelNum uint64, messageSeq uint64) []byte {
+	key := make([]byte, TableMessageFederationQueue.Size)
+	key[0] = TableMessageFederationQueue.Id[0]
+	key[1] = TableMessageFederationQueue.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], channelNum)
+	binary.BigEndian.PutUint64(key[12:], messageSeq)
+	return key
+}
+
+func ParseMessageFederationQueueKey(key []byte) (channelNum uint64, messageSeq uint64, err error) {
+	if len(key) != TableMessageFederationQueue.Size {
+		err = fmt.Errorf("federation queue: invalid key length, keyLen: %d", len(key))
+		return
+	}
+	channelNum = binary.BigEndian.Uint64(key[4:])
+	messageSeq = binary.BigEndian.Uint64(key[12:])
+	return
+}
+
+// ---------------------- AuditEvent ----------------------
+
+func NewAuditEventKey(id uint64) []byte {
+	key := make([]byte, TableAuditEvent.Size)
+	key[0] = TableAuditEvent.Id[0]
+	key[1] = TableAuditEvent.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], id)
+	return key
+}
+
+// ---------------------- LogChecksumIndex ----------------------
+
+func NewLogChecksumIndexKey(shardType uint8, shardNo string) []byte {
+	key := make([]byte, TableLogChecksumIndex.Size)
+	key[0] = TableLogChecksumIndex.Id[0]
+	key[1] = TableLogChecksumIndex.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	key[4] = shardType
+	binary.BigEndian.PutUint64(key[5:], HashWithString(shardNo))
+	return key
+}
+
 // ---------------------- ChannelClusterConfig ----------------------
 
 func NewChannelClusterConfigColumnKey(primaryKey uint64, columnName [2]byte) []byte {
@@ -1002,3 +1064,234 @@ func ParseTesterColumnKey(key []byte) (id uint64, columnName [2]byte, err error)
 	columnName[1] = key[13]
 	return
 }
+
+// ---------------------- DataKey ----------------------
+
+func NewDataKeyColumnKey(version uint32, columnName [2]byte) []byte {
+	key := make([]byte, TableDataKey.Size)
+	key[0] = TableDataKey.Id[0]
+	key[1] = TableDataKey.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], uint64(version))
+	key[12] = columnName[0]
+	key[13] = columnName[1]
+	return key
+}
+
+func ParseDataKeyColumnKey(key []byte) (version uint32, columnName [2]byte, err error) {
+	if len(key) != TableDataKey.Size {
+		err = fmt.Errorf("dataKey: invalid key length, keyLen: %d", len(key))
+		return
+	}
+	version = uint32(binary.BigEndian.Uint64(key[4:]))
+	columnName[0] = key[12]
+	columnName[1] = key[13]
+	return
+}
+
+func NewDataKeyCurrentVersionKey() []byte {
+	key := make([]byte, 6)
+	key[0] = TableDataKey.Id[0]
+	key[1] = TableDataKey.Id[1]
+	key[2] = dataTypeOther
+	key[3] = 0
+	key[4] = TableDataKey.Other.CurrentVersion[0]
+	key[5] = TableDataKey.Other.CurrentVersion[1]
+	return key
+}
+
+// ---------------------- DeviceSyncCursor ----------------------
+
+func NewDeviceSyncCursorKey(uid string, deviceFlag uint8, channelId string, channelType uint8) []byte {
+	key := make([]byte, TableDeviceSyncCursor.Size)
+	key[0] = TableDeviceSyncCursor.Id[0]
+	key[1] = TableDeviceSyncCursor.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], HashWithString(uid))
+	key[12] = deviceFlag
+	binary.BigEndian.PutUint64(key[13:], channelToNum(channelId, channelType))
+	return key
+}
+
+// NewDeviceSyncCursorLowKey 用户指定设备的同步位置的最小key
+func NewDeviceSyncCursorLowKey(uid string, deviceFlag uint8) []byte {
+	key := make([]byte, TableDeviceSyncCursor.Size)
+	key[0] = TableDeviceSyncCursor.Id[0]
+	key[1] = TableDeviceSyncCursor.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], HashWithString(uid))
+	key[12] = deviceFlag
+	binary.BigEndian.PutUint64(key[13:], 0)
+	return key
+}
+
+// NewDeviceSyncCursorHighKey 用户指定设备的同步位置的最大key
+func NewDeviceSyncCursorHighKey(uid string, deviceFlag uint8) []byte {
+	key := make([]byte, TableDeviceSyncCursor.Size)
+	key[0] = TableDeviceSyncCursor.Id[0]
+	key[1] = TableDeviceSyncCursor.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], HashWithString(uid))
+	key[12] = deviceFlag
+	binary.BigEndian.PutUint64(key[13:], math.MaxUint64)
+	return key
+}
+
+// ---------------------- Presence ----------------------
+
+func NewPresenceKey(uid string) []byte {
+	key := make([]byte, TablePresence.Size)
+	key[0] = TablePresence.Id[0]
+	key[1] = TablePresence.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], HashWithString(uid))
+	return key
+}
+
+// ---------------------- MessageDedup ----------------------
+
+func NewMessageDedupKey(channelId string, channelType uint8, fromUid string, clientMsgNo string) []byte {
+	key := make([]byte, TableMessageDedup.Size)
+	key[0] = TableMessageDedup.Id[0]
+	key[1] = TableMessageDedup.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], ChannelToNum(channelId, channelType))
+	binary.BigEndian.PutUint64(key[12:], HashWithString(fromUid))
+	binary.BigEndian.PutUint64(key[20:], HashWithString(clientMsgNo))
+	return key
+}
+
+// NewMessageDedupExpireKey 去重索引的过期索引，timestamp为消息时间（秒）
+func NewMessageDedupExpireKey(timestamp uint64, channelId string, channelType uint8, fromUid string, clientMsgNo string) []byte {
+	key := make([]byte, TableMessageDedupExpire.Size)
+	key[0] = TableMessageDedupExpire.Id[0]
+	key[1] = TableMessageDedupExpire.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], timestamp)
+	binary.BigEndian.PutUint64(key[12:], ChannelToNum(channelId, channelType))
+	binary.BigEndian.PutUint64(key[20:], HashWithString(fromUid))
+	binary.BigEndian.PutUint64(key[28:], HashWithString(clientMsgNo))
+	return key
+}
+
+// NewMessageDedupExpireTimestampKey 去重过期索引指定时间的前缀，用于范围查询
+func NewMessageDedupExpireTimestampKey(timestamp uint64) []byte {
+	key := make([]byte, TableMessageDedupExpire.Size)
+	key[0] = TableMessageDedupExpire.Id[0]
+	key[1] = TableMessageDedupExpire.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], timestamp)
+	return key
+}
+
+// ParseMessageDedupExpireKey 从过期索引解析出对应的去重索引
+func ParseMessageDedupExpireKey(expireKey []byte) (dedupKey []byte, err error) {
+	if len(expireKey) != TableMessageDedupExpire.Size {
+		return nil, fmt.Errorf("message dedup expire key: invalid key length, keyLen: %d", len(expireKey))
+	}
+	dedupKey = make([]byte, TableMessageDedup.Size)
+	dedupKey[0] = TableMessageDedup.Id[0]
+	dedupKey[1] = TableMessageDedup.Id[1]
+	dedupKey[2] = dataTypeTable
+	dedupKey[3] = 0
+	copy(dedupKey[4:], expireKey[12:])
+	return dedupKey, nil
+}
+
+// ---------------------- Mention ----------------------
+
+func NewMentionKey(channelId string, channelType uint8, messageSeq uint64, uid string) []byte {
+	key := make([]byte, TableMention.Size)
+	key[0] = TableMention.Id[0]
+	key[1] = TableMention.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], ChannelToNum(channelId, channelType))
+	binary.BigEndian.PutUint64(key[12:], messageSeq)
+	binary.BigEndian.PutUint64(key[20:], HashWithString(uid))
+	return key
+}
+
+// NewMentionSeqKey 频道内指定消息序号的提醒索引前缀，用于范围查询
+func NewMentionSeqKey(channelId string, channelType uint8, messageSeq uint64) []byte {
+	key := make([]byte, TableMention.Size-8)
+	key[0] = TableMention.Id[0]
+	key[1] = TableMention.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], ChannelToNum(channelId, channelType))
+	binary.BigEndian.PutUint64(key[12:], messageSeq)
+	return key
+}
+
+func ParseMentionKey(key []byte) (messageSeq uint64, uidHash uint64, err error) {
+	if len(key) != TableMention.Size {
+		err = fmt.Errorf("mention: invalid key length, keyLen: %d", len(key))
+		return
+	}
+	messageSeq = binary.BigEndian.Uint64(key[12:])
+	uidHash = binary.BigEndian.Uint64(key[20:])
+	return
+}
+
+// ---------------------- ChannelChild ----------------------
+
+func NewChannelChildKey(parentChannelId string, parentChannelType uint8, childChannelId string, childChannelType uint8) []byte {
+	key := make([]byte, TableChannelChild.Size)
+	key[0] = TableChannelChild.Id[0]
+	key[1] = TableChannelChild.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], ChannelToNum(parentChannelId, parentChannelType))
+	binary.BigEndian.PutUint64(key[12:], ChannelToNum(childChannelId, childChannelType))
+	return key
+}
+
+// NewChannelChildLowKey 父频道的子频道索引的最小key
+func NewChannelChildLowKey(parentChannelId string, parentChannelType uint8) []byte {
+	return newChannelChildBoundKey(parentChannelId, parentChannelType, 0)
+}
+
+// NewChannelChildHighKey 父频道的子频道索引的最大key
+func NewChannelChildHighKey(parentChannelId string, parentChannelType uint8) []byte {
+	return newChannelChildBoundKey(parentChannelId, parentChannelType, math.MaxUint64)
+}
+
+func newChannelChildBoundKey(parentChannelId string, parentChannelType uint8, childHash uint64) []byte {
+	key := make([]byte, TableChannelChild.Size)
+	key[0] = TableChannelChild.Id[0]
+	key[1] = TableChannelChild.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	binary.BigEndian.PutUint64(key[4:], ChannelToNum(parentChannelId, parentChannelType))
+	binary.BigEndian.PutUint64(key[12:], childHash)
+	return key
+}
+
+// NewMessageTableLowKey 消息表的最小key（包含所有频道）
+func NewMessageTableLowKey() []byte {
+	key := make([]byte, 4)
+	key[0] = TableMessage.Id[0]
+	key[1] = TableMessage.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 0
+	return key
+}
+
+// NewMessageTableHighKey 消息表的最大key（包含所有频道）
+func NewMessageTableHighKey() []byte {
+	key := make([]byte, 4)
+	key[0] = TableMessage.Id[0]
+	key[1] = TableMessage.Id[1]
+	key[2] = dataTypeTable
+	key[3] = 1
+	return key
+}
@@ -26,19 +26,20 @@ var TableMessage = struct {
 	IndexSize       int
 	SecondIndexSize int
 	Column          struct {
-		Header      [2]byte
-		Setting     [2]byte
-		Expire      [2]byte
-		MessageId   [2]byte
-		MessageSeq  [2]byte
-		ClientMsgNo [2]byte
-		Timestamp   [2]byte
-		ChannelId   [2]byte
-		ChannelType [2]byte
-		Topic       [2]byte
-		FromUid     [2]byte
-		Payload     [2]byte
-		Term        [2]byte
+		Header           [2]byte
+		Setting          [2]byte
+		Expire           [2]byte
+		MessageId        [2]byte
+		MessageSeq       [2]byte
+		ClientMsgNo      [2]byte
+		Timestamp        [2]byte
+		ChannelId        [2]byte
+		ChannelType      [2]byte
+		Topic            [2]byte
+		FromUid          [2]byte
+		Payload          [2]byte
+		Term             [2]byte
+		EncryptedPayload [2]byte // 加密后的payload（开启静态加密后payload写入此列）
 	}
 	Index struct {
 		MessageId [2]byte
@@ -55,33 +56,35 @@ var TableMessage = struct {
 	IndexSize:       2 + 2 + 2 + 8,      // tableId + dataType + indexName + columnHash
 	SecondIndexSize: 2 + 2 + 2 + 8 + 16, // tableId + dataType + secondIndexName + columnValue + primaryKey
 	Column: struct {
-		Header      [2]byte
-		Setting     [2]byte
-		Expire      [2]byte
-		MessageId   [2]byte
-		MessageSeq  [2]byte
-		ClientMsgNo [2]byte
-		Timestamp   [2]byte
-		ChannelId   [2]byte
-		ChannelType [2]byte
-		Topic       [2]byte
-		FromUid     [2]byte
-		Payload     [2]byte
-		Term        [2]byte
-	}{
-		Header:      [2]byte{0x01, 0x01},
-		Setting:     [2]byte{0x01, 0x02},
-		Expire:      [2]byte{0x01, 0x03},
-		MessageId:   [2]byte{0x01, 0x04},
-		MessageSeq:  [2]byte{0x01, 0x05},
-		ClientMsgNo: [2]byte{0x01, 0x06},
-		Timestamp:   [2]byte{0x01, 0x07},
-		ChannelId:   [2]byte{0x01, 0x08},
-		ChannelType: [2]byte{0x01, 0x09},
-		Topic:       [2]byte{0x01, 0x0A},
-		FromUid:     [2]byte{0x01, 0x0B},
-		Payload:     [2]byte{0x01, 0x0C},
-		Term:        [2]byte{0x01, 0x0D},
+		Header           [2]byte
+		Setting          [2]byte
+		Expire           [2]byte
+		MessageId        [2]byte
+		MessageSeq       [2]byte
+		ClientMsgNo      [2]byte
+		Timestamp        [2]byte
+		ChannelId        [2]byte
+		ChannelType      [2]byte
+		Topic            [2]byte
+		FromUid          [2]byte
+		Payload          [2]byte
+		Term             [2]byte
+		EncryptedPayload [2]byte
+	}{
+		Header:           [2]byte{0x01, 0x01},
+		Setting:          [2]byte{0x01, 0x02},
+		Expire:           [2]byte{0x01, 0x03},
+		MessageId:        [2]byte{0x01, 0x04},
+		MessageSeq:       [2]byte{0x01, 0x05},
+		ClientMsgNo:      [2]byte{0x01, 0x06},
+		Timestamp:        [2]byte{0x01, 0x07},
+		ChannelId:        [2]byte{0x01, 0x08},
+		ChannelType:      [2]byte{0x01, 0x09},
+		Topic:            [2]byte{0x01, 0x0A},
+		FromUid:          [2]byte{0x01, 0x0B},
+		Payload:          [2]byte{0x01, 0x0C},
+		Term:             [2]byte{0x01, 0x0D},
+		EncryptedPayload: [2]byte{0x01, 0x0E},
 	},
 	Index: struct {
 		MessageId [2]byte
@@ -183,6 +186,10 @@ var TableDevice = struct {
 		DeviceLevel [2]byte // 设备等级
 		CreatedAt   [2]byte // 创建时间
 		UpdatedAt   [2]byte // 更新时间
+		LastLoginAt [2]byte // 最后一次登录时间
+		LastLoginIp [2]byte // 最后一次登录IP
+		DeviceId    [2]byte // 最后一次登录的客户端设备ID
+		Version     [2]byte // 最后一次登录的客户端协议版本
 	}
 	SecondIndex struct {
 		Uid         [2]byte
@@ -203,6 +210,10 @@ var TableDevice = struct {
 		DeviceLevel [2]byte
 		CreatedAt   [2]byte
 		UpdatedAt   [2]byte
+		LastLoginAt [2]byte
+		LastLoginIp [2]byte
+		DeviceId    [2]byte
+		Version     [2]byte
 	}{
 		Uid:         [2]byte{0x03, 0x01},
 		Token:       [2]byte{0x03, 0x02},
@@ -210,6 +221,10 @@ var TableDevice = struct {
 		DeviceLevel: [2]byte{0x03, 0x04},
 		CreatedAt:   [2]byte{0x03, 0x05},
 		UpdatedAt:   [2]byte{0x03, 0x06},
+		LastLoginAt: [2]byte{0x03, 0x07},
+		LastLoginIp: [2]byte{0x03, 0x08},
+		DeviceId:    [2]byte{0x03, 0x09},
+		Version:     [2]byte{0x03, 0x0A},
 	},
 	SecondIndex: struct {
 		Uid         [2]byte
@@ -234,9 +249,10 @@ var TableSubscriber = struct {
 	IndexSize       int
 	SecondIndexSize int
 	Column          struct {
-		Uid       [2]byte
-		CreatedAt [2]byte
-		UpdatedAt [2]byte
+		Uid        [2]byte
+		CreatedAt  [2]byte
+		UpdatedAt  [2]byte
+		JoinMsgSeq [2]byte // 加入频道时频道的最新消息序号
 	}
 	Index struct {
 		Uid [2]byte
@@ -251,13 +267,15 @@ var TableSubscriber = struct {
 	IndexSize:       2 + 2 + 2 + 8 + 8,     // tableId + dataType + indexName + channel hash + columnHash
 	SecondIndexSize: 2 + 2 + 2 + 8 + 8 + 8, // tableId + dataType + secondIndexName + channel hash +  columnValue + primaryKey
 	Column: struct {
-		Uid       [2]byte
-		CreatedAt [2]byte
-		UpdatedAt [2]byte
+		Uid        [2]byte
+		CreatedAt  [2]byte
+		UpdatedAt  [2]byte
+		JoinMsgSeq [2]byte
 	}{
-		Uid:       [2]byte{0x04, 0x01},
-		CreatedAt: [2]byte{0x04, 0x02},
-		UpdatedAt: [2]byte{0x04, 0x03},
+		Uid:        [2]byte{0x04, 0x01},
+		CreatedAt:  [2]byte{0x04, 0x02},
+		UpdatedAt:  [2]byte{0x04, 0x03},
+		JoinMsgSeq: [2]byte{0x04, 0x04},
 	},
 	Index: struct {
 		Uid [2]byte
@@ -302,17 +320,22 @@ var TableChannelInfo = struct {
 	IndexSize       int
 	SecondIndexSize int
 	Column          struct {
-		Id              [2]byte
-		ChannelId       [2]byte
-		ChannelType     [2]byte
-		Ban             [2]byte
-		Large           [2]byte
-		Disband         [2]byte
-		SubscriberCount [2]byte // 订阅者数量
-		AllowlistCount  [2]byte // 白名单数量
-		DenylistCount   [2]byte // 黑名单数量
-		CreatedAt       [2]byte
-		UpdatedAt       [2]byte
+		Id                [2]byte
+		ChannelId         [2]byte
+		ChannelType       [2]byte
+		Ban               [2]byte
+		Large             [2]byte
+		Disband           [2]byte
+		SubscriberCount   [2]byte // 订阅者数量
+		AllowlistCount    [2]byte // 白名单数量
+		DenylistCount     [2]byte // 黑名单数量
+		CreatedAt         [2]byte
+		UpdatedAt         [2]byte
+		ParentChannelId   [2]byte // 父频道ID
+		ParentChannelType [2]byte // 父频道类型
+		Inherit           [2]byte // 是否继承父频道
+		HistoryVisibility [2]byte // 新成员的历史消息可见策略
+		HistoryCount      [2]byte // 新成员可见的加入前消息数量
 	}
 	Index struct {
 		Channel [2]byte
@@ -332,29 +355,39 @@ var TableChannelInfo = struct {
 	IndexSize:       2 + 2 + 2 + 8,     // tableId + dataType + indexName  + columnHash
 	SecondIndexSize: 2 + 2 + 2 + 8 + 8, // tableId + dataType + secondIndexName + columnValue + primaryKey
 	Column: struct {
-		Id              [2]byte
-		ChannelId       [2]byte
-		ChannelType     [2]byte
-		Ban             [2]byte
-		Large           [2]byte
-		Disband         [2]byte
-		SubscriberCount [2]byte
-		AllowlistCount  [2]byte
-		DenylistCount   [2]byte
-		CreatedAt       [2]byte
-		UpdatedAt       [2]byte
-	}{
-		Id:              [2]byte{0x06, 0x01},
-		ChannelId:       [2]byte{0x06, 0x02},
-		ChannelType:     [2]byte{0x06, 0x03},
-		Ban:             [2]byte{0x06, 0x04},
-		Large:           [2]byte{0x06, 0x05},
-		Disband:         [2]byte{0x06, 0x06},
-		SubscriberCount: [2]byte{0x06, 0x07},
-		AllowlistCount:  [2]byte{0x06, 0x08},
-		DenylistCount:   [2]byte{0x06, 0x09},
-		CreatedAt:       [2]byte{0x06, 0x0A},
-		UpdatedAt:       [2]byte{0x06, 0x0B},
+		Id                [2]byte
+		ChannelId         [2]byte
+		ChannelType       [2]byte
+		Ban               [2]byte
+		Large             [2]byte
+		Disband           [2]byte
+		SubscriberCount   [2]byte
+		AllowlistCount    [2]byte
+		DenylistCount     [2]byte
+		CreatedAt         [2]byte
+		UpdatedAt         [2]byte
+		ParentChannelId   [2]byte
+		ParentChannelType [2]byte
+		Inherit           [2]byte
+		HistoryVisibility [2]byte
+		HistoryCount      [2]byte
+	}{
+		Id:                [2]byte{0x06, 0x01},
+		ChannelId:         [2]byte{0x06, 0x02},
+		ChannelType:       [2]byte{0x06, 0x03},
+		Ban:               [2]byte{0x06, 0x04},
+		Large:             [2]byte{0x06, 0x05},
+		Disband:           [2]byte{0x06, 0x06},
+		SubscriberCount:   [2]byte{0x06, 0x07},
+		AllowlistCount:    [2]byte{0x06, 0x08},
+		DenylistCount:     [2]byte{0x06, 0x09},
+		CreatedAt:         [2]byte{0x06, 0x0A},
+		UpdatedAt:         [2]byte{0x06, 0x0B},
+		ParentChannelId:   [2]byte{0x06, 0x0C},
+		ParentChannelType: [2]byte{0x06, 0x0D},
+		Inherit:           [2]byte{0x06, 0x0E},
+		HistoryVisibility: [2]byte{0x06, 0x0F},
+		HistoryCount:      [2]byte{0x06, 0x10},
 	},
 	Index: struct {
 		Channel [2]byte
@@ -482,14 +515,20 @@ var TableConversation = struct {
 	IndexSize       int
 	SecondIndexSize int
 	Column          struct {
-		Uid            [2]byte
-		ChannelId      [2]byte
-		ChannelType    [2]byte
-		Type           [2]byte
-		UnreadCount    [2]byte
-		ReadedToMsgSeq [2]byte
-		CreatedAt      [2]byte
-		UpdatedAt      [2]byte
+		Uid              [2]byte
+		ChannelId        [2]byte
+		ChannelType      [2]byte
+		Type             [2]byte
+		UnreadCount      [2]byte
+		ReadedToMsgSeq   [2]byte
+		CreatedAt        [2]byte
+		UpdatedAt        [2]byte
+		Pinned           [2]byte
+		Mute             [2]byte
+		Archived         [2]byte
+		Folder           [2]byte
+		Draft            [2]byte
+		SettingUpdatedAt [2]byte
 	}
 	Index struct {
 		Channel [2]byte
@@ -505,23 +544,35 @@ var TableConversation = struct {
 	IndexSize:       2 + 2 + 2 + 8 + 8,     // tableId + dataType   + indexName + primaryKey + columnHash
 	SecondIndexSize: 2 + 2 + 8 + 2 + 8 + 8, // tableId + dataType + uid hash  + secondIndexName + columnValue + primaryKey
 	Column: struct {
-		Uid            [2]byte
-		ChannelId      [2]byte
-		ChannelType    [2]byte
-		Type           [2]byte
-		UnreadCount    [2]byte
-		ReadedToMsgSeq [2]byte
-		CreatedAt      [2]byte
-		UpdatedAt      [2]byte
-	}{
-		Uid:            [2]byte{0x09, 0x01},
-		ChannelId:      [2]byte{0x09, 0x02},
-		ChannelType:    [2]byte{0x09, 0x03},
-		Type:           [2]byte{0x09, 0x04},
-		UnreadCount:    [2]byte{0x09, 0x05},
-		ReadedToMsgSeq: [2]byte{0x09, 0x06},
-		CreatedAt:      [2]byte{0x09, 0x07},
-		UpdatedAt:      [2]byte{0x09, 0x08},
+		Uid              [2]byte
+		ChannelId        [2]byte
+		ChannelType      [2]byte
+		Type             [2]byte
+		UnreadCount      [2]byte
+		ReadedToMsgSeq   [2]byte
+		CreatedAt        [2]byte
+		UpdatedAt        [2]byte
+		Pinned           [2]byte
+		Mute             [2]byte
+		Archived         [2]byte
+		Folder           [2]byte
+		Draft            [2]byte
+		SettingUpdatedAt [2]byte
+	}{
+		Uid:              [2]byte{0x09, 0x01},
+		ChannelId:        [2]byte{0x09, 0x02},
+		ChannelType:      [2]byte{0x09, 0x03},
+		Type:             [2]byte{0x09, 0x04},
+		UnreadCount:      [2]byte{0x09, 0x05},
+		ReadedToMsgSeq:   [2]byte{0x09, 0x06},
+		CreatedAt:        [2]byte{0x09, 0x07},
+		UpdatedAt:        [2]byte{0x09, 0x08},
+		Pinned:           [2]byte{0x09, 0x09},
+		Mute:             [2]byte{0x09, 0x0a},
+		Archived:         [2]byte{0x09, 0x0b},
+		Folder:           [2]byte{0x09, 0x0c},
+		Draft:            [2]byte{0x09, 0x0d},
+		SettingUpdatedAt: [2]byte{0x09, 0x0e},
 	},
 	Index: struct {
 		Channel [2]byte
@@ -583,6 +634,7 @@ var TableChannelClusterConfig = struct {
 		Version         [2]byte
 		CreatedAt       [2]byte
 		UpdatedAt       [2]byte
+		PreferredLeader [2]byte // 优先的领导节点
 	}
 }{
 	Id:              [2]byte{0x0B, 0x01},
@@ -623,6 +675,7 @@ var TableChannelClusterConfig = struct {
 		Version         [2]byte
 		CreatedAt       [2]byte
 		UpdatedAt       [2]byte
+		PreferredLeader [2]byte
 	}{
 		ChannelId:       [2]byte{0x0B, 0x01},
 		ChannelType:     [2]byte{0x0B, 0x02},
@@ -638,6 +691,7 @@ var TableChannelClusterConfig = struct {
 		Version:         [2]byte{0x0B, 0x0C},
 		CreatedAt:       [2]byte{0x0B, 0x0D},
 		UpdatedAt:       [2]byte{0x0B, 0x0E},
+		PreferredLeader: [2]byte{0x0B, 0x0F},
 	},
 }
 
@@ -795,3 +849,164 @@ var TableTester = struct {
 		UpdatedAt: [2]byte{0x14, 0x04},
 	},
 }
+
+// ======================== TableDataKey ========================
+
+// 数据密钥表（每个分区独立保存，值为被主密钥加密后的数据密钥）
+var TableDataKey = struct {
+	Id     [2]byte
+	Size   int
+	Column struct {
+		Key       [2]byte // 加密后的数据密钥
+		CreatedAt [2]byte // 创建时间
+	}
+	Other struct {
+		CurrentVersion [2]byte // 当前使用的数据密钥版本
+	}
+}{
+	Id:   [2]byte{0x15, 0x01},
+	Size: 2 + 2 + 8 + 2, // tableId + dataType  + version + columnKey
+	Column: struct {
+		Key       [2]byte
+		CreatedAt [2]byte
+	}{
+		Key:       [2]byte{0x15, 0x01},
+		CreatedAt: [2]byte{0x15, 0x02},
+	},
+	Other: struct {
+		CurrentVersion [2]byte
+	}{
+		CurrentVersion: [2]byte{0x15, 0x01},
+	},
+}
+
+// ======================== TableDeviceSyncCursor ========================
+
+// 设备同步位置表（记录用户每个设备在每个频道已同步到的消息序号）
+// ---------------------
+// | tableID  | dataType	| uid hash | deviceFlag | channel hash |
+// | 2 byte   | 1 byte   	| 8 字节   |  1 字节	  | 8 字节		 |
+// ---------------------
+var TableDeviceSyncCursor = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x16, 0x01},
+	Size: 2 + 2 + 8 + 1 + 8, // tableId + dataType  + uid hash + deviceFlag + channel hash
+}
+
+// ======================== TablePresence ========================
+
+// 用户在线状态表（自定义状态、最后在线时间）
+var TablePresence = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x17, 0x01},
+	Size: 2 + 2 + 8, // tableId + dataType  + uid hash
+}
+
+// ======================== TableMessageDedup ========================
+
+// 消息去重索引（相同发送者在同一频道的client_msg_no对应的消息）
+// ---------------------
+// | tableID  | dataType	| channel hash | fromUid hash | clientMsgNo hash |
+// | 2 byte   | 2 byte   	| 8 字节       |  8 字节      | 8 字节           |
+// ---------------------
+var TableMessageDedup = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x18, 0x01},
+	Size: 2 + 2 + 8 + 8 + 8, // tableId + dataType  + channel hash + fromUid hash + clientMsgNo hash
+}
+
+// ======================== TableMention ========================
+
+// 消息提醒（@）索引，提醒所有人时uid为空
+// ---------------------
+// | tableID  | dataType	| channel hash | messageSeq | uid hash |
+// | 2 byte   | 1 byte   	| 8 字节       |  8 字节    | 8 字节   |
+// ---------------------
+var TableMention = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x19, 0x01},
+	Size: 2 + 2 + 8 + 8 + 8, // tableId + dataType  + channel hash + messageSeq + uid hash
+}
+
+// ======================== TableChannelChild ========================
+
+// 子频道索引（保存在父频道所在的分区）
+// ---------------------
+// | tableID  | dataType	| parent channel hash | child channel hash |
+// | 2 byte   | 1 byte   	| 8 字节              |  8 字节            |
+// ---------------------
+var TableChannelChild = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x1A, 0x01},
+	Size: 2 + 2 + 8 + 8, // tableId + dataType  + parent channel hash + child channel hash
+}
+
+// ======================== TableMessageFederationQueue ========================
+
+// 联邦同步出站队列（与消息保存在同一分区并在同一个batch里写入，按频道区分，频道内按消息序号排序）
+// ---------------------
+// | tableID  | dataType	| channel hash | message seq |
+// | 2 byte   | 2 byte   	| 8 字节       |  8 字节     |
+// ---------------------
+var TableMessageFederationQueue = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x1B, 0x01},
+	Size: 2 + 2 + 8 + 8, // tableId + dataType  + channel hash + message seq
+}
+
+// ======================== TableAuditEvent ========================
+
+// 运维审计事件（保存在默认分区，id按时间递增）
+// ---------------------
+// | tableID  | dataType	| id     |
+// | 2 byte   | 1 byte   	| 8 字节 |
+// ---------------------
+var TableAuditEvent = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x1C, 0x01},
+	Size: 2 + 2 + 8, // tableId + dataType  + id
+}
+
+// ======================== TableLogChecksumIndex ========================
+
+// 副本日志校验进度（保存在默认分区，只记录本节点作为领导时的校验进度）
+// ---------------------
+// | tableID  | dataType	| shardType | shardNo hash |
+// | 2 byte   | 1 byte   	| 1 字节    |  8 字节      |
+// ---------------------
+var TableLogChecksumIndex = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x1D, 0x01},
+	Size: 2 + 2 + 1 + 8, // tableId + dataType  + shardType + shardNo hash
+}
+
+// ======================== TableMessageDedupExpire ========================
+
+// 消息去重索引的过期索引（按消息时间排序，用于清理超过去重窗口的去重索引）
+// ---------------------
+// | tableID  | dataType	| timestamp | channel hash | fromUid hash | clientMsgNo hash |
+// | 2 byte   | 2 byte   	| 8 字节    | 8 字节       |  8 字节      | 8 字节           |
+// ---------------------
+var TableMessageDedupExpire = struct {
+	Id   [2]byte
+	Size int
+}{
+	Id:   [2]byte{0x1E, 0x01},
+	Size: 2 + 2 + 8 + 8 + 8 + 8, // tableId + dataType + timestamp + channel hash + fromUid hash + clientMsgNo hash
+}
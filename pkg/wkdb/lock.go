@@ -16,6 +16,7 @@ type dblock struct {
 	userLock               *userLock
 	addOrUpdateChannelLock *addOrUpdateChannelLock
 	conversationLock       *conversationLock
+	messageShardLock       *messageShardLock
 }
 
 func newDBLock() *dblock {
@@ -28,6 +29,7 @@ func newDBLock() *dblock {
 		totalLock:              newTotalLock(),
 		addOrUpdateChannelLock: newAddOrUpdateChannelLock(),
 		conversationLock:       newConversationLock(),
+		messageShardLock:       newMessageShardLock(),
 	}
 
 }
@@ -40,6 +42,7 @@ func (d *dblock) start() {
 	d.userLock.StartCleanLoop()
 	d.addOrUpdateChannelLock.StartCleanLoop()
 	d.conversationLock.StartCleanLoop()
+	d.messageShardLock.StartCleanLoop()
 }
 
 func (d *dblock) stop() {
@@ -50,6 +53,7 @@ func (d *dblock) stop() {
 	d.userLock.StopCleanLoop()
 	d.addOrUpdateChannelLock.StopCleanLoop()
 	d.conversationLock.StopCleanLoop()
+	d.messageShardLock.StopCleanLoop()
 }
 
 type channelClusterConfigLock struct {
@@ -248,3 +252,22 @@ func (c *conversationLock) lock(uid string) {
 func (c *conversationLock) unlock(uid string) {
 	c.Unlock(uid)
 }
+
+// 分区的消息锁，截断消息和重新加密payload互斥
+type messageShardLock struct {
+	*keylock.KeyLock
+}
+
+func newMessageShardLock() *messageShardLock {
+	return &messageShardLock{
+		keylock.NewKeyLock(),
+	}
+}
+
+func (m *messageShardLock) lock(shardId uint32) {
+	m.Lock(strconv.FormatUint(uint64(shardId), 10))
+}
+
+func (m *messageShardLock) unlock(shardId uint32) {
+	m.Unlock(strconv.FormatUint(uint64(shardId), 10))
+}
@@ -0,0 +1,32 @@
+package wkdb
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+)
+
+// 值为 index(8字节) + shardNo，读取时比对shardNo，避免hash冲突读到其他分区的进度
+func (wk *wukongDB) SetLogChecksumIndex(shardType uint8, shardNo string, index uint64) error {
+	value := make([]byte, 8+len(shardNo))
+	wk.endian.PutUint64(value, index)
+	copy(value[8:], shardNo)
+
+	batch := wk.defaultShardBatchDB().NewBatch()
+	batch.Set(key.NewLogChecksumIndexKey(shardType, shardNo), value)
+	return batch.CommitWait()
+}
+
+func (wk *wukongDB) GetLogChecksumIndex(shardType uint8, shardNo string) (uint64, error) {
+	value, closer, err := wk.defaultShardDB().Get(key.NewLogChecksumIndexKey(shardType, shardNo))
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer closer.Close()
+	if len(value) < 8 || string(value[8:]) != shardNo {
+		return 0, nil
+	}
+	return wk.endian.Uint64(value), nil
+}
@@ -0,0 +1,41 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogChecksumIndex(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	index, err := d.GetLogChecksumIndex(1, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), index)
+
+	err = d.SetLogChecksumIndex(1, "1", 100)
+	assert.NoError(t, err)
+	err = d.SetLogChecksumIndex(2, "1", 200)
+	assert.NoError(t, err)
+
+	index, err = d.GetLogChecksumIndex(1, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), index)
+
+	index, err = d.GetLogChecksumIndex(2, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), index)
+
+	err = d.SetLogChecksumIndex(1, "1", 150)
+	assert.NoError(t, err)
+	index, err = d.GetLogChecksumIndex(1, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(150), index)
+}
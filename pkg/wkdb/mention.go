@@ -0,0 +1,130 @@
+package wkdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+)
+
+// MentionAll 提醒所有人
+const MentionAll = "all"
+
+// Mention 消息的提醒（@）信息，来自消息payload里的mention字段
+// 格式：{"mention":{"uids":["u1","u2"],"all":1}}，uids里包含all也表示提醒所有人
+type Mention struct {
+	Uids []string `json:"uids,omitempty"` // 被提醒的用户
+	All  bool     `json:"all,omitempty"`  // 是否提醒所有人
+}
+
+var mentionField = []byte(`"mention"`)
+
+// ParseMention 从消息payload里解析提醒信息，payload不是json或者没有提醒返回空
+func ParseMention(payload []byte) Mention {
+	if len(payload) == 0 || !bytes.Contains(payload, mentionField) {
+		return Mention{}
+	}
+	var content struct {
+		Mention *struct {
+			Uids []string        `json:"uids"`
+			All  json.RawMessage `json:"all"`
+		} `json:"mention"`
+	}
+	if err := json.Unmarshal(payload, &content); err != nil || content.Mention == nil {
+		return Mention{}
+	}
+	mention := Mention{}
+	switch string(bytes.TrimSpace(content.Mention.All)) {
+	case "1", "true":
+		mention.All = true
+	}
+	for _, uid := range content.Mention.Uids {
+		if uid == MentionAll {
+			mention.All = true
+			continue
+		}
+		if uid != "" {
+			mention.Uids = append(mention.Uids, uid)
+		}
+	}
+	return mention
+}
+
+// IsEmpty 是否没有提醒任何人
+func (m Mention) IsEmpty() bool {
+	return !m.All && len(m.Uids) == 0
+}
+
+// Contains 是否提醒了指定用户
+func (m Mention) Contains(uid string) bool {
+	if m.All {
+		return true
+	}
+	for _, u := range m.Uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func (wk *wukongDB) GetMentionStat(channelId string, channelType uint8, uid string, startMessageSeq uint64) (int, uint64, error) {
+	// 只统计频道现有消息范围内的提醒，消息被删除（截断）后残留的提醒索引不再计入
+	lastMsgSeq, _, err := wk.GetChannelLastMessageSeq(channelId, channelType)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lastMsgSeq < startMessageSeq {
+		return 0, 0, nil
+	}
+	iter := wk.channelDb(channelId, channelType).NewIter(&pebble.IterOptions{
+		LowerBound: key.NewMentionSeqKey(channelId, channelType, startMessageSeq),
+		UpperBound: key.NewMentionSeqKey(channelId, channelType, lastMsgSeq+1),
+	})
+	defer iter.Close()
+
+	var (
+		uidHash        = key.HashWithString(uid)
+		allHash        = key.HashWithString("")
+		count          int
+		lastMessageSeq uint64
+	)
+	for iter.First(); iter.Valid(); iter.Next() {
+		messageSeq, hash, err := key.ParseMentionKey(iter.Key())
+		if err != nil {
+			return 0, 0, err
+		}
+		if hash != uidHash && hash != allHash {
+			continue
+		}
+		// 同一条消息既提醒了所有人又提醒了此用户，只算一次
+		if messageSeq == lastMessageSeq {
+			continue
+		}
+		count++
+		lastMessageSeq = messageSeq
+	}
+	return count, lastMessageSeq, nil
+}
+
+// 删除从messageSeq（包含）开始的消息的提醒索引
+func (wk *wukongDB) deleteMentionsFrom(channelId string, channelType uint8, messageSeq uint64, w *Batch) {
+	w.DeleteRange(key.NewMentionSeqKey(channelId, channelType, messageSeq), key.NewMentionSeqKey(channelId, channelType, math.MaxUint64))
+}
+
+// 写入消息的提醒索引
+func (wk *wukongDB) writeMention(channelId string, channelType uint8, msg Message, w *Batch) {
+	mention := ParseMention(msg.Payload)
+	if mention.IsEmpty() {
+		return
+	}
+	messageSeq := uint64(msg.MessageSeq)
+	if mention.All {
+		w.Set(key.NewMentionKey(channelId, channelType, messageSeq, ""), nil)
+	}
+	for _, uid := range mention.Uids {
+		w.Set(key.NewMentionKey(channelId, channelType, messageSeq, uid), nil)
+	}
+}
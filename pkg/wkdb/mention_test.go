@@ -0,0 +1,92 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMention(t *testing.T) {
+	mention := wkdb.ParseMention([]byte(`{"type":1,"content":"hi","mention":{"uids":["u1","u2"]}}`))
+	assert.False(t, mention.All)
+	assert.Equal(t, []string{"u1", "u2"}, mention.Uids)
+	assert.True(t, mention.Contains("u2"))
+	assert.False(t, mention.Contains("u3"))
+
+	mention = wkdb.ParseMention([]byte(`{"mention":{"all":1}}`))
+	assert.True(t, mention.All)
+	assert.True(t, mention.Contains("u3"))
+
+	mention = wkdb.ParseMention([]byte(`{"mention":{"uids":["all"]}}`))
+	assert.True(t, mention.All)
+
+	assert.True(t, wkdb.ParseMention([]byte(`hello "mention"`)).IsEmpty())
+	assert.True(t, wkdb.ParseMention([]byte(`{"content":"hi"}`)).IsEmpty())
+}
+
+func TestGetMentionStat(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	channelId := "channel"
+	channelType := uint8(2)
+
+	payloads := []string{
+		`{"content":"1","mention":{"uids":["u1"]}}`,
+		`{"content":"2"}`,
+		`{"content":"3","mention":{"uids":["u2"]}}`,
+		`{"content":"4","mention":{"uids":["u1"],"all":1}}`,
+		`{"content":"5","mention":{"all":1}}`,
+	}
+	msgs := make([]wkdb.Message, 0, len(payloads))
+	for i, payload := range payloads {
+		msgs = append(msgs, wkdb.Message{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   int64(100 + i),
+				MessageSeq:  uint32(i + 1),
+				FromUID:     "u3",
+				ChannelID:   channelId,
+				ChannelType: channelType,
+				Payload:     []byte(payload),
+			},
+		})
+	}
+	err = d.AppendMessages(channelId, channelType, msgs)
+	assert.NoError(t, err)
+
+	count, lastSeq, err := d.GetMentionStat(channelId, channelType, "u1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, uint64(5), lastSeq)
+
+	count, lastSeq, err = d.GetMentionStat(channelId, channelType, "u2", 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, uint64(5), lastSeq)
+
+	// 截断后的消息不再计入
+	err = d.TruncateLogTo(channelId, channelType, 4)
+	assert.NoError(t, err)
+
+	count, lastSeq, err = d.GetMentionStat(channelId, channelType, "u1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, uint64(1), lastSeq)
+
+	// 频道最后一条消息之后残留的提醒索引不计入
+	err = d.SetChannelLastMessageSeq(channelId, channelType, 0)
+	assert.NoError(t, err)
+
+	count, lastSeq, err = d.GetMentionStat(channelId, channelType, "u1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, uint64(0), lastSeq)
+}
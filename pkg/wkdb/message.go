@@ -492,9 +492,20 @@ func (wk *wukongDB) TruncateLogTo(channelId string, channelType uint8, messageSe
 		}()
 	}
 
+	// 和重新加密payload互斥，防止被截断的消息又被重新加密写入
+	shardId := wk.channelDbIndex(channelId, channelType)
+	wk.dblock.messageShardLock.lock(shardId)
+	defer wk.dblock.messageShardLock.unlock(shardId)
+
 	db := wk.channelBatchDb(channelId, channelType)
 	batch := db.NewBatch()
+	// 去重索引需要读取被截断的消息，要在删除消息之前
+	if err = wk.deleteDedupFrom(channelId, channelType, messageSeq, batch); err != nil {
+		return err
+	}
 	batch.DeleteRange(key.NewMessagePrimaryKey(channelId, channelType, messageSeq), key.NewMessagePrimaryKey(channelId, channelType, math.MaxUint64))
+	wk.deleteMentionsFrom(channelId, channelType, messageSeq, batch)
+	wk.deleteFederationQueueFrom(channelId, channelType, messageSeq, batch)
 
 	err = wk.setChannelLastMessageSeq(channelId, channelType, messageSeq-1, batch)
 	if err != nil {
@@ -853,25 +864,20 @@ func (wk *wukongDB) iteratorChannelMessagesDirection(iter *pebble.Iterator, limi
 		hasData        bool = false
 	)
 
+	var iterStepFnc func() bool
 	if reverse {
 		if !iter.Last() {
 			return nil
 		}
+		iterStepFnc = iter.Prev
 	} else {
 		if !iter.First() {
 			return nil
 		}
+		iterStepFnc = iter.Next
 	}
-	for iter.Valid() {
-		if reverse {
-			if !iter.Prev() {
-				break
-			}
-		} else {
-			if !iter.Next() {
-				break
-			}
-		}
+	// 先处理定位到的第一个key再移动，否则正序会丢第一条消息的header，倒序会丢最后一条消息的最后一列
+	for ; iter.Valid(); iterStepFnc() {
 		messageSeq, coulmnName, err := key.ParseMessageColumnKey(iter.Key())
 		if err != nil {
 			return err
@@ -923,6 +929,12 @@ func (wk *wukongDB) iteratorChannelMessagesDirection(iter *pebble.Iterator, limi
 			var payload = make([]byte, len(iter.Value()))
 			copy(payload, iter.Value())
 			preMessage.Payload = payload
+		case key.TableMessage.Column.EncryptedPayload:
+			payload, err := wk.decryptPayload(iter.Value())
+			if err != nil {
+				return err
+			}
+			preMessage.Payload = payload
 		case key.TableMessage.Column.Term:
 			preMessage.Term = wk.endian.Uint64(iter.Value())
 
@@ -1001,6 +1013,12 @@ func (wk *wukongDB) parseChannelMessagesWithLimitSize(iter *pebble.Iterator, lim
 			var payload = make([]byte, len(iter.Value()))
 			copy(payload, iter.Value())
 			preMessage.Payload = payload
+		case key.TableMessage.Column.EncryptedPayload:
+			payload, err := wk.decryptPayload(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			preMessage.Payload = payload
 		case key.TableMessage.Column.Term:
 			preMessage.Term = wk.endian.Uint64(iter.Value())
 		}
@@ -1063,7 +1081,15 @@ func (wk *wukongDB) writeMessage(channelId string, channelType uint8, msg Messag
 	w.Set(key.NewMessageColumnKey(channelId, channelType, uint64(msg.MessageSeq), key.TableMessage.Column.FromUid), []byte(msg.RecvPacket.FromUID))
 
 	// payload
-	w.Set(key.NewMessageColumnKey(channelId, channelType, uint64(msg.MessageSeq), key.TableMessage.Column.Payload), msg.Payload)
+	if wk.crypto != nil {
+		encryptedPayload, err := wk.crypto.encrypt(uint32(w.DbIndex()), msg.Payload)
+		if err != nil {
+			return err
+		}
+		w.Set(key.NewMessageColumnKey(channelId, channelType, uint64(msg.MessageSeq), key.TableMessage.Column.EncryptedPayload), encryptedPayload)
+	} else {
+		w.Set(key.NewMessageColumnKey(channelId, channelType, uint64(msg.MessageSeq), key.TableMessage.Column.Payload), msg.Payload)
+	}
 
 	// term
 	termBytes := make([]byte, 8)
@@ -1086,5 +1112,14 @@ func (wk *wukongDB) writeMessage(channelId string, channelType uint8, msg Messag
 	// index timestamp
 	w.Set(key.NewMessageIndexTimestampKey(uint64(msg.Timestamp), primaryValue), nil)
 
+	// 去重索引
+	wk.writeDedup(channelId, channelType, msg, w)
+
+	// 提醒（@）索引
+	wk.writeMention(channelId, channelType, msg, w)
+
+	// 联邦同步队列
+	wk.writeFederationQueue(channelId, channelType, msg, w)
+
 	return nil
 }
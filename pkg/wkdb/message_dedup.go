@@ -0,0 +1,136 @@
+package wkdb
+
+import (
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+	"go.uber.org/zap"
+)
+
+// 去重索引的清理间隔
+var dedupCleanInterval = time.Minute * 10
+
+func (wk *wukongDB) GetDedupMessage(channelId string, channelType uint8, fromUid string, clientMsgNo string) (Message, error) {
+	if wk.opts.DedupWindow <= 0 {
+		return EmptyMessage, ErrNotFound
+	}
+	data, closer, err := wk.channelDb(channelId, channelType).Get(key.NewMessageDedupKey(channelId, channelType, fromUid, clientMsgNo))
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return EmptyMessage, ErrNotFound
+		}
+		return EmptyMessage, err
+	}
+	messageSeq := wk.endian.Uint64(data)
+	closer.Close()
+
+	msg, err := wk.LoadMsg(channelId, channelType, messageSeq)
+	if err != nil {
+		return EmptyMessage, err
+	}
+	// 消息可能已被截断覆盖或者hash冲突
+	if msg.FromUID != fromUid || msg.ClientMsgNo != clientMsgNo {
+		return EmptyMessage, ErrNotFound
+	}
+	return msg, nil
+}
+
+// 写入消息的去重索引和过期索引，没有开启去重不写入
+func (wk *wukongDB) writeDedup(channelId string, channelType uint8, msg Message, w *Batch) {
+	if wk.opts.DedupWindow <= 0 || msg.ClientMsgNo == "" {
+		return
+	}
+	// messageSeq + timestamp
+	value := make([]byte, 16)
+	wk.endian.PutUint64(value, uint64(msg.MessageSeq))
+	wk.endian.PutUint64(value[8:], uint64(msg.Timestamp))
+	w.Set(key.NewMessageDedupKey(channelId, channelType, msg.FromUID, msg.ClientMsgNo), value)
+	w.Set(key.NewMessageDedupExpireKey(uint64(msg.Timestamp), channelId, channelType, msg.FromUID, msg.ClientMsgNo), nil)
+}
+
+// 删除频道内messageSeq（包含）之后消息的去重索引，用于截断日志
+func (wk *wukongDB) deleteDedupFrom(channelId string, channelType uint8, messageSeq uint64, w *Batch) error {
+	if wk.opts.DedupWindow <= 0 {
+		return nil
+	}
+	msgs, err := wk.LoadNextRangeMsgs(channelId, channelType, messageSeq, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if msg.ClientMsgNo == "" {
+			continue
+		}
+		w.Delete(key.NewMessageDedupKey(channelId, channelType, msg.FromUID, msg.ClientMsgNo))
+		w.Delete(key.NewMessageDedupExpireKey(uint64(msg.Timestamp), channelId, channelType, msg.FromUID, msg.ClientMsgNo))
+	}
+	return nil
+}
+
+// 定时清理超过去重窗口的去重索引
+func (wk *wukongDB) cleanDedupLoop() {
+	ticker := time.NewTicker(dedupCleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			expireAt := time.Now().Add(-wk.opts.DedupWindow).Unix()
+			if expireAt <= 0 {
+				continue
+			}
+			for i := range wk.dbs {
+				count, err := wk.cleanExpiredDedup(uint32(i), uint64(expireAt))
+				if err != nil {
+					wk.Warn("clean expired dedup failed", zap.Error(err), zap.Int("shardId", i))
+					continue
+				}
+				if count > 0 {
+					wk.Debug("clean expired dedup", zap.Int("shardId", i), zap.Int("count", count))
+				}
+			}
+		case <-wk.cancelCtx.Done():
+			return
+		}
+	}
+}
+
+// 清理分区内消息时间早于expireAt（秒）的去重索引
+func (wk *wukongDB) cleanExpiredDedup(shardId uint32, expireAt uint64) (int, error) {
+	db := wk.shardDBById(shardId)
+	iter := db.NewIter(&pebble.IterOptions{
+		LowerBound: key.NewMessageDedupExpireTimestampKey(0),
+		UpperBound: key.NewMessageDedupExpireTimestampKey(expireAt),
+	})
+	defer iter.Close()
+
+	batch := wk.shardBatchDBById(shardId).NewBatch()
+	count := 0
+	for iter.First(); iter.Valid(); iter.Next() {
+		expireKey := append([]byte(nil), iter.Key()...) // iter.Key()在Next后会被复用
+		dedupKey, err := key.ParseMessageDedupExpireKey(expireKey)
+		if err != nil {
+			return 0, err
+		}
+		// 去重索引可能已被窗口外相同client_msg_no的新消息覆盖，只删除不晚于过期时间的
+		timestamp := wk.endian.Uint64(expireKey[4:])
+		data, closer, err := db.Get(dedupKey)
+		if err == nil {
+			if len(data) < 16 || wk.endian.Uint64(data[8:]) <= timestamp {
+				batch.Delete(dedupKey)
+			}
+			closer.Close()
+		} else if err != pebble.ErrNotFound {
+			return 0, err
+		}
+		batch.Delete(expireKey)
+		count++
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, batch.CommitWait()
+}
@@ -0,0 +1,68 @@
+package wkdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/trace"
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanExpiredDedup(t *testing.T) {
+	trace.SetGlobalTrace(trace.New(context.Background(), trace.NewOptions()))
+	wk := NewWukongDB(NewOptions(WithDir(t.TempDir()), WithShardNum(1), WithDedupWindow(time.Hour))).(*wukongDB)
+	err := wk.Open()
+	assert.NoError(t, err)
+	defer func() {
+		err := wk.Close()
+		assert.NoError(t, err)
+	}()
+
+	now := time.Now().Unix()
+	newMessage := func(messageSeq uint32, clientMsgNo string, timestamp int64) Message {
+		return Message{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   int64(messageSeq),
+				MessageSeq:  messageSeq,
+				ClientMsgNo: clientMsgNo,
+				FromUID:     "u1",
+				ChannelID:   "channel",
+				ChannelType: 2,
+				Timestamp:   int32(timestamp),
+			},
+		}
+	}
+	err = wk.AppendMessages("channel", 2, []Message{
+		newMessage(1, "old", now-7200),
+		newMessage(2, "reused", now-7200),
+		newMessage(3, "new", now),
+		// 窗口外相同client_msg_no的新消息覆盖了去重索引
+		newMessage(4, "reused", now),
+	})
+	assert.NoError(t, err)
+
+	count, err := wk.cleanExpiredDedup(0, uint64(now-3600))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = wk.GetDedupMessage("channel", 2, "u1", "old")
+	assert.Equal(t, ErrNotFound, err)
+
+	msg, err := wk.GetDedupMessage("channel", 2, "u1", "reused")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(4), msg.MessageSeq)
+
+	_, err = wk.GetDedupMessage("channel", 2, "u1", "new")
+	assert.NoError(t, err)
+
+	// 过期索引已清理
+	count, err = wk.cleanExpiredDedup(0, uint64(now-3600))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, closer, err := wk.shardDBById(0).Get(key.NewMessageDedupExpireKey(uint64(now), "channel", 2, "u1", "new"))
+	assert.NoError(t, err)
+	closer.Close()
+}
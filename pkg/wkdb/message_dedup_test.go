@@ -0,0 +1,83 @@
+package wkdb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDedupMessage(t *testing.T) {
+	d := newTestDB(t, wkdb.WithDedupWindow(time.Hour))
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	channelId := "channel"
+	channelType := uint8(2)
+
+	err = d.AppendMessages(channelId, channelType, []wkdb.Message{
+		{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   100,
+				MessageSeq:  1,
+				ClientMsgNo: "no1",
+				FromUID:     "u1",
+				ChannelID:   channelId,
+				ChannelType: channelType,
+				Payload:     []byte("hello"),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	msg, err := d.GetDedupMessage(channelId, channelType, "u1", "no1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), msg.MessageID)
+	assert.Equal(t, uint32(1), msg.MessageSeq)
+
+	// 不同的发送者
+	_, err = d.GetDedupMessage(channelId, channelType, "u2", "no1")
+	assert.Equal(t, wkdb.ErrNotFound, err)
+
+	// 消息被截断后不再视为重复
+	err = d.TruncateLogTo(channelId, channelType, 1)
+	assert.NoError(t, err)
+	_, err = d.GetDedupMessage(channelId, channelType, "u1", "no1")
+	assert.Equal(t, wkdb.ErrNotFound, err)
+}
+
+func TestGetDedupMessageWithoutWindow(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	err = d.AppendMessages("channel", 2, []wkdb.Message{
+		{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   100,
+				MessageSeq:  1,
+				ClientMsgNo: "no1",
+				FromUID:     "u1",
+				ChannelID:   "channel",
+				ChannelType: 2,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	// 没有开启去重不写去重索引
+	_, err = d.GetDedupMessage("channel", 2, "u1", "no1")
+	assert.Equal(t, wkdb.ErrNotFound, err)
+}
@@ -0,0 +1,94 @@
+package wkdb
+
+import (
+	"math"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/WuKongIM/WuKongIM/pkg/wkutil"
+	"github.com/cockroachdb/pebble"
+	"go.uber.org/zap"
+)
+
+// GetFederationQueueChannels 获取联邦同步队列里有待同步消息的频道
+func (wk *wukongDB) GetFederationQueueChannels() ([]Channel, error) {
+	var channels []Channel
+	for i := range wk.dbs {
+		iter := wk.shardDBById(uint32(i)).NewIter(&pebble.IterOptions{
+			LowerBound: key.NewMessageFederationQueueKeyWithChannelNum(0, 0),
+			UpperBound: key.NewMessageFederationQueueKeyWithChannelNum(math.MaxUint64, math.MaxUint64),
+		})
+		for valid := iter.First(); valid; {
+			channelNum, _, err := key.ParseMessageFederationQueueKey(iter.Key())
+			if err != nil {
+				iter.Close()
+				return nil, err
+			}
+			channelId, channelType := wkutil.ChannelFromlKey(string(iter.Value()))
+			channels = append(channels, Channel{
+				ChannelId:   channelId,
+				ChannelType: channelType,
+			})
+			if channelNum == math.MaxUint64 {
+				break
+			}
+			// 跳到下一个频道
+			valid = iter.SeekGE(key.NewMessageFederationQueueKeyWithChannelNum(channelNum+1, 0))
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// GetMessagesOfFederationQueue 按消息序号顺序获取频道联邦同步队列里大于startMessageSeq的消息（最多count条）
+func (wk *wukongDB) GetMessagesOfFederationQueue(channelId string, channelType uint8, startMessageSeq uint64, count int) ([]Message, error) {
+
+	iter := wk.channelDb(channelId, channelType).NewIter(&pebble.IterOptions{
+		LowerBound: key.NewMessageFederationQueueKey(channelId, channelType, startMessageSeq+1),
+		UpperBound: key.NewMessageFederationQueueKey(channelId, channelType, math.MaxUint64),
+	})
+	defer iter.Close()
+
+	channelKey := wkutil.ChannelToKey(channelId, channelType)
+	msgs := make([]Message, 0, count)
+	for iter.First(); iter.Valid() && len(msgs) < count; iter.Next() {
+		if string(iter.Value()) != channelKey { // hash冲突的其他频道
+			continue
+		}
+		_, messageSeq, err := key.ParseMessageFederationQueueKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		msg, err := wk.LoadMsg(channelId, channelType, messageSeq)
+		if err != nil {
+			if err == ErrNotFound { // 消息已被截断
+				wk.Warn("federation queue message not found", zap.String("channelId", channelId), zap.Uint8("channelType", channelType), zap.Uint64("messageSeq", messageSeq))
+				continue
+			}
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, iter.Error()
+}
+
+// RemoveMessagesOfFederationQueue 移除频道联邦同步队列里小于等于endMessageSeq的消息
+func (wk *wukongDB) RemoveMessagesOfFederationQueue(channelId string, channelType uint8, endMessageSeq uint64) error {
+	batch := wk.channelBatchDb(channelId, channelType).NewBatch()
+	batch.DeleteRange(key.NewMessageFederationQueueKey(channelId, channelType, 0), key.NewMessageFederationQueueKey(channelId, channelType, endMessageSeq+1))
+	return batch.CommitWait()
+}
+
+// 消息需要联邦同步时与消息在同一个batch里写入联邦同步队列
+func (wk *wukongDB) writeFederationQueue(channelId string, channelType uint8, msg Message, w *Batch) {
+	if wk.opts.IsFederationMessage == nil || !wk.opts.IsFederationMessage(channelId, channelType, msg.ClientMsgNo) {
+		return
+	}
+	w.Set(key.NewMessageFederationQueueKey(channelId, channelType, uint64(msg.MessageSeq)), []byte(wkutil.ChannelToKey(channelId, channelType)))
+}
+
+// 删除从messageSeq（包含）开始的联邦同步队列
+func (wk *wukongDB) deleteFederationQueueFrom(channelId string, channelType uint8, messageSeq uint64, w *Batch) {
+	w.DeleteRange(key.NewMessageFederationQueueKey(channelId, channelType, messageSeq), key.NewMessageFederationQueueKey(channelId, channelType, math.MaxUint64))
+}
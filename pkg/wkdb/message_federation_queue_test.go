@@ -0,0 +1,91 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	wkproto "github.com/WuKongIM/WuKongIMGoProto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageFederationQueue(t *testing.T) {
+	d := newTestDB(t, wkdb.WithIsFederationMessage(func(channelId string, channelType uint8, clientMsgNo string) bool {
+		return channelId != "local" && clientMsgNo != "from-peer"
+	}))
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	newMessage := func(channelId string, messageSeq uint32, clientMsgNo string) wkdb.Message {
+		return wkdb.Message{
+			RecvPacket: wkproto.RecvPacket{
+				MessageID:   int64(messageSeq) + 100,
+				MessageSeq:  messageSeq,
+				ClientMsgNo: clientMsgNo,
+				ChannelID:   channelId,
+				ChannelType: 2,
+				FromUID:     "from",
+				Payload:     []byte("content"),
+			},
+		}
+	}
+
+	// 消息写入时同时写入所在频道的联邦同步队列，对端同步过来的消息和非联邦频道不写入
+	err = d.AppendMessages("channel1", 2, []wkdb.Message{
+		newMessage("channel1", 1, "c1"),
+		newMessage("channel1", 2, "from-peer"),
+		newMessage("channel1", 3, "c3"),
+		newMessage("channel1", 4, "c4"),
+	})
+	assert.NoError(t, err)
+	err = d.AppendMessages("channel2", 2, []wkdb.Message{newMessage("channel2", 1, "c1")})
+	assert.NoError(t, err)
+	err = d.AppendMessages("local", 2, []wkdb.Message{newMessage("local", 1, "c1")})
+	assert.NoError(t, err)
+
+	channels, err := d.GetFederationQueueChannels()
+	assert.NoError(t, err)
+	assert.Len(t, channels, 2)
+
+	msgs, err := d.GetMessagesOfFederationQueue("channel1", 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, uint32(1), msgs[0].MessageSeq)
+	assert.Equal(t, uint32(3), msgs[1].MessageSeq)
+	assert.Equal(t, uint32(4), msgs[2].MessageSeq)
+
+	msgs, err = d.GetMessagesOfFederationQueue("channel1", 2, 1, 1)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, uint32(3), msgs[0].MessageSeq)
+
+	// 移除不影响其他频道
+	err = d.RemoveMessagesOfFederationQueue("channel1", 2, 3)
+	assert.NoError(t, err)
+
+	msgs, err = d.GetMessagesOfFederationQueue("channel1", 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, uint32(4), msgs[0].MessageSeq)
+
+	msgs, err = d.GetMessagesOfFederationQueue("channel2", 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 1)
+
+	// 截断日志同时截断队列
+	err = d.TruncateLogTo("channel1", 2, 3)
+	assert.NoError(t, err)
+
+	msgs, err = d.GetMessagesOfFederationQueue("channel1", 2, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, msgs, 0)
+
+	channels, err = d.GetFederationQueueChannels()
+	assert.NoError(t, err)
+	assert.Len(t, channels, 1)
+	assert.Equal(t, "channel2", channels[0].ChannelId)
+}
@@ -210,3 +210,107 @@ func TestSearchMessages(t *testing.T) {
 	assert.Equal(t, 10, len(resultMessages))
 
 }
+
+func TestSearchMessagesDirection(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	messages := []wkdb.Message{}
+
+	channelId := "channel"
+	channelType := uint8(2)
+
+	num := 10
+
+	for i := 0; i < num; i++ {
+		messages = append(messages, wkdb.Message{
+			RecvPacket: wkproto.RecvPacket{
+				Framer: wkproto.Framer{
+					RedDot: true,
+				},
+				ChannelID:   channelId,
+				ChannelType: channelType,
+				MessageID:   int64(i + 1),
+				MessageSeq:  uint32(i + 1),
+				Payload:     []byte("hello"),
+			},
+			Term: 1,
+		})
+	}
+
+	err = d.AppendMessages(channelId, channelType, messages)
+	assert.NoError(t, err)
+
+	// 第一个遍历到的key（正序是第一条消息的header，倒序是最后一条消息的最后一列）不能丢
+	assertComplete := func(msgs []wkdb.Message) {
+		for _, m := range msgs {
+			assert.True(t, m.RedDot)
+			assert.Equal(t, uint64(1), m.Term)
+			assert.Equal(t, []byte("hello"), m.Payload)
+		}
+	}
+
+	// 倒序分页
+	resultMessages, err := d.SearchMessages(wkdb.MessageSearchReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		Limit:       3,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resultMessages, 3)
+	assert.Equal(t, uint32(10), resultMessages[0].MessageSeq)
+	assert.Equal(t, uint32(8), resultMessages[2].MessageSeq)
+	assertComplete(resultMessages)
+
+	resultMessages, err = d.SearchMessages(wkdb.MessageSearchReq{
+		ChannelId:        channelId,
+		ChannelType:      channelType,
+		OffsetMessageSeq: 8,
+		Limit:            3,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resultMessages, 3)
+	assert.Equal(t, uint32(7), resultMessages[0].MessageSeq)
+	assert.Equal(t, uint32(5), resultMessages[2].MessageSeq)
+	assertComplete(resultMessages)
+
+	// 正序分页
+	resultMessages, err = d.SearchMessages(wkdb.MessageSearchReq{
+		ChannelId:   channelId,
+		ChannelType: channelType,
+		Pre:         true,
+		Limit:       3,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resultMessages, 3)
+	assert.Equal(t, uint32(1), resultMessages[0].MessageSeq)
+	assert.Equal(t, uint32(3), resultMessages[2].MessageSeq)
+	assertComplete(resultMessages)
+
+	resultMessages, err = d.SearchMessages(wkdb.MessageSearchReq{
+		ChannelId:        channelId,
+		ChannelType:      channelType,
+		OffsetMessageSeq: 8,
+		Pre:              true,
+		Limit:            3,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resultMessages, 2)
+	assert.Equal(t, uint32(9), resultMessages[0].MessageSeq)
+	assert.Equal(t, uint32(10), resultMessages[1].MessageSeq)
+	assertComplete(resultMessages)
+
+	// 按数量限制加载
+	resultMessages, err = d.LoadNextRangeMsgs(channelId, channelType, 1, 0, 4)
+	assert.NoError(t, err)
+	assert.Len(t, resultMessages, 4)
+	assert.Equal(t, uint32(1), resultMessages[0].MessageSeq)
+	assert.Equal(t, uint32(4), resultMessages[3].MessageSeq)
+	assertComplete(resultMessages)
+}
@@ -119,6 +119,12 @@ type Device struct {
 	RecvMsgBytes uint64     `json:"recv_msg_bytes,omitempty"` // 接收消息字节数
 	CreatedAt    *time.Time `json:"created_at,omitempty"`     // 创建时间
 	UpdatedAt    *time.Time `json:"updated_at,omitempty"`     // 更新时间
+
+	// 最后一次登录信息（只通过UpdateDeviceLogin更新）
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"` // 最后一次登录时间
+	LastLoginIp string     `json:"last_login_ip,omitempty"` // 最后一次登录IP
+	DeviceId    string     `json:"device_id,omitempty"`     // 最后一次登录的客户端设备ID
+	Version     uint8      `json:"version,omitempty"`       // 最后一次登录的客户端协议版本
 }
 
 var EmptyUser = User{}
@@ -158,6 +164,38 @@ type ChannelInfo struct {
 	Webhook         string     `json:"webhook,omitempty"`          // webhook地址
 	CreatedAt       *time.Time `json:"created_at,omitempty"`       // 创建时间
 	UpdatedAt       *time.Time `json:"updated_at,omitempty"`       // 更新时间
+
+	// 父频道（例如社区群里的话题子频道），为空表示没有父频道
+	ParentChannelId   string `json:"parent_channel_id,omitempty"`   // 父频道ID
+	ParentChannelType uint8  `json:"parent_channel_type,omitempty"` // 父频道类型
+	Inherit           bool   `json:"inherit,omitempty"`             // 是否继承父频道的订阅者和权限（黑白名单、封禁、解散）
+
+	HistoryVisibility uint8  `json:"history_visibility,omitempty"` // 新成员的历史消息可见策略 见HistoryVisibilityXXX
+	HistoryCount      uint32 `json:"history_count,omitempty"`      // HistoryVisibilityLastN时，新成员可见的加入前消息数量
+}
+
+// 新成员的历史消息可见策略
+const (
+	// HistoryVisibilityAll 可见全部历史消息
+	HistoryVisibilityAll uint8 = iota
+	// HistoryVisibilityJoined 只可见加入之后的消息
+	HistoryVisibilityJoined
+	// HistoryVisibilityLastN 可见加入之前的最近N条消息
+	HistoryVisibilityLastN
+)
+
+// VisibleStartMsgSeq 订阅者可见的起始消息序号（包含），0表示全部可见
+func (c ChannelInfo) VisibleStartMsgSeq(member Member) uint64 {
+	switch c.HistoryVisibility {
+	case HistoryVisibilityJoined:
+		return member.JoinMsgSeq + 1
+	case HistoryVisibilityLastN:
+		if member.JoinMsgSeq <= uint64(c.HistoryCount) {
+			return 0
+		}
+		return member.JoinMsgSeq - uint64(c.HistoryCount) + 1
+	}
+	return 0
 }
 
 func NewChannelInfo(channelId string, channelType uint8) ChannelInfo {
@@ -321,6 +359,14 @@ type Conversation struct {
 	UnreadCount  uint32           `json:"unread_count,omitempty"`      // 未读消息数量（这个可以用户自己设置）
 	ReadToMsgSeq uint64           `json:"readed_to_msg_seq,omitempty"` // 已经读至的消息序号
 
+	// 会话设置（由用户自己设置，多端同步）
+	Pinned           bool       `json:"pinned,omitempty"`             // 是否置顶
+	Mute             bool       `json:"mute,omitempty"`               // 是否免打扰
+	Archived         bool       `json:"archived,omitempty"`           // 是否归档
+	Folder           string     `json:"folder,omitempty"`             // 自定义分组
+	Draft            string     `json:"draft,omitempty"`              // 草稿
+	SettingUpdatedAt *time.Time `json:"setting_updated_at,omitempty"` // 会话设置更新时间，为空则表示本次更新不修改会话设置
+
 	CreatedAt *time.Time `json:"created_at,omitempty"` // 创建时间
 	UpdatedAt *time.Time `json:"updated_at,omitempty"` // 更新时间
 }
@@ -348,6 +394,18 @@ func (c *Conversation) Marshal() ([]byte, error) {
 		enc.WriteUint64(0)
 	}
 
+	// 会话设置
+	enc.WriteUint8(wkutil.BoolToUint8(c.Pinned))
+	enc.WriteUint8(wkutil.BoolToUint8(c.Mute))
+	enc.WriteUint8(wkutil.BoolToUint8(c.Archived))
+	enc.WriteString(c.Folder)
+	enc.WriteString(c.Draft)
+	if c.SettingUpdatedAt != nil {
+		enc.WriteUint64(uint64(c.SettingUpdatedAt.UnixNano()))
+	} else {
+		enc.WriteUint64(0)
+	}
+
 	return enc.Bytes(), nil
 }
 
@@ -401,6 +459,41 @@ func (c *Conversation) Unmarshal(data []byte) error {
 		c.UpdatedAt = &ct
 	}
 
+	// 兼容旧版本数据（没有会话设置）
+	if dec.Len() == 0 {
+		return nil
+	}
+
+	var pinned, mute, archived uint8
+	if pinned, err = dec.Uint8(); err != nil {
+		return err
+	}
+	c.Pinned = wkutil.Uint8ToBool(pinned)
+	if mute, err = dec.Uint8(); err != nil {
+		return err
+	}
+	c.Mute = wkutil.Uint8ToBool(mute)
+	if archived, err = dec.Uint8(); err != nil {
+		return err
+	}
+	c.Archived = wkutil.Uint8ToBool(archived)
+
+	if c.Folder, err = dec.String(); err != nil {
+		return err
+	}
+	if c.Draft, err = dec.String(); err != nil {
+		return err
+	}
+
+	var settingUpdatedAt uint64
+	if settingUpdatedAt, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if settingUpdatedAt > 0 {
+		ct := time.Unix(int64(settingUpdatedAt/1e9), int64(settingUpdatedAt%1e9))
+		c.SettingUpdatedAt = &ct
+	}
+
 	return nil
 }
 
@@ -472,6 +565,7 @@ type ChannelClusterConfig struct {
 	ConfVersion     uint64               `json:"conf_version,omitempty"`      // 配置文件版本号
 	CreatedAt       *time.Time           `json:"created_at,omitempty"`        // 创建时间
 	UpdatedAt       *time.Time           `json:"updated_at,omitempty"`        // 更新时间
+	PreferredLeader uint64               `json:"preferred_leader,omitempty"`  // 优先的领导节点（选举时日志同样新的情况下优先选它，为0表示不指定）
 
 	version uint16 // 数据协议版本
 }
@@ -493,6 +587,7 @@ func (c *ChannelClusterConfig) Clone() ChannelClusterConfig {
 		version:         c.version,
 		CreatedAt:       c.CreatedAt,
 		UpdatedAt:       c.UpdatedAt,
+		PreferredLeader: c.PreferredLeader,
 	}
 }
 
@@ -543,6 +638,9 @@ func (c *ChannelClusterConfig) Equal(cfg ChannelClusterConfig) bool {
 	if c.ConfVersion != cfg.ConfVersion {
 		return false
 	}
+	if c.PreferredLeader != cfg.PreferredLeader {
+		return false
+	}
 	return true
 }
 
@@ -582,6 +680,7 @@ func (c *ChannelClusterConfig) Marshal() ([]byte, error) {
 	} else {
 		enc.WriteUint64(0)
 	}
+	enc.WriteUint64(c.PreferredLeader)
 	return enc.Bytes(), nil
 }
 
@@ -668,12 +767,19 @@ func (c *ChannelClusterConfig) Unmarshal(data []byte) error {
 		c.UpdatedAt = &ct
 	}
 
+	// 旧版本数据没有优先领导
+	if dec.Len() > 0 {
+		if c.PreferredLeader, err = dec.Uint64(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (c *ChannelClusterConfig) String() string {
-	return fmt.Sprintf("ChannelId: %s, ChannelType: %d, ReplicaMaxCount: %d, Replicas: %v, Learners: %v MigrateFrom: %d MigrateTo: %d LeaderId: %d, Term: %d",
-		c.ChannelId, c.ChannelType, c.ReplicaMaxCount, c.Replicas, c.Learners, c.MigrateFrom, c.MigrateTo, c.LeaderId, c.Term)
+	return fmt.Sprintf("ChannelId: %s, ChannelType: %d, ReplicaMaxCount: %d, Replicas: %v, Learners: %v MigrateFrom: %d MigrateTo: %d LeaderId: %d, Term: %d, PreferredLeader: %d",
+		c.ChannelId, c.ChannelType, c.ReplicaMaxCount, c.Replicas, c.Learners, c.MigrateFrom, c.MigrateTo, c.LeaderId, c.Term, c.PreferredLeader)
 }
 
 // 批量更新会话
@@ -757,11 +863,21 @@ type Channel struct {
 	ChannelType uint8  `json:"channel_type,omitempty"`
 }
 
+// ChannelChild 子频道
+type ChannelChild struct {
+	ChannelId   string `json:"channel_id,omitempty"`
+	ChannelType uint8  `json:"channel_type,omitempty"`
+	Inherit     bool   `json:"inherit,omitempty"` // 是否继承父频道的订阅者和权限
+}
+
+var EmptyMember = Member{}
+
 type Member struct {
-	Id        uint64     `json:"id"`
-	Uid       string     `json:"uid"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Id         uint64     `json:"id"`
+	Uid        string     `json:"uid"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	JoinMsgSeq uint64     `json:"join_msg_seq,omitempty"` // 加入频道时频道的最新消息序号
 
 	version uint16 // 数据版本
 }
@@ -784,6 +900,7 @@ func (m *Member) Marshal() ([]byte, error) {
 	} else {
 		enc.WriteUint64(0)
 	}
+	enc.WriteUint64(m.JoinMsgSeq)
 	return enc.Bytes(), nil
 }
 
@@ -817,6 +934,12 @@ func (m *Member) Unmarshal(data []byte) error {
 		ct := time.Unix(int64(updatedAt/1e9), int64(updatedAt%1e9))
 		m.UpdatedAt = &ct
 	}
+	// 旧版本数据没有加入时的消息序号
+	if dec.Len() > 0 {
+		if m.JoinMsgSeq, err = dec.Uint64(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -851,3 +974,157 @@ func (t *Tester) Unmarshal(data []byte) error {
 	}
 	return nil
 }
+
+// EncryptionStatus 消息payload静态加密状态
+type EncryptionStatus struct {
+	On               bool                 // 是否开启加密
+	Shards           []ShardDataKeyStatus // 每个分区的数据密钥状态
+	Reencrypting     bool                 // 是否正在重加密
+	ReencryptedCount int64                // 本次（或最近一次）重加密的payload数量
+	ReencryptErr     string               // 最近一次重加密的错误
+	LastReencryptAt  int64                // 最近一次重加密完成时间（纳秒）
+}
+
+type ShardDataKeyStatus struct {
+	ShardId        uint32 // 分区id
+	CurrentVersion uint32 // 当前数据密钥版本
+	KeyCount       int    // 数据密钥数量
+}
+
+// DeviceSyncCursor 用户设备在频道内的同步位置
+type DeviceSyncCursor struct {
+	Uid         string
+	DeviceFlag  uint8
+	ChannelId   string
+	ChannelType uint8
+	MessageSeq  uint64 // 已同步到的消息序号
+}
+
+func (d *DeviceSyncCursor) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(d.Uid)
+	enc.WriteUint8(d.DeviceFlag)
+	enc.WriteString(d.ChannelId)
+	enc.WriteUint8(d.ChannelType)
+	enc.WriteUint64(d.MessageSeq)
+	return enc.Bytes(), nil
+}
+
+func (d *DeviceSyncCursor) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if d.Uid, err = dec.String(); err != nil {
+		return err
+	}
+	if d.DeviceFlag, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if d.ChannelId, err = dec.String(); err != nil {
+		return err
+	}
+	if d.ChannelType, err = dec.Uint8(); err != nil {
+		return err
+	}
+	if d.MessageSeq, err = dec.Uint64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Presence 用户的在线状态（连接之外的自定义状态和最后在线时间）
+type Presence struct {
+	Uid        string
+	State      string // 状态，比如 away、busy，为空表示正常
+	StatusText string // 自定义状态文字
+	Emoji      string // 自定义状态表情
+	LastSeen   int64  // 最后在线时间（秒）
+	UpdatedAt  int64  // 状态的更新时间（秒）
+}
+
+func (p *Presence) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteString(p.Uid)
+	enc.WriteString(p.State)
+	enc.WriteString(p.StatusText)
+	enc.WriteString(p.Emoji)
+	enc.WriteInt64(p.LastSeen)
+	enc.WriteInt64(p.UpdatedAt)
+	return enc.Bytes(), nil
+}
+
+func (p *Presence) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if p.Uid, err = dec.String(); err != nil {
+		return err
+	}
+	if p.State, err = dec.String(); err != nil {
+		return err
+	}
+	if p.StatusText, err = dec.String(); err != nil {
+		return err
+	}
+	if p.Emoji, err = dec.String(); err != nil {
+		return err
+	}
+	if p.LastSeen, err = dec.Int64(); err != nil {
+		return err
+	}
+	if p.UpdatedAt, err = dec.Int64(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AuditEvent 运维审计事件（强制修改副本配置等高危操作的记录）
+type AuditEvent struct {
+	Id        uint64 // 事件id（按时间递增）
+	Action    string // 操作，例如 slot_force_reconfig
+	Target    string // 操作对象，例如 slot:1
+	Operator  string // 操作者
+	NodeId    uint64 // 执行操作的节点
+	Detail    string // 操作详情（json）
+	CreatedAt int64  // 创建时间（秒）
+}
+
+func (a *AuditEvent) Marshal() ([]byte, error) {
+	enc := wkproto.NewEncoder()
+	defer enc.End()
+	enc.WriteUint64(a.Id)
+	enc.WriteString(a.Action)
+	enc.WriteString(a.Target)
+	enc.WriteString(a.Operator)
+	enc.WriteUint64(a.NodeId)
+	enc.WriteString(a.Detail)
+	enc.WriteInt64(a.CreatedAt)
+	return enc.Bytes(), nil
+}
+
+func (a *AuditEvent) Unmarshal(data []byte) error {
+	dec := wkproto.NewDecoder(data)
+	var err error
+	if a.Id, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if a.Action, err = dec.String(); err != nil {
+		return err
+	}
+	if a.Target, err = dec.String(); err != nil {
+		return err
+	}
+	if a.Operator, err = dec.String(); err != nil {
+		return err
+	}
+	if a.NodeId, err = dec.Uint64(); err != nil {
+		return err
+	}
+	if a.Detail, err = dec.String(); err != nil {
+		return err
+	}
+	if a.CreatedAt, err = dec.Int64(); err != nil {
+		return err
+	}
+	return nil
+}
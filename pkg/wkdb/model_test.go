@@ -2,6 +2,7 @@ package wkdb
 
 import (
 	"testing"
+	"time"
 
 	wkproto "github.com/WuKongIM/WuKongIMGoProto"
 	"github.com/stretchr/testify/assert"
@@ -33,3 +34,36 @@ func TestMessageUnmarshal(t *testing.T) {
 	assert.Equal(t, msg.MessageID, newMsg.MessageID)
 	assert.Equal(t, msg.Term, newMsg.Term)
 }
+
+func TestConversationUnmarshal(t *testing.T) {
+	settingUpdatedAt := time.Now()
+	conversation := &Conversation{
+		Id:               1,
+		Uid:              "u1",
+		ChannelId:        "g1",
+		ChannelType:      2,
+		UnreadCount:      3,
+		ReadToMsgSeq:     10,
+		Pinned:           true,
+		Mute:             true,
+		Archived:         true,
+		Folder:           "work",
+		Draft:            "hello",
+		SettingUpdatedAt: &settingUpdatedAt,
+	}
+
+	data, err := conversation.Marshal()
+	assert.NoError(t, err)
+
+	newConversation := &Conversation{}
+	err = newConversation.Unmarshal(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, conversation.ReadToMsgSeq, newConversation.ReadToMsgSeq)
+	assert.Equal(t, conversation.Pinned, newConversation.Pinned)
+	assert.Equal(t, conversation.Mute, newConversation.Mute)
+	assert.Equal(t, conversation.Archived, newConversation.Archived)
+	assert.Equal(t, conversation.Folder, newConversation.Folder)
+	assert.Equal(t, conversation.Draft, newConversation.Draft)
+	assert.Equal(t, settingUpdatedAt.UnixNano(), newConversation.SettingUpdatedAt.UnixNano())
+}
@@ -1,17 +1,28 @@
 package wkdb
 
+import "time"
+
 type Options struct {
 	NodeId            uint64
 	DataDir           string
 	ConversationLimit int // 最近会话查询数量限制
 	SlotCount         int // 槽位数量
+	// GetSlotId 获取槽id，槽数量可以在运行时调整，设置后不再使用SlotCount计算
+	GetSlotId func(v string) uint32
 	// 耗时配置开启
 	EnableCost   bool
 	ShardNum     int               // 数据库分区数量，一但设置就不能修改
 	IsCmdChannel func(string) bool // 是否是cmd频道
-	MemTableSize int
+	// IsFederationMessage 消息是否需要联邦同步，需要的消息写入时同时写入联邦同步队列
+	IsFederationMessage func(channelId string, channelType uint8, clientMsgNo string) bool
+	MemTableSize        int
+	// DedupWindow 消息去重的时间窗口，大于0时消息写入同时写入去重索引，超过窗口的索引会被定时清理
+	DedupWindow time.Duration
 
 	BatchPerSize int // 每个batch里key的大小
+
+	EncryptionMasterKey    []byte // 消息payload加密的主密钥，为空表示不开启加密
+	EncryptionOldMasterKey []byte // 旧的主密钥，主密钥轮换时配置，启动时会用新的主密钥重新加密数据密钥
 }
 
 func NewOptions(opt ...Option) *Options {
@@ -50,6 +61,12 @@ func WithSlotCount(slotCount int) Option {
 	}
 }
 
+func WithGetSlotId(f func(v string) uint32) Option {
+	return func(o *Options) {
+		o.GetSlotId = f
+	}
+}
+
 func WithConversationLimit(limit int) Option {
 	return func(o *Options) {
 		o.ConversationLimit = limit
@@ -74,8 +91,27 @@ func WithIsCmdChannel(f func(string) bool) Option {
 	}
 }
 
+func WithIsFederationMessage(f func(channelId string, channelType uint8, clientMsgNo string) bool) Option {
+	return func(o *Options) {
+		o.IsFederationMessage = f
+	}
+}
+
+func WithDedupWindow(dedupWindow time.Duration) Option {
+	return func(o *Options) {
+		o.DedupWindow = dedupWindow
+	}
+}
+
 func WithMemTableSize(size int) Option {
 	return func(o *Options) {
 		o.MemTableSize = size
 	}
 }
+
+func WithEncryptionMasterKey(masterKey, oldMasterKey []byte) Option {
+	return func(o *Options) {
+		o.EncryptionMasterKey = masterKey
+		o.EncryptionOldMasterKey = oldMasterKey
+	}
+}
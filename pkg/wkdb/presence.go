@@ -0,0 +1,36 @@
+package wkdb
+
+import (
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb/key"
+	"github.com/cockroachdb/pebble"
+)
+
+func (wk *wukongDB) SetPresence(presence Presence) error {
+	data, err := presence.Marshal()
+	if err != nil {
+		return err
+	}
+	batch := wk.sharedBatchDB(presence.Uid).NewBatch()
+	batch.Set(key.NewPresenceKey(presence.Uid), data)
+	return batch.CommitWait()
+}
+
+func (wk *wukongDB) GetPresence(uid string) (Presence, error) {
+	data, closer, err := wk.shardDB(uid).Get(key.NewPresenceKey(uid))
+	if err != nil {
+		if err == pebble.ErrNotFound {
+			return Presence{Uid: uid}, nil
+		}
+		return Presence{}, err
+	}
+	defer closer.Close()
+
+	var presence Presence
+	if err = presence.Unmarshal(data); err != nil {
+		return Presence{}, err
+	}
+	if presence.Uid != uid { // uid hash冲突
+		return Presence{Uid: uid}, nil
+	}
+	return presence, nil
+}
@@ -0,0 +1,42 @@
+package wkdb_test
+
+import (
+	"testing"
+
+	"github.com/WuKongIM/WuKongIM/pkg/wkdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	t.Run("GetPresenceNotExist", func(t *testing.T) {
+		presence, err := d.GetPresence("u1")
+		assert.NoError(t, err)
+		assert.Equal(t, wkdb.Presence{Uid: "u1"}, presence)
+	})
+
+	t.Run("SetPresence", func(t *testing.T) {
+		presence := wkdb.Presence{
+			Uid:        "u1",
+			State:      "busy",
+			StatusText: "meeting",
+			Emoji:      "📅",
+			LastSeen:   100,
+			UpdatedAt:  200,
+		}
+		err := d.SetPresence(presence)
+		assert.NoError(t, err)
+
+		p, err := d.GetPresence("u1")
+		assert.NoError(t, err)
+		assert.Equal(t, presence, p)
+	})
+}
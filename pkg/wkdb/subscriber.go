@@ -64,6 +64,17 @@ func (wk *wukongDB) GetSubscribers(channelId string, channelType uint8) ([]Membe
 	return members, nil
 }
 
+func (wk *wukongDB) GetSubscriber(channelId string, channelType uint8, uid string) (Member, error) {
+	members, err := wk.getSubscribersByUids(channelId, channelType, []string{uid})
+	if err != nil {
+		return EmptyMember, err
+	}
+	if len(members) == 0 {
+		return EmptyMember, ErrNotFound
+	}
+	return members[0], nil
+}
+
 // 获取订阅者数量
 func (wk *wukongDB) GetSubscriberCount(channelId string, channelType uint8) (int, error) {
 	iter := wk.channelDb(channelId, channelType).NewIter(&pebble.IterOptions{
@@ -277,6 +288,8 @@ func (wk *wukongDB) iterateSubscriber(iter *pebble.Iterator, iterFnc func(member
 				t := time.Unix(tm/1e9, tm%1e9)
 				preMember.UpdatedAt = &t
 			}
+		case key.TableSubscriber.Column.JoinMsgSeq:
+			preMember.JoinMsgSeq = wk.endian.Uint64(iter.Value())
 		}
 		hasData = true
 	}
@@ -294,6 +307,11 @@ func (wk *wukongDB) writeSubscriber(channelId string, channelType uint8, member
 	// uid
 	w.Set(key.NewSubscriberColumnKey(channelId, channelType, member.Id, key.TableSubscriber.Column.Uid), []byte(member.Uid))
 
+	// joinMsgSeq
+	joinMsgSeqBytes := make([]byte, 8)
+	wk.endian.PutUint64(joinMsgSeqBytes, member.JoinMsgSeq)
+	w.Set(key.NewSubscriberColumnKey(channelId, channelType, member.Id, key.TableSubscriber.Column.JoinMsgSeq), joinMsgSeqBytes)
+
 	// uid index
 	idBytes := make([]byte, 8)
 	wk.endian.PutUint64(idBytes, member.Id)
@@ -240,3 +240,43 @@ func TestRemoveAllSubscriber(t *testing.T) {
 
 	assert.Equal(t, 0, len(subscribers2))
 }
+
+func TestGetSubscriberJoinMsgSeq(t *testing.T) {
+	d := newTestDB(t)
+	err := d.Open()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := d.Close()
+		assert.NoError(t, err)
+	}()
+
+	channelId := "channel1"
+	channelType := uint8(2)
+	err = d.AddSubscribers(channelId, channelType, []wkdb.Member{
+		{Uid: "uid1"},
+		{Uid: "uid2", JoinMsgSeq: 100},
+	})
+	assert.NoError(t, err)
+
+	member, err := d.GetSubscriber(channelId, channelType, "uid2")
+	assert.NoError(t, err)
+	assert.Equal(t, "uid2", member.Uid)
+	assert.Equal(t, uint64(100), member.JoinMsgSeq)
+
+	_, err = d.GetSubscriber(channelId, channelType, "uid3")
+	assert.Equal(t, wkdb.ErrNotFound, err)
+
+	channelInfo := wkdb.ChannelInfo{HistoryVisibility: wkdb.HistoryVisibilityAll}
+	assert.Equal(t, uint64(0), channelInfo.VisibleStartMsgSeq(member))
+
+	channelInfo.HistoryVisibility = wkdb.HistoryVisibilityJoined
+	assert.Equal(t, uint64(101), channelInfo.VisibleStartMsgSeq(member))
+
+	channelInfo.HistoryVisibility = wkdb.HistoryVisibilityLastN
+	channelInfo.HistoryCount = 10
+	assert.Equal(t, uint64(91), channelInfo.VisibleStartMsgSeq(member))
+
+	channelInfo.HistoryCount = 200
+	assert.Equal(t, uint64(0), channelInfo.VisibleStartMsgSeq(member))
+}
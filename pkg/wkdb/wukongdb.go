@@ -38,6 +38,8 @@ type wukongDB struct {
 	metrics trace.IDBMetrics
 
 	h hash.Hash32
+
+	crypto *payloadCrypto // payload加密，为nil表示没有开启加密
 }
 
 func NewWukongDB(opts *Options) DB {
@@ -116,6 +118,15 @@ func (wk *wukongDB) Open() error {
 		wk.wkdbs = append(wk.wkdbs, wkdb)
 	}
 
+	// 加载数据密钥
+	if err := wk.openEncryption(); err != nil {
+		return err
+	}
+
+	if wk.opts.DedupWindow > 0 {
+		go wk.cleanDedupLoop()
+	}
+
 	// go wk.collectMetricsLoop()
 
 	return nil
@@ -176,6 +187,9 @@ func (wk *wukongDB) defaultShardBatchDB() *BatchDB {
 }
 
 func (wk *wukongDB) channelSlotId(channelId string) uint32 {
+	if wk.opts.GetSlotId != nil {
+		return wk.opts.GetSlotId(channelId)
+	}
 	return wkutil.GetSlotNum(int(wk.opts.SlotCount), channelId)
 }
 
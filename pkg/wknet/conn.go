@@ -137,6 +137,10 @@ type DefaultConn struct {
 	uid            atomic.String
 	valueMap       sync.Map
 
+	proxyProtoPending bool   // 是否等待解析代理协议头（开启了代理协议并且连接来源可信）
+	proxyProtoBuff    []byte // 还未接收完整的代理协议头
+	proxyProtoAddr    bool   // 远程地址是否来自代理协议
+
 	uptime       atomic.Time
 	lastActivity atomic.Time
 	maxIdle      time.Duration
@@ -164,6 +168,9 @@ func GetDefaultConn(id int64, connFd NetFd, localAddr, remoteAddr net.Addr, eg *
 	defaultConn.lastActivity.Store(time.Now())
 	defaultConn.maxIdle = 0
 	defaultConn.uptime.Store(time.Now())
+	defaultConn.proxyProtoPending = eg.options.ProxyProtocol.On && eg.options.trustedProxy(remoteAddr)
+	defaultConn.proxyProtoBuff = nil
+	defaultConn.proxyProtoAddr = false
 	defaultConn.Log = wklog.NewWKLog(fmt.Sprintf("Conn[[reactor-%d]%d]", reactorSub.idx, id))
 
 	defaultConn.inboundBuffer = eg.eventHandler.OnNewInboundConn(defaultConn, eg)
@@ -218,10 +225,47 @@ func (d *DefaultConn) ReadToInboundBuffer() (int, error) {
 		return 0, fmt.Errorf("inbound buffer overflow, fd: %d buffSize:%d n: %d currentSize: %d maxSize: %d", d.fd, d.inboundBuffer.BoundBufferSize(), n, d.inboundBuffer.BoundBufferSize()+n, d.eg.options.MaxReadBufferSize)
 	}
 	d.KeepLastActivity()
-	_, err = d.inboundBuffer.Write(readBuffer[:n])
+	data, err := d.readProxyProto(readBuffer[:n])
+	if err != nil || len(data) == 0 {
+		return n, err
+	}
+	_, err = d.inboundBuffer.Write(data)
 	return n, err
 }
 
+// 解析连接最开始的代理协议头（PROXY protocol v1/v2），将连接的远程地址设置为客户端的真实地址，返回去掉协议头后的数据
+func (d *DefaultConn) readProxyProto(data []byte) ([]byte, error) {
+	if !d.proxyProtoPending {
+		return data, nil
+	}
+	if len(d.proxyProtoBuff) > 0 {
+		d.proxyProtoBuff = append(d.proxyProtoBuff, data...)
+		data = d.proxyProtoBuff
+	}
+	remoteAddr, size, err := parseProxyProto(data)
+	if err == ErrProxyProtoIncomplete { // 协议头不完整，等待后续数据
+		if len(d.proxyProtoBuff) == 0 {
+			d.proxyProtoBuff = append(make([]byte, 0, len(data)), data...)
+		}
+		return nil, nil
+	}
+	d.proxyProtoPending = false
+	d.proxyProtoBuff = nil
+	if err == ErrNoProxyProtocol { // 没有代理协议头，按原始数据处理
+		return data, nil
+	}
+	if err != nil {
+		d.Warn("failed to parse proxy protocol", zap.Error(err), zap.String("remoteAddr", d.remoteAddr.String()))
+		return nil, err
+	}
+	if tcpAddr, ok := remoteAddr.(*net.TCPAddr); ok {
+		d.Debug("parse proxy protocol success", zap.String("proxyAddr", d.remoteAddr.String()), zap.String("remoteAddr", tcpAddr.String()))
+		d.SetRemoteAddr(tcpAddr)
+		d.proxyProtoAddr = true
+	}
+	return data[size:], nil
+}
+
 func (d *DefaultConn) KeepLastActivity() {
 	d.lastActivity.Store(time.Now())
 }
@@ -647,7 +691,11 @@ func (t *TLSConn) ReadToInboundBuffer() (int, error) {
 	if t.d.eg.options.Event.OnReadBytes != nil {
 		t.d.eg.options.Event.OnReadBytes(n)
 	}
-	_, err = t.tmpInboundBuffer.Write(readBuffer[:n]) // 将tls加密的内容写到tmpInboundBuffer内， tls会从tmpInboundBuffer读取数据（BuffReader接口）
+	data, err := t.d.readProxyProto(readBuffer[:n]) // 代理协议头在tls握手之前
+	if err != nil || len(data) == 0 {
+		return n, err
+	}
+	_, err = t.tmpInboundBuffer.Write(data) // 将tls加密的内容写到tmpInboundBuffer内， tls会从tmpInboundBuffer读取数据（BuffReader接口）
 	if err != nil {
 		return 0, err
 	}
@@ -1,6 +1,7 @@
 package wknet
 
 import (
+	"net"
 	"runtime"
 	"time"
 
@@ -18,6 +19,13 @@ type Options struct {
 	// WsAddr is the listen addr  example: ws://127.0.0.1:5200或 wss://127.0.0.1:5200
 	WsAddr  string
 	WssAddr string // wss addr
+	// WSCompression websocket permessage-deflate(RFC 7692) compression
+	WSCompression struct {
+		On        bool // 是否开启压缩协商
+		Threshold int  // 消息大小达到此值才压缩
+	}
+	// WSTextCodec 创建文本帧协议（子协议 WSProtocolJSON）的编解码器，为nil表示不支持文本帧协议
+	WSTextCodec func() WSTextCodec
 	// WSTlsConfig ws tls config
 	// MaxOpenFiles is the maximum number of open files that the server can
 	MaxOpenFiles int
@@ -36,6 +44,11 @@ type Options struct {
 	SocketSendBuffer int
 	// TCPKeepAlive sets up a duration for (SO_KEEPALIVE) socket option.
 	TCPKeepAlive time.Duration
+	// ProxyProtocol PROXY protocol(v1/v2)配置，开启后从可信来源连接的协议头中解析客户端的真实地址（tcp和websocket都生效）
+	ProxyProtocol struct {
+		On           bool
+		TrustedCIDRs []*net.IPNet // 可信的来源网段（一般是负载均衡的网段），为空表示不信任任何来源
+	}
 
 	Event struct {
 		OnReadBytes  func(n int) // 读到的字节大小
@@ -51,6 +64,13 @@ func NewOptions() *Options {
 		ReadBufferSize:     1024 * 32,
 		MaxWriteBufferSize: 1024 * 1024 * 50,
 		MaxReadBufferSize:  1024 * 1024 * 50,
+		WSCompression: struct {
+			On        bool
+			Threshold int
+		}{
+			On:        false,
+			Threshold: 256,
+		},
 	}
 }
 
@@ -75,6 +95,23 @@ func WithWSSAddr(v string) Option {
 	}
 }
 
+// WithWSCompression 开启websocket的permessage-deflate压缩，threshold为消息压缩的最小大小
+func WithWSCompression(on bool, threshold int) Option {
+	return func(opts *Options) {
+		opts.WSCompression.On = on
+		if threshold > 0 {
+			opts.WSCompression.Threshold = threshold
+		}
+	}
+}
+
+// WithWSTextCodec 设置websocket文本帧协议的编解码器
+func WithWSTextCodec(f func() WSTextCodec) Option {
+	return func(opts *Options) {
+		opts.WSTextCodec = f
+	}
+}
+
 func WithTCPTLSConfig(v *tls.Config) Option {
 	return func(opts *Options) {
 		opts.TCPTLSConfig = v
@@ -122,6 +159,31 @@ func WithTCPKeepAlive(v time.Duration) Option {
 	}
 }
 
+// WithProxyProtocol 开启PROXY protocol(v1/v2)，只解析来自trustedCIDRs的连接，trustedCIDRs为空时不解析任何连接
+func WithProxyProtocol(on bool, trustedCIDRs []*net.IPNet) Option {
+	return func(opts *Options) {
+		opts.ProxyProtocol.On = on
+		opts.ProxyProtocol.TrustedCIDRs = trustedCIDRs
+	}
+}
+
+// 连接来源是否是可信的代理（没有配置可信网段时不信任任何来源，防止客户端伪造来源地址）
+func (o *Options) trustedProxy(addr net.Addr) bool {
+	if len(o.ProxyProtocol.TrustedCIDRs) == 0 {
+		return false
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, cidr := range o.ProxyProtocol.TrustedCIDRs {
+		if cidr.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
 func WithOnReadBytes(f func(n int)) Option {
 	return func(opts *Options) {
 		opts.Event.OnReadBytes = f
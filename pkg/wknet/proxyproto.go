@@ -1,9 +1,8 @@
-package server
+package wknet
 
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
 	"io"
 	"net"
 	"net/netip"
@@ -29,6 +28,9 @@ var (
 	ErrInvalidPortNumber                = errors.New("proxyproto: invalid port number")
 	ErrCantReadLength                   = errors.New("proxyproto: can't read length")
 	ErrInvalidLength                    = errors.New("proxyproto: invalid length")
+	ErrCantReadVersion2Header           = errors.New("proxyproto: can't read version 2 header")
+	ErrUnsupportedVersion2              = errors.New("proxyproto: unsupported version 2 version or command")
+	ErrProxyProtoIncomplete             = errors.New("proxyproto: header is incomplete")
 )
 
 const (
@@ -39,6 +41,9 @@ const (
 	lengthV4     = uint16(12)
 	lengthV6     = uint16(36)
 	lengthUnix   = uint16(216)
+
+	v2CommandLocal = byte(0x0)
+	v2CommandProxy = byte(0x1)
 )
 
 // AddressFamilyAndProtocol represents address family and transport protocol.
@@ -102,43 +107,27 @@ func (ap AddressFamilyAndProtocol) IsUnspec() bool {
 // 	return byte(UNSPEC)
 // }
 
-// 是否是代理协议
-func isProxyProto(buff []byte) bool {
-
-	b1 := buff[:1]
-	if bytes.Equal(b1[:1], SIGV1[:1]) || bytes.Equal(b1[:1], SIGV2[:1]) {
-		return true
-	}
-	return false
-}
-
 // 解析代理协议 如果是代理协议则解析出真实的地址（如果通过反向代理并开启了代理协议，需要从代理协议里获取到连接的真实ip）
+// 数据不完整时返回 ErrProxyProtoIncomplete，不是代理协议时返回 ErrNoProxyProtocol
 func parseProxyProto(buff []byte) (remoteAddr net.Addr, size int, err error) {
 
-	dataLen := len(buff)
-
-	if dataLen <= 5 {
-		return nil, 0, ErrNoProxyProtocol
+	if isSignaturePrefix(buff, SIGV1) || isSignaturePrefix(buff, SIGV2) {
+		return nil, 0, ErrProxyProtoIncomplete
 	}
-
-	b1 := buff[:1]
-
-	if bytes.Equal(b1[:1], SIGV1[:1]) || bytes.Equal(b1[:1], SIGV2[:1]) {
-		signature := buff[:5]
-		if bytes.Equal(signature[:5], SIGV1) {
-			return parseProxyProtoV1(buff)
-		}
-
-		signature = buff[:12]
-		if bytes.Equal(signature[:12], SIGV2) {
-			fmt.Println("proxyproto: proxy protocol v2")
-			return parseProxyProtoV2(buff)
-		}
+	if bytes.HasPrefix(buff, SIGV1) {
+		return parseProxyProtoV1(buff)
+	}
+	if bytes.HasPrefix(buff, SIGV2) {
+		return parseProxyProtoV2(buff)
 	}
-
 	return nil, 0, ErrNoProxyProtocol
 }
 
+// buff是否是签名的前缀（数据还不够判断是否是代理协议）
+func isSignaturePrefix(buff []byte, signature []byte) bool {
+	return len(buff) < len(signature) && bytes.HasPrefix(signature, buff)
+}
+
 func parseProxyProtoV1(data []byte) (remoteAddr net.Addr, size int, err error) {
 	//The header cannot be more than 107 bytes long. Per spec:
 	//
@@ -153,8 +142,15 @@ func parseProxyProtoV1(data []byte) (remoteAddr net.Addr, size int, err error) {
 	// It must also be CRLF terminated, as above. The header does not otherwise
 	// contain a CR or LF byte.
 
-	buffArray := bytes.Split(data, []byte{'\n'})
-	buf := buffArray[0]
+	idx := bytes.IndexByte(data, '\n')
+	if idx < 0 {
+		if len(data) >= 107 {
+			// No delimiter in first 107 bytes
+			return nil, 0, ErrVersion1HeaderTooLong
+		}
+		return nil, 0, ErrProxyProtoIncomplete
+	}
+	buf := data[:idx]
 
 	if len(buf) >= 107 {
 		// No delimiter in first 107 bytes
@@ -187,8 +183,13 @@ func parseProxyProtoV1(data []byte) (remoteAddr net.Addr, size int, err error) {
 		return nil, 0, ErrCantReadAddressFamilyAndProtocol
 	}
 
+	// UNKNOWN 表示代理无法获取到真实地址，忽略剩余内容，保留连接原有地址
+	if transportProtocol == UNSPEC {
+		return nil, len(buf) + 1, nil
+	}
+
 	// Expect 6 tokens only when UNKNOWN is not present.
-	if transportProtocol != UNSPEC && len(tokens) < 6 {
+	if len(tokens) < 6 {
 		return nil, 0, ErrCantReadAddressFamilyAndProtocol
 	}
 
@@ -239,13 +240,28 @@ func parseV1PortNumber(portStr string) (int, error) {
 
 func parseProxyProtoV2(buff []byte) (remoteAddr net.Addr, size int, err error) {
 
+	// 签名(12) + 版本和命令(1) + 地址族和协议(1) + 长度(2)
+	if len(buff) < 16 {
+		return nil, 0, ErrProxyProtoIncomplete
+	}
+
 	decoder := wkproto.NewDecoder(buff)
 
 	// Skip first 12 bytes (signature)
 	_, _ = decoder.Bytes(12)
 
 	// Read the 13th byte, protocol version and command
-	_, _ = decoder.Bytes(1)
+	versionCommand, err := decoder.Bytes(1)
+	if err != nil {
+		return nil, 0, ErrCantReadVersion2Header
+	}
+	if versionCommand[0]>>4 != 0x2 {
+		return nil, 0, ErrUnsupportedVersion2
+	}
+	command := versionCommand[0] & 0x0F
+	if command != v2CommandLocal && command != v2CommandProxy {
+		return nil, 0, ErrUnsupportedVersion2
+	}
 
 	// Read the 14th byte, address family and protocol
 	addrFamilyProto, err := decoder.Bytes(1)
@@ -261,13 +277,17 @@ func parseProxyProtoV2(buff []byte) (remoteAddr net.Addr, size int, err error) {
 		return nil, 0, ErrCantReadLength
 	}
 
-	if !validateProxyProtoLength(addressFamilyAndProtocol, length) {
-		return nil, 0, ErrInvalidLength
+	size = 12 + 1 + 1 + 2 + int(length)
+	if len(buff) < size {
+		return nil, 0, ErrProxyProtoIncomplete
+	}
+
+	// LOCAL 命令是代理自身发起的连接（比如健康检查），保留连接原有地址
+	if command == v2CommandLocal {
+		return nil, size, nil
 	}
 
-	// Return early if the length is zero, which means that
-	// there's no address information and TLVs present for UNSPEC.
-	if length == 0 {
+	if !validateProxyProtoLength(addressFamilyAndProtocol, length) {
 		return nil, 0, ErrInvalidLength
 	}
 
@@ -278,8 +298,6 @@ func parseProxyProtoV2(buff []byte) (remoteAddr net.Addr, size int, err error) {
 
 	payloadReader := io.LimitReader(bytes.NewReader(payload), int64(length)).(*io.LimitedReader)
 
-	size = 12 + 1 + 1 + 2 + int(length)
-
 	if addressFamilyAndProtocol != UNSPEC {
 		if addressFamilyAndProtocol.IsIPv4() {
 			var addr _addr4
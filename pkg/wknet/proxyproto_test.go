@@ -0,0 +1,149 @@
+package wknet
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProxyProtoV1(t *testing.T) {
+	header := "PROXY TCP4 192.168.1.10 10.0.0.1 56324 5100\r\n"
+	data := append([]byte(header), []byte("hello")...)
+
+	remoteAddr, size, err := parseProxyProto(data)
+	assert.NoError(t, err)
+	assert.Equal(t, len(header), size)
+	assert.Equal(t, "192.168.1.10:56324", remoteAddr.String())
+
+	// 数据不完整
+	_, _, err = parseProxyProto([]byte("PRO"))
+	assert.Equal(t, ErrProxyProtoIncomplete, err)
+	_, _, err = parseProxyProto([]byte("PROXY TCP4 192.168"))
+	assert.Equal(t, ErrProxyProtoIncomplete, err)
+
+	// UNKNOWN 保留原有地址
+	remoteAddr, size, err = parseProxyProto([]byte("PROXY UNKNOWN\r\n"))
+	assert.NoError(t, err)
+	assert.Nil(t, remoteAddr)
+	assert.Equal(t, 15, size)
+
+	// 不是代理协议
+	_, _, err = parseProxyProto([]byte("hello"))
+	assert.Equal(t, ErrNoProxyProtocol, err)
+}
+
+func TestParseProxyProtoV2(t *testing.T) {
+	header := proxyProtoV2Header(net.ParseIP("192.168.1.10"), 56324)
+
+	remoteAddr, size, err := parseProxyProto(append(header, []byte("hello")...))
+	assert.NoError(t, err)
+	assert.Equal(t, len(header), size)
+	assert.Equal(t, "192.168.1.10:56324", remoteAddr.String())
+
+	// 数据不完整
+	_, _, err = parseProxyProto(header[:10])
+	assert.Equal(t, ErrProxyProtoIncomplete, err)
+	_, _, err = parseProxyProto(header[:20])
+	assert.Equal(t, ErrProxyProtoIncomplete, err)
+
+	// LOCAL 命令保留原有地址
+	local := append([]byte{}, SIGV2...)
+	local = append(local, 0x20, byte(UNSPEC), 0, 0)
+	remoteAddr, size, err = parseProxyProto(local)
+	assert.NoError(t, err)
+	assert.Nil(t, remoteAddr)
+	assert.Equal(t, 16, size)
+}
+
+func TestProxyProtoConn(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("127.0.0.0/8")
+	e := NewEngine(WithAddr("tcp://127.0.0.1:0"), WithProxyProtocol(true, []*net.IPNet{trusted}))
+	err := e.Start()
+	assert.NoError(t, err)
+	defer e.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	e.OnData(func(conn Conn) error {
+		buff, err := conn.Peek(-1)
+		assert.NoError(t, err)
+		if len(buff) > 0 {
+			assert.Equal(t, "hello", string(buff))
+			assert.Equal(t, "192.168.1.10:56324", conn.RemoteAddr().String())
+			wg.Done()
+		}
+		return nil
+	})
+
+	conn, err := net.Dial("tcp", e.TCPRealListenAddr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// 协议头分多次发送
+	header := proxyProtoV2Header(net.ParseIP("192.168.1.10"), 56324)
+	_, err = conn.Write(header[:10])
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond * 50)
+	_, err = conn.Write(append(header[10:], []byte("hello")...))
+	assert.NoError(t, err)
+
+	wg.Wait()
+}
+
+func TestProxyProtoUntrustedConn(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	e := NewEngine(WithAddr("tcp://127.0.0.1:0"), WithProxyProtocol(true, []*net.IPNet{trusted}))
+	err := e.Start()
+	assert.NoError(t, err)
+	defer e.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	e.OnData(func(conn Conn) error {
+		buff, err := conn.Peek(-1)
+		assert.NoError(t, err)
+		if len(buff) > 0 {
+			// 不可信的来源不解析代理协议头
+			assert.Equal(t, "127.0.0.1", conn.RemoteAddr().(*net.TCPAddr).IP.String())
+			wg.Done()
+		}
+		return nil
+	})
+
+	conn, err := net.Dial("tcp", e.TCPRealListenAddr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("PROXY TCP4 192.168.1.10 10.0.0.1 56324 5100\r\nhello"))
+	assert.NoError(t, err)
+
+	wg.Wait()
+}
+
+func TestProxyProtoTrustedProxy(t *testing.T) {
+	opts := NewOptions()
+	addr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5100}
+
+	// 没有配置可信网段时不信任任何来源
+	assert.False(t, opts.trustedProxy(addr))
+
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	opts.ProxyProtocol.TrustedCIDRs = []*net.IPNet{trusted}
+	assert.True(t, opts.trustedProxy(addr))
+	assert.False(t, opts.trustedProxy(&net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 5100}))
+}
+
+func proxyProtoV2Header(ip net.IP, port uint16) []byte {
+	header := append([]byte{}, SIGV2...)
+	header = append(header, 0x21, byte(TCPv4))
+	header = binary.BigEndian.AppendUint16(header, lengthV4)
+	header = append(header, ip.To4()...)
+	header = append(header, 10, 0, 0, 1)
+	header = binary.BigEndian.AppendUint16(header, port)
+	header = binary.BigEndian.AppendUint16(header, 5100)
+	return header
+}
@@ -33,6 +33,7 @@ func CreateWSSConn(id int64, connFd NetFd, localAddr, remoteAddr net.Addr, eg *E
 type WSConn struct {
 	*DefaultConn
 	upgraded         bool
+	negotiated       wsNegotiated  // 握手协商的压缩扩展和子协议
 	tmpInboundBuffer InboundBuffer // inboundBuffer InboundBuffer
 }
 
@@ -53,7 +54,11 @@ func (w *WSConn) ReadToInboundBuffer() (int, error) {
 	if w.eg.options.Event.OnReadBytes != nil {
 		w.eg.options.Event.OnReadBytes(n)
 	}
-	_, err = w.tmpInboundBuffer.Write(readBuffer[:n])
+	data, err := w.readProxyProto(readBuffer[:n]) // 代理协议头在websocket握手之前
+	if err != nil || len(data) == 0 {
+		return n, err
+	}
+	_, err = w.tmpInboundBuffer.Write(data)
 	if err != nil {
 		return 0, err
 	}
@@ -64,10 +69,11 @@ func (w *WSConn) ReadToInboundBuffer() (int, error) {
 	return n, err
 }
 
+// WriteServerBinary 写入二进制协议数据（文本帧协议的连接会转换为文本帧）
 func (w *WSConn) WriteServerBinary(data []byte) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return wsutil.WriteServerBinary(w.outboundBuffer, data)
+	return w.negotiated.writeServerMessage(w.outboundBuffer, data, w.eg.options.WSCompression.Threshold)
 }
 
 // 解包ws的数据
@@ -94,7 +100,11 @@ func (w *WSConn) unpacketWSData() error {
 				}
 				continue
 			}
-			_, err = w.inboundBuffer.Write(msg.Payload)
+			payload, err := w.negotiated.decodePayload(msg)
+			if err != nil {
+				return err
+			}
+			_, err = w.inboundBuffer.Write(payload)
 			if err != nil {
 				return err
 			}
@@ -112,40 +122,16 @@ func (w *WSConn) decode() ([]wsutil.Message, error) {
 		w.Debug("数据不完整", zap.Int("len", len(buff)))
 		return nil, nil
 	}
-	tmpReader := bytes.NewReader(buff)
-	header, err := ws.ReadHeader(tmpReader)
+	messages, n, err := readClientMessages(buff, w.negotiated.compressed, w.eg.options.MaxReadBufferSize)
 	if err != nil {
-		if err == io.EOF || err == io.ErrUnexpectedEOF { //数据不完整
-			return nil, nil
-		}
 		w.Debug("发送错误，丢弃数据", zap.Error(err))
 		w.DiscardFromTemp(len(buff)) // 发送错误，丢弃数据
 		return nil, err
 	}
-	dataLen := header.Length
-	if dataLen > int64(tmpReader.Len()) { // 数据不完整
-		w.Debug("数据不完整", zap.Int64("dataLen", dataLen), zap.Int64("tmpReader.Len()", int64(tmpReader.Len())))
-		return nil, nil
-	}
-
-	if header.Fin { // 当前 frame 已经是最后一个frame
-		var messages []wsutil.Message
-		tmpReader.Reset(buff)
-		remLen := tmpReader.Len()
-		for tmpReader.Len() > 0 {
-			messages, err = wsutil.ReadClientMessage(tmpReader, messages)
-			if err != nil {
-				w.Warn("read client message error", zap.Error(err))
-				break
-			}
-		}
-		remLen = remLen - tmpReader.Len()
-		w.DiscardFromTemp(remLen)
-		return messages, nil
-	} else {
-		w.Debug("ws header not is fin", zap.Int("len", len(buff)))
+	if n > 0 {
+		w.DiscardFromTemp(n)
 	}
-	return nil, nil
+	return messages, nil
 }
 
 func (w *WSConn) upgrade() error {
@@ -155,7 +141,7 @@ func (w *WSConn) upgrade() error {
 	}
 	tmpReader := bytes.NewReader(buff)
 	tmpWriter := bytes.NewBuffer(nil)
-	_, err = ws.Upgrade(&readWrite{
+	err = w.negotiated.upgrade(w.eg, &readWrite{
 		Reader: tmpReader,
 		Writer: tmpWriter,
 	})
@@ -175,7 +161,7 @@ func (w *WSConn) upgrade() error {
 
 	realIp := w.getRealIp(req) // 获取真实ip
 	realPortStr := req.Header.Get("X-Real-Port")
-	if strings.TrimSpace(realIp) != "" {
+	if strings.TrimSpace(realIp) != "" && !w.proxyProtoAddr { // 已经通过代理协议获取到真实地址的不再信任http头
 		realPort := 0
 		if strings.TrimSpace(realPortStr) != "" {
 			realPort = wkutil.ParseInt(realPortStr)
@@ -256,7 +242,8 @@ type readWrite struct {
 
 type WSSConn struct {
 	*TLSConn
-	upgraded bool
+	upgraded   bool
+	negotiated wsNegotiated // 握手协商的压缩扩展和子协议
 
 	wsTmpInboundBuffer InboundBuffer // inboundBuffer InboundBuffer
 }
@@ -278,7 +265,11 @@ func (w *WSSConn) ReadToInboundBuffer() (int, error) {
 		w.d.eg.options.Event.OnReadBytes(n)
 	}
 
-	_, err = w.tmpInboundBuffer.Write(readBuffer[:n])
+	data, err := w.d.readProxyProto(readBuffer[:n]) // 代理协议头在tls握手之前
+	if err != nil || len(data) == 0 {
+		return n, err
+	}
+	_, err = w.tmpInboundBuffer.Write(data)
 	if err != nil {
 		return 0, err
 	}
@@ -340,7 +331,7 @@ func (w *WSSConn) upgrade() error {
 
 	tmpReader := bytes.NewReader(buff)
 	tmpWriter := bytes.NewBuffer(nil)
-	_, err = ws.Upgrade(&readWrite{
+	err = w.negotiated.upgrade(w.d.eg, &readWrite{
 		Reader: tmpReader,
 		Writer: tmpWriter,
 	})
@@ -386,7 +377,11 @@ func (w *WSSConn) unpacketWSData() error {
 				}
 				continue
 			}
-			_, err = w.d.inboundBuffer.Write(msg.Payload)
+			payload, err := w.negotiated.decodePayload(msg)
+			if err != nil {
+				return err
+			}
+			_, err = w.d.inboundBuffer.Write(payload)
 			if err != nil {
 				return err
 			}
@@ -401,10 +396,11 @@ func (w *WSSConn) Close() error {
 	return w.TLSConn.Close()
 }
 
+// WriteServerBinary 写入二进制协议数据（文本帧协议的连接会转换为文本帧）
 func (w *WSSConn) WriteServerBinary(data []byte) error {
 	w.d.mu.Lock()
 	defer w.d.mu.Unlock()
-	return wsutil.WriteServerBinary(w.TLSConn, data)
+	return w.negotiated.writeServerMessage(w.TLSConn, data, w.d.eg.options.WSCompression.Threshold)
 }
 
 func (w *WSSConn) decode() ([]wsutil.Message, error) {
@@ -416,38 +412,14 @@ func (w *WSSConn) decode() ([]wsutil.Message, error) {
 		w.d.Debug("数据还没读完", zap.Int("len", len(buff)))
 		return nil, nil
 	}
-	tmpReader := bytes.NewReader(buff)
-	header, err := ws.ReadHeader(tmpReader)
+	messages, n, err := readClientMessages(buff, w.negotiated.compressed, w.d.eg.options.MaxReadBufferSize)
 	if err != nil {
-		if err == io.EOF || err == io.ErrUnexpectedEOF { //数据不完整
-			return nil, nil
-		}
 		w.d.Debug("wss: 发送错误，丢弃数据", zap.Error(err))
 		w.discardFromWSTemp(len(buff)) // 发送错误，丢弃数据
 		return nil, err
 	}
-	dataLen := header.Length
-	if dataLen > int64(tmpReader.Len()) { // 数据不完整
-		w.d.Debug("wss: 数据还没读完....", zap.Int("dataLen", int(dataLen)), zap.Int("tmpReader.Len()", int(tmpReader.Len())))
-		return nil, nil
-	}
-	if header.Fin { // 当前 frame 已经是最后一个frame
-
-		var messages []wsutil.Message
-		tmpReader.Reset(buff)
-		remLen := tmpReader.Len()
-		for tmpReader.Len() > 0 {
-			messages, err = wsutil.ReadClientMessage(tmpReader, messages)
-			if err != nil {
-				w.d.Warn("read client message error", zap.Error(err))
-				break
-			}
-		}
-		remLen = remLen - tmpReader.Len()
-		w.discardFromWSTemp(remLen)
-		return messages, nil
-	} else {
-		w.d.Debug("wss: ws header not is fin", zap.Int("len", len(buff)))
+	if n > 0 {
+		w.discardFromWSTemp(n)
 	}
-	return nil, nil
+	return messages, nil
 }
@@ -0,0 +1,264 @@
+package wknet
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsflate"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// WSProtocolJSON json文本帧协议的websocket子协议名（客户端通过Sec-WebSocket-Protocol头选择）
+const WSProtocolJSON = "wukongim.json"
+
+// WSTextCodec websocket文本帧协议的编解码器，每个连接一个实例
+type WSTextCodec interface {
+	// Decode 将客户端发送的文本帧转换为二进制协议数据
+	Decode(text []byte) ([]byte, error)
+	// Encode 将服务端的二进制协议数据转换为websocket消息（一个协议包对应一个消息）
+	// 不能转换为文本帧的协议包以二进制帧原样发送，不完整的协议包由编解码器缓存到下次调用
+	Encode(data []byte) ([]wsutil.Message, error)
+}
+
+var ErrWSMessageTooLarge = errors.New("websocket message too large")
+
+// permessage-deflate的压缩器（握手时协商了no_context_takeover，所以每条消息独立压缩）
+var wsFlateHelper = wsflate.Helper{
+	Compressor: newFlateCompressor,
+	Decompressor: func(r io.Reader) wsflate.Decompressor {
+		return flate.NewReader(r)
+	},
+}
+
+// 复用压缩器，flate.Writer的创建开销很大，不能每条消息都创建
+var wsFlateWriterPool = sync.Pool{
+	New: func() interface{} {
+		return wsflate.NewWriter(nil, newFlateCompressor)
+	},
+}
+
+func newFlateCompressor(w io.Writer) wsflate.Compressor {
+	f, _ := flate.NewWriter(w, flate.BestSpeed)
+	return flateCompressor{w: f}
+}
+
+// 只暴露Write、Flush和Reset，不能Close（Close会写入结束块，导致消息尾部不是0x0000ffff）
+type flateCompressor struct {
+	w *flate.Writer
+}
+
+func (f flateCompressor) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+func (f flateCompressor) Flush() error {
+	return f.w.Flush()
+}
+
+func (f flateCompressor) Reset(w io.Writer) {
+	f.w.Reset(w)
+}
+
+// 压缩消息的数据（permessage-deflate）
+func compressWSPayload(p []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(p)/2))
+	fw := wsFlateWriterPool.Get().(*wsflate.Writer)
+	defer func() {
+		fw.Reset(nil)
+		wsFlateWriterPool.Put(fw)
+	}()
+	fw.Reset(buf)
+	if _, err := fw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// websocket握手协商的结果
+type wsNegotiated struct {
+	compressed bool        // 是否协商了permessage-deflate
+	textCodec  WSTextCodec // 文本帧协议编解码器，为nil表示使用二进制协议
+}
+
+// websocket握手，协商压缩扩展和子协议
+func (n *wsNegotiated) upgrade(eg *Engine, rw io.ReadWriter) error {
+	var ext *wsflate.Extension
+	upgrader := ws.Upgrader{}
+	if eg.options.WSCompression.On {
+		ext = &wsflate.Extension{
+			Parameters: wsflate.DefaultParameters,
+		}
+		upgrader.Negotiate = ext.Negotiate
+	}
+	if eg.options.WSTextCodec != nil {
+		upgrader.Protocol = func(p []byte) bool {
+			return string(p) == WSProtocolJSON
+		}
+	}
+	hs, err := upgrader.Upgrade(rw)
+	if err != nil {
+		return err
+	}
+	if ext != nil {
+		_, n.compressed = ext.Accepted()
+	}
+	if hs.Protocol == WSProtocolJSON {
+		n.textCodec = eg.options.WSTextCodec()
+	}
+	return nil
+}
+
+// 将客户端的数据消息转换为二进制协议数据
+func (n *wsNegotiated) decodePayload(msg wsutil.Message) ([]byte, error) {
+	if n.textCodec != nil && msg.OpCode == ws.OpText {
+		return n.textCodec.Decode(msg.Payload)
+	}
+	return msg.Payload, nil
+}
+
+// 写入服务端的二进制协议数据（文本帧协议的连接会转换为文本帧）
+func (n *wsNegotiated) writeServerMessage(w io.Writer, data []byte, threshold int) error {
+	if n.textCodec == nil {
+		return n.writeServerFrame(w, ws.OpBinary, data, threshold)
+	}
+	msgs, err := n.textCodec.Encode(data)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if err = n.writeServerFrame(w, msg.OpCode, msg.Payload, threshold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *wsNegotiated) writeServerFrame(w io.Writer, op ws.OpCode, data []byte, threshold int) error {
+	frame := ws.NewFrame(op, true, data)
+	if n.compressed && len(data) >= threshold {
+		payload, err := compressWSPayload(data)
+		if err != nil {
+			return err
+		}
+		frame.Payload = payload
+		frame.Header.Length = int64(len(payload))
+		if frame.Header, err = wsflate.SetBit(frame.Header); err != nil {
+			return err
+		}
+	}
+	return ws.WriteFrame(w, frame)
+}
+
+// readClientMessages 从缓存数据中读取客户端的完整消息，返回消息和已消费的字节数
+// 不完整的消息不会被消费，等待下次数据到达后再读取
+func readClientMessages(data []byte, compressed bool, maxSize int) ([]wsutil.Message, int, error) {
+	var (
+		messages    []wsutil.Message
+		consumed    int
+		fragOp      ws.OpCode
+		fragFlate   bool
+		fragPayload []byte
+		fragging    bool
+		fragControl []wsutil.Message // 分片消息之间的控制帧，等分片消息完整后再交付
+	)
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		header, err := ws.ReadHeader(r)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF { // 数据不完整
+				break
+			}
+			return nil, 0, err
+		}
+		if header.Length > int64(r.Len()) { // 数据不完整
+			break
+		}
+		payload := make([]byte, header.Length)
+		_, _ = r.Read(payload)
+		if header.Masked {
+			ws.Cipher(payload, header.Mask, 0)
+		}
+
+		if header.OpCode.IsControl() {
+			if !header.Fin {
+				return nil, 0, ws.ErrProtocolControlNotFinal
+			}
+			if header.Rsv != 0 {
+				return nil, 0, ws.ErrProtocolNonZeroRsv
+			}
+			msg := wsutil.Message{OpCode: header.OpCode, Payload: payload}
+			if fragging {
+				fragControl = append(fragControl, msg)
+				continue
+			}
+			messages = append(messages, msg)
+			consumed = len(data) - r.Len()
+			continue
+		}
+
+		// rsv1表示消息被压缩，只允许出现在消息的第一个帧上
+		if header.Rsv2() || header.Rsv3() || (header.Rsv1() && (!compressed || header.OpCode == ws.OpContinuation)) {
+			return nil, 0, ws.ErrProtocolNonZeroRsv
+		}
+		if header.OpCode == ws.OpContinuation {
+			if !fragging {
+				return nil, 0, ws.ErrProtocolContinuationUnexpected
+			}
+		} else {
+			if fragging {
+				return nil, 0, ws.ErrProtocolContinuationExpected
+			}
+			fragging = true
+			fragOp = header.OpCode
+			fragFlate = header.Rsv1()
+			fragPayload = fragPayload[:0]
+		}
+		fragPayload = append(fragPayload, payload...)
+		if maxSize > 0 && len(fragPayload) > maxSize {
+			return nil, 0, ErrWSMessageTooLarge
+		}
+		if !header.Fin {
+			continue
+		}
+
+		msgPayload := fragPayload
+		if fragFlate {
+			buf := &limitedBuffer{max: maxSize}
+			if err = wsFlateHelper.DecompressTo(buf, fragPayload); err != nil {
+				return nil, 0, err
+			}
+			msgPayload = buf.Bytes()
+		} else {
+			msgPayload = append([]byte(nil), fragPayload...)
+		}
+		messages = append(messages, wsutil.Message{OpCode: fragOp, Payload: msgPayload})
+		messages = append(messages, fragControl...)
+		fragControl = fragControl[:0]
+		fragging = false
+		consumed = len(data) - r.Len()
+	}
+	return messages, consumed, nil
+}
+
+// 限制大小的缓存，防止压缩炸弹
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if l.max > 0 && l.Len()+len(p) > l.max {
+		return 0, ErrWSMessageTooLarge
+	}
+	return l.Buffer.Write(p)
+}
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	stls "github.com/WuKongIM/crypto/tls"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 )
@@ -280,3 +282,96 @@ func testWSSConnSend(msgNum int, addr string, t *testing.T, wg *sync.WaitGroup,
 		assert.NoError(t, err)
 	}
 }
+
+func TestWebsocketCompression(t *testing.T) {
+	e := NewEngine(WithWSAddr("ws://0.0.0.0:0"), WithWSCompression(true, 1))
+	e.Start()
+	defer e.Stop()
+
+	payload := bytes.Repeat([]byte("hello"), 1000)
+	e.OnData(func(conn Conn) error {
+		data, err := conn.Peek(-1)
+		assert.NoError(t, err)
+		if len(data) < len(payload) {
+			return nil
+		}
+		_, _ = conn.Discard(len(data))
+		assert.Equal(t, payload, data)
+		go func() {
+			err := conn.(IWSConn).WriteServerBinary(data)
+			assert.NoError(t, err)
+			_ = conn.WakeWrite()
+		}()
+		return nil
+	})
+
+	u := url.URL{Scheme: "ws", Host: e.WSRealListenAddr().String(), Path: "/"}
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	c, resp, err := dialer.Dial(u.String(), nil)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	c.EnableWriteCompression(true)
+	err = c.WriteMessage(websocket.BinaryMessage, payload)
+	assert.NoError(t, err)
+
+	_ = c.SetReadDeadline(time.Now().Add(time.Second * 5))
+	msgType, data, err := c.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, payload, data)
+}
+
+type testTextCodec struct {
+}
+
+func (testTextCodec) Decode(text []byte) ([]byte, error) {
+	return append([]byte("bin:"), text...), nil
+}
+
+func (testTextCodec) Encode(data []byte) ([]wsutil.Message, error) {
+	return []wsutil.Message{{OpCode: ws.OpText, Payload: append([]byte("text:"), data...)}}, nil
+}
+
+func TestWebsocketTextCodec(t *testing.T) {
+	e := NewEngine(WithWSAddr("ws://0.0.0.0:0"), WithWSTextCodec(func() WSTextCodec {
+		return testTextCodec{}
+	}))
+	e.Start()
+	defer e.Stop()
+
+	e.OnData(func(conn Conn) error {
+		data, err := conn.Peek(-1)
+		assert.NoError(t, err)
+		if len(data) == 0 {
+			return nil
+		}
+		_, _ = conn.Discard(len(data))
+		assert.Equal(t, "bin:hello", string(data))
+		go func() {
+			err := conn.(IWSConn).WriteServerBinary([]byte("world"))
+			assert.NoError(t, err)
+			_ = conn.WakeWrite()
+		}()
+		return nil
+	})
+
+	u := url.URL{Scheme: "ws", Host: e.WSRealListenAddr().String(), Path: "/"}
+
+	dialer := websocket.Dialer{Subprotocols: []string{WSProtocolJSON}}
+	c, _, err := dialer.Dial(u.String(), nil)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, WSProtocolJSON, c.Subprotocol())
+
+	err = c.WriteMessage(websocket.TextMessage, []byte("hello"))
+	assert.NoError(t, err)
+
+	_ = c.SetReadDeadline(time.Now().Add(time.Second * 5))
+	msgType, data, err := c.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, msgType)
+	assert.Equal(t, "text:world", string(data))
+}